@@ -15,6 +15,7 @@ import (
 	"github.com/ourrootsorg/cms-server/stdplace"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/dgrijalva/jwt-go"
 
 	"github.com/ourrootsorg/go-oidc"
 	"github.com/streadway/amqp"
@@ -23,6 +24,7 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	lru "github.com/hashicorp/golang-lru"
+	"github.com/ourrootsorg/cms-server/mail"
 	"github.com/ourrootsorg/cms-server/model"
 )
 
@@ -43,13 +45,21 @@ type LocalAPI interface {
 	GetPosts(ctx context.Context /* filter/search criteria */) (*PostResult, error)
 	GetPost(ctx context.Context, id uint32) (*model.Post, error)
 	GetPostImage(ctx context.Context, id uint32, filePath string, thumbnail bool, expireSeconds int) (*ImageMetadata, error)
+	GetPostImageOCR(ctx context.Context, id uint32, filePath, highlight string) (*model.ImageOCR, error)
+	GetPostImageDuplicates(ctx context.Context, id uint32) ([]model.ImageDuplicate, error)
 	AddPost(ctx context.Context, in model.PostIn) (*model.Post, error)
 	UpdatePost(ctx context.Context, id uint32, in model.Post) (*model.Post, error)
+	OverridePostStatus(ctx context.Context, id uint32, in model.PostStatusOverride) (*model.Post, error)
+	GetPostEvents(ctx context.Context, id uint32) ([]model.PostEvent, error)
 	DeletePost(ctx context.Context, id uint32) error
 	PostContentRequest(ctx context.Context, contentRequest ContentRequest) (*ContentResult, error)
 	GetContent(ctx context.Context, key string) ([]byte, error)
 	GetContentRequest(ctx context.Context, key string) (*ContentResult, error)
-	RetrieveUser(ctx context.Context, provider OIDCProvider, token *oidc.IDToken, rawToken string) (*model.User, bool, error)
+	RetrieveUser(ctx context.Context, provider OIDCProvider, claims OIDCClaims, token *oidc.IDToken, rawToken string) (*model.User, bool, error)
+	DeactivateUser(ctx context.Context, id uint32) (*model.User, error)
+	GetUserIdentities(ctx context.Context) ([]model.UserIdentity, error)
+	LinkUserIdentity(ctx context.Context, token *oidc.IDToken) (*model.UserIdentity, error)
+	DeleteUserIdentity(ctx context.Context, id uint32) error
 	GetRecordsForPost(ctx context.Context, postid uint32, limit int) (*RecordsResult, error)
 	GetRecordsByID(ctx context.Context, ids []uint32, enforceContextSocietyMatch bool) ([]model.Record, error)
 	GetRecord(ctx context.Context, includeDetails bool, id uint32) (*RecordDetail, error)
@@ -65,9 +75,22 @@ type LocalAPI interface {
 	SearchByID(ctx context.Context, id string, req *SearchByIDRequest) (*model.SearchHit, error)
 	SearchImage(ctx context.Context, societyID, id uint32, filePath string, thumbnail bool, expireSeconds int) (*ImageMetadata, error)
 	SearchDeleteByID(ctx context.Context, id string) error
-	StandardizePlace(ctx context.Context, text, defaultContainingPlace string) (*model.Place, error)
+	StandardizePlace(ctx context.Context, text, defaultContainingPlace string, eventYear int) (*model.Place, error)
 	GetPlacesByPrefix(ctx context.Context, prefix string, count int) ([]model.Place, error)
+	StandardizePlaces(ctx context.Context, reqs []PlaceStandardizeRequest) ([]StandardizedPlace, error)
+	StandardizeDates(ctx context.Context, reqs []DateStandardizeRequest) ([]StandardizedDate, error)
+	GetPlace(ctx context.Context, id uint32) (*model.Place, error)
+	AddPlace(ctx context.Context, in model.PlaceIn) (*model.Place, error)
+	UpdatePlace(ctx context.Context, id uint32, in model.PlaceIn) (*model.Place, error)
+	MergePlace(ctx context.Context, id uint32, in model.PlaceMerge) (*model.Place, error)
+	UpdatePlaceAltNames(ctx context.Context, id uint32, in model.PlaceAltNames) (*model.Place, error)
+	GetPlaceReviews(ctx context.Context, status model.PlaceReviewStatus) ([]model.PlaceReview, error)
+	GetPlaceReview(ctx context.Context, id uint32) (*model.PlaceReview, error)
+	ResolvePlaceReview(ctx context.Context, id uint32, in model.PlaceReviewDecision) (*model.PlaceReview, error)
 	GetNameVariants(ctx context.Context, nameType model.NameType, name string) (*model.NameVariants, error)
+	GetSocietyNameVariants(ctx context.Context, nameType model.NameType) ([]model.SocietyNameVariants, error)
+	AddNameVariants(ctx context.Context, nameType model.NameType, name string, in model.NameVariantsEdit) (*model.NameVariants, error)
+	RemoveNameVariants(ctx context.Context, nameType model.NameType, name string, in model.NameVariantsEdit) (*model.NameVariants, error)
 	GetSocietySummariesForCurrentUser(ctx context.Context) ([]model.SocietySummary, error)
 	GetSocietySummary(ctx context.Context) (*model.SocietySummary, error)
 	GetSociety(ctx context.Context, id uint32) (*model.Society, error)
@@ -81,9 +104,35 @@ type LocalAPI interface {
 	DeleteSocietyUser(ctx context.Context, id uint32) error
 	GetInvitations(ctx context.Context) ([]model.Invitation, error)
 	AddInvitation(ctx context.Context, body model.InvitationBody) (*model.Invitation, error)
+	AddInvitations(ctx context.Context, bodies []model.InvitationBody) ([]BulkInvitationResult, error)
 	DeleteInvitation(ctx context.Context, id uint32) error
 	GetInvitationSocietyName(ctx context.Context, code string) (*InvitationSocietyName, error)
 	AcceptInvitation(ctx context.Context, code string) (*model.SocietyUser, error)
+	GetAPIKeys(ctx context.Context) ([]model.APIKey, error)
+	AddAPIKey(ctx context.Context, body model.APIKeyBody) (*model.APIKeySecret, error)
+	DeleteAPIKey(ctx context.Context, id uint32) error
+	AuthenticateAPIKey(ctx context.Context, key string) (*model.APIKey, error)
+	GetAPIKeyUser(ctx context.Context, apiKey *model.APIKey) (*model.User, error)
+	GetSearchKeys(ctx context.Context) ([]model.SearchKey, error)
+	AddSearchKey(ctx context.Context, body model.SearchKeyBody) (*model.SearchKey, error)
+	DeleteSearchKey(ctx context.Context, kid string) error
+	MintSearchToken(ctx context.Context, req SearchTokenRequest) (*SearchToken, error)
+	SearchTokenKey(ctx context.Context, societyID uint32, token *jwt.Token) (interface{}, error)
+	GetCollectionGrants(ctx context.Context) ([]model.CollectionGrant, error)
+	AddCollectionGrant(ctx context.Context, in model.CollectionGrantIn) (*model.CollectionGrant, error)
+	DeleteCollectionGrant(ctx context.Context, id uint32) error
+	GetPermissions(ctx context.Context, userID uint32, level model.AuthLevel) (*model.Permissions, error)
+	GetAuditEvents(ctx context.Context, filter model.AuditFilter) (*AuditResult, error)
+	GetTrash(ctx context.Context) ([]model.TrashItem, error)
+	GetTrashedSocieties(ctx context.Context) ([]model.TrashItem, error)
+	RestoreSociety(ctx context.Context) (*model.Society, error)
+	RestoreCollection(ctx context.Context, id uint32) (*model.Collection, error)
+	RestorePost(ctx context.Context, id uint32) (*model.Post, error)
+	GetSocietyTemplates(ctx context.Context) ([]model.SocietyTemplate, error)
+	GetSocietyTemplate(ctx context.Context, id uint32) (*model.SocietyTemplate, error)
+	ExportSocietyTemplate(ctx context.Context, in model.SocietyTemplateExport) (*model.SocietyTemplate, error)
+	DeleteSocietyTemplate(ctx context.Context, id uint32) error
+	ApplySocietyTemplate(ctx context.Context, id uint32) (*SocietyTemplateResult, error)
 }
 
 // API is the container for the apilication
@@ -93,11 +142,23 @@ type API struct {
 	postPersister            model.PostPersister
 	recordPersister          model.RecordPersister
 	userPersister            model.UserPersister
+	userIdentityPersister    model.UserIdentityPersister
 	placePersister           model.PlacePersister
+	placeAdminPersister      model.PlaceAdminPersister
+	placeReviewPersister     model.PlaceReviewPersister
 	namePersister            model.NamePersister
+	nameAdminPersister       model.NameAdminPersister
 	societyPersister         model.SocietyPersister
 	societyUserPersister     model.SocietyUserPersister
+	societyTemplatePersister model.SocietyTemplatePersister
 	invitationPersister      model.InvitationPersister
+	apiKeyPersister          model.APIKeyPersister
+	searchKeyPersister       model.SearchKeyPersister
+	collectionGrantPersister model.CollectionGrantPersister
+	imageHashPersister       model.ImageHashPersister
+	postEventPersister       model.PostEventPersister
+	auditPersister           model.AuditPersister
+	trashPersister           model.TrashPersister
 	validate                 *validator.Validate
 	blobStoreConfig          BlobStoreConfig
 	pubSubConfig             PubSubConfig
@@ -106,9 +167,15 @@ type API struct {
 	societyCache             *lru.TwoQueueCache
 	societyUserCache         *lru.TwoQueueCache
 	nameVariantsCache        *lru.TwoQueueCache
+	searchKeyCache           *lru.TwoQueueCache
+	userRefreshInterval      time.Duration
+	platformAdmins           []uint32
+	trashRetention           time.Duration
 	rabbitmqTopicConn        *amqp.Connection
 	rabbitmqSubscriptionConn *amqp.Connection
 	es                       *elasticsearch.Client
+	mailer                   mail.Mailer
+	invitationURL            string
 }
 
 // BlobStoreConfig contains configuration information for the blob store
@@ -152,6 +219,12 @@ func NewAPI() (*API, error) {
 	if err != nil {
 		return nil, err
 	}
+	api.searchKeyCache, err = lru.New2Q(100)
+	if err != nil {
+		return nil, err
+	}
+	api.userRefreshInterval = DefaultUserRefreshInterval
+	api.trashRetention = DefaultTrashRetention
 	api.pubSubConfig = PubSubConfig{queueURL: map[string]string{}}
 	return api, nil
 }
@@ -235,6 +308,61 @@ func (api *API) InvitationPersister(cp model.InvitationPersister) *API {
 	return api
 }
 
+// APIKeyPersister sets the APIKeyPersister for the api
+func (api *API) APIKeyPersister(cp model.APIKeyPersister) *API {
+	api.apiKeyPersister = cp
+	return api
+}
+
+// SearchKeyPersister sets the SearchKeyPersister for the api
+func (api *API) SearchKeyPersister(cp model.SearchKeyPersister) *API {
+	api.searchKeyPersister = cp
+	return api
+}
+
+// TrashPersister sets the TrashPersister for the API; without one, deletes can't be undone
+func (api *API) TrashPersister(p model.TrashPersister) *API {
+	api.trashPersister = p
+	return api
+}
+
+// SocietyTemplatePersister sets the SocietyTemplatePersister for the API
+func (api *API) SocietyTemplatePersister(p model.SocietyTemplatePersister) *API {
+	api.societyTemplatePersister = p
+	return api
+}
+
+// CollectionGrantPersister sets the CollectionGrantPersister for the api
+func (api *API) CollectionGrantPersister(cp model.CollectionGrantPersister) *API {
+	api.collectionGrantPersister = cp
+	return api
+}
+
+// Mailer sets the mailer that sends invitations, and the URL that invitation codes are appended to
+func (api *API) Mailer(mailer mail.Mailer, invitationURL string) *API {
+	api.mailer = mailer
+	api.invitationURL = invitationURL
+	return api
+}
+
+// ImageHashPersister sets the ImageHashPersister for the api
+func (api *API) ImageHashPersister(cp model.ImageHashPersister) *API {
+	api.imageHashPersister = cp
+	return api
+}
+
+// PostEventPersister sets the PostEventPersister for the api
+func (api *API) PostEventPersister(cp model.PostEventPersister) *API {
+	api.postEventPersister = cp
+	return api
+}
+
+// AuditPersister sets the AuditPersister for the api
+func (api *API) AuditPersister(cp model.AuditPersister) *API {
+	api.auditPersister = cp
+	return api
+}
+
 // BlobStoreConfig configures the blob store service
 func (api *API) BlobStoreConfig(region, endpoint, accessKeyID, secretAccessKey, bucket string, disableSSL bool) *API {
 	api.blobStoreConfig = BlobStoreConfig{region, endpoint, accessKeyID, secretAccessKey, bucket, disableSSL}
@@ -253,12 +381,48 @@ func (api *API) UserPersister(p model.UserPersister) *API {
 	return api
 }
 
+// UserIdentityPersister sets the UserIdentityPersister for the API
+func (api *API) UserIdentityPersister(p model.UserIdentityPersister) *API {
+	api.userIdentityPersister = p
+	return api
+}
+
+// UserRefreshInterval sets how often users' names and emails are refreshed from the identity provider
+func (api *API) UserRefreshInterval(d time.Duration) *API {
+	api.userRefreshInterval = d
+	return api
+}
+
+// PlatformAdmins sets the IDs of the users who can deactivate other users and restore any trashed society
+func (api *API) PlatformAdmins(ids []uint32) *API {
+	api.platformAdmins = ids
+	return api
+}
+
+// TrashRetention sets how long deleted societies, collections and posts stay in the trash; 0 deletes them right away
+func (api *API) TrashRetention(d time.Duration) *API {
+	api.trashRetention = d
+	return api
+}
+
 // PlacePersister sets the PostPersister for the api
 func (api *API) PlacePersister(p model.PlacePersister) *API {
 	api.placePersister = p
 	return api
 }
 
+// PlaceAdminPersister sets the PlaceAdminPersister for the api
+func (api *API) PlaceAdminPersister(p model.PlaceAdminPersister) *API {
+	api.placeAdminPersister = p
+	return api
+}
+
+// PlaceReviewPersister sets the PlaceReviewPersister for the api
+func (api *API) PlaceReviewPersister(p model.PlaceReviewPersister) *API {
+	api.placeReviewPersister = p
+	return api
+}
+
 // PlaceStandardizer sets the placeStandardizer for the api
 func (api *API) PlaceStandardizer(ctx context.Context, p model.PlacePersister) *API {
 	std, err := stdplace.NewStandardizer(ctx, p)
@@ -275,6 +439,12 @@ func (api *API) NamePersister(p model.NamePersister) *API {
 	return api
 }
 
+// NameAdminPersister sets the NameAdminPersister for the api
+func (api *API) NameAdminPersister(p model.NameAdminPersister) *API {
+	api.nameAdminPersister = p
+	return api
+}
+
 // ElasticsearchConfig sets the Elasticsearch client
 func (api *API) ElasticsearchConfig(esURL string, transport http.RoundTripper) *API {
 	retryBackoff := backoff.NewExponentialBackOff()
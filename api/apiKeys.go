@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+// APIKeyIssuer is the issuer of the service accounts that API keys act as
+const APIKeyIssuer = "urn:ourroots:api-key"
+
+// APIKeyPrefix starts every API key, so keys can be told apart from OIDC access tokens
+const APIKeyPrefix = "ork_"
+
+// apiKeyDisplayLength is how many characters of a key are kept so administrators can tell keys apart
+const apiKeyDisplayLength = len(APIKeyPrefix) + 8
+
+// apiKeyLastUsedInterval limits how often the last-used time of a key is written
+const apiKeyLastUsedInterval = time.Minute
+
+// IsAPIKey returns true if the bearer token is an API key rather than an OIDC access token
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// GetAPIKeys returns the API keys for the society in the context
+func (api API) GetAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	if err := api.checkAPIKeysConfigured(); err != nil {
+		return nil, err
+	}
+	apiKeys, err := api.apiKeyPersister.SelectAPIKeys(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	return apiKeys, nil
+}
+
+// AddAPIKey creates an API key for the society in the context.
+// The key is returned only once; just its hash is stored.
+func (api API) AddAPIKey(ctx context.Context, body model.APIKeyBody) (*model.APIKeySecret, error) {
+	if err := api.checkAPIKeysConfigured(); err != nil {
+		return nil, err
+	}
+	err := api.validate.Struct(body)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	if _, err := utils.GetAPIKeyFromContext(ctx); err == nil {
+		return nil, NewHTTPError(errors.New("API keys cannot create API keys"), http.StatusForbidden)
+	}
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	// generate key
+	b := make([]byte, 24)
+	_, err = rand.Read(b)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	key := APIKeyPrefix + hex.EncodeToString(b)
+
+	in := model.APIKeyIn{
+		APIKeyBody: body,
+		Prefix:     key[:apiKeyDisplayLength],
+		Hash:       hashAPIKey(key),
+		SocietyID:  societyID,
+		CreatedBy:  currentUserID(ctx),
+	}
+	apiKey, err := api.apiKeyPersister.InsertAPIKey(ctx, in)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityAPIKey, EntityID: apiKey.ID, Action: model.AuditActionCreate}, nil, apiKey)
+	return &model.APIKeySecret{
+		APIKey: *apiKey,
+		Key:    key,
+	}, nil
+}
+
+// DeleteAPIKey revokes an API key
+func (api API) DeleteAPIKey(ctx context.Context, id uint32) error {
+	if err := api.checkAPIKeysConfigured(); err != nil {
+		return err
+	}
+	var old *model.APIKey
+	if apiKeys, err := api.apiKeyPersister.SelectAPIKeys(ctx); err == nil {
+		for i := range apiKeys {
+			if apiKeys[i].ID == id {
+				old = &apiKeys[i]
+			}
+		}
+	}
+	err := api.apiKeyPersister.DeleteAPIKey(ctx, id)
+	if err != nil {
+		return NewError(err)
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityAPIKey, EntityID: id, Action: model.AuditActionDelete}, old, nil)
+	return nil
+}
+
+// AuthenticateAPIKey returns the API key matching the bearer token and records that it was used.
+// The caller must check that the key belongs to the society being accessed.
+func (api API) AuthenticateAPIKey(ctx context.Context, key string) (*model.APIKey, error) {
+	if err := api.checkAPIKeysConfigured(); err != nil {
+		return nil, err
+	}
+	apiKey, err := api.apiKeyPersister.SelectAPIKeyByHash(ctx, hashAPIKey(key))
+	if model.ErrNotFound.Matches(err) {
+		return nil, NewHTTPError(errors.New("Invalid API key"), http.StatusUnauthorized)
+	}
+	if err != nil {
+		return nil, NewError(err)
+	}
+	if err := api.checkSocietyNotTrashed(ctx, apiKey.SocietyID); err != nil {
+		return nil, NewHTTPError(errors.New("Invalid API key"), http.StatusUnauthorized)
+	}
+	now := time.Now()
+	if apiKey.LastUsedTime == nil || now.Sub(*apiKey.LastUsedTime) >= apiKeyLastUsedInterval {
+		// failing to record the last-used time shouldn't fail the request
+		if err := api.apiKeyPersister.UpdateAPIKeyLastUsed(ctx, apiKey.ID, now); err != nil {
+			log.Printf("[ERROR] updating last used time for API key %d: %v", apiKey.ID, err)
+		} else {
+			apiKey.LastUsedTime = &now
+		}
+	}
+	return apiKey, nil
+}
+
+// GetAPIKeyUser returns the service account that requests made with an API key act as, creating it on the key's first use.
+// Each key has its own account, so created-by fields and the audit log identify the key.
+func (api API) GetAPIKeyUser(ctx context.Context, apiKey *model.APIKey) (*model.User, error) {
+	body := model.UserBody{
+		Name:    apiKey.Name,
+		Issuer:  APIKeyIssuer,
+		Subject: strconv.Itoa(int(apiKey.ID)),
+		Enabled: true,
+	}
+	// a key's account never changes, so its ID can be cached without refreshing it
+	cacheKey := body.Issuer + "|" + body.Subject
+	if u, ok := api.userCache.Get(cacheKey); ok {
+		if cached, ok := u.(cachedUser); ok {
+			return &model.User{ID: cached.id, UserBody: body}, nil
+		}
+	}
+	user, _, err := api.userPersister.RetrieveUser(ctx, model.UserIn{UserBody: body})
+	if err != nil {
+		return nil, NewHTTPError(err, http.StatusUnauthorized)
+	}
+	api.userCache.Add(cacheKey, cachedUser{id: user.ID, refreshed: time.Now()})
+	return user, nil
+}
+
+func (api *API) checkAPIKeysConfigured() error {
+	if api.apiKeyPersister == nil {
+		return NewHTTPError(errors.New("API keys are not configured"), http.StatusNotImplemented)
+	}
+	return nil
+}
+
+// hashAPIKey returns the hash of a key; keys are long and random, so a fast hash is enough
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// apiKeyMock holds API keys in memory
+type apiKeyMock struct {
+	apiKeys      map[uint32]model.APIKey
+	lastUsedSets int
+}
+
+func (am *apiKeyMock) SelectAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	societyID, _ := utils.GetSocietyIDFromContext(ctx)
+	var result []model.APIKey
+	for _, apiKey := range am.apiKeys {
+		if apiKey.SocietyID == societyID {
+			result = append(result, apiKey)
+		}
+	}
+	return result, nil
+}
+func (am *apiKeyMock) SelectAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	for _, apiKey := range am.apiKeys {
+		if apiKey.Hash == hash {
+			return &apiKey, nil
+		}
+	}
+	return nil, model.NewError(model.ErrNotFound, hash)
+}
+func (am *apiKeyMock) InsertAPIKey(ctx context.Context, in model.APIKeyIn) (*model.APIKey, error) {
+	apiKey := model.NewAPIKey(uint32(len(am.apiKeys)+1), in)
+	am.apiKeys[apiKey.ID] = apiKey
+	return &apiKey, nil
+}
+func (am *apiKeyMock) UpdateAPIKeyLastUsed(ctx context.Context, id uint32, lastUsedTime time.Time) error {
+	apiKey, ok := am.apiKeys[id]
+	if !ok {
+		return model.NewError(model.ErrNotFound, fmt.Sprint(id))
+	}
+	apiKey.LastUsedTime = &lastUsedTime
+	am.apiKeys[id] = apiKey
+	am.lastUsedSets++
+	return nil
+}
+func (am *apiKeyMock) DeleteAPIKey(ctx context.Context, id uint32) error {
+	delete(am.apiKeys, id)
+	return nil
+}
+
+func TestAPIKeys(t *testing.T) {
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 7)
+	ctx = utils.AddUserToContext(ctx, &model.User{ID: 3})
+	am := &apiKeyMock{apiKeys: map[uint32]model.APIKey{}}
+	testAPI := &API{validate: validator.New()}
+
+	// not configured
+	_, err := testAPI.GetAPIKeys(ctx)
+	assert.Equal(t, http.StatusNotImplemented, err.(*Error).HTTPStatus())
+	testAPI.APIKeyPersister(am)
+
+	_, err = testAPI.AddAPIKey(ctx, model.APIKeyBody{Name: "Nightly"})
+	assert.Error(t, err)
+
+	secret, err := testAPI.AddAPIKey(ctx, model.APIKeyBody{Name: "Nightly", Level: model.AuthContributor})
+	assert.NoError(t, err)
+	assert.True(t, IsAPIKey(secret.Key))
+	assert.True(t, strings.HasPrefix(secret.Key, secret.Prefix))
+	assert.NotContains(t, secret.Hash, secret.Key)
+	assert.Equal(t, uint32(7), secret.SocietyID)
+	assert.Equal(t, uint32(3), secret.CreatedBy)
+
+	// only the hash is stored
+	apiKeys, err := testAPI.GetAPIKeys(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, apiKeys, 1)
+	assert.Equal(t, hashAPIKey(secret.Key), apiKeys[0].Hash)
+	assert.Nil(t, apiKeys[0].LastUsedTime)
+
+	// the last-used time is recorded, but not on every request
+	apiKey, err := testAPI.AuthenticateAPIKey(context.TODO(), secret.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, model.AuthLevel(model.AuthContributor), apiKey.Level)
+	assert.NotNil(t, apiKey.LastUsedTime)
+	_, err = testAPI.AuthenticateAPIKey(context.TODO(), secret.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, am.lastUsedSets)
+
+	// each key acts as its own service account
+	userCache, err := lru.New2Q(100)
+	assert.NoError(t, err)
+	testAPI.userCache = userCache
+	testAPI.UserPersister(&userMock{users: []model.User{{ID: 1}}})
+	user, err := testAPI.GetAPIKeyUser(ctx, apiKey)
+	assert.NoError(t, err)
+	assert.NotZero(t, user.ID)
+	assert.Equal(t, "Nightly", user.Name)
+	cached, err := testAPI.GetAPIKeyUser(ctx, apiKey)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, cached.ID)
+	other, err := testAPI.GetAPIKeyUser(ctx, &model.APIKey{ID: apiKey.ID + 1})
+	assert.NoError(t, err)
+	assert.NotEqual(t, user.ID, other.ID)
+
+	// requests authenticated with a key can't create keys
+	_, err = testAPI.AddAPIKey(utils.AddAPIKeyToContext(ctx, apiKey), model.APIKeyBody{Name: "Another", Level: model.AuthAdmin})
+	assert.Equal(t, http.StatusForbidden, err.(*Error).HTTPStatus())
+
+	// revoked keys are rejected
+	err = testAPI.DeleteAPIKey(ctx, apiKey.ID)
+	assert.NoError(t, err)
+	_, err = testAPI.AuthenticateAPIKey(context.TODO(), secret.Key)
+	assert.Equal(t, http.StatusUnauthorized, err.(*Error).HTTPStatus())
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/ourrootsorg/cms-server/model"
 	"github.com/ourrootsorg/go-oidc"
 )
@@ -60,12 +61,28 @@ func (a *ApiMock) GetPost(ctx context.Context, id uint32) (*model.Post, error) {
 func (a *ApiMock) GetPostImage(ctx context.Context, id uint32, filePath string, thumbnail bool, expireSeconds int) (*ImageMetadata, error) {
 	return a.Result.(*ImageMetadata), a.Errors
 }
+
+func (a *ApiMock) GetPostImageOCR(ctx context.Context, id uint32, filePath, highlight string) (*model.ImageOCR, error) {
+	return a.Result.(*model.ImageOCR), a.Errors
+}
+
+func (a *ApiMock) GetPostImageDuplicates(ctx context.Context, id uint32) ([]model.ImageDuplicate, error) {
+	return a.Result.([]model.ImageDuplicate), a.Errors
+}
 func (a *ApiMock) AddPost(ctx context.Context, in model.PostIn) (*model.Post, error) {
 	return a.Result.(*model.Post), a.Errors
 }
 func (a *ApiMock) UpdatePost(ctx context.Context, id uint32, in model.Post) (*model.Post, error) {
 	return a.Result.(*model.Post), a.Errors
 }
+
+func (a *ApiMock) OverridePostStatus(ctx context.Context, id uint32, in model.PostStatusOverride) (*model.Post, error) {
+	return a.Result.(*model.Post), a.Errors
+}
+
+func (a *ApiMock) GetPostEvents(ctx context.Context, id uint32) ([]model.PostEvent, error) {
+	return a.Result.([]model.PostEvent), a.Errors
+}
 func (a *ApiMock) DeletePost(ctx context.Context, id uint32) error {
 	return a.Errors
 }
@@ -80,10 +97,29 @@ func (a *ApiMock) GetContentRequest(ctx context.Context, key string) (*ContentRe
 	return a.Result.(*ContentResult), a.Errors
 }
 
-func (a *ApiMock) RetrieveUser(ctx context.Context, provider OIDCProvider, token *oidc.IDToken, rawToken string) (*model.User, bool, error) {
+func (a *ApiMock) RetrieveUser(ctx context.Context, provider OIDCProvider, claims OIDCClaims, token *oidc.IDToken, rawToken string) (*model.User, bool, error) {
 	return a.Result.(*model.User), false, a.Errors
 }
 
+func (a *ApiMock) DeactivateUser(ctx context.Context, id uint32) (*model.User, error) {
+	a.Request = id
+	return a.Result.(*model.User), a.Errors
+}
+
+func (a *ApiMock) GetUserIdentities(ctx context.Context) ([]model.UserIdentity, error) {
+	return a.Result.([]model.UserIdentity), a.Errors
+}
+
+func (a *ApiMock) LinkUserIdentity(ctx context.Context, token *oidc.IDToken) (*model.UserIdentity, error) {
+	a.Request = token
+	return a.Result.(*model.UserIdentity), a.Errors
+}
+
+func (a *ApiMock) DeleteUserIdentity(ctx context.Context, id uint32) error {
+	a.Request = id
+	return a.Errors
+}
+
 func (a *ApiMock) GetRecordsForPost(ctx context.Context, postid uint32, limit int) (*RecordsResult, error) {
 	return a.Result.(*RecordsResult), a.Errors
 }
@@ -132,18 +168,71 @@ func (a *ApiMock) SearchDeleteByID(ctx context.Context, id string) error {
 	return a.Errors
 }
 
-func (a *ApiMock) StandardizePlace(ctx context.Context, text, defaultContainingPlace string) (*model.Place, error) {
+func (a *ApiMock) StandardizePlace(ctx context.Context, text, defaultContainingPlace string, eventYear int) (*model.Place, error) {
 	return a.Result.(*model.Place), a.Errors
 }
 func (a *ApiMock) GetPlacesByPrefix(ctx context.Context, prefix string, count int) ([]model.Place, error) {
 	a.Request = prefix
 	return a.Result.([]model.Place), a.Errors
 }
+func (a *ApiMock) StandardizePlaces(ctx context.Context, reqs []PlaceStandardizeRequest) ([]StandardizedPlace, error) {
+	a.Request = reqs
+	return a.Result.([]StandardizedPlace), a.Errors
+}
+func (a *ApiMock) StandardizeDates(ctx context.Context, reqs []DateStandardizeRequest) ([]StandardizedDate, error) {
+	a.Request = reqs
+	return a.Result.([]StandardizedDate), a.Errors
+}
+func (a *ApiMock) GetPlace(ctx context.Context, id uint32) (*model.Place, error) {
+	return a.Result.(*model.Place), a.Errors
+}
+func (a *ApiMock) AddPlace(ctx context.Context, in model.PlaceIn) (*model.Place, error) {
+	a.Request = in
+	return a.Result.(*model.Place), a.Errors
+}
+func (a *ApiMock) UpdatePlace(ctx context.Context, id uint32, in model.PlaceIn) (*model.Place, error) {
+	a.Request = in
+	return a.Result.(*model.Place), a.Errors
+}
+func (a *ApiMock) MergePlace(ctx context.Context, id uint32, in model.PlaceMerge) (*model.Place, error) {
+	a.Request = in
+	return a.Result.(*model.Place), a.Errors
+}
+func (a *ApiMock) UpdatePlaceAltNames(ctx context.Context, id uint32, in model.PlaceAltNames) (*model.Place, error) {
+	a.Request = in
+	return a.Result.(*model.Place), a.Errors
+}
+func (a *ApiMock) GetPlaceReviews(ctx context.Context, status model.PlaceReviewStatus) ([]model.PlaceReview, error) {
+	a.Request = status
+	return a.Result.([]model.PlaceReview), a.Errors
+}
+func (a *ApiMock) GetPlaceReview(ctx context.Context, id uint32) (*model.PlaceReview, error) {
+	return a.Result.(*model.PlaceReview), a.Errors
+}
+func (a *ApiMock) ResolvePlaceReview(ctx context.Context, id uint32, in model.PlaceReviewDecision) (*model.PlaceReview, error) {
+	a.Request = in
+	return a.Result.(*model.PlaceReview), a.Errors
+}
 
 func (a *ApiMock) GetNameVariants(ctx context.Context, nameType model.NameType, name string) (*model.NameVariants, error) {
 	return a.Result.(*model.NameVariants), a.Errors
 }
 
+func (a *ApiMock) GetSocietyNameVariants(ctx context.Context, nameType model.NameType) ([]model.SocietyNameVariants, error) {
+	a.Request = nameType
+	return a.Result.([]model.SocietyNameVariants), a.Errors
+}
+
+func (a *ApiMock) AddNameVariants(ctx context.Context, nameType model.NameType, name string, in model.NameVariantsEdit) (*model.NameVariants, error) {
+	a.Request = in
+	return a.Result.(*model.NameVariants), a.Errors
+}
+
+func (a *ApiMock) RemoveNameVariants(ctx context.Context, nameType model.NameType, name string, in model.NameVariantsEdit) (*model.NameVariants, error) {
+	a.Request = in
+	return a.Result.(*model.NameVariants), a.Errors
+}
+
 func (a *ApiMock) GetSocietySummariesForCurrentUser(ctx context.Context) ([]model.SocietySummary, error) {
 	return a.Result.([]model.SocietySummary), a.Errors
 }
@@ -186,9 +275,120 @@ func (a *ApiMock) AddInvitation(ctx context.Context, body model.InvitationBody)
 func (a *ApiMock) DeleteInvitation(ctx context.Context, id uint32) error {
 	return a.Errors
 }
+func (a *ApiMock) AddInvitations(ctx context.Context, bodies []model.InvitationBody) ([]BulkInvitationResult, error) {
+	a.Request = bodies
+	return a.Result.([]BulkInvitationResult), a.Errors
+}
 func (a *ApiMock) GetInvitationSocietyName(ctx context.Context, code string) (*InvitationSocietyName, error) {
 	return a.Result.(*InvitationSocietyName), a.Errors
 }
 func (a *ApiMock) AcceptInvitation(ctx context.Context, code string) (*model.SocietyUser, error) {
 	return a.Result.(*model.SocietyUser), a.Errors
 }
+
+func (a *ApiMock) GetAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	return a.Result.([]model.APIKey), a.Errors
+}
+func (a *ApiMock) AddAPIKey(ctx context.Context, body model.APIKeyBody) (*model.APIKeySecret, error) {
+	a.Request = body
+	return a.Result.(*model.APIKeySecret), a.Errors
+}
+func (a *ApiMock) DeleteAPIKey(ctx context.Context, id uint32) error {
+	a.Request = id
+	return a.Errors
+}
+func (a *ApiMock) AuthenticateAPIKey(ctx context.Context, key string) (*model.APIKey, error) {
+	a.Request = key
+	return a.Result.(*model.APIKey), a.Errors
+}
+
+// GetAPIKeyUser is called after AuthenticateAPIKey in the same request, so it builds the user from the key
+// rather than using Result
+func (a *ApiMock) GetAPIKeyUser(ctx context.Context, apiKey *model.APIKey) (*model.User, error) {
+	return &model.User{ID: apiKey.ID, UserBody: model.UserBody{Name: apiKey.Name, Enabled: true}}, nil
+}
+
+func (a *ApiMock) GetSearchKeys(ctx context.Context) ([]model.SearchKey, error) {
+	return a.Result.([]model.SearchKey), a.Errors
+}
+func (a *ApiMock) AddSearchKey(ctx context.Context, body model.SearchKeyBody) (*model.SearchKey, error) {
+	a.Request = body
+	return a.Result.(*model.SearchKey), a.Errors
+}
+func (a *ApiMock) DeleteSearchKey(ctx context.Context, kid string) error {
+	a.Request = kid
+	return a.Errors
+}
+func (a *ApiMock) MintSearchToken(ctx context.Context, req SearchTokenRequest) (*SearchToken, error) {
+	a.Request = req
+	return a.Result.(*SearchToken), a.Errors
+}
+func (a *ApiMock) SearchTokenKey(ctx context.Context, societyID uint32, token *jwt.Token) (interface{}, error) {
+	return a.Result, a.Errors
+}
+
+func (a *ApiMock) GetCollectionGrants(ctx context.Context) ([]model.CollectionGrant, error) {
+	return a.Result.([]model.CollectionGrant), a.Errors
+}
+func (a *ApiMock) AddCollectionGrant(ctx context.Context, in model.CollectionGrantIn) (*model.CollectionGrant, error) {
+	a.Request = in
+	return a.Result.(*model.CollectionGrant), a.Errors
+}
+func (a *ApiMock) DeleteCollectionGrant(ctx context.Context, id uint32) error {
+	a.Request = id
+	return a.Errors
+}
+func (a *ApiMock) GetPermissions(ctx context.Context, userID uint32, level model.AuthLevel) (*model.Permissions, error) {
+	a.Request = userID
+	return a.Result.(*model.Permissions), a.Errors
+}
+func (a *ApiMock) GetAuditEvents(ctx context.Context, filter model.AuditFilter) (*AuditResult, error) {
+	a.Request = filter
+	return a.Result.(*AuditResult), a.Errors
+}
+
+func (a *ApiMock) GetSocietyTemplates(ctx context.Context) ([]model.SocietyTemplate, error) {
+	return a.Result.([]model.SocietyTemplate), a.Errors
+}
+
+func (a *ApiMock) GetSocietyTemplate(ctx context.Context, id uint32) (*model.SocietyTemplate, error) {
+	a.Request = id
+	return a.Result.(*model.SocietyTemplate), a.Errors
+}
+
+func (a *ApiMock) ExportSocietyTemplate(ctx context.Context, in model.SocietyTemplateExport) (*model.SocietyTemplate, error) {
+	a.Request = in
+	return a.Result.(*model.SocietyTemplate), a.Errors
+}
+
+func (a *ApiMock) DeleteSocietyTemplate(ctx context.Context, id uint32) error {
+	a.Request = id
+	return a.Errors
+}
+
+func (a *ApiMock) ApplySocietyTemplate(ctx context.Context, id uint32) (*SocietyTemplateResult, error) {
+	a.Request = id
+	return a.Result.(*SocietyTemplateResult), a.Errors
+}
+
+func (a *ApiMock) GetTrash(ctx context.Context) ([]model.TrashItem, error) {
+	return a.Result.([]model.TrashItem), a.Errors
+}
+
+func (a *ApiMock) GetTrashedSocieties(ctx context.Context) ([]model.TrashItem, error) {
+	return a.Result.([]model.TrashItem), a.Errors
+}
+
+func (a *ApiMock) RestoreSociety(ctx context.Context) (*model.Society, error) {
+	return a.Result.(*model.Society), a.Errors
+}
+
+func (a *ApiMock) RestoreCollection(ctx context.Context, id uint32) (*model.Collection, error) {
+	a.Request = id
+	return a.Result.(*model.Collection), a.Errors
+}
+
+func (a *ApiMock) RestorePost(ctx context.Context, id uint32) (*model.Post, error) {
+	a.Request = id
+	return a.Result.(*model.Post), a.Errors
+}
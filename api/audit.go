@@ -0,0 +1,166 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+// DefaultAuditLimit is the number of audit events returned when no limit is requested
+const DefaultAuditLimit = 100
+
+// MaxAuditLimit is the most audit events that can be returned at once
+const MaxAuditLimit = 1000
+
+// auditRedactedFields hold secrets; changes to them are recorded, but not their values
+var auditRedactedFields = map[string]bool{"secretKey": true, "secret": true, "key": true}
+
+// auditIgnoredFields change on every write, so recording them would only add noise
+var auditIgnoredFields = map[string]bool{"insert_time": true, "last_update_time": true, "last_used_time": true}
+
+var auditRedactedValue = json.RawMessage(`"[redacted]"`)
+
+// AuditResult is a page of audit events; pass NextPage as before to get the next page
+type AuditResult struct {
+	AuditEvents []model.AuditEvent `json:"auditEvents"`
+	NextPage    string             `json:"next_page"`
+}
+
+// GetAuditEvents returns the society's audit events that match the filter, newest first
+func (api API) GetAuditEvents(ctx context.Context, filter model.AuditFilter) (*AuditResult, error) {
+	if api.auditPersister == nil {
+		return nil, NewHTTPError(errors.New("audit log is not configured"), http.StatusNotImplemented)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAuditLimit
+	}
+	if filter.Limit > MaxAuditLimit {
+		filter.Limit = MaxAuditLimit
+	}
+	limit := filter.Limit
+	// read one extra event to find out whether there is another page
+	filter.Limit++
+	auditEvents, err := api.auditPersister.SelectAuditEvents(ctx, filter)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	result := &AuditResult{AuditEvents: auditEvents}
+	if len(auditEvents) > limit {
+		result.AuditEvents = auditEvents[:limit]
+		result.NextPage = strconv.FormatUint(uint64(auditEvents[limit-1].ID), 10)
+	}
+	return result, nil
+}
+
+// audit appends an event to the society's audit log, recording the fields that differ between before and after;
+// before is nil for creates and after is nil for deletes.
+// Only changes made by users and API keys are audited; workers record what they do in post events.
+// Like post events, audit events are best-effort, so failures are logged but not returned.
+func (api API) audit(ctx context.Context, body model.AuditEventBody, before, after interface{}) {
+	user, err := utils.GetUserFromContext(ctx)
+	if err != nil || user == nil {
+		return
+	}
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+	body.User = user.ID
+	if apiKey, err := utils.GetAPIKeyFromContext(ctx); err == nil {
+		body.APIKey = apiKey.ID
+	}
+	if requestID, err := utils.GetRequestIDFromContext(ctx); err == nil {
+		body.RequestID = requestID
+	}
+	body.Changes, err = auditChanges(before, after)
+	if err != nil {
+		log.Printf("[ERROR] computing changes for audit event %#v %v", body, err)
+	}
+	in := model.AuditEventIn{
+		AuditEventBody: body,
+		SocietyID:      societyID,
+	}
+	if api.auditPersister == nil {
+		log.Printf("[INFO] audit event %#v", in)
+		return
+	}
+	if _, err := api.auditPersister.InsertAuditEvent(ctx, in); err != nil {
+		log.Printf("[ERROR] adding audit event %#v %v", in, err)
+	}
+}
+
+// postAuditAction returns publish or unpublish when an update requests that the post be published or unpublished
+func postAuditAction(curr, next *model.Post) model.AuditAction {
+	if curr.PostStatus != next.PostStatus {
+		switch next.PostStatus {
+		case model.PostStatusToPublish:
+			return model.AuditActionPublish
+		case model.PostStatusToUnpublish:
+			return model.AuditActionUnpublish
+		}
+	}
+	return model.AuditActionUpdate
+}
+
+// auditChanges returns the top-level JSON fields whose values differ between before and after
+func auditChanges(before, after interface{}) (map[string]model.AuditChange, error) {
+	from, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := map[string]model.AuditChange{}
+	for field, value := range from {
+		if !bytes.Equal(value, to[field]) {
+			changes[field] = model.AuditChange{From: value, To: to[field]}
+		}
+	}
+	for field, value := range to {
+		if _, ok := from[field]; !ok {
+			changes[field] = model.AuditChange{To: value}
+		}
+	}
+	for field, change := range changes {
+		if auditRedactedFields[field] {
+			if change.From != nil {
+				change.From = auditRedactedValue
+			}
+			if change.To != nil {
+				change.To = auditRedactedValue
+			}
+			changes[field] = change
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return changes, nil
+}
+
+func auditFields(v interface{}) (map[string]json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	for field := range auditIgnoredFields {
+		delete(fields, field)
+	}
+	return fields, nil
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// auditMock holds audit events in memory
+type auditMock struct {
+	events []model.AuditEvent
+}
+
+func (am *auditMock) SelectAuditEvents(ctx context.Context, filter model.AuditFilter) ([]model.AuditEvent, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]model.AuditEvent, 0)
+	for i := len(am.events) - 1; i >= 0; i-- {
+		event := am.events[i]
+		if event.SocietyID != societyID || (filter.Before > 0 && event.ID >= filter.Before) || !filter.Matches(event) {
+			continue
+		}
+		events = append(events, event)
+		if len(events) == filter.Limit {
+			break
+		}
+	}
+	return events, nil
+}
+func (am *auditMock) InsertAuditEvent(ctx context.Context, in model.AuditEventIn) (*model.AuditEvent, error) {
+	event := model.AuditEvent{ID: uint32(len(am.events) + 1), AuditEventIn: in, InsertTime: time.Now()}
+	am.events = append(am.events, event)
+	return &event, nil
+}
+
+func TestAuditChanges(t *testing.T) {
+	before := model.Society{ID: 1, SocietyIn: model.NewSocietyIn("Old name", "secret-1", ""), LastUpdateTime: time.Now()}
+	after := before
+	after.Name = "New name"
+	after.SecretKey = "secret-2"
+	after.LastUpdateTime = time.Now().Add(time.Minute)
+
+	changes, err := auditChanges(&before, &after)
+	assert.NoError(t, err)
+	// timestamps are ignored, and secrets are redacted
+	assert.Equal(t, map[string]model.AuditChange{
+		"name":      {From: json.RawMessage(`"Old name"`), To: json.RawMessage(`"New name"`)},
+		"secretKey": {From: auditRedactedValue, To: auditRedactedValue},
+	}, changes)
+
+	// creates and deletes record every field
+	changes, err = auditChanges(nil, &after)
+	assert.NoError(t, err)
+	assert.Equal(t, json.RawMessage(`"New name"`), changes["name"].To)
+	assert.Nil(t, changes["name"].From)
+	var none *model.Society
+	changes, err = auditChanges(&before, none)
+	assert.NoError(t, err)
+	assert.Equal(t, json.RawMessage(`"Old name"`), changes["name"].From)
+	assert.Nil(t, changes["name"].To)
+
+	changes, err = auditChanges(&before, &before)
+	assert.NoError(t, err)
+	assert.Nil(t, changes)
+}
+
+func TestAudit(t *testing.T) {
+	am := &auditMock{}
+	sm := &societyUserMock{societyUsers: []model.SocietyUser{
+		{ID: 1, SocietyUserIn: model.SocietyUserIn{SocietyUserBody: model.SocietyUserBody{Level: model.AuthAdmin}, UserID: 10, SocietyID: 7}},
+		{ID: 2, SocietyUserIn: model.SocietyUserIn{SocietyUserBody: model.SocietyUserBody{Level: model.AuthReader}, UserID: 11, SocietyID: 7}},
+	}}
+	testAPI := &API{validate: validator.New()}
+	testAPI.SocietyUserPersister(sm).AuditPersister(am)
+	ctx := utils.AddSocietyIDToContext(userContext("admin@example.com", 10), 7)
+	ctx = utils.AddRequestIDToContext(ctx, "req-1")
+
+	// a level change records who made it, the request, and the old and new levels
+	in := SocietyUserEmail{SocietyUser: sm.societyUsers[1]}
+	in.Level = model.AuthEditor
+	_, err := testAPI.UpdateSocietyUserEmail(ctx, 2, in)
+	assert.NoError(t, err)
+	assert.Len(t, am.events, 1)
+	event := am.events[0]
+	assert.Equal(t, uint32(7), event.SocietyID)
+	assert.Equal(t, uint32(10), event.User)
+	assert.Equal(t, "req-1", event.RequestID)
+	assert.Equal(t, model.AuditEntitySocietyUser, event.Entity)
+	assert.Equal(t, uint32(2), event.EntityID)
+	assert.Equal(t, model.AuditActionUpdate, event.Action)
+	assert.Equal(t, map[string]model.AuditChange{
+		"level": {From: json.RawMessage("1"), To: json.RawMessage("3")},
+	}, event.Changes)
+
+	// changes made with an API key record the key
+	apiKey := &model.APIKey{ID: 5}
+	keyCtx := utils.AddUserToContext(utils.AddAPIKeyToContext(utils.AddSocietyIDToContext(context.TODO(), 7), apiKey), &model.User{})
+	testAPI.audit(keyCtx, model.AuditEventBody{Entity: model.AuditEntityPost, EntityID: 3, Action: model.AuditActionPublish}, nil, nil)
+	assert.Len(t, am.events, 2)
+	assert.Equal(t, uint32(5), am.events[1].APIKey)
+
+	// workers don't have a user, and aren't audited
+	workerCtx := utils.AddSocietyIDToContext(context.TODO(), 7)
+	testAPI.audit(workerCtx, model.AuditEventBody{Entity: model.AuditEntityPost, EntityID: 3, Action: model.AuditActionUpdate}, nil, nil)
+	assert.Len(t, am.events, 2)
+}
+
+func TestGetAuditEvents(t *testing.T) {
+	am := &auditMock{}
+	testAPI := &API{validate: validator.New()}
+	_, err := testAPI.GetAuditEvents(context.TODO(), model.AuditFilter{})
+	assert.Equal(t, http.StatusNotImplemented, err.(*Error).HTTPStatus())
+
+	testAPI.AuditPersister(am)
+	ctx := utils.AddSocietyIDToContext(userContext("admin@example.com", 10), 7)
+	for i := 0; i < 5; i++ {
+		testAPI.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityCategory, EntityID: uint32(i + 1), Action: model.AuditActionCreate}, nil, nil)
+	}
+	testAPI.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityPost, EntityID: 1, Action: model.AuditActionDelete}, nil, nil)
+	// other societies' events aren't returned
+	otherCtx := utils.AddSocietyIDToContext(userContext("admin@example.com", 10), 8)
+	testAPI.audit(otherCtx, model.AuditEventBody{Entity: model.AuditEntityCategory, EntityID: 9, Action: model.AuditActionCreate}, nil, nil)
+
+	// page through the categories, newest first
+	result, err := testAPI.GetAuditEvents(ctx, model.AuditFilter{Entity: model.AuditEntityCategory, Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, result.AuditEvents, 2)
+	assert.Equal(t, uint32(5), result.AuditEvents[0].EntityID)
+	assert.Equal(t, uint32(4), result.AuditEvents[1].EntityID)
+	assert.Equal(t, "4", result.NextPage)
+
+	result, err = testAPI.GetAuditEvents(ctx, model.AuditFilter{Entity: model.AuditEntityCategory, Limit: 2, Before: 4})
+	assert.NoError(t, err)
+	assert.Len(t, result.AuditEvents, 2)
+	assert.Equal(t, "2", result.NextPage)
+
+	result, err = testAPI.GetAuditEvents(ctx, model.AuditFilter{Entity: model.AuditEntityCategory, Limit: 2, Before: 2})
+	assert.NoError(t, err)
+	assert.Len(t, result.AuditEvents, 1)
+	assert.Empty(t, result.NextPage)
+
+	result, err = testAPI.GetAuditEvents(ctx, model.AuditFilter{Action: model.AuditActionDelete})
+	assert.NoError(t, err)
+	assert.Len(t, result.AuditEvents, 1)
+	assert.Equal(t, model.AuditEntityPost, result.AuditEvents[0].Entity)
+}
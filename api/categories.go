@@ -50,6 +50,7 @@ func (api API) AddCategory(ctx context.Context, in model.CategoryIn) (*model.Cat
 	if err != nil {
 		return nil, NewError(err)
 	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityCategory, EntityID: category.ID, Action: model.AuditActionCreate}, nil, category)
 	return category, nil
 }
 
@@ -59,18 +60,22 @@ func (api API) UpdateCategory(ctx context.Context, id uint32, in model.Category)
 	if err != nil {
 		return nil, NewError(err)
 	}
+	old, _ := api.categoryPersister.SelectOneCategory(ctx, id)
 	category, err := api.categoryPersister.UpdateCategory(ctx, id, in)
 	if err != nil {
 		return nil, NewError(err)
 	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityCategory, EntityID: id, Action: model.AuditActionUpdate}, old, category)
 	return category, nil
 }
 
 // DeleteCategory holds the business logic around deleting a Category
 func (api API) DeleteCategory(ctx context.Context, id uint32) error {
+	old, _ := api.categoryPersister.SelectOneCategory(ctx, id)
 	err := api.categoryPersister.DeleteCategory(ctx, id)
 	if err != nil {
 		return NewError(err)
 	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityCategory, EntityID: id, Action: model.AuditActionDelete}, old, nil)
 	return nil
 }
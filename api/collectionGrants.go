@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+// GetCollectionGrants returns the collection grants for the society in the context
+func (api API) GetCollectionGrants(ctx context.Context) ([]model.CollectionGrant, error) {
+	if err := api.checkCollectionGrantsConfigured(); err != nil {
+		return nil, err
+	}
+	grants, err := api.collectionGrantPersister.SelectCollectionGrants(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	return grants, nil
+}
+
+// AddCollectionGrant grants a user a level on a collection or category, replacing any grant the user already has on it
+func (api API) AddCollectionGrant(ctx context.Context, in model.CollectionGrantIn) (*model.CollectionGrant, error) {
+	if err := api.checkCollectionGrantsConfigured(); err != nil {
+		return nil, err
+	}
+	err := api.validate.Struct(in)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	if (in.CollectionID == 0) == (in.CategoryID == 0) {
+		return nil, NewHTTPError(errors.New("exactly one of collectionId and categoryId is required"), http.StatusBadRequest)
+	}
+	// the collection or category and the user must belong to the society
+	if in.CollectionID != 0 {
+		_, err = api.collectionPersister.SelectOneCollection(ctx, in.CollectionID)
+	} else {
+		_, err = api.categoryPersister.SelectOneCategory(ctx, in.CategoryID)
+	}
+	if model.ErrNotFound.Matches(err) {
+		return nil, NewHTTPError(fmt.Errorf("collection %d or category %d not found in society", in.CollectionID, in.CategoryID), http.StatusBadRequest)
+	}
+	if err != nil {
+		return nil, NewError(err)
+	}
+	_, err = api.societyUserPersister.SelectOneSocietyUserByUser(ctx, in.UserID)
+	if model.ErrNotFound.Matches(err) {
+		return nil, NewHTTPError(fmt.Errorf("user %d is not a member of the society", in.UserID), http.StatusBadRequest)
+	}
+	if err != nil {
+		return nil, NewError(err)
+	}
+	grants, err := api.collectionGrantPersister.SelectCollectionGrantsByUser(ctx, in.UserID)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	var replaced *model.CollectionGrant
+	for i, grant := range grants {
+		if grant.CollectionID == in.CollectionID && grant.CategoryID == in.CategoryID {
+			if err := api.collectionGrantPersister.DeleteCollectionGrant(ctx, grant.ID); err != nil {
+				return nil, NewError(err)
+			}
+			replaced = &grants[i]
+		}
+	}
+	grant, err := api.collectionGrantPersister.InsertCollectionGrant(ctx, in)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	if replaced != nil {
+		api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityCollectionGrant, EntityID: replaced.ID, Action: model.AuditActionDelete}, replaced, nil)
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityCollectionGrant, EntityID: grant.ID, Action: model.AuditActionCreate}, nil, grant)
+	return grant, nil
+}
+
+// DeleteCollectionGrant removes a collection grant, so the user's society level applies again
+func (api API) DeleteCollectionGrant(ctx context.Context, id uint32) error {
+	if err := api.checkCollectionGrantsConfigured(); err != nil {
+		return err
+	}
+	var old *model.CollectionGrant
+	if grants, err := api.collectionGrantPersister.SelectCollectionGrants(ctx); err == nil {
+		for i := range grants {
+			if grants[i].ID == id {
+				old = &grants[i]
+			}
+		}
+	}
+	err := api.collectionGrantPersister.DeleteCollectionGrant(ctx, id)
+	if err != nil {
+		return NewError(err)
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityCollectionGrant, EntityID: id, Action: model.AuditActionDelete}, old, nil)
+	return nil
+}
+
+// GetPermissions returns a user's society level combined with the user's collection grants
+func (api API) GetPermissions(ctx context.Context, userID uint32, level model.AuthLevel) (*model.Permissions, error) {
+	var grants []model.CollectionGrant
+	if api.collectionGrantPersister != nil && level < model.AuthAdmin {
+		var err error
+		grants, err = api.collectionGrantPersister.SelectCollectionGrantsByUser(ctx, userID)
+		if err != nil {
+			return nil, NewError(err)
+		}
+	}
+	permissions := model.NewPermissions(level, grants)
+	return &permissions, nil
+}
+
+func (api *API) checkCollectionGrantsConfigured() error {
+	if api.collectionGrantPersister == nil {
+		return NewHTTPError(errors.New("Collection grants are not configured"), http.StatusNotImplemented)
+	}
+	return nil
+}
+
+// grantedPermissions returns the permissions in the context if grants give the user different levels on
+// different collections, or nil if the society level checked when the request was authenticated applies everywhere
+func grantedPermissions(ctx context.Context) *model.Permissions {
+	permissions, err := utils.GetPermissionsFromContext(ctx)
+	if err != nil || !permissions.HasGrants() {
+		return nil
+	}
+	return permissions
+}
+
+// canAccessCollection returns true if the current user's level on the collection is at least minLevel
+func canAccessCollection(ctx context.Context, id uint32, categories []uint32, minLevel model.AuthLevel) bool {
+	permissions := grantedPermissions(ctx)
+	return permissions == nil || permissions.CollectionLevel(id, categories) >= minLevel
+}
+
+// checkCollectionLevel returns a forbidden error if the current user's level on the collection is below minLevel
+func checkCollectionLevel(ctx context.Context, id uint32, categories []uint32, minLevel model.AuthLevel) error {
+	if !canAccessCollection(ctx, id, categories, minLevel) {
+		return NewHTTPError(fmt.Errorf("level '%s' is required for collection %d", minLevel, id), http.StatusForbidden)
+	}
+	return nil
+}
+
+// checkCollectionIDLevel reads a collection and checks the current user's level on it
+func (api API) checkCollectionIDLevel(ctx context.Context, id uint32, minLevel model.AuthLevel) error {
+	if grantedPermissions(ctx) == nil {
+		return nil
+	}
+	collection, err := api.collectionPersister.SelectOneCollection(ctx, id)
+	if err != nil {
+		return NewError(err)
+	}
+	return checkCollectionLevel(ctx, collection.ID, collection.Categories, minLevel)
+}
+
+// checkPostLevel reads a post and checks the current user's level on its collection
+func (api API) checkPostLevel(ctx context.Context, postID uint32, minLevel model.AuthLevel) error {
+	if grantedPermissions(ctx) == nil {
+		return nil
+	}
+	post, err := api.postPersister.SelectOnePost(ctx, postID)
+	if err != nil {
+		return NewError(err)
+	}
+	return api.checkCollectionIDLevel(ctx, post.Collection, minLevel)
+}
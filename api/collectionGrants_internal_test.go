@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// collectionGrantMock holds collection grants in memory
+type collectionGrantMock struct {
+	grants []model.CollectionGrant
+}
+
+func (gm *collectionGrantMock) SelectCollectionGrants(ctx context.Context) ([]model.CollectionGrant, error) {
+	return gm.grants, nil
+}
+func (gm *collectionGrantMock) SelectCollectionGrantsByUser(ctx context.Context, userID uint32) ([]model.CollectionGrant, error) {
+	var grants []model.CollectionGrant
+	for _, grant := range gm.grants {
+		if grant.UserID == userID {
+			grants = append(grants, grant)
+		}
+	}
+	return grants, nil
+}
+func (gm *collectionGrantMock) InsertCollectionGrant(ctx context.Context, in model.CollectionGrantIn) (*model.CollectionGrant, error) {
+	grant := model.NewCollectionGrant(uint32(len(gm.grants)+100), in)
+	gm.grants = append(gm.grants, grant)
+	return &grant, nil
+}
+func (gm *collectionGrantMock) DeleteCollectionGrant(ctx context.Context, id uint32) error {
+	for i, grant := range gm.grants {
+		if grant.ID == id {
+			gm.grants = append(gm.grants[:i], gm.grants[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+func (gm *collectionGrantMock) DeleteCollectionGrantsByUser(ctx context.Context, userID uint32) error {
+	return fmt.Errorf("DeleteCollectionGrantsByUser not implemented")
+}
+
+// collectionMock holds collections in memory
+type collectionMock struct {
+	collections []model.Collection
+}
+
+func (cm *collectionMock) SelectCollections(ctx context.Context) ([]model.Collection, error) {
+	return cm.collections, nil
+}
+func (cm *collectionMock) SelectCollectionsByID(ctx context.Context, ids []uint32, enforceContextSocietyMatch bool) ([]model.Collection, error) {
+	return nil, fmt.Errorf("SelectCollectionsByID not implemented")
+}
+func (cm *collectionMock) SelectOneCollection(ctx context.Context, id uint32) (*model.Collection, error) {
+	for _, collection := range cm.collections {
+		if collection.ID == id {
+			return &collection, nil
+		}
+	}
+	return nil, model.NewError(model.ErrNotFound, fmt.Sprint(id))
+}
+func (cm *collectionMock) InsertCollection(ctx context.Context, in model.CollectionIn) (*model.Collection, error) {
+	collection := model.NewCollection(uint32(len(cm.collections)+1), in)
+	cm.collections = append(cm.collections, collection)
+	return &collection, nil
+}
+func (cm *collectionMock) UpdateCollection(ctx context.Context, id uint32, in model.Collection) (*model.Collection, error) {
+	return nil, fmt.Errorf("UpdateCollection not implemented")
+}
+func (cm *collectionMock) DeleteCollection(ctx context.Context, id uint32) error {
+	return nil
+}
+
+func TestCollectionGrants(t *testing.T) {
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 1)
+	gm := &collectionGrantMock{}
+	testAPI := &API{validate: validator.New()}
+	testAPI.CollectionGrantPersister(gm).CollectionPersister(&collectionMock{collections: []model.Collection{
+		model.NewCollection(1, model.CollectionIn{Categories: []uint32{10}}),
+		model.NewCollection(2, model.CollectionIn{Categories: []uint32{10}}),
+		model.NewCollection(3, model.CollectionIn{Categories: []uint32{11}}),
+	}}).CategoryPersister(&categoryMock{categories: []model.Category{
+		model.NewCategory(10, model.CategoryIn{}),
+		model.NewCategory(11, model.CategoryIn{}),
+	}}).SocietyUserPersister(&societyUserMock{societyUsers: []model.SocietyUser{
+		{ID: 1, SocietyUserIn: model.SocietyUserIn{UserID: 5, SocietyID: 1}},
+	}})
+
+	// a grant targets exactly one of a collection and a category
+	_, err := testAPI.AddCollectionGrant(ctx, model.CollectionGrantIn{UserID: 5})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.(*Error).HTTPStatus())
+	_, err = testAPI.AddCollectionGrant(ctx, model.CollectionGrantIn{UserID: 5, CollectionID: 1, CategoryID: 10})
+	assert.Error(t, err)
+	_, err = testAPI.AddCollectionGrant(ctx, model.CollectionGrantIn{
+		CollectionGrantBody: model.CollectionGrantBody{Level: model.AuthAdmin}, UserID: 5, CollectionID: 1})
+	assert.Error(t, err)
+
+	// the collection or category and the user must belong to the society
+	_, err = testAPI.AddCollectionGrant(ctx, model.CollectionGrantIn{
+		CollectionGrantBody: model.CollectionGrantBody{Level: model.AuthEditor}, UserID: 5, CollectionID: 4})
+	assert.Equal(t, http.StatusBadRequest, err.(*Error).HTTPStatus())
+	_, err = testAPI.AddCollectionGrant(ctx, model.CollectionGrantIn{
+		CollectionGrantBody: model.CollectionGrantBody{Level: model.AuthEditor}, UserID: 5, CategoryID: 12})
+	assert.Equal(t, http.StatusBadRequest, err.(*Error).HTTPStatus())
+	_, err = testAPI.AddCollectionGrant(ctx, model.CollectionGrantIn{
+		CollectionGrantBody: model.CollectionGrantBody{Level: model.AuthEditor}, UserID: 7, CollectionID: 1})
+	assert.Equal(t, http.StatusBadRequest, err.(*Error).HTTPStatus())
+	assert.Empty(t, gm.grants)
+
+	// a second grant on the same collection replaces the first
+	_, err = testAPI.AddCollectionGrant(ctx, model.CollectionGrantIn{
+		CollectionGrantBody: model.CollectionGrantBody{Level: model.AuthEditor}, UserID: 5, CollectionID: 1})
+	assert.NoError(t, err)
+	_, err = testAPI.AddCollectionGrant(ctx, model.CollectionGrantIn{
+		CollectionGrantBody: model.CollectionGrantBody{Level: model.AuthContributor}, UserID: 5, CollectionID: 1})
+	assert.NoError(t, err)
+	_, err = testAPI.AddCollectionGrant(ctx, model.CollectionGrantIn{
+		CollectionGrantBody: model.CollectionGrantBody{Level: model.AuthGuest}, UserID: 5, CategoryID: 11})
+	assert.NoError(t, err)
+	grants, err := testAPI.GetCollectionGrants(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, grants, 2)
+
+	// a society reader sees collections 1 and 2, can contribute only to 1, and can't see 3
+	permissions, err := testAPI.GetPermissions(ctx, 5, model.AuthReader)
+	assert.NoError(t, err)
+	assert.Equal(t, model.AuthLevel(model.AuthContributor), permissions.MaxLevel())
+	userCtx := utils.AddPermissionsToContext(ctx, permissions)
+	result, err := testAPI.GetCollections(userCtx)
+	assert.NoError(t, err)
+	assert.Len(t, result.Collections, 2)
+	_, err = testAPI.GetCollection(userCtx, 3)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, err.(*Error).HTTPStatus())
+	assert.NoError(t, testAPI.checkCollectionIDLevel(userCtx, 1, model.AuthContributor))
+	assert.Error(t, testAPI.checkCollectionIDLevel(userCtx, 2, model.AuthContributor))
+	err = testAPI.DeleteCollection(userCtx, 1)
+	assert.Error(t, err)
+
+	// without grants the society level applies everywhere
+	result, err = testAPI.GetCollections(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, result.Collections, 3)
+	permissions, err = testAPI.GetPermissions(ctx, 6, model.AuthReader)
+	assert.NoError(t, err)
+	assert.False(t, permissions.HasGrants())
+}
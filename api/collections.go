@@ -2,7 +2,10 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net/http"
+	"reflect"
 
 	"github.com/ourrootsorg/cms-server/model"
 )
@@ -20,6 +23,16 @@ func (api API) GetCollections(ctx context.Context /* filter/search criteria */)
 	if err != nil {
 		return nil, NewError(err)
 	}
+	// omit collections the user's grants hide
+	if grantedPermissions(ctx) != nil {
+		var readable []model.Collection
+		for _, col := range cols {
+			if canAccessCollection(ctx, col.ID, col.Categories, model.AuthReader) {
+				readable = append(readable, col)
+			}
+		}
+		cols = readable
+	}
 	return &CollectionResult{Collections: cols}, nil
 }
 
@@ -38,6 +51,9 @@ func (api API) GetCollection(ctx context.Context, id uint32) (*model.Collection,
 	if err != nil {
 		return nil, NewError(err)
 	}
+	if err := checkCollectionLevel(ctx, collection.ID, collection.Categories, model.AuthReader); err != nil {
+		return nil, err
+	}
 	return collection, nil
 }
 
@@ -48,10 +64,14 @@ func (api API) AddCollection(ctx context.Context, in model.CollectionIn) (*model
 		log.Printf("[ERROR] Invalid collection %v", err)
 		return nil, NewError(err)
 	}
+	if err := checkCollectionLevel(ctx, 0, in.Categories, model.AuthEditor); err != nil {
+		return nil, err
+	}
 	collection, e := api.collectionPersister.InsertCollection(ctx, in)
 	if e != nil {
 		return nil, NewError(e)
 	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityCollection, EntityID: collection.ID, Action: model.AuditActionCreate}, nil, collection)
 	return collection, nil
 }
 
@@ -62,18 +82,57 @@ func (api API) UpdateCollection(ctx context.Context, id uint32, in model.Collect
 	if err != nil {
 		return nil, NewError(err)
 	}
+	currCollection, e := api.GetCollection(ctx, id)
+	if e != nil {
+		return nil, e
+	}
+	// the user must be able to edit the collection both before and after its categories change
+	if err := checkCollectionLevel(ctx, id, currCollection.Categories, model.AuthEditor); err != nil {
+		return nil, err
+	}
+	if err := checkCollectionLevel(ctx, id, in.Categories, model.AuthEditor); err != nil {
+		return nil, err
+	}
 	collection, e := api.collectionPersister.UpdateCollection(ctx, id, in)
 	if e != nil {
 		return nil, NewError(e)
 	}
+	// regenerate image derivatives if the watermark changed
+	if !reflect.DeepEqual(currCollection.Watermark, collection.Watermark) {
+		if err := api.requestCollectionImageDerivatives(ctx, id); err != nil {
+			// log the error but don't undo the update
+			log.Printf("[ERROR] requesting image derivatives when updating collection %d %v", id, err)
+		}
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityCollection, EntityID: id, Action: model.AuditActionUpdate}, currCollection, collection)
 	return collection, nil
 }
 
 // DeleteCollection holds the business logic around deleting a Collection
 func (api API) DeleteCollection(ctx context.Context, id uint32) error {
-	err := api.collectionPersister.DeleteCollection(ctx, id)
+	if err := api.checkCollectionIDLevel(ctx, id, model.AuthEditor); err != nil {
+		return err
+	}
+	old, _ := api.collectionPersister.SelectOneCollection(ctx, id)
+	var err error
+	if api.trashEnabled() {
+		// posts can't be restored into a trashed collection, so the collection can't be trashed while it has live posts
+		posts, err := api.postPersister.SelectPosts(ctx)
+		if err != nil {
+			return NewError(err)
+		}
+		for _, post := range posts {
+			if post.Collection == id {
+				return NewHTTPError(fmt.Errorf("collection %d has posts; delete them first", id), http.StatusConflict)
+			}
+		}
+		err = api.trashPersister.TrashCollection(ctx, id)
+	} else {
+		err = api.collectionPersister.DeleteCollection(ctx, id)
+	}
 	if err != nil {
 		return NewError(err)
 	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityCollection, EntityID: id, Action: model.AuditActionDelete}, old, nil)
 	return nil
 }
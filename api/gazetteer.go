@@ -0,0 +1,403 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+// maxPlaceDepth bounds the walk up a place's ancestors when checking for cycles
+const maxPlaceDepth = 20
+
+// GetPlace returns a global place or a place added by the society, along with the society's alternate names for it
+func (api *API) GetPlace(ctx context.Context, id uint32) (*model.Place, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	place, err := api.placePersister.SelectPlace(ctx, id)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	// places added by other societies aren't visible
+	if place.SocietyID != 0 && place.SocietyID != societyID {
+		return nil, NewError(model.NewError(model.ErrNotFound, strconv.Itoa(int(id))))
+	}
+	if place.SocietyID == 0 && api.placeAdminPersister != nil {
+		altNames, err := api.placeAdminPersister.SelectPlaceAltNames(ctx, id)
+		if err != nil {
+			return nil, NewError(err)
+		}
+		place.LocalAltNames = altNames
+	}
+	return place, nil
+}
+
+// AddPlace adds a place to the society's gazetteer
+func (api *API) AddPlace(ctx context.Context, in model.PlaceIn) (*model.Place, error) {
+	if err := api.checkGazetteerConfigured(); err != nil {
+		return nil, err
+	}
+	if err := api.validate.Struct(in); err != nil {
+		log.Printf("[ERROR] Invalid place %v", err)
+		return nil, NewError(err)
+	}
+	place, err := api.placeFromIn(ctx, 0, in)
+	if err != nil {
+		return nil, err
+	}
+	place, err = api.placeAdminPersister.InsertPlace(ctx, *place)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	if err := api.updatePlaceWords(ctx, place.ID, nil, api.placeWords(place.AllNames())); err != nil {
+		return nil, err
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityPlace, EntityID: place.ID, Action: model.AuditActionCreate}, nil, place)
+	return place, nil
+}
+
+// UpdatePlace renames, re-parents or otherwise updates a place added by the society
+// The full names of places located within it are updated to match
+func (api *API) UpdatePlace(ctx context.Context, id uint32, in model.PlaceIn) (*model.Place, error) {
+	if err := api.checkGazetteerConfigured(); err != nil {
+		return nil, err
+	}
+	if err := api.validate.Struct(in); err != nil {
+		log.Printf("[ERROR] Invalid place %v", err)
+		return nil, NewError(err)
+	}
+	curr, err := api.getSocietyPlace(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	place, err := api.placeFromIn(ctx, id, in)
+	if err != nil {
+		return nil, err
+	}
+	place, err = api.placeAdminPersister.UpdatePlace(ctx, id, *place)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	api.placeStandardizer.InvalidatePlaces(id)
+	if err := api.updatePlaceWords(ctx, id, api.placeWords(curr.AllNames()), api.placeWords(place.AllNames())); err != nil {
+		return nil, err
+	}
+	if place.FullName != curr.FullName || place.Level != curr.Level || place.CountryID != curr.CountryID {
+		if err := api.updateChildPlaces(ctx, place, maxPlaceDepth); err != nil {
+			return nil, err
+		}
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityPlace, EntityID: id, Action: model.AuditActionUpdate}, curr, place)
+	return place, nil
+}
+
+// MergePlace merges a place added by the society into another place
+// Places located within the merged place are moved into the other place, and the names of the merged place become
+// the society's alternate names for the other place
+func (api *API) MergePlace(ctx context.Context, id uint32, in model.PlaceMerge) (*model.Place, error) {
+	if err := api.checkGazetteerConfigured(); err != nil {
+		return nil, err
+	}
+	if err := api.validate.Struct(in); err != nil {
+		return nil, NewError(err)
+	}
+	from, err := api.getSocietyPlace(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	into, err := api.getReferencedPlace(ctx, in.IntoID)
+	if err != nil {
+		return nil, err
+	}
+	if into.ID == from.ID {
+		return nil, NewHTTPError(errors.New("can't merge a place into itself"), http.StatusBadRequest)
+	}
+	if inside, err := api.isPlaceWithin(ctx, into, from.ID); err != nil || inside {
+		if err != nil {
+			return nil, err
+		}
+		return nil, NewHTTPError(fmt.Errorf("can't merge place %d into place %d located within it", from.ID, into.ID), http.StatusBadRequest)
+	}
+
+	// move children
+	children, err := api.placeAdminPersister.SelectPlaceChildren(ctx, from.ID)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	for _, child := range children {
+		child.LocatedInID = into.ID
+		if err := api.moveChildPlace(ctx, &child, into, maxPlaceDepth); err != nil {
+			return nil, err
+		}
+	}
+
+	// keep the names of the merged place as alternate names
+	if _, err := api.addPlaceAltNames(ctx, into, from.AllNames()); err != nil {
+		return nil, err
+	}
+
+	// remove the merged place
+	if err := api.updatePlaceWords(ctx, from.ID, api.placeWords(from.AllNames()), nil); err != nil {
+		return nil, err
+	}
+	if err := api.placeAdminPersister.DeletePlace(ctx, from.ID); err != nil {
+		return nil, NewError(err)
+	}
+	api.placeStandardizer.InvalidatePlaces(from.ID)
+	merged, err := api.GetPlace(ctx, into.ID)
+	if err != nil {
+		return nil, err
+	}
+	// the merged place is recorded as deleted, and the place it was merged into as changed
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityPlace, EntityID: from.ID, Action: model.AuditActionDelete}, from, nil)
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityPlace, EntityID: into.ID, Action: model.AuditActionMerge}, into, merged)
+	return merged, nil
+}
+
+// UpdatePlaceAltNames sets the society's alternate names for a place
+// Alternate names of global places are only used when standardizing the society's records
+func (api *API) UpdatePlaceAltNames(ctx context.Context, id uint32, in model.PlaceAltNames) (*model.Place, error) {
+	if err := api.checkGazetteerConfigured(); err != nil {
+		return nil, err
+	}
+	place, err := api.GetPlace(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	old := *place
+	auditBody := model.AuditEventBody{Entity: model.AuditEntityPlace, EntityID: id, Action: model.AuditActionUpdate}
+	altNames := cleanPlaceNames(in.AltNames)
+	if place.SocietyID != 0 {
+		oldWords := api.placeWords(place.AllNames())
+		place.AltNames = altNames
+		place, err = api.placeAdminPersister.UpdatePlace(ctx, id, *place)
+		if err != nil {
+			return nil, NewError(err)
+		}
+		api.placeStandardizer.InvalidatePlaces(id)
+		if err := api.updatePlaceWords(ctx, id, oldWords, api.placeWords(place.AllNames())); err != nil {
+			return nil, err
+		}
+		api.audit(ctx, auditBody, old, place)
+		return place, nil
+	}
+	if err := api.placeAdminPersister.UpdatePlaceAltNames(ctx, id, altNames); err != nil {
+		return nil, NewError(err)
+	}
+	if err := api.updatePlaceWords(ctx, id, api.placeWords(place.LocalAltNames), api.placeWords(altNames)); err != nil {
+		return nil, err
+	}
+	place.LocalAltNames = altNames
+	api.audit(ctx, auditBody, old, place)
+	return place, nil
+}
+
+func (api *API) checkGazetteerConfigured() error {
+	if api.placeAdminPersister == nil || api.placeStandardizer == nil {
+		return NewHTTPError(errors.New("gazetteer administration is not configured"), http.StatusNotImplemented)
+	}
+	return nil
+}
+
+// getSocietyPlace returns a place that the society in the context is allowed to change
+func (api *API) getSocietyPlace(ctx context.Context, id uint32) (*model.Place, error) {
+	place, err := api.GetPlace(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if place.SocietyID == 0 {
+		return nil, NewHTTPError(fmt.Errorf("place %d is part of the global gazetteer; add alternate names instead", id), http.StatusForbidden)
+	}
+	return place, nil
+}
+
+// getReferencedPlace returns a place referenced by another place, or a bad reference error
+func (api *API) getReferencedPlace(ctx context.Context, id uint32) (*model.Place, error) {
+	place, err := api.GetPlace(ctx, id)
+	if err != nil {
+		if e, ok := err.(*Error); ok && len(e.errs) > 0 && e.errs[0].Code == model.ErrNotFound {
+			return nil, NewError(model.NewError(model.ErrBadReference, strconv.Itoa(int(id)), "place"))
+		}
+		return nil, err
+	}
+	return place, nil
+}
+
+// placeFromIn constructs a place from the payload, deriving its full name, level and country from its parent
+func (api *API) placeFromIn(ctx context.Context, id uint32, in model.PlaceIn) (*model.Place, error) {
+	place := &model.Place{
+		ID:               id,
+		Name:             strings.TrimSpace(in.Name),
+		AltNames:         cleanPlaceNames(in.AltNames),
+		Types:            in.Types,
+		LocatedInID:      in.LocatedInID,
+		AlsoLocatedInIDs: in.AlsoLocatedInIDs,
+		Latitude:         in.Latitude,
+		Longitude:        in.Longitude,
+		Periods:          in.Periods,
+	}
+	if place.Types == nil {
+		place.Types = model.StringSlice{}
+	}
+	if place.AlsoLocatedInIDs == nil {
+		place.AlsoLocatedInIDs = model.Uint32Slice{}
+	}
+	place.FullName = place.Name
+	place.Level = 1
+	if in.LocatedInID != 0 {
+		parent, err := api.getReferencedPlace(ctx, in.LocatedInID)
+		if err != nil {
+			return nil, err
+		}
+		if id != 0 {
+			if inside, err := api.isPlaceWithin(ctx, parent, id); err != nil || inside {
+				if err != nil {
+					return nil, err
+				}
+				return nil, NewHTTPError(fmt.Errorf("place %d can't be located within itself", id), http.StatusBadRequest)
+			}
+		}
+		place.FullName = place.Name + ", " + parent.FullName
+		place.Level = parent.Level + 1
+		place.CountryID = parent.CountryID
+	}
+	var parentIDs []uint32
+	parentIDs = append(parentIDs, in.AlsoLocatedInIDs...)
+	for _, period := range in.Periods {
+		parentIDs = append(parentIDs, period.LocatedInID)
+	}
+	for _, parentID := range parentIDs {
+		if parentID == 0 {
+			continue
+		}
+		if parentID == id {
+			return nil, NewHTTPError(fmt.Errorf("place %d can't be located within itself", id), http.StatusBadRequest)
+		}
+		if _, err := api.getReferencedPlace(ctx, parentID); err != nil {
+			return nil, err
+		}
+	}
+	return place, nil
+}
+
+// isPlaceWithin returns true if place is id or is located within id
+func (api *API) isPlaceWithin(ctx context.Context, place *model.Place, id uint32) (bool, error) {
+	for depth := 0; place != nil && depth < maxPlaceDepth; depth++ {
+		if place.ID == id {
+			return true, nil
+		}
+		if place.LocatedInID == 0 {
+			return false, nil
+		}
+		var err error
+		place, err = api.placePersister.SelectPlace(ctx, place.LocatedInID)
+		if err != nil {
+			return false, NewError(err)
+		}
+	}
+	return false, nil
+}
+
+// updateChildPlaces updates the full names, levels and countries of the society's places located within parent
+func (api *API) updateChildPlaces(ctx context.Context, parent *model.Place, max int) error {
+	if max <= 0 {
+		return nil
+	}
+	children, err := api.placeAdminPersister.SelectPlaceChildren(ctx, parent.ID)
+	if err != nil {
+		return NewError(err)
+	}
+	for _, child := range children {
+		if err := api.moveChildPlace(ctx, &child, parent, max); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (api *API) moveChildPlace(ctx context.Context, child, parent *model.Place, max int) error {
+	child.FullName = child.Name + ", " + parent.FullName
+	child.Level = parent.Level + 1
+	child.CountryID = parent.CountryID
+	updated, err := api.placeAdminPersister.UpdatePlace(ctx, child.ID, *child)
+	if err != nil {
+		return NewError(err)
+	}
+	api.placeStandardizer.InvalidatePlaces(child.ID)
+	return api.updateChildPlaces(ctx, updated, max-1)
+}
+
+// addPlaceAltNames adds to the names of a place: to its alternate names if the society added it,
+// otherwise to the society's alternate names for it
+func (api *API) addPlaceAltNames(ctx context.Context, place *model.Place, names []string) (*model.Place, error) {
+	var altNames model.StringSlice
+	if place.SocietyID != 0 {
+		altNames = append(altNames, place.AltNames...)
+	} else {
+		altNames = append(altNames, place.LocalAltNames...)
+	}
+	for _, name := range cleanPlaceNames(names) {
+		if name != place.Name && !containsString(altNames, name) {
+			altNames = append(altNames, name)
+		}
+	}
+	return api.UpdatePlaceAltNames(ctx, place.ID, model.PlaceAltNames{AltNames: altNames})
+}
+
+// placeWords returns the words under which the names are indexed
+func (api *API) placeWords(names []string) []string {
+	var words []string
+	for _, name := range names {
+		word := api.placeStandardizer.NameWord(name)
+		if word != "" && !containsString(words, word) {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// updatePlaceWords updates the society's word index for a place and removes the changed words from the standardizer cache
+func (api *API) updatePlaceWords(ctx context.Context, id uint32, oldWords, newWords []string) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return NewError(err)
+	}
+	var changed []string
+	for _, word := range oldWords {
+		if !containsString(newWords, word) {
+			if err := api.placeAdminPersister.RemovePlaceWordID(ctx, word, id); err != nil {
+				return NewError(err)
+			}
+			changed = append(changed, word)
+		}
+	}
+	for _, word := range newWords {
+		if !containsString(oldWords, word) {
+			if err := api.placeAdminPersister.AddPlaceWordID(ctx, word, id); err != nil {
+				return NewError(err)
+			}
+			changed = append(changed, word)
+		}
+	}
+	api.placeStandardizer.InvalidateWords(societyID, changed...)
+	return nil
+}
+
+func cleanPlaceNames(names []string) model.StringSlice {
+	result := model.StringSlice{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name != "" && !containsString(result, name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
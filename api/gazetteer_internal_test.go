@@ -0,0 +1,193 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/stdplace"
+	"github.com/ourrootsorg/cms-server/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// gazetteerMock is an in-memory gazetteer holding global places and places added by a single society
+type gazetteerMock struct {
+	nextID   uint32
+	places   map[uint32]model.Place
+	altNames map[uint32]model.StringSlice
+	words    map[string]model.Uint32Slice
+}
+
+func newGazetteerMock() *gazetteerMock {
+	return &gazetteerMock{
+		nextID: 1000000000,
+		places: map[uint32]model.Place{
+			1: {ID: 1, Name: "Poland", FullName: "Poland", Level: 1, CountryID: 1},
+			2: {ID: 2, Name: "Wroclaw", FullName: "Wroclaw, Poland", Level: 2, CountryID: 1, LocatedInID: 1},
+		},
+		altNames: map[uint32]model.StringSlice{},
+		words:    map[string]model.Uint32Slice{},
+	}
+}
+
+func (gm *gazetteerMock) SelectPlaceSettings(ctx context.Context) (*model.PlaceSettings, error) {
+	return &model.PlaceSettings{}, nil
+}
+func (gm *gazetteerMock) SelectPlace(ctx context.Context, id uint32) (*model.Place, error) {
+	place, ok := gm.places[id]
+	if !ok {
+		return nil, model.NewError(model.ErrNotFound, fmt.Sprint(id))
+	}
+	return &place, nil
+}
+func (gm *gazetteerMock) SelectPlacesByID(ctx context.Context, ids []uint32) ([]model.Place, error) {
+	var places []model.Place
+	for _, id := range ids {
+		if place, ok := gm.places[id]; ok {
+			places = append(places, place)
+		}
+	}
+	return places, nil
+}
+func (gm *gazetteerMock) SelectPlacesByFullNamePrefix(ctx context.Context, prefix string, count int) ([]model.Place, error) {
+	return nil, nil
+}
+func (gm *gazetteerMock) SelectPlaceWord(ctx context.Context, word string) (*model.PlaceWord, error) {
+	return nil, model.NewError(model.ErrNotFound, word)
+}
+func (gm *gazetteerMock) SelectPlaceWordsByWord(ctx context.Context, words []string) ([]model.PlaceWord, error) {
+	return []model.PlaceWord{}, nil
+}
+func (gm *gazetteerMock) SelectSocietyPlaceWordsByWord(ctx context.Context, societyID uint32, words []string) ([]model.PlaceWord, error) {
+	var placeWords []model.PlaceWord
+	for _, word := range words {
+		if ids, ok := gm.words[word]; ok {
+			placeWords = append(placeWords, model.PlaceWord{Word: word, IDs: ids})
+		}
+	}
+	return placeWords, nil
+}
+func (gm *gazetteerMock) InsertPlace(ctx context.Context, in model.Place) (*model.Place, error) {
+	in.ID = gm.nextID
+	in.SocietyID, _ = utils.GetSocietyIDFromContext(ctx)
+	if in.CountryID == 0 {
+		in.CountryID = in.ID
+	}
+	gm.nextID++
+	gm.places[in.ID] = in
+	return &in, nil
+}
+func (gm *gazetteerMock) UpdatePlace(ctx context.Context, id uint32, in model.Place) (*model.Place, error) {
+	curr, ok := gm.places[id]
+	if !ok || curr.SocietyID == 0 {
+		return nil, model.NewError(model.ErrNotFound, fmt.Sprint(id))
+	}
+	in.ID = id
+	in.SocietyID = curr.SocietyID
+	gm.places[id] = in
+	return &in, nil
+}
+func (gm *gazetteerMock) DeletePlace(ctx context.Context, id uint32) error {
+	delete(gm.places, id)
+	return nil
+}
+func (gm *gazetteerMock) SelectPlaceChildren(ctx context.Context, id uint32) ([]model.Place, error) {
+	var children []model.Place
+	for _, place := range gm.places {
+		if place.LocatedInID == id && place.SocietyID != 0 {
+			children = append(children, place)
+		}
+	}
+	return children, nil
+}
+func (gm *gazetteerMock) SelectPlaceAltNames(ctx context.Context, placeID uint32) (model.StringSlice, error) {
+	if altNames, ok := gm.altNames[placeID]; ok {
+		return altNames, nil
+	}
+	return model.StringSlice{}, nil
+}
+func (gm *gazetteerMock) UpdatePlaceAltNames(ctx context.Context, placeID uint32, altNames model.StringSlice) error {
+	gm.altNames[placeID] = altNames
+	return nil
+}
+func (gm *gazetteerMock) AddPlaceWordID(ctx context.Context, word string, id uint32) error {
+	gm.words[word] = append(gm.words[word], id)
+	return nil
+}
+func (gm *gazetteerMock) RemovePlaceWordID(ctx context.Context, word string, id uint32) error {
+	var ids model.Uint32Slice
+	for _, wordID := range gm.words[word] {
+		if wordID != id {
+			ids = append(ids, wordID)
+		}
+	}
+	if len(ids) == 0 {
+		delete(gm.words, word)
+	} else {
+		gm.words[word] = ids
+	}
+	return nil
+}
+
+func TestGazetteerAdmin(t *testing.T) {
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 7)
+	gm := newGazetteerMock()
+	std, err := stdplace.NewStandardizer(ctx, gm)
+	assert.NoError(t, err)
+	defer std.Close()
+	testAPI := &API{validate: validator.New(), placeStandardizer: std}
+	testAPI.PlacePersister(gm).PlaceAdminPersister(gm)
+
+	// add a district and a cemetery within it
+	district, err := testAPI.AddPlace(ctx, model.PlaceIn{Name: "Krzyki", LocatedInID: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "Krzyki, Wroclaw, Poland", district.FullName)
+	assert.Equal(t, 3, district.Level)
+	assert.Equal(t, uint32(1), district.CountryID)
+	cemetery, err := testAPI.AddPlace(ctx, model.PlaceIn{Name: "Oak Hill Cemetery", AltNames: model.StringSlice{"Oak Hill"}, LocatedInID: district.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, "Oak Hill Cemetery, Krzyki, Wroclaw, Poland", cemetery.FullName)
+	assert.Contains(t, gm.words["oakhillcemetery"], cemetery.ID)
+	assert.Contains(t, gm.words["oakhill"], cemetery.ID)
+
+	// a missing parent is a bad reference
+	_, err = testAPI.AddPlace(ctx, model.PlaceIn{Name: "Nowhere", LocatedInID: 99})
+	assert.Equal(t, model.ErrBadReference, err.(*Error).Errs()[0].Code)
+
+	// global places can't be changed
+	_, err = testAPI.UpdatePlace(ctx, 2, model.PlaceIn{Name: "Breslau", LocatedInID: 1})
+	assert.Equal(t, 403, err.(*Error).HTTPStatus())
+
+	// a place can't be moved within itself
+	_, err = testAPI.UpdatePlace(ctx, district.ID, model.PlaceIn{Name: "Krzyki", LocatedInID: cemetery.ID})
+	assert.Equal(t, 400, err.(*Error).HTTPStatus())
+
+	// renaming a place updates the full names of places within it
+	_, err = testAPI.UpdatePlace(ctx, district.ID, model.PlaceIn{Name: "Krzyki District", LocatedInID: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "Oak Hill Cemetery, Krzyki District, Wroclaw, Poland", gm.places[cemetery.ID].FullName)
+	assert.Contains(t, gm.words["krzykidistrict"], district.ID)
+	assert.NotContains(t, gm.words, "krzyki")
+
+	// alternate names for global places are kept separately
+	wroclaw, err := testAPI.UpdatePlaceAltNames(ctx, 2, model.PlaceAltNames{AltNames: model.StringSlice{"Breslau", " Breslau "}})
+	assert.NoError(t, err)
+	assert.Equal(t, model.StringSlice{"Breslau"}, wroclaw.LocalAltNames)
+	assert.Empty(t, gm.places[2].AltNames)
+	assert.Contains(t, gm.words["breslau"], uint32(2))
+
+	// a place can't be merged into a place within it
+	_, err = testAPI.MergePlace(ctx, district.ID, model.PlaceMerge{IntoID: cemetery.ID})
+	assert.Equal(t, 400, err.(*Error).HTTPStatus())
+
+	// merging moves children and keeps the merged names
+	wroclaw, err = testAPI.MergePlace(ctx, district.ID, model.PlaceMerge{IntoID: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, model.StringSlice{"Breslau", "Krzyki District"}, wroclaw.LocalAltNames)
+	assert.NotContains(t, gm.places, district.ID)
+	assert.Equal(t, uint32(2), gm.places[cemetery.ID].LocatedInID)
+	assert.Equal(t, "Oak Hill Cemetery, Wroclaw, Poland", gm.places[cemetery.ID].FullName)
+	assert.Equal(t, model.Uint32Slice{2}, gm.words["krzykidistrict"])
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/pubsub"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+// derivativeSuffixes are the suffixes of the blobs generated from an image
+var derivativeSuffixes = []string{model.ImageDimensionsSuffix, model.ImageThumbnailSuffix, model.ImageOCRSuffix, model.ImagePublicSuffix}
+
+// PostImagePaths returns the paths of the images in a post
+func (api API) PostImagePaths(ctx context.Context, postID uint32) ([]string, error) {
+	var paths []string
+	if api.imageHashPersister != nil {
+		imageHashes, err := api.imageHashPersister.SelectImageHashesForPost(ctx, postID)
+		if err != nil {
+			return nil, NewError(err)
+		}
+		for _, imageHash := range imageHashes {
+			paths = append(paths, imageHash.Path)
+		}
+		if len(paths) > 0 {
+			return paths, nil
+		}
+	}
+
+	// images loaded without hashes are found by listing the post's images
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	bucket, err := api.OpenBucket(ctx, false)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	defer bucket.Close()
+	prefix := fmt.Sprintf("/%d/%s", societyID, fmt.Sprintf(ImagesPrefix, postID))
+	li := bucket.List(&blob.ListOptions{
+		Prefix: prefix,
+	})
+	for {
+		obj, err := li.Next(ctx)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, NewError(err)
+		}
+		if isImageDerivative(obj.Key) {
+			continue
+		}
+		paths = append(paths, strings.TrimPrefix(obj.Key, prefix))
+	}
+	return paths, nil
+}
+
+func isImageDerivative(key string) bool {
+	for _, suffix := range derivativeSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// changedImageRedactionPaths returns the paths of images whose redactions differ
+func changedImageRedactionPaths(curr, next []model.ImageRedaction) []string {
+	var paths []string
+	seen := map[string]bool{}
+	currBody := model.PostBody{ImageRedactions: curr}
+	nextBody := model.PostBody{ImageRedactions: next}
+	for _, redactions := range [][]model.ImageRedaction{curr, next} {
+		for _, redaction := range redactions {
+			if seen[redaction.Path] {
+				continue
+			}
+			seen[redaction.Path] = true
+			if !reflect.DeepEqual(currBody.ImageRedactionsFor(redaction.Path), nextBody.ImageRedactionsFor(redaction.Path)) {
+				paths = append(paths, redaction.Path)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// requestImageDerivatives asks imageswriter to regenerate the derivatives of images in a post;
+// all of the post's images are regenerated if paths is empty
+func (api API) requestImageDerivatives(ctx context.Context, societyID, postID uint32, paths []string) error {
+	imagesWriterTopic, err := api.OpenTopic(ctx, "imageswriter")
+	if err != nil {
+		log.Printf("[ERROR] Can't open imageswriter topic %v", err)
+		return NewError(err)
+	}
+	defer imagesWriterTopic.Shutdown(ctx)
+
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+	for _, path := range paths {
+		msg, err := json.Marshal(model.ImagesWriterMsg{
+			Action:    model.ImagesWriterActionGenerateDerivatives,
+			SocietyID: societyID,
+			PostID:    postID,
+			ImagePath: path,
+		})
+		if err != nil {
+			log.Printf("[ERROR] Can't marshal message %v", err)
+			return NewError(err)
+		}
+		if err = imagesWriterTopic.Send(ctx, &pubsub.Message{Body: msg}); err != nil {
+			log.Printf("[ERROR] Can't send message %v", err)
+			return NewError(err)
+		}
+	}
+	return nil
+}
+
+// requestCollectionImageDerivatives asks imageswriter to regenerate the derivatives of the images in all posts in a collection
+func (api API) requestCollectionImageDerivatives(ctx context.Context, collectionID uint32) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return NewError(err)
+	}
+	posts, err := api.postPersister.SelectPosts(ctx)
+	if err != nil {
+		return NewError(err)
+	}
+	for _, post := range posts {
+		if post.Collection != collectionID || len(post.ImagesKeys) == 0 {
+			continue
+		}
+		if err := api.requestImageDerivatives(ctx, societyID, post.ID, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedImageRedactionPaths(t *testing.T) {
+	curr := []model.ImageRedaction{
+		{Path: "a.jpg", Left: 0.1, Top: 0.1, Width: 0.2, Height: 0.2},
+		{Path: "b.jpg", Left: 0.5, Top: 0.5, Width: 0.1, Height: 0.1},
+	}
+	next := []model.ImageRedaction{
+		{Path: "a.jpg", Left: 0.1, Top: 0.1, Width: 0.2, Height: 0.2},
+		{Path: "b.jpg", Left: 0.5, Top: 0.5, Width: 0.2, Height: 0.1},
+		{Path: "c.jpg", Left: 0, Top: 0, Width: 1, Height: 0.1},
+	}
+	assert.Equal(t, []string{"b.jpg", "c.jpg"}, changedImageRedactionPaths(curr, next))
+	assert.Equal(t, []string{"a.jpg", "b.jpg"}, changedImageRedactionPaths(curr, nil))
+	assert.Empty(t, changedImageRedactionPaths(curr, curr))
+	assert.True(t, isImageDerivative("/1/images/2/a.jpg__thumbnail.jpg__public.jpg"))
+	assert.False(t, isImageDerivative("/1/images/2/a.jpg"))
+}
+
+func TestSearchImagePrivateWithRedactions(t *testing.T) {
+	ctx := utils.AddSearchUserIDToContext(utils.AddSocietyIDToContext(context.TODO(), 1), 0)
+	societyCache, err := lru.New2Q(100)
+	assert.NoError(t, err)
+	testAPI := &API{validate: validator.New(), societyCache: societyCache}
+	testAPI.SocietyPersister(&societyMock{societies: map[uint32]model.Society{1: {ID: 1, SocietyIn: model.SocietyIn{SocietyBody: model.SocietyBody{LoginURL: "https://example.org/login"}}}}}).
+		CollectionPersister(&collectionMock{collections: []model.Collection{
+			model.NewCollection(1, model.CollectionIn{CollectionBody: model.CollectionBody{
+				PrivacyLevel: model.PrivacyPrivateImages,
+				Watermark:    &model.ImageWatermark{Text: "Society"},
+			}}),
+		}}).
+		PostPersister(&postMock{posts: []model.Post{
+			model.NewPost(5, model.PostIn{PostBody: model.PostBody{
+				ImageRedactions: []model.ImageRedaction{{Path: "a.jpg", Width: 0.5, Height: 0.5}},
+			}, Collection: 1}),
+		}})
+
+	// redactions and watermarks choose the derivative to serve, but don't make private images public
+	metadata, err := testAPI.SearchImage(ctx, 1, 5, "a.jpg", false, 60)
+	assert.NoError(t, err)
+	assert.True(t, metadata.Private)
+	assert.Empty(t, metadata.URL)
+	assert.Equal(t, "https://example.org/login", metadata.LoginURL)
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/ourrootsorg/cms-server/model"
+)
+
+// GetImageHashesBySHA256 returns the images in the society having the specified content hash
+func (api API) GetImageHashesBySHA256(ctx context.Context, sha256 string) ([]model.ImageHash, error) {
+	imageHashes, err := api.imageHashPersister.SelectImageHashesBySHA256(ctx, sha256)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	return imageHashes, nil
+}
+
+// GetImageHashesByStorageKey returns the images in the society whose bytes are stored under the specified key
+func (api API) GetImageHashesByStorageKey(ctx context.Context, storageKey string) ([]model.ImageHash, error) {
+	imageHashes, err := api.imageHashPersister.SelectImageHashesByStorageKey(ctx, storageKey)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	return imageHashes, nil
+}
+
+// AddImageHash records the hashes of an image in a post, replacing any previous hashes for that image
+func (api API) AddImageHash(ctx context.Context, in model.ImageHashIn) (*model.ImageHash, error) {
+	if err := api.validate.Struct(in); err != nil {
+		log.Printf("[ERROR] Invalid image hash %v", err)
+		return nil, NewError(err)
+	}
+	imageHash, err := api.imageHashPersister.UpsertImageHash(ctx, in)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	return imageHash, nil
+}
+
+// GetPostImageDuplicates returns the exact and near duplicates of a post's images within the post and across the society
+func (api API) GetPostImageDuplicates(ctx context.Context, postID uint32) ([]model.ImageDuplicate, error) {
+	if api.imageHashPersister == nil {
+		return nil, NewHTTPError(errors.New("duplicate image detection is not configured"), http.StatusNotImplemented)
+	}
+	if _, err := api.GetPost(ctx, postID); err != nil {
+		return nil, err
+	}
+	postHashes, err := api.imageHashPersister.SelectImageHashesForPost(ctx, postID)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	societyHashes, err := api.imageHashPersister.SelectImageHashes(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	// omit images in collections the user's grants hide
+	if grantedPermissions(ctx) != nil {
+		postResult, err := api.GetPosts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		readablePosts := map[uint32]bool{}
+		for _, post := range postResult.Posts {
+			readablePosts[post.ID] = true
+		}
+		var readable []model.ImageHash
+		for _, imageHash := range societyHashes {
+			if readablePosts[imageHash.Post] {
+				readable = append(readable, imageHash)
+			}
+		}
+		societyHashes = readable
+	}
+	return model.FindImageDuplicates(postHashes, societyHashes), nil
+}
+
+// ImageStorageKey returns the key (without the society prefix) of the blob that holds an image;
+// this is a different post's image when storage has been deduplicated
+func (api API) ImageStorageKey(ctx context.Context, postID uint32, filePath string) (string, error) {
+	key := fmt.Sprintf(ImagesPrefix, postID) + filePath
+	if api.imageHashPersister == nil {
+		return key, nil
+	}
+	imageHash, err := api.imageHashPersister.SelectOneImageHash(ctx, postID, filePath)
+	if model.ErrNotFound.Matches(err) {
+		return key, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if imageHash.StorageKey != "" {
+		key = imageHash.StorageKey
+	}
+	return key, nil
+}
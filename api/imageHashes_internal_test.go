@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// imageHashMock holds image hashes in memory
+type imageHashMock struct {
+	imageHashes []model.ImageHash
+}
+
+func (im *imageHashMock) SelectImageHashes(ctx context.Context) ([]model.ImageHash, error) {
+	return im.imageHashes, nil
+}
+func (im *imageHashMock) SelectImageHashesForPost(ctx context.Context, postID uint32) ([]model.ImageHash, error) {
+	var imageHashes []model.ImageHash
+	for _, imageHash := range im.imageHashes {
+		if imageHash.Post == postID {
+			imageHashes = append(imageHashes, imageHash)
+		}
+	}
+	return imageHashes, nil
+}
+func (im *imageHashMock) SelectImageHashesBySHA256(ctx context.Context, sha256 string) ([]model.ImageHash, error) {
+	return nil, fmt.Errorf("SelectImageHashesBySHA256 not implemented")
+}
+func (im *imageHashMock) SelectImageHashesByStorageKey(ctx context.Context, storageKey string) ([]model.ImageHash, error) {
+	return nil, fmt.Errorf("SelectImageHashesByStorageKey not implemented")
+}
+func (im *imageHashMock) SelectOneImageHash(ctx context.Context, postID uint32, path string) (*model.ImageHash, error) {
+	return nil, fmt.Errorf("SelectOneImageHash not implemented")
+}
+func (im *imageHashMock) UpsertImageHash(ctx context.Context, in model.ImageHashIn) (*model.ImageHash, error) {
+	return nil, fmt.Errorf("UpsertImageHash not implemented")
+}
+func (im *imageHashMock) DeleteImageHashesForPost(ctx context.Context, postID uint32) error {
+	return fmt.Errorf("DeleteImageHashesForPost not implemented")
+}
+
+func TestGetPostImageDuplicates(t *testing.T) {
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 1)
+	testAPI := &API{validate: validator.New()}
+	testAPI.CollectionGrantPersister(&collectionGrantMock{}).
+		SocietyUserPersister(&societyUserMock{societyUsers: []model.SocietyUser{
+			{ID: 1, SocietyUserIn: model.SocietyUserIn{UserID: 5, SocietyID: 1}},
+		}}).
+		CollectionPersister(&collectionMock{collections: []model.Collection{
+			model.NewCollection(1, model.CollectionIn{Categories: []uint32{10}}),
+			model.NewCollection(2, model.CollectionIn{Categories: []uint32{11}}),
+		}}).
+		PostPersister(&postMock{posts: []model.Post{
+			model.NewPost(5, model.PostIn{PostBody: model.PostBody{Name: "Visible"}, Collection: 1}),
+			model.NewPost(6, model.PostIn{PostBody: model.PostBody{Name: "Also visible"}, Collection: 1}),
+			model.NewPost(7, model.PostIn{PostBody: model.PostBody{Name: "Hidden"}, Collection: 2}),
+		}}).
+		ImageHashPersister(&imageHashMock{imageHashes: []model.ImageHash{
+			{ImageHashIn: model.NewImageHashIn(5, "a.jpg", "same", "", "")},
+			{ImageHashIn: model.NewImageHashIn(6, "b.jpg", "same", "", "")},
+			{ImageHashIn: model.NewImageHashIn(7, "c.jpg", "same", "", "")},
+		}})
+
+	// without grants the whole society is matched
+	duplicates, err := testAPI.GetPostImageDuplicates(ctx, 5)
+	assert.NoError(t, err)
+	assert.Len(t, duplicates, 2)
+
+	// a user granted only collection 1 doesn't see images in collection 2
+	_, err = testAPI.AddCollectionGrant(ctx, model.CollectionGrantIn{
+		CollectionGrantBody: model.CollectionGrantBody{Level: model.AuthReader}, UserID: 5, CollectionID: 1})
+	assert.NoError(t, err)
+	permissions, err := testAPI.GetPermissions(ctx, 5, model.AuthGuest)
+	assert.NoError(t, err)
+	duplicates, err = testAPI.GetPostImageDuplicates(utils.AddPermissionsToContext(ctx, permissions), 5)
+	assert.NoError(t, err)
+	assert.Len(t, duplicates, 1)
+	assert.Equal(t, uint32(6), duplicates[0].DuplicatePost)
+}
@@ -3,18 +3,41 @@ package api
 import (
 	"context"
 	"crypto/rand"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ourrootsorg/cms-server/mail"
 	"github.com/ourrootsorg/cms-server/model"
 	"github.com/ourrootsorg/cms-server/utils"
 )
 
+// DefaultInvitationExpiry is how long an invitation can be accepted when it is created without an expiry
+const DefaultInvitationExpiry = 14 * 24 * time.Hour
+
+// MaxBulkInvitations limits how many invitations can be created at once
+const MaxBulkInvitations = 1000
+
 type InvitationSocietyName struct {
 	model.Invitation
 	SocietyName string `json:"societyName"`
 }
 
+// BulkInvitationResult is the outcome of one invitation in a bulk upload
+type BulkInvitationResult struct {
+	// Row is the position of the invitation in the upload, starting at 1 and not counting the header
+	Row        int               `json:"row"`
+	Invitation *model.Invitation `json:"invitation,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
 func (api API) GetInvitations(ctx context.Context) ([]model.Invitation, error) {
 	invitations, err := api.invitationPersister.SelectInvitations(ctx)
 	if err != nil {
@@ -23,15 +46,58 @@ func (api API) GetInvitations(ctx context.Context) ([]model.Invitation, error) {
 	return invitations, nil
 }
 
+// AddInvitation creates an invitation, and emails it if it has an email address and a mailer is configured
 func (api API) AddInvitation(ctx context.Context, body model.InvitationBody) (*model.Invitation, error) {
-	err := api.validate.Struct(body)
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
 	if err != nil {
 		return nil, NewError(err)
 	}
+	societyName, err := api.invitationSocietyName(ctx, societyID, []model.InvitationBody{body})
+	if err != nil {
+		return nil, err
+	}
+	return api.addInvitation(ctx, societyID, societyName, body)
+}
+
+// AddInvitations creates many invitations at once; invitations that can't be created are reported
+// in their results rather than failing the others
+func (api API) AddInvitations(ctx context.Context, bodies []model.InvitationBody) ([]BulkInvitationResult, error) {
+	if len(bodies) > MaxBulkInvitations {
+		return nil, NewHTTPError(fmt.Errorf("at most %d invitations can be created at once", MaxBulkInvitations), http.StatusBadRequest)
+	}
 	societyID, err := utils.GetSocietyIDFromContext(ctx)
 	if err != nil {
 		return nil, NewError(err)
 	}
+	societyName, err := api.invitationSocietyName(ctx, societyID, bodies)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]BulkInvitationResult, len(bodies))
+	for i, body := range bodies {
+		results[i].Row = i + 1
+		invitation, err := api.addInvitation(ctx, societyID, societyName, body)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Invitation = invitation
+	}
+	return results, nil
+}
+
+func (api API) addInvitation(ctx context.Context, societyID uint32, societyName string, body model.InvitationBody) (*model.Invitation, error) {
+	err := api.validate.Struct(body)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	now := time.Now()
+	if body.ExpiresAt == nil {
+		expiresAt := now.Add(DefaultInvitationExpiry)
+		body.ExpiresAt = &expiresAt
+	} else if !body.ExpiresAt.After(now) {
+		return nil, NewHTTPError(errors.New("expiresAt must be in the future"), http.StatusBadRequest)
+	}
 
 	// generate code
 	b := make([]byte, 8)
@@ -51,14 +117,130 @@ func (api API) AddInvitation(ctx context.Context, body model.InvitationBody) (*m
 	if err != nil {
 		return nil, NewError(err)
 	}
+
+	if invitation.Email != "" && api.mailer != nil {
+		if err := api.mailer.Send(ctx, api.invitationMessage(societyName, invitation)); err != nil {
+			log.Printf("[ERROR] sending invitation %d to %s %v", invitation.ID, invitation.Email, err)
+			// an invitation bound to an address that never received it is of no use
+			_ = api.invitationPersister.DeleteInvitation(ctx, invitation.ID)
+			return nil, NewHTTPError(fmt.Errorf("sending invitation to %s: %v", invitation.Email, err), http.StatusBadGateway)
+		}
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityInvitation, EntityID: invitation.ID, Action: model.AuditActionCreate}, nil, invitation)
 	return invitation, nil
 }
 
+// invitationSocietyName returns the society name for invitation emails, if any of the invitations will be emailed
+func (api API) invitationSocietyName(ctx context.Context, societyID uint32, bodies []model.InvitationBody) (string, error) {
+	if api.mailer == nil {
+		return "", nil
+	}
+	for _, body := range bodies {
+		if body.Email != "" {
+			society, err := api.societyPersister.SelectSocietySummary(ctx, societyID)
+			if err != nil {
+				return "", NewError(err)
+			}
+			return society.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// invitationMessage returns the email that sends an invitation
+func (api API) invitationMessage(societyName string, invitation *model.Invitation) mail.Message {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Hello %s,\n\n", invitation.Name)
+	fmt.Fprintf(&sb, "You have been invited to join %s on OurRoots as a %s.\n\n", societyName, invitation.Level)
+	fmt.Fprintf(&sb, "To accept the invitation, sign in at %s%s\n", api.invitationURL, invitation.Code)
+	if invitation.ExpiresAt != nil {
+		fmt.Fprintf(&sb, "\nThe invitation expires on %s.\n", invitation.ExpiresAt.UTC().Format("January 2, 2006 at 15:04 UTC"))
+	}
+	return mail.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("Invitation to join %s", societyName),
+		Body:    sb.String(),
+	}
+}
+
+// ParseInvitationsCSV reads invitations from CSV. The header row names the columns: name, level and email,
+// and optionally maxUses and expiresAt. Levels can be names like Editor or numbers, and expiresAt can be
+// an RFC 3339 time or a date.
+func ParseInvitationsCSV(r io.Reader) ([]model.InvitationBody, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, NewHTTPError(errors.New("CSV is empty"), http.StatusBadRequest)
+	}
+	if err != nil {
+		return nil, NewHTTPError(err, http.StatusBadRequest)
+	}
+	columns := map[string]int{}
+	for i, h := range header {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, required := range []string{"name", "level"} {
+		if _, ok := columns[required]; !ok {
+			return nil, NewHTTPError(fmt.Errorf("CSV is missing the %s column", required), http.StatusBadRequest)
+		}
+	}
+	get := func(row []string, column string) string {
+		if i, ok := columns[strings.ToLower(column)]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var bodies []model.InvitationBody
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, NewHTTPError(err, http.StatusBadRequest)
+		}
+		body := model.InvitationBody{
+			Name:  get(row, "name"),
+			Email: get(row, "email"),
+		}
+		if body.Name == "" && body.Email == "" {
+			continue // skip blank lines
+		}
+		body.Level, err = model.ParseAuthLevel(get(row, "level"))
+		if err != nil {
+			return nil, NewHTTPError(fmt.Errorf("line %d: %v", line, err), http.StatusBadRequest)
+		}
+		if s := get(row, "maxUses"); s != "" {
+			body.MaxUses, err = strconv.Atoi(s)
+			if err != nil {
+				return nil, NewHTTPError(fmt.Errorf("line %d: invalid maxUses '%s'", line, s), http.StatusBadRequest)
+			}
+		}
+		if s := get(row, "expiresAt"); s != "" {
+			expiresAt, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				expiresAt, err = time.Parse("2006-01-02", s)
+			}
+			if err != nil {
+				return nil, NewHTTPError(fmt.Errorf("line %d: invalid expiresAt '%s'", line, s), http.StatusBadRequest)
+			}
+			body.ExpiresAt = &expiresAt
+		}
+		bodies = append(bodies, body)
+	}
+	return bodies, nil
+}
+
 func (api API) DeleteInvitation(ctx context.Context, id uint32) error {
+	old, _ := api.invitationPersister.SelectOneInvitation(ctx, id)
 	err := api.invitationPersister.DeleteInvitation(ctx, id)
 	if err != nil {
 		return NewError(err)
 	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityInvitation, EntityID: id, Action: model.AuditActionDelete}, old, nil)
 	return nil
 }
 
@@ -68,6 +250,9 @@ func (api API) GetInvitationSocietyName(ctx context.Context, code string) (*Invi
 	if err != nil {
 		return nil, err
 	}
+	if invitation.Expired(time.Now()) {
+		return nil, NewHTTPError(errors.New("invitation has expired"), http.StatusGone)
+	}
 	// get society
 	society, err := api.societyPersister.SelectSocietySummary(ctx, invitation.SocietyID)
 	if err != nil {
@@ -90,11 +275,32 @@ func (api API) AcceptInvitation(ctx context.Context, code string) (*model.Societ
 	if err != nil {
 		return nil, err
 	}
+	if invitation.Expired(time.Now()) {
+		return nil, NewHTTPError(errors.New("invitation has expired"), http.StatusGone)
+	}
+	if invitation.Email != "" && !strings.EqualFold(invitation.Email, user.Email) {
+		return nil, NewHTTPError(errors.New("invitation was sent to a different email address"), http.StatusForbidden)
+	}
+	// the email must be confirmed to prove the user owns the address the invitation was sent to
+	if invitation.Email != "" && !user.EmailConfirmed {
+		return nil, NewHTTPError(errors.New("user email is not confirmed"), http.StatusForbidden)
+	}
 	// set society context
 	sctx := utils.AddSocietyIDToContext(ctx, invitation.SocietyID)
+	// record the use before adding the user, so concurrent acceptances can't exceed the allowed uses
+	err = api.invitationPersister.IncrementInvitationUses(sctx, invitation.ID, invitation.AllowedUses())
+	if model.ErrNotFound.Matches(err) {
+		return nil, NewHTTPError(errors.New("invitation has already been used"), http.StatusGone)
+	}
+	if err != nil {
+		return nil, err
+	}
 	// add or update society user
+	var oldSocietyUser *model.SocietyUser
 	societyUser, err := api.societyUserPersister.SelectOneSocietyUserByUser(sctx, user.ID)
 	if err == nil {
+		old := *societyUser
+		oldSocietyUser = &old
 		societyUser.Level = model.AuthLevel(math.Max(float64(societyUser.Level), float64(invitation.Level)))
 		societyUser, err = api.societyUserPersister.UpdateSocietyUser(sctx, societyUser.ID, *societyUser)
 	} else {
@@ -110,10 +316,20 @@ func (api API) AcceptInvitation(ctx context.Context, code string) (*model.Societ
 	if err != nil {
 		return nil, err
 	}
-	// delete invitation
-	err = api.invitationPersister.DeleteInvitation(sctx, invitation.ID)
-	if err != nil {
-		return nil, err
+	accepted := *invitation
+	accepted.Uses++
+	api.audit(sctx, model.AuditEventBody{Entity: model.AuditEntityInvitation, EntityID: invitation.ID, Action: model.AuditActionAccept}, invitation, &accepted)
+	societyUserAction := model.AuditActionCreate
+	if oldSocietyUser != nil {
+		societyUserAction = model.AuditActionUpdate
+	}
+	api.audit(sctx, model.AuditEventBody{Entity: model.AuditEntitySocietyUser, EntityID: societyUser.ID, Action: societyUserAction}, oldSocietyUser, societyUser)
+	// delete the invitation once it has been used up
+	if invitation.Uses+1 >= invitation.AllowedUses() {
+		err = api.invitationPersister.DeleteInvitation(sctx, invitation.ID)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return societyUser, nil
 }
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/ourrootsorg/cms-server/mail"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// invitationMock holds invitations in memory
+type invitationMock struct {
+	invitations []model.Invitation
+}
+
+func (im *invitationMock) SelectInvitationByCode(ctx context.Context, code string) (*model.Invitation, error) {
+	for _, invitation := range im.invitations {
+		if invitation.Code == code {
+			return &invitation, nil
+		}
+	}
+	return nil, model.NewError(model.ErrNotFound, code)
+}
+func (im *invitationMock) SelectInvitations(ctx context.Context) ([]model.Invitation, error) {
+	return im.invitations, nil
+}
+func (im *invitationMock) SelectOneInvitation(ctx context.Context, id uint32) (*model.Invitation, error) {
+	return nil, fmt.Errorf("SelectOneInvitation not implemented")
+}
+func (im *invitationMock) InsertInvitation(ctx context.Context, in model.InvitationIn) (*model.Invitation, error) {
+	invitation := model.NewInvitation(uint32(len(im.invitations)+1), in)
+	im.invitations = append(im.invitations, invitation)
+	return &invitation, nil
+}
+func (im *invitationMock) IncrementInvitationUses(ctx context.Context, id uint32, maxUses int) error {
+	for i := range im.invitations {
+		if im.invitations[i].ID == id && im.invitations[i].Uses < maxUses {
+			im.invitations[i].Uses++
+			return nil
+		}
+	}
+	return model.NewError(model.ErrNotFound, fmt.Sprint(id))
+}
+func (im *invitationMock) DeleteInvitation(ctx context.Context, id uint32) error {
+	for i, invitation := range im.invitations {
+		if invitation.ID == id {
+			im.invitations = append(im.invitations[:i], im.invitations[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// societyUserMock holds society users in memory
+type societyUserMock struct {
+	societyUsers []model.SocietyUser
+}
+
+func (sm *societyUserMock) SelectSocietyUsers(ctx context.Context) ([]model.SocietyUser, error) {
+	return sm.societyUsers, nil
+}
+func (sm *societyUserMock) SelectAllSocietyUsersByUser(ctx context.Context, userID uint32) ([]model.SocietyUser, error) {
+	societyUsers := make([]model.SocietyUser, 0)
+	for _, societyUser := range sm.societyUsers {
+		if societyUser.UserID == userID {
+			societyUsers = append(societyUsers, societyUser)
+		}
+	}
+	return societyUsers, nil
+}
+func (sm *societyUserMock) SelectOneSocietyUser(ctx context.Context, id uint32) (*model.SocietyUser, error) {
+	for _, societyUser := range sm.societyUsers {
+		if societyUser.ID == id {
+			return &societyUser, nil
+		}
+	}
+	return nil, model.NewError(model.ErrNotFound, fmt.Sprint(id))
+}
+func (sm *societyUserMock) SelectOneSocietyUserByUser(ctx context.Context, userID uint32) (*model.SocietyUser, error) {
+	for _, societyUser := range sm.societyUsers {
+		if societyUser.UserID == userID {
+			return &societyUser, nil
+		}
+	}
+	return nil, model.NewError(model.ErrNotFound, fmt.Sprint(userID))
+}
+func (sm *societyUserMock) InsertSocietyUser(ctx context.Context, in model.SocietyUserIn) (*model.SocietyUser, error) {
+	societyUser := model.SocietyUser{ID: uint32(len(sm.societyUsers) + 1), SocietyUserIn: in}
+	sm.societyUsers = append(sm.societyUsers, societyUser)
+	return &societyUser, nil
+}
+func (sm *societyUserMock) UpdateSocietyUser(ctx context.Context, id uint32, in model.SocietyUser) (*model.SocietyUser, error) {
+	for i := range sm.societyUsers {
+		if sm.societyUsers[i].ID == id {
+			sm.societyUsers[i].SocietyUserBody = in.SocietyUserBody
+			societyUser := sm.societyUsers[i]
+			return &societyUser, nil
+		}
+	}
+	return nil, model.NewError(model.ErrNotFound, fmt.Sprint(id))
+}
+func (sm *societyUserMock) DeleteSocietyUser(ctx context.Context, id uint32) error {
+	for i, societyUser := range sm.societyUsers {
+		if societyUser.ID == id {
+			sm.societyUsers = append(sm.societyUsers[:i], sm.societyUsers[i+1:]...)
+			return nil
+		}
+	}
+	return model.NewError(model.ErrNotFound, fmt.Sprint(id))
+}
+
+// mailerMock records the messages it sends, and fails for addresses at fail.example.com
+type mailerMock struct {
+	messages []mail.Message
+}
+
+func (mm *mailerMock) Send(ctx context.Context, msg mail.Message) error {
+	if strings.HasSuffix(msg.To, "@fail.example.com") {
+		return errors.New("mailbox unavailable")
+	}
+	mm.messages = append(mm.messages, msg)
+	return nil
+}
+
+func newInvitationTestAPI() (*API, *invitationMock, *mailerMock) {
+	im := &invitationMock{}
+	mm := &mailerMock{}
+	testAPI := &API{validate: validator.New()}
+	testAPI.InvitationPersister(im).
+		SocietyUserPersister(&societyUserMock{}).
+		SocietyPersister(&societyMock{societies: map[uint32]model.Society{
+			7: model.NewSociety(7, model.NewSocietyIn("Test society", "", "")),
+		}}).
+		Mailer(mm, "https://db.example.com?code=")
+	return testAPI, im, mm
+}
+
+func userContext(email string, id uint32) context.Context {
+	user := &model.User{ID: id, UserBody: model.UserBody{Email: email, EmailConfirmed: true}}
+	return utils.AddUserToContext(context.TODO(), user)
+}
+
+func TestAddInvitation(t *testing.T) {
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 7)
+	testAPI, im, mm := newInvitationTestAPI()
+
+	// invitations without an email aren't sent, and expire by default
+	invitation, err := testAPI.AddInvitation(ctx, model.InvitationBody{Name: "Anyone", Level: model.AuthReader})
+	assert.NoError(t, err)
+	assert.NotNil(t, invitation.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(DefaultInvitationExpiry), *invitation.ExpiresAt, time.Minute)
+	assert.Empty(t, mm.messages)
+
+	invitation, err = testAPI.AddInvitation(ctx, model.InvitationBody{Name: "Ann", Email: "ann@example.com", Level: model.AuthEditor})
+	assert.NoError(t, err)
+	assert.Len(t, mm.messages, 1)
+	assert.Equal(t, "ann@example.com", mm.messages[0].To)
+	assert.Contains(t, mm.messages[0].Subject, "Test society")
+	assert.Contains(t, mm.messages[0].Body, "https://db.example.com?code="+invitation.Code)
+	assert.Contains(t, mm.messages[0].Body, "as a Editor")
+
+	// an invitation that can't be delivered isn't kept
+	_, err = testAPI.AddInvitation(ctx, model.InvitationBody{Name: "Bob", Email: "bob@fail.example.com", Level: model.AuthReader})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadGateway, err.(*Error).HTTPStatus())
+	assert.Len(t, im.invitations, 2)
+
+	past := time.Now().Add(-time.Hour)
+	_, err = testAPI.AddInvitation(ctx, model.InvitationBody{Name: "Late", Level: model.AuthReader, ExpiresAt: &past})
+	assert.Error(t, err)
+	_, err = testAPI.AddInvitation(ctx, model.InvitationBody{Name: "Bad", Email: "not an email", Level: model.AuthReader})
+	assert.Error(t, err)
+}
+
+func TestAddInvitationsCSV(t *testing.T) {
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 7)
+	testAPI, im, mm := newInvitationTestAPI()
+
+	csv := "Name,Email,Level,maxUses,expiresAt\n" +
+		"Ann,ann@example.com,editor,,\n" +
+		"Cemetery volunteers,,Contributor,20,2099-01-31\n" +
+		",,,,\n" +
+		"Bob,bob@fail.example.com,1,,2099-01-31T12:00:00Z\n"
+	bodies, err := ParseInvitationsCSV(strings.NewReader(csv))
+	assert.NoError(t, err)
+	assert.Len(t, bodies, 3)
+	assert.Equal(t, model.AuthLevel(model.AuthEditor), bodies[0].Level)
+	assert.Equal(t, 20, bodies[1].MaxUses)
+	assert.Equal(t, 2099, bodies[1].ExpiresAt.Year())
+	assert.Equal(t, model.AuthLevel(model.AuthReader), bodies[2].Level)
+
+	results, err := testAPI.AddInvitations(ctx, bodies)
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.NotNil(t, results[0].Invitation)
+	assert.NotNil(t, results[1].Invitation)
+	assert.Nil(t, results[2].Invitation)
+	assert.Equal(t, 3, results[2].Row)
+	assert.Contains(t, results[2].Error, "mailbox unavailable")
+	assert.Len(t, im.invitations, 2)
+	assert.Len(t, mm.messages, 1)
+
+	for _, bad := range []string{
+		"",
+		"email\nann@example.com\n",
+		"name,level\nAnn,Owner\n",
+		"name,level,maxUses\nAnn,Reader,many\n",
+		"name,level,expiresAt\nAnn,Reader,tomorrow\n",
+	} {
+		_, err := ParseInvitationsCSV(strings.NewReader(bad))
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 7)
+	testAPI, im, _ := newInvitationTestAPI()
+
+	bound, err := testAPI.AddInvitation(ctx, model.InvitationBody{Name: "Ann", Email: "Ann@Example.com", Level: model.AuthEditor})
+	assert.NoError(t, err)
+	_, err = testAPI.AcceptInvitation(userContext("mallory@example.com", 1), bound.Code)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, err.(*Error).HTTPStatus())
+	unconfirmed := utils.AddUserToContext(context.TODO(), &model.User{ID: 2, UserBody: model.UserBody{Email: "ann@example.com"}})
+	_, err = testAPI.AcceptInvitation(unconfirmed, bound.Code)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, err.(*Error).HTTPStatus())
+	societyUser, err := testAPI.AcceptInvitation(userContext("ann@example.com", 2), bound.Code)
+	assert.NoError(t, err)
+	assert.Equal(t, model.AuthLevel(model.AuthEditor), societyUser.Level)
+	// used up, so deleted
+	_, err = testAPI.AcceptInvitation(userContext("ann@example.com", 2), bound.Code)
+	assert.Error(t, err)
+
+	shared, err := testAPI.AddInvitation(ctx, model.InvitationBody{Name: "Volunteers", Level: model.AuthContributor, MaxUses: 2})
+	assert.NoError(t, err)
+	_, err = testAPI.AcceptInvitation(userContext("one@example.com", 3), shared.Code)
+	assert.NoError(t, err)
+	_, err = testAPI.GetInvitationSocietyName(ctx, shared.Code)
+	assert.NoError(t, err)
+	_, err = testAPI.AcceptInvitation(userContext("two@example.com", 4), shared.Code)
+	assert.NoError(t, err)
+	_, err = testAPI.AcceptInvitation(userContext("three@example.com", 5), shared.Code)
+	assert.Error(t, err)
+
+	expiring, err := testAPI.AddInvitation(ctx, model.InvitationBody{Name: "Soon", Level: model.AuthReader})
+	assert.NoError(t, err)
+	expired := time.Now().Add(-time.Minute)
+	im.invitations[len(im.invitations)-1].ExpiresAt = &expired
+	_, err = testAPI.GetInvitationSocietyName(ctx, expiring.Code)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusGone, err.(*Error).HTTPStatus())
+	_, err = testAPI.AcceptInvitation(userContext("late@example.com", 6), expiring.Code)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusGone, err.(*Error).HTTPStatus())
+}
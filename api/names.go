@@ -2,10 +2,229 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/stdtext"
+	"github.com/ourrootsorg/cms-server/utils"
 )
 
+// nameVariantsCacheTTL bounds how long name variants are cached, so edits made through other servers are seen
+const nameVariantsCacheTTL = 5 * time.Minute
+
+type nameVariantsCacheEntry struct {
+	nameVariants *model.NameVariants // nil if the name has no variants
+	expires      time.Time
+}
+
+// GetNameVariants returns the variants of a name
+// If there is a society in the context, the society's dictionary is layered over the global one
 func (api *API) GetNameVariants(ctx context.Context, nameType model.NameType, name string) (*model.NameVariants, error) {
-	return api.namePersister.SelectNameVariants(ctx, nameType, name)
+	name = normalizeName(name)
+	societyID, _ := utils.GetSocietyIDFromContext(ctx)
+	cacheKey := nameVariantsCacheKey(societyID, nameType, name)
+	if value, ok := api.nameVariantsCache.Get(cacheKey); ok {
+		if entry, ok := value.(nameVariantsCacheEntry); ok && time.Now().Before(entry.expires) {
+			if entry.nameVariants == nil {
+				return nil, model.NewError(model.ErrNotFound, name)
+			}
+			result := *entry.nameVariants
+			return &result, nil
+		}
+	}
+
+	nameVariants, err := api.namePersister.SelectNameVariants(ctx, nameType, name)
+	if err != nil {
+		if !model.ErrNotFound.Matches(err) {
+			return nil, err
+		}
+		nameVariants = nil
+	}
+	if societyID > 0 && api.nameAdminPersister != nil {
+		societyNameVariants, err := api.nameAdminPersister.SelectSocietyNameVariants(ctx, nameType, name)
+		if err != nil && !model.ErrNotFound.Matches(err) {
+			return nil, err
+		}
+		if societyNameVariants != nil {
+			nameVariants = layerNameVariants(name, nameVariants, societyNameVariants)
+		}
+	}
+
+	api.nameVariantsCache.Add(cacheKey, nameVariantsCacheEntry{nameVariants: nameVariants, expires: time.Now().Add(nameVariantsCacheTTL)})
+	if nameVariants == nil {
+		return nil, model.NewError(model.ErrNotFound, name)
+	}
+	result := *nameVariants
+	return &result, nil
+}
+
+// GetSocietyNameVariants returns the society's changes to the global variants of names
+func (api *API) GetSocietyNameVariants(ctx context.Context, nameType model.NameType) ([]model.SocietyNameVariants, error) {
+	if err := api.checkNameAdminConfigured(); err != nil {
+		return nil, err
+	}
+	result, err := api.nameAdminPersister.SelectAllSocietyNameVariants(ctx, nameType)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	return result, nil
+}
+
+// AddNameVariants adds variants to a name in the society's dictionary
+// Variants are symmetric, so the name is also added as a variant of each of the variants
+// Variants are applied when searching, so edits take effect on this server at once and on other servers
+// as soon as their cached variants expire after nameVariantsCacheTTL
+func (api *API) AddNameVariants(ctx context.Context, nameType model.NameType, name string, in model.NameVariantsEdit) (*model.NameVariants, error) {
+	return api.editNameVariants(ctx, nameType, name, in, true)
+}
+
+// RemoveNameVariants removes variants from a name in the society's dictionary, including variants in the global dictionary
+// Variants are symmetric, so the name is also removed from the variants of each of the variants
+func (api *API) RemoveNameVariants(ctx context.Context, nameType model.NameType, name string, in model.NameVariantsEdit) (*model.NameVariants, error) {
+	return api.editNameVariants(ctx, nameType, name, in, false)
+}
+
+func (api *API) editNameVariants(ctx context.Context, nameType model.NameType, name string, in model.NameVariantsEdit, add bool) (*model.NameVariants, error) {
+	if err := api.checkNameAdminConfigured(); err != nil {
+		return nil, err
+	}
+	if err := api.validate.Struct(in); err != nil {
+		return nil, NewHTTPError(err, http.StatusBadRequest)
+	}
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	name = normalizeName(name)
+	if name == "" {
+		return nil, NewHTTPError(errors.New("name is required"), http.StatusBadRequest)
+	}
+	old, err := api.GetNameVariants(ctx, nameType, name)
+	if err != nil && !model.ErrNotFound.Matches(err) {
+		return nil, NewError(err)
+	}
+	var variants []string
+	for _, variant := range in.Variants {
+		variant = normalizeName(variant)
+		if variant != "" && variant != name && !containsString(variants, variant) {
+			variants = append(variants, variant)
+		}
+	}
+
+	for _, variant := range variants {
+		if err := api.editSocietyNameVariant(ctx, nameType, name, variant, add); err != nil {
+			return nil, err
+		}
+		if err := api.editSocietyNameVariant(ctx, nameType, variant, name, add); err != nil {
+			return nil, err
+		}
+	}
+	for _, n := range append(variants, name) {
+		api.nameVariantsCache.Remove(nameVariantsCacheKey(societyID, nameType, n))
+	}
+
+	nameVariants, err := api.GetNameVariants(ctx, nameType, name)
+	if model.ErrNotFound.Matches(err) {
+		nameVariants, err = &model.NameVariants{Name: name, Variants: model.StringSlice{}}, nil
+	}
+	if err != nil {
+		return nil, NewError(err)
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityNameVariants, Name: nameType.String() + ":" + name, Action: model.AuditActionUpdate},
+		old, nameVariants)
+	return nameVariants, nil
+}
+
+// editSocietyNameVariant adds or removes a single variant of a name in the society's dictionary
+func (api *API) editSocietyNameVariant(ctx context.Context, nameType model.NameType, name, variant string, add bool) error {
+	societyNameVariants, err := api.nameAdminPersister.SelectSocietyNameVariants(ctx, nameType, name)
+	if err != nil && !model.ErrNotFound.Matches(err) {
+		return NewError(err)
+	}
+	if societyNameVariants == nil {
+		societyNameVariants = &model.SocietyNameVariants{Name: name, Added: model.StringSlice{}, Removed: model.StringSlice{}}
+	}
+	inGlobal := false
+	globalNameVariants, err := api.namePersister.SelectNameVariants(ctx, nameType, name)
+	if err != nil && !model.ErrNotFound.Matches(err) {
+		return NewError(err)
+	}
+	if err == nil {
+		inGlobal = containsString(globalNameVariants.Variants, variant)
+	}
+
+	if add {
+		societyNameVariants.Removed = removeString(societyNameVariants.Removed, variant)
+		if !inGlobal && !containsString(societyNameVariants.Added, variant) {
+			societyNameVariants.Added = append(societyNameVariants.Added, variant)
+		}
+	} else {
+		societyNameVariants.Added = removeString(societyNameVariants.Added, variant)
+		if inGlobal && !containsString(societyNameVariants.Removed, variant) {
+			societyNameVariants.Removed = append(societyNameVariants.Removed, variant)
+		}
+	}
+
+	if len(societyNameVariants.Added) == 0 && len(societyNameVariants.Removed) == 0 {
+		err = api.nameAdminPersister.DeleteSocietyNameVariants(ctx, nameType, name)
+	} else {
+		_, err = api.nameAdminPersister.UpsertSocietyNameVariants(ctx, nameType, *societyNameVariants)
+	}
+	if err != nil {
+		return NewError(err)
+	}
+	return nil
+}
+
+func (api *API) checkNameAdminConfigured() error {
+	if api.nameAdminPersister == nil {
+		return NewHTTPError(errors.New("name dictionaries are not configured"), http.StatusNotImplemented)
+	}
+	return nil
+}
+
+// layerNameVariants applies a society's changes to the global variants of a name, which may be nil
+func layerNameVariants(name string, global *model.NameVariants, society *model.SocietyNameVariants) *model.NameVariants {
+	result := &model.NameVariants{Name: name, Variants: model.StringSlice{}, InsertTime: society.InsertTime, LastUpdateTime: society.LastUpdateTime}
+	if global != nil {
+		result.Type = global.Type
+		result.InsertTime = global.InsertTime
+		if global.LastUpdateTime.After(result.LastUpdateTime) {
+			result.LastUpdateTime = global.LastUpdateTime
+		}
+		for _, variant := range global.Variants {
+			if !containsString(society.Removed, variant) {
+				result.Variants = append(result.Variants, variant)
+			}
+		}
+	}
+	for _, variant := range society.Added {
+		if !containsString(result.Variants, variant) {
+			result.Variants = append(result.Variants, variant)
+		}
+	}
+	return result
+}
+
+func nameVariantsCacheKey(societyID uint32, nameType model.NameType, name string) string {
+	return fmt.Sprintf("%d/%d/%s", societyID, nameType, name)
+}
+
+// normalizeName normalizes a name the same way names are normalized when searching
+func normalizeName(name string) string {
+	return stdtext.AsciiFold(strings.ToLower(strings.TrimSpace(name)))
+}
+
+func removeString(values []string, value string) []string {
+	result := []string{}
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
 }
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// nameMock holds the global name variants and the societies' dictionaries in memory
+type nameMock struct {
+	global  map[string]model.StringSlice
+	society map[uint32]map[string]model.SocietyNameVariants
+}
+
+func (nm *nameMock) SelectNameVariants(ctx context.Context, nameType model.NameType, name string) (*model.NameVariants, error) {
+	variants, ok := nm.global[name]
+	if !ok {
+		return nil, model.NewError(model.ErrNotFound, name)
+	}
+	return &model.NameVariants{Name: name, Variants: variants}, nil
+}
+func (nm *nameMock) SelectSocietyNameVariants(ctx context.Context, nameType model.NameType, name string) (*model.SocietyNameVariants, error) {
+	societyID, _ := utils.GetSocietyIDFromContext(ctx)
+	nameVariants, ok := nm.society[societyID][name]
+	if !ok {
+		return nil, model.NewError(model.ErrNotFound, name)
+	}
+	return &nameVariants, nil
+}
+func (nm *nameMock) SelectAllSocietyNameVariants(ctx context.Context, nameType model.NameType) ([]model.SocietyNameVariants, error) {
+	societyID, _ := utils.GetSocietyIDFromContext(ctx)
+	var result []model.SocietyNameVariants
+	for _, nameVariants := range nm.society[societyID] {
+		result = append(result, nameVariants)
+	}
+	return result, nil
+}
+func (nm *nameMock) UpsertSocietyNameVariants(ctx context.Context, nameType model.NameType, in model.SocietyNameVariants) (*model.SocietyNameVariants, error) {
+	societyID, _ := utils.GetSocietyIDFromContext(ctx)
+	if nm.society[societyID] == nil {
+		nm.society[societyID] = map[string]model.SocietyNameVariants{}
+	}
+	nm.society[societyID][in.Name] = in
+	return &in, nil
+}
+func (nm *nameMock) DeleteSocietyNameVariants(ctx context.Context, nameType model.NameType, name string) error {
+	societyID, _ := utils.GetSocietyIDFromContext(ctx)
+	delete(nm.society[societyID], name)
+	return nil
+}
+
+func TestSocietyNameVariants(t *testing.T) {
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 7)
+	otherCtx := utils.AddSocietyIDToContext(context.TODO(), 8)
+	nm := &nameMock{
+		global: map[string]model.StringSlice{
+			"jones": {"johns", "jonas"},
+			"johns": {"jones"},
+			"jonas": {"jones"},
+		},
+		society: map[uint32]map[string]model.SocietyNameVariants{},
+	}
+	cache, err := lru.New2Q(100)
+	assert.NoError(t, err)
+	testAPI := &API{validate: validator.New(), nameVariantsCache: cache}
+	testAPI.NamePersister(nm).NameAdminPersister(nm)
+
+	// cache the global variants before the edit
+	nameVariants, err := testAPI.GetNameVariants(ctx, model.SurnameType, "jones")
+	assert.NoError(t, err)
+	assert.Equal(t, model.StringSlice{"johns", "jonas"}, nameVariants.Variants)
+
+	// add a Welsh patronymic variant and remove a global variant
+	nameVariants, err = testAPI.AddNameVariants(ctx, model.SurnameType, "Jones", model.NameVariantsEdit{Variants: model.StringSlice{"Siôn", "johns"}})
+	assert.NoError(t, err)
+	assert.Equal(t, model.StringSlice{"johns", "jonas", "sion"}, nameVariants.Variants)
+	nameVariants, err = testAPI.RemoveNameVariants(ctx, model.SurnameType, "jones", model.NameVariantsEdit{Variants: model.StringSlice{"jonas"}})
+	assert.NoError(t, err)
+	assert.Equal(t, model.StringSlice{"johns", "sion"}, nameVariants.Variants)
+	assert.Equal(t, model.StringSlice{"jonas"}, nm.society[7]["jones"].Removed)
+
+	// variants are symmetric
+	nameVariants, err = testAPI.GetNameVariants(ctx, model.SurnameType, "sion")
+	assert.NoError(t, err)
+	assert.Equal(t, model.StringSlice{"jones"}, nameVariants.Variants)
+	nameVariants, err = testAPI.GetNameVariants(ctx, model.SurnameType, "jonas")
+	assert.NoError(t, err)
+	assert.Empty(t, nameVariants.Variants)
+
+	// other societies see the global variants
+	nameVariants, err = testAPI.GetNameVariants(otherCtx, model.SurnameType, "jones")
+	assert.NoError(t, err)
+	assert.Equal(t, model.StringSlice{"johns", "jonas"}, nameVariants.Variants)
+	_, err = testAPI.GetNameVariants(otherCtx, model.SurnameType, "sion")
+	assert.True(t, model.ErrNotFound.Matches(err))
+
+	// removing an added variant removes the society's entry
+	_, err = testAPI.RemoveNameVariants(ctx, model.SurnameType, "sion", model.NameVariantsEdit{Variants: model.StringSlice{"jones"}})
+	assert.NoError(t, err)
+	_, ok := nm.society[7]["sion"]
+	assert.False(t, ok)
+	_, err = testAPI.GetNameVariants(ctx, model.SurnameType, "sion")
+	assert.True(t, model.ErrNotFound.Matches(err))
+
+	_, err = testAPI.AddNameVariants(ctx, model.SurnameType, "jones", model.NameVariantsEdit{})
+	assert.Error(t, err)
+}
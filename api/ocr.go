@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/ocr"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+// GetPostImageOCR returns the text recognized in an image, with words matching highlight flagged
+func (api *API) GetPostImageOCR(ctx context.Context, id uint32, filePath, highlight string) (*model.ImageOCR, error) {
+	if err := checkImagePath(filePath); err != nil {
+		return nil, err
+	}
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	if err := api.checkPostLevel(ctx, id, model.AuthReader); err != nil {
+		return nil, err
+	}
+	bucket, err := api.OpenBucket(ctx, false)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	defer bucket.Close()
+
+	key, err := api.ImageStorageKey(ctx, id, filePath)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	result, err := readImageOCR(ctx, bucket, societyID, key)
+	if err != nil {
+		log.Printf("[ERROR] GetPostImageOCR read %#v\n", err)
+		return nil, NewError(fmt.Errorf("GetPostImageOCR read %v", err))
+	}
+	if result == nil {
+		return nil, NewHTTPError(fmt.Errorf("no text found for image %s", filePath), http.StatusNotFound)
+	}
+	if highlight != "" {
+		ocr.Highlight(result, highlight)
+	}
+	return result, nil
+}
+
+// readImageOCR reads the OCR results for the image stored under key; returns nil if the image has no OCR results
+func readImageOCR(ctx context.Context, bucket *blob.Bucket, societyID uint32, key string) (*model.ImageOCR, error) {
+	b, err := bucket.ReadAll(ctx, fmt.Sprintf("/%d/%s", societyID, key)+model.ImageOCRSuffix)
+	if gcerrors.Code(err) == gcerrors.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var result model.ImageOCR
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
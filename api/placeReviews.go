@@ -0,0 +1,228 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/stdplace"
+)
+
+// maxPlaceReviewRetries bounds the retries when records writers for different posts update the same place review
+const maxPlaceReviewRetries = 3
+
+// GetPlaceReviews returns the society's place reviews with the specified status, or all place reviews if status is empty
+func (api *API) GetPlaceReviews(ctx context.Context, status model.PlaceReviewStatus) ([]model.PlaceReview, error) {
+	if err := api.checkPlaceReviewConfigured(); err != nil {
+		return nil, err
+	}
+	if status != "" && !model.ValidPlaceReviewStatus(status) {
+		return nil, NewHTTPError(errors.New("invalid place review status: "+string(status)), http.StatusBadRequest)
+	}
+	placeReviews, err := api.placeReviewPersister.SelectPlaceReviews(ctx, status)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	return placeReviews, nil
+}
+
+// GetPlaceReview returns a place review
+func (api *API) GetPlaceReview(ctx context.Context, id uint32) (*model.PlaceReview, error) {
+	if err := api.checkPlaceReviewConfigured(); err != nil {
+		return nil, err
+	}
+	placeReview, err := api.placeReviewPersister.SelectPlaceReview(ctx, id)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	return placeReview, nil
+}
+
+// ResolvePlaceReview records an editor's choice of place for the text of a place review
+// The choice is applied to the society's existing records with the text, and records loaded later use it instead of standardizing the text
+func (api *API) ResolvePlaceReview(ctx context.Context, id uint32, in model.PlaceReviewDecision) (*model.PlaceReview, error) {
+	if err := api.checkPlaceReviewConfigured(); err != nil {
+		return nil, err
+	}
+	placeReview, err := api.placeReviewPersister.SelectPlaceReview(ctx, id)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	old := *placeReview
+	var place *model.Place
+	if in.PlaceID != 0 {
+		if place, err = api.getReferencedPlace(ctx, in.PlaceID); err != nil {
+			return nil, err
+		}
+	}
+	placeReview.Status = model.PlaceReviewStatusResolved
+	placeReview.PlaceID = in.PlaceID
+	placeReview.ResolvedBy = currentUserID(ctx)
+	placeReview, err = api.placeReviewPersister.UpdatePlaceReview(ctx, id, *placeReview)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	if err := api.applyPlaceReview(ctx, placeReview, place); err != nil {
+		return nil, err
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityPlaceReview, EntityID: id, Action: model.AuditActionResolve}, old, placeReview)
+	return placeReview, nil
+}
+
+// MatchPlace matches place text against the gazetteer, reporting whether the match needs review
+func (api *API) MatchPlace(ctx context.Context, text, defaultContainingPlace string, eventYear int) (*stdplace.Match, error) {
+	return api.placeStandardizer.Match(ctx, text, defaultContainingPlace, eventYear)
+}
+
+// ReviewedPlace returns the place an editor chose for place text; reviewed is false if an editor hasn't resolved the text
+// The place is nil if the editor chose to leave the text unstandardized
+func (api *API) ReviewedPlace(ctx context.Context, text string) (place *model.Place, reviewed bool, err error) {
+	if api.placeReviewPersister == nil {
+		return nil, false, nil
+	}
+	placeReview, err := api.placeReviewPersister.SelectPlaceReviewByText(ctx, model.NormalizePlaceText(text))
+	if err != nil {
+		if model.ErrNotFound.Matches(err) {
+			return nil, false, nil
+		}
+		return nil, false, NewError(err)
+	}
+	if placeReview.Status != model.PlaceReviewStatusResolved {
+		return nil, false, nil
+	}
+	if placeReview.PlaceID == 0 {
+		return nil, true, nil
+	}
+	place, err = api.placePersister.SelectPlace(ctx, placeReview.PlaceID)
+	if err != nil {
+		return nil, false, NewError(err)
+	}
+	return place, true, nil
+}
+
+// QueuePlaceReview adds the occurrences of place text in a post to the society's place review queue
+// Pending reviews are updated with the latest candidates; resolved reviews keep the editor's choice
+func (api *API) QueuePlaceReview(ctx context.Context, postID uint32, in model.PlaceReviewIn) error {
+	if api.placeReviewPersister == nil {
+		return nil
+	}
+	in.Text = model.NormalizePlaceText(in.Text)
+	var err error
+	for i := 0; i < maxPlaceReviewRetries; i++ {
+		var curr *model.PlaceReview
+		curr, err = api.placeReviewPersister.SelectPlaceReviewByText(ctx, in.Text)
+		if err != nil && !model.ErrNotFound.Matches(err) {
+			return NewError(err)
+		}
+		if curr == nil {
+			placeReview := in
+			placeReview.Status = model.PlaceReviewStatusPending
+			placeReview.PostOccurrences = map[uint32]int{postID: in.Occurrences}
+			_, err = api.placeReviewPersister.InsertPlaceReview(ctx, placeReview)
+		} else {
+			placeReview := *curr
+			if placeReview.Status == model.PlaceReviewStatusPending {
+				placeReview.Reason = in.Reason
+				placeReview.DefaultContainingPlace = in.DefaultContainingPlace
+				placeReview.StandardizedPlaceID = in.StandardizedPlaceID
+				placeReview.StandardizedName = in.StandardizedName
+				placeReview.Candidates = in.Candidates
+			}
+			if placeReview.PostOccurrences == nil {
+				placeReview.PostOccurrences = map[uint32]int{}
+			}
+			// reloading a post replaces its occurrences
+			placeReview.PostOccurrences[postID] = in.Occurrences
+			placeReview.Occurrences = 0
+			for _, occurrences := range placeReview.PostOccurrences {
+				placeReview.Occurrences += occurrences
+			}
+			_, err = api.placeReviewPersister.UpdatePlaceReview(ctx, placeReview.ID, placeReview)
+		}
+		if err == nil || !(model.ErrConflict.Matches(err) || model.ErrConcurrentUpdate.Matches(err)) {
+			break
+		}
+		log.Printf("[DEBUG] Retrying place review for %s: %v", in.Text, err)
+	}
+	if err != nil {
+		return NewError(err)
+	}
+	return nil
+}
+
+// StandardizedPlaceData returns the record data fields holding the standardized place for the place field key
+func (api *API) StandardizedPlaceData(ctx context.Context, key string, place *model.Place) map[string]string {
+	var std, geo, hist string
+	if place != nil {
+		std = place.FullName
+		geo = stdplace.FormatGeo(place)
+		names, err := api.HistoricalPlaceNames(ctx, place)
+		if err != nil {
+			log.Printf("[ERROR] Historical place names %s %v\n", place.FullName, err)
+		}
+		hist = strings.Join(names, stdplace.HistSeparator)
+	}
+	return map[string]string{
+		key + stdplace.StdSuffix:  std,
+		key + stdplace.GeoSuffix:  geo,
+		key + stdplace.HistSuffix: hist,
+	}
+}
+
+func (api *API) checkPlaceReviewConfigured() error {
+	if api.placeReviewPersister == nil {
+		return NewHTTPError(errors.New("place review is not configured"), http.StatusNotImplemented)
+	}
+	return nil
+}
+
+// applyPlaceReview updates the standardized place in the records of the posts in which the place review text occurs,
+// and re-indexes the posts that are published
+func (api *API) applyPlaceReview(ctx context.Context, placeReview *model.PlaceReview, place *model.Place) error {
+	for postID := range placeReview.PostOccurrences {
+		post, err := api.postPersister.SelectOnePost(ctx, postID)
+		if err != nil {
+			if model.ErrNotFound.Matches(err) {
+				// the post has been deleted
+				continue
+			}
+			return NewError(err)
+		}
+		records, err := api.recordPersister.SelectRecordsForPost(ctx, postID, 0)
+		if err != nil {
+			return NewError(err)
+		}
+		updated := false
+		for _, record := range records {
+			changed := false
+			for key, value := range record.Data {
+				if _, isPlaceField := record.Data[key+stdplace.StdSuffix]; !isPlaceField ||
+					model.NormalizePlaceText(value) != placeReview.Text {
+					continue
+				}
+				for k, v := range api.StandardizedPlaceData(ctx, key, place) {
+					if record.Data[k] != v {
+						record.Data[k] = v
+						changed = true
+					}
+				}
+			}
+			if changed {
+				if _, err := api.recordPersister.UpdateRecord(ctx, record.ID, record); err != nil {
+					return NewError(err)
+				}
+				updated = true
+			}
+		}
+		if updated && post.PostStatus == model.PostStatusPublished && api.es != nil {
+			if err := api.IndexPost(ctx, post); err != nil {
+				log.Printf("[ERROR] Re-indexing post %d after place review %d: %v", postID, placeReview.ID, err)
+				return NewError(err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// placeReviewMock is an in-memory place review queue for a single society
+type placeReviewMock struct {
+	placeReviews map[string]model.PlaceReview
+}
+
+func (pm *placeReviewMock) SelectPlaceReviews(ctx context.Context, status model.PlaceReviewStatus) ([]model.PlaceReview, error) {
+	var placeReviews []model.PlaceReview
+	for _, placeReview := range pm.placeReviews {
+		if status == "" || placeReview.Status == status {
+			placeReviews = append(placeReviews, placeReview)
+		}
+	}
+	return placeReviews, nil
+}
+func (pm *placeReviewMock) SelectPlaceReview(ctx context.Context, id uint32) (*model.PlaceReview, error) {
+	for _, placeReview := range pm.placeReviews {
+		if placeReview.ID == id {
+			return &placeReview, nil
+		}
+	}
+	return nil, model.NewError(model.ErrNotFound, fmt.Sprint(id))
+}
+func (pm *placeReviewMock) SelectPlaceReviewByText(ctx context.Context, text string) (*model.PlaceReview, error) {
+	placeReview, ok := pm.placeReviews[text]
+	if !ok {
+		return nil, model.NewError(model.ErrNotFound, text)
+	}
+	return &placeReview, nil
+}
+func (pm *placeReviewMock) InsertPlaceReview(ctx context.Context, in model.PlaceReviewIn) (*model.PlaceReview, error) {
+	if _, ok := pm.placeReviews[in.Text]; ok {
+		return nil, model.NewError(model.ErrConflict)
+	}
+	placeReview := model.PlaceReview{ID: uint32(len(pm.placeReviews) + 1), PlaceReviewIn: in}
+	pm.placeReviews[in.Text] = placeReview
+	return &placeReview, nil
+}
+func (pm *placeReviewMock) UpdatePlaceReview(ctx context.Context, id uint32, in model.PlaceReview) (*model.PlaceReview, error) {
+	in.ID = id
+	pm.placeReviews[in.Text] = in
+	return &in, nil
+}
+
+func TestQueuePlaceReview(t *testing.T) {
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 7)
+	pm := &placeReviewMock{placeReviews: map[string]model.PlaceReview{}}
+	gm := newGazetteerMock()
+	testAPI := &API{}
+	testAPI.PlacePersister(gm).PlaceReviewPersister(pm)
+
+	in := model.PlaceReviewIn{Text: " Olawa,  Lower Silesia", PlaceReviewBody: model.PlaceReviewBody{
+		Reason:      model.PlaceReviewReasonAmbiguous,
+		Candidates:  []model.PlaceCandidate{{PlaceID: 6, Score: 10}, {PlaceID: 7, Score: 5}},
+		Occurrences: 2,
+	}}
+	assert.NoError(t, testAPI.QueuePlaceReview(ctx, 1, in))
+	assert.NoError(t, testAPI.QueuePlaceReview(ctx, 2, in))
+	// reloading a post replaces its occurrences
+	in.Occurrences = 1
+	assert.NoError(t, testAPI.QueuePlaceReview(ctx, 1, in))
+	placeReview := pm.placeReviews["olawa, lower silesia"]
+	assert.Equal(t, model.PlaceReviewStatusPending, placeReview.Status)
+	assert.Equal(t, 3, placeReview.Occurrences)
+	assert.Equal(t, map[uint32]int{1: 1, 2: 2}, placeReview.PostOccurrences)
+
+	// pending reviews aren't used
+	_, reviewed, err := testAPI.ReviewedPlace(ctx, "Olawa, Lower Silesia")
+	assert.NoError(t, err)
+	assert.False(t, reviewed)
+
+	// resolved reviews are used regardless of case and spacing
+	placeReview.Status = model.PlaceReviewStatusResolved
+	placeReview.PlaceID = 2
+	pm.placeReviews[placeReview.Text] = placeReview
+	place, reviewed, err := testAPI.ReviewedPlace(ctx, "OLAWA, LOWER SILESIA")
+	assert.NoError(t, err)
+	assert.True(t, reviewed)
+	assert.Equal(t, uint32(2), place.ID)
+
+	// queueing more occurrences keeps the editor's decision
+	assert.NoError(t, testAPI.QueuePlaceReview(ctx, 3, in))
+	placeReview = pm.placeReviews["olawa, lower silesia"]
+	assert.Equal(t, model.PlaceReviewStatusResolved, placeReview.Status)
+	assert.Equal(t, uint32(2), placeReview.PlaceID)
+	assert.Equal(t, 4, placeReview.Occurrences)
+}
@@ -6,8 +6,14 @@ import (
 	"github.com/ourrootsorg/cms-server/model"
 )
 
-func (api *API) StandardizePlace(ctx context.Context, text, defaultContainingPlace string) (*model.Place, error) {
-	return api.placeStandardizer.Standardize(ctx, text, defaultContainingPlace)
+// StandardizePlace returns the place that best matches text; eventYear is 0 if the year of the event is unknown
+func (api *API) StandardizePlace(ctx context.Context, text, defaultContainingPlace string, eventYear int) (*model.Place, error) {
+	return api.placeStandardizer.Standardize(ctx, text, defaultContainingPlace, eventYear)
+}
+
+// HistoricalPlaceNames returns the full names the place had under its historical jurisdictions
+func (api *API) HistoricalPlaceNames(ctx context.Context, place *model.Place) ([]string, error) {
+	return api.placeStandardizer.HistoricalFullNames(place)
 }
 
 func (api *API) GetPlacesByPrefix(ctx context.Context, prefix string, count int) ([]model.Place, error) {
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"gocloud.dev/pubsub"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+// AddPostEvent appends an event to a post's event log; events are best-effort, so failures are logged but not returned
+func (api API) AddPostEvent(ctx context.Context, postID uint32, body model.PostEventBody) {
+	if api.postEventPersister == nil {
+		log.Printf("[INFO] post %d event %#v", postID, body)
+		return
+	}
+	if _, err := api.postEventPersister.InsertPostEvent(ctx, model.NewPostEventIn(postID, body)); err != nil {
+		log.Printf("[ERROR] adding event %#v for post %d %v", body, postID, err)
+	}
+}
+
+// GetPostEvents returns a post's event log, oldest first
+func (api API) GetPostEvents(ctx context.Context, id uint32) ([]model.PostEvent, error) {
+	if api.postEventPersister == nil {
+		return nil, NewHTTPError(errors.New("post event log is not configured"), http.StatusNotImplemented)
+	}
+	if _, err := api.GetPost(ctx, id); err != nil {
+		return nil, err
+	}
+	postEvents, err := api.postEventPersister.SelectPostEvents(ctx, id)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	return postEvents, nil
+}
+
+// addStatusEvents records the status changes between two versions of a post
+func (api API) addStatusEvents(ctx context.Context, curr, next *model.Post) {
+	userID := currentUserID(ctx)
+	if curr.PostStatus != next.PostStatus {
+		api.AddPostEvent(ctx, next.ID, model.PostEventBody{Type: model.PostEventTypeStatus, Field: "postStatus",
+			From: string(curr.PostStatus), To: string(next.PostStatus), User: userID, Error: next.PostError})
+	}
+	if curr.RecordsStatus != next.RecordsStatus {
+		api.AddPostEvent(ctx, next.ID, model.PostEventBody{Type: model.PostEventTypeStatus, Field: "recordsStatus",
+			From: string(curr.RecordsStatus), To: string(next.RecordsStatus), User: userID, Error: next.RecordsError})
+	}
+	if curr.ImagesStatus != next.ImagesStatus {
+		api.AddPostEvent(ctx, next.ID, model.PostEventBody{Type: model.PostEventTypeStatus, Field: "imagesStatus",
+			From: string(curr.ImagesStatus), To: string(next.ImagesStatus), User: userID, Error: next.ImagesError})
+	}
+}
+
+// currentUserID returns the ID of the current user, or 0 if there is none
+func currentUserID(ctx context.Context) uint32 {
+	user, err := utils.GetUserFromContext(ctx)
+	if err != nil || user == nil {
+		return 0
+	}
+	return user.ID
+}
+
+// OverridePostStatus forces a post's statuses, bypassing the transitions UpdatePost allows;
+// it is used by administrators to recover posts that are stuck
+func (api API) OverridePostStatus(ctx context.Context, id uint32, in model.PostStatusOverride) (*model.Post, error) {
+	if err := api.validate.Struct(in); err != nil {
+		return nil, NewError(err)
+	}
+	if in.PostStatus == nil && in.RecordsStatus == nil && in.ImagesStatus == nil {
+		return nil, NewHTTPError(errors.New("at least one of postStatus, recordsStatus or imagesStatus is required"), http.StatusBadRequest)
+	}
+	if in.PostStatus != nil && !model.ValidPostStatus(*in.PostStatus) {
+		return nil, NewHTTPError(fmt.Errorf("invalid post status: %s", *in.PostStatus), http.StatusBadRequest)
+	}
+	if in.RecordsStatus != nil && !model.ValidRecordsStatus(*in.RecordsStatus) {
+		return nil, NewHTTPError(fmt.Errorf("invalid records status: %s", *in.RecordsStatus), http.StatusBadRequest)
+	}
+	if in.ImagesStatus != nil && !model.ValidImagesStatus(*in.ImagesStatus) {
+		return nil, NewHTTPError(fmt.Errorf("invalid images status: %s", *in.ImagesStatus), http.StatusBadRequest)
+	}
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	currPost, errs := api.GetPost(ctx, id)
+	if errs != nil {
+		return nil, errs
+	}
+	post := *currPost
+	var events []model.PostEventBody
+	type message struct {
+		topic string
+		body  interface{}
+	}
+	var messages []message
+
+	if in.PostStatus != nil {
+		events = append(events, model.PostEventBody{Field: "postStatus", From: string(post.PostStatus), To: string(*in.PostStatus)})
+		post.PostStatus = *in.PostStatus
+		if post.PostStatus == model.PostStatusToPublish || post.PostStatus == model.PostStatusToUnpublish {
+			action := model.PublisherActionIndex
+			if post.PostStatus == model.PostStatusToUnpublish {
+				action = model.PublisherActionUnindex
+			}
+			post.PostError = ""
+			messages = append(messages, message{"publisher", model.PublisherMsg{Action: action, SocietyID: societyID, PostID: id}})
+		}
+	}
+	if in.RecordsStatus != nil {
+		events = append(events, model.PostEventBody{Field: "recordsStatus", From: string(post.RecordsStatus), To: string(*in.RecordsStatus)})
+		post.RecordsStatus = *in.RecordsStatus
+		if post.RecordsStatus == model.RecordsStatusToLoad && post.RecordsKey != "" {
+			post.RecordsError = ""
+			messages = append(messages, message{"recordswriter", model.RecordsWriterMsg{SocietyID: societyID, PostID: id}})
+		}
+	}
+	if in.ImagesStatus != nil {
+		events = append(events, model.PostEventBody{Field: "imagesStatus", From: string(post.ImagesStatus), To: string(*in.ImagesStatus)})
+		post.ImagesStatus = *in.ImagesStatus
+		if post.ImagesStatus == model.ImagesStatusToLoad && len(post.ImagesKeys) > 0 {
+			post.ImagesError = ""
+			messages = append(messages, message{"imageswriter", model.ImagesWriterMsg{
+				Action:    model.ImagesWriterActionUnzip,
+				SocietyID: societyID,
+				PostID:    id,
+				NewZips:   post.ImagesKeys,
+			}})
+		}
+	}
+	if in.Resend && len(messages) == 0 {
+		err := fmt.Errorf("post %d has no worker message to resend; set a status to Publication Requested, Unpublication Requested or Load Requested", id)
+		return nil, NewHTTPError(err, http.StatusBadRequest)
+	}
+
+	log.Printf("[INFO] overriding statuses for post %d: %s", id, in.Reason)
+	updated, e := api.postPersister.UpdatePost(ctx, id, post)
+	if e != nil {
+		return nil, NewError(e)
+	}
+
+	if in.Resend {
+		for _, m := range messages {
+			body, err := json.Marshal(m.body)
+			if err != nil {
+				log.Printf("[ERROR] Can't marshal message %v", err)
+				return nil, NewError(err)
+			}
+			topic, err := api.OpenTopic(ctx, m.topic)
+			if err != nil {
+				log.Printf("[ERROR] Can't open %s topic %v", m.topic, err)
+				return nil, NewError(err)
+			}
+			err = topic.Send(ctx, &pubsub.Message{Body: body})
+			topic.Shutdown(ctx)
+			if err != nil {
+				log.Printf("[ERROR] Can't send message %v", err)
+				return nil, NewError(err)
+			}
+		}
+	}
+
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityPost, EntityID: id, Action: model.AuditActionOverride}, currPost, updated)
+	userID := currentUserID(ctx)
+	for _, event := range events {
+		event.Type = model.PostEventTypeOverride
+		event.Reason = in.Reason
+		event.User = userID
+		event.Resent = in.Resend
+		api.AddPostEvent(ctx, id, event)
+	}
+	return updated, nil
+}
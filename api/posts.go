@@ -40,6 +40,24 @@ func (api API) GetPosts(ctx context.Context /* filter/search criteria */) (*Post
 	if err != nil {
 		return nil, NewError(err)
 	}
+	// omit posts in collections the user's grants hide
+	if grantedPermissions(ctx) != nil {
+		cols, err := api.collectionPersister.SelectCollections(ctx)
+		if err != nil {
+			return nil, NewError(err)
+		}
+		readableCols := map[uint32]bool{}
+		for _, col := range cols {
+			readableCols[col.ID] = canAccessCollection(ctx, col.ID, col.Categories, model.AuthReader)
+		}
+		var readable []model.Post
+		for _, post := range posts {
+			if readableCols[post.Collection] {
+				readable = append(readable, post)
+			}
+		}
+		posts = readable
+	}
 	return &PostResult{Posts: posts}, nil
 }
 
@@ -49,11 +67,38 @@ func (api API) GetPost(ctx context.Context, id uint32) (*model.Post, error) {
 	if err != nil {
 		return nil, NewError(err)
 	}
+	if err := api.checkCollectionIDLevel(ctx, post.Collection, model.AuthReader); err != nil {
+		return nil, err
+	}
 	return post, nil
 }
 
 // GetPostImage returns a signed S3 URL to return an image file
 func (api *API) GetPostImage(ctx context.Context, id uint32, filePath string, thumbnail bool, expireSeconds int) (*ImageMetadata, error) {
+	if err := api.checkPostLevel(ctx, id, model.AuthReader); err != nil {
+		return nil, err
+	}
+	return api.getPostImage(ctx, id, filePath, thumbnail, false, expireSeconds)
+}
+
+// checkImagePath rejects image paths that would address keys outside the post's images
+func checkImagePath(filePath string) error {
+	if strings.HasPrefix(filePath, "/") {
+		return NewHTTPError(fmt.Errorf("invalid image path %s", filePath), http.StatusBadRequest)
+	}
+	for _, part := range strings.Split(filePath, "/") {
+		if part == ".." {
+			return NewHTTPError(fmt.Errorf("invalid image path %s", filePath), http.StatusBadRequest)
+		}
+	}
+	return nil
+}
+
+// getPostImage returns a signed S3 URL to return an image file, or its redacted and watermarked derivative if public is set
+func (api *API) getPostImage(ctx context.Context, id uint32, filePath string, thumbnail, public bool, expireSeconds int) (*ImageMetadata, error) {
+	if err := checkImagePath(filePath); err != nil {
+		return nil, err
+	}
 	societyID, err := utils.GetSocietyIDFromContext(ctx)
 	if err != nil {
 		return nil, NewError(err)
@@ -71,13 +116,24 @@ func (api *API) GetPostImage(ctx context.Context, id uint32, filePath string, th
 	}
 	defer bucket.Close()
 
-	key := fmt.Sprintf(ImagesPrefix, id) + filePath
+	key, err := api.ImageStorageKey(ctx, id, filePath)
+	if err != nil {
+		return nil, NewError(err)
+	}
 	fullKey := fmt.Sprintf("/%d/%s", societyID, key)
 
 	if thumbnail {
 		fullKey += model.ImageThumbnailSuffix
 	}
 	dimensionsKey := fullKey + model.ImageDimensionsSuffix
+	if public {
+		// derivatives are stored under the post's own key, even when the image itself is shared with another post
+		fullKey = fmt.Sprintf("/%d/%s", societyID, fmt.Sprintf(ImagesPrefix, id)+filePath)
+		if thumbnail {
+			fullKey += model.ImageThumbnailSuffix
+		}
+		fullKey += model.ImagePublicSuffix
+	}
 
 	// read image dimensions
 	reader, err := bucket.NewReader(ctx, dimensionsKey, nil)
@@ -119,6 +175,9 @@ func (api API) AddPost(ctx context.Context, in model.PostIn) (*model.Post, error
 		log.Printf("[ERROR] Invalid post %v", err)
 		return nil, NewError(err)
 	}
+	if err := api.checkCollectionIDLevel(ctx, in.Collection, model.AuthContributor); err != nil {
+		return nil, err
+	}
 	log.Printf("[DEBUG] Starting post %#v", in)
 	in.PostStatus = model.PostStatusDraft
 	in.RecordsStatus = model.RecordsStatusDefault
@@ -197,6 +256,7 @@ func (api API) AddPost(ctx context.Context, in model.PostIn) (*model.Post, error
 		log.Printf("[DEBUG] Sent imageswriter message '%s'", string(body))
 	}
 
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityPost, EntityID: post.ID, Action: model.AuditActionCreate}, nil, post)
 	return post, nil
 }
 
@@ -218,6 +278,17 @@ func (api API) UpdatePost(ctx context.Context, id uint32, in model.Post) (*model
 		return nil, errs
 	}
 
+	// editing a post, including requesting that it be published or unpublished, requires Editor on its collection,
+	// and on the collection it is moved to
+	if errs := api.checkCollectionIDLevel(ctx, currPost.Collection, model.AuthEditor); errs != nil {
+		return nil, errs
+	}
+	if in.Collection != currPost.Collection {
+		if errs := api.checkCollectionIDLevel(ctx, in.Collection, model.AuthEditor); errs != nil {
+			return nil, errs
+		}
+	}
+
 	var recordsWriterTopic, imagesWriterTopic, publisherTopic *pubsub.Topic
 	var recordsMsg, imagesMsg, publisherMsg []byte
 
@@ -426,6 +497,18 @@ func (api API) UpdatePost(ctx context.Context, id uint32, in model.Post) (*model
 		}
 	}
 
+	api.addStatusEvents(ctx, currPost, post)
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityPost, EntityID: id, Action: postAuditAction(currPost, post)}, currPost, post)
+
+	// regenerate derivatives of images whose redactions changed
+	if paths := changedImageRedactionPaths(currPost.ImageRedactions, in.ImageRedactions); len(paths) > 0 && len(currPost.ImagesKeys) > 0 {
+		if err := api.requestImageDerivatives(ctx, societyID, id, paths); err != nil {
+			// undo the update
+			_, _ = api.postPersister.UpdatePost(ctx, id, *currPost)
+			return nil, err
+		}
+	}
+
 	// remove old records if any
 	if currPost.RecordsKey != "" && currPost.RecordsKey != in.RecordsKey {
 		if err := api.deleteReferencedContent(ctx, currPost.RecordsKey); err != nil {
@@ -456,6 +539,9 @@ func (api API) DeletePost(ctx context.Context, id uint32) error {
 		log.Printf("[ERROR] reading post %d error=%v", id, err)
 		return NewError(err)
 	}
+	if err := api.checkCollectionIDLevel(ctx, post.Collection, model.AuthEditor); err != nil {
+		return err
+	}
 	// allow deleting posts only when post is draft or error, and when records and images are default or error,
 	// or post has been stuck in loading status for awhile
 	oldPost := time.Since(post.LastUpdateTime).Seconds() > 1800
@@ -466,6 +552,22 @@ func (api API) DeletePost(ctx context.Context, id uint32) error {
 			"post status is %s, records status is %s, and images status is %s", post.ID, post.PostStatus, post.RecordsStatus, post.ImagesStatus))
 	}
 
+	// move the post to the trash; the trash purge deletes it for good later
+	if api.trashEnabled() {
+		if err := api.trashPersister.TrashPost(ctx, id); err != nil {
+			return NewError(err)
+		}
+	} else if err := api.purgePost(ctx, post); err != nil {
+		return err
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityPost, EntityID: id, Action: model.AuditActionDelete}, post, nil)
+	return nil
+}
+
+// purgePost deletes a post along with its records, households, events, image hashes, images and content
+func (api API) purgePost(ctx context.Context, post *model.Post) error {
+	id := post.ID
+	var err error
 	log.Printf("[DEBUG] deleting records for %d", id)
 	// delete record households for post first so we don't have referential integrity errors
 	if err := api.DeleteRecordHouseholdsForPost(ctx, id); err != nil {
@@ -476,6 +578,24 @@ func (api API) DeletePost(ctx context.Context, id uint32) error {
 		return err
 	}
 
+	// delete events for post first so we don't have referential integrity errors
+	if api.postEventPersister != nil {
+		if err := api.postEventPersister.DeletePostEvents(ctx, id); err != nil {
+			return NewError(err)
+		}
+	}
+
+	// delete image hashes for post first so we don't have referential integrity errors
+	var imageHashes []model.ImageHash
+	if api.imageHashPersister != nil {
+		if imageHashes, err = api.imageHashPersister.SelectImageHashesForPost(ctx, id); err != nil {
+			return NewError(err)
+		}
+		if err := api.imageHashPersister.DeleteImageHashesForPost(ctx, id); err != nil {
+			return NewError(err)
+		}
+	}
+
 	log.Printf("[DEBUG] deleting post %d", id)
 	if err := api.postPersister.DeletePost(ctx, id); err != nil {
 		return NewError(err)
@@ -490,7 +610,7 @@ func (api API) DeletePost(ctx context.Context, id uint32) error {
 	}
 	if len(post.ImagesKeys) > 0 {
 		log.Printf("[DEBUG] deleting images for %d", id)
-		if err := api.deleteImages(ctx, id); err != nil {
+		if err := api.deleteImages(ctx, id, imageHashes); err != nil {
 			// log the error but don't undo the delete
 			log.Printf("[ERROR] deleting images when deleting post %d %v", post.ID, err)
 		}
@@ -525,7 +645,9 @@ func (api API) deleteReferencedContent(ctx context.Context, key string) error {
 }
 
 // deleteImagesForPost holds the business logic around deleting the images for a Post
-func (api API) deleteImages(ctx context.Context, postID uint32) error {
+// imageHashes are the (already-deleted) hashes for the post's images; images whose storage is shared
+// with other posts are deleted only when no other post references them
+func (api API) deleteImages(ctx context.Context, postID uint32, imageHashes []model.ImageHash) error {
 	societyID, err := utils.GetSocietyIDFromContext(ctx)
 	if err != nil {
 		return NewError(err)
@@ -536,19 +658,49 @@ func (api API) deleteImages(ctx context.Context, postID uint32) error {
 	}
 	defer bucket.Close()
 	prefix := fmt.Sprintf(ImagesPrefix, postID)
-	fullPrefix := fmt.Sprintf("/%d/%s", societyID, prefix)
+	prefixes := []string{prefix}
+	// delete shared images stored under other posts if this post was the last to reference them
+	for _, imageHash := range imageHashes {
+		if strings.HasPrefix(imageHash.StorageKey, prefix) {
+			continue
+		}
+		referenced, err := api.isImageReferenced(ctx, imageHash.StorageKey)
+		if err != nil {
+			return err
+		}
+		if !referenced {
+			prefixes = append(prefixes, imageHash.StorageKey)
+		}
+	}
 
-	li := bucket.List(&blob.ListOptions{
-		Prefix: fullPrefix,
-	})
 	var errs []string
-	for {
-		obj, e := li.Next(ctx)
-		if e == io.EOF {
-			break
-		} else if e != nil {
-			errs = append(errs, fmt.Sprintf("error getting next object with prefix %s: %v", prefix, e))
-		} else {
+	for _, prefix := range prefixes {
+		fullPrefix := fmt.Sprintf("/%d/%s", societyID, prefix)
+		li := bucket.List(&blob.ListOptions{
+			Prefix: fullPrefix,
+		})
+		for {
+			obj, e := li.Next(ctx)
+			if e == io.EOF {
+				break
+			} else if e != nil {
+				errs = append(errs, fmt.Sprintf("error getting next object with prefix %s: %v", prefix, e))
+				break
+			}
+			// keep images (and their derivatives) that other posts still reference
+			key := strings.TrimPrefix(obj.Key, fmt.Sprintf("/%d/", societyID))
+			for _, suffix := range []string{model.ImageDimensionsSuffix, model.ImageThumbnailSuffix, model.ImageOCRSuffix} {
+				key = strings.TrimSuffix(key, suffix)
+			}
+			referenced, e := api.isImageReferenced(ctx, key)
+			if e != nil {
+				errs = append(errs, fmt.Sprintf("error checking references to key %s: %v", obj.Key, e))
+				continue
+			}
+			if referenced {
+				log.Printf("[DEBUG] Keeping shared key %s", obj.Key)
+				continue
+			}
 			log.Printf("[DEBUG] Deleting key %s", obj.Key)
 			e = bucket.Delete(ctx, obj.Key)
 			if e != nil {
@@ -561,3 +713,15 @@ func (api API) deleteImages(ctx context.Context, postID uint32) error {
 	}
 	return nil
 }
+
+// isImageReferenced returns true if any post's images are stored under key
+func (api API) isImageReferenced(ctx context.Context, key string) (bool, error) {
+	if api.imageHashPersister == nil {
+		return false, nil
+	}
+	imageHashes, err := api.imageHashPersister.SelectImageHashesByStorageKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	return len(imageHashes) > 0, nil
+}
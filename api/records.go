@@ -29,6 +29,9 @@ type HeaderLabel struct {
 // GetRecordsForPost holds the business logic around getting up to limit Records for a post
 func (api API) GetRecordsForPost(ctx context.Context, postID uint32, limit int) (*RecordsResult, error) {
 	// TODO: handle search criteria and paged results
+	if err := api.checkPostLevel(ctx, postID, model.AuthReader); err != nil {
+		return nil, err
+	}
 	records, err := api.recordPersister.SelectRecordsForPost(ctx, postID, limit)
 	if err != nil {
 		return nil, NewError(err)
@@ -51,6 +54,9 @@ func (api API) GetRecord(ctx context.Context, includeDetails bool, id uint32) (*
 	if err != nil {
 		return nil, NewError(err)
 	}
+	if err := api.checkPostLevel(ctx, record.Post, model.AuthReader); err != nil {
+		return nil, err
+	}
 	if !includeDetails {
 		return &RecordDetail{
 			Record: *record,
@@ -125,6 +131,7 @@ func (api API) AddRecord(ctx context.Context, in model.RecordIn) (*model.Record,
 		return nil, NewError(e)
 	}
 	//log.Printf("[DEBUG] Added record ID %d", record.ID)
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityRecord, EntityID: record.ID, Action: model.AuditActionCreate}, nil, record)
 	return record, nil
 }
 
@@ -134,23 +141,28 @@ func (api API) UpdateRecord(ctx context.Context, id uint32, in model.Record) (*m
 	if err != nil {
 		return nil, NewError(err)
 	}
+	old, _ := api.recordPersister.SelectOneRecord(ctx, id)
 	record, e := api.recordPersister.UpdateRecord(ctx, id, in)
 	if e != nil {
 		return nil, NewError(e)
 	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityRecord, EntityID: id, Action: model.AuditActionUpdate}, old, record)
 	return record, nil
 }
 
 // DeleteRecord holds the business logic around deleting a Record
 func (api API) DeleteRecord(ctx context.Context, id uint32) error {
+	old, _ := api.recordPersister.SelectOneRecord(ctx, id)
 	err := api.recordPersister.DeleteRecord(ctx, id)
 	if err != nil {
 		return NewError(err)
 	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityRecord, EntityID: id, Action: model.AuditActionDelete}, old, nil)
 	return nil
 }
 
-// DeleteRecordsForPost holds the business logic around deleting the Records for a Post
+// DeleteRecordsForPost holds the business logic around deleting the Records for a Post;
+// it isn't audited separately because it is part of deleting or reloading the post
 func (api API) DeleteRecordsForPost(ctx context.Context, postID uint32) error {
 	err := api.recordPersister.DeleteRecordsForPost(ctx, postID)
 	if err != nil {
@@ -190,10 +202,13 @@ func (api API) AddRecordHousehold(ctx context.Context, in model.RecordHouseholdI
 		return nil, NewError(e)
 	}
 	log.Printf("[DEBUG] Added record Household post=%d household=%s\n", recordHousehold.Post, recordHousehold.Household)
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityRecordHousehold, EntityID: recordHousehold.Post, Name: recordHousehold.Household,
+		Action: model.AuditActionCreate}, nil, recordHousehold)
 	return recordHousehold, nil
 }
 
-// DeleteRecordHouseholdsForPost holds the business logic around deleting the Record Households for a Post
+// DeleteRecordHouseholdsForPost holds the business logic around deleting the Record Households for a Post;
+// like DeleteRecordsForPost, it isn't audited separately
 func (api API) DeleteRecordHouseholdsForPost(ctx context.Context, postID uint32) error {
 	err := api.recordPersister.DeleteRecordHouseholdsForPost(ctx, postID)
 	if err != nil {
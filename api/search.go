@@ -28,6 +28,7 @@ const (
 	FuzzyNameSoundsLikeBroad  = 1 << iota // 8 - sounds-like (broad) - low-precision, high-recall
 	FuzzyNameLevenshtein      = 1 << iota // 16 - fuzzy (levenshtein)
 	FuzzyNameInitials         = 1 << iota // 32 - initials (applies only to given)
+	FuzzyNamePatronymic       = 1 << iota // 64 - patronymics and farm names (applies only to surname; not included in the default)
 )
 
 // date fuzziness constants cannot by OR'd together
@@ -50,6 +51,10 @@ const (
 
 const Public = "PUBLIC"
 
+// HighlightPreTag and HighlightPostTag surround matching words in image text highlights
+const HighlightPreTag = "<em>"
+const HighlightPostTag = "</em>"
+
 const MaxFrom = 1000
 const MaxSize = 100
 const DefaultSize = 10
@@ -85,11 +90,12 @@ type ESSearchTotal struct {
 	Relation string `json:"relation"`
 }
 type ESSearchHit struct {
-	ID      string         `json:"_id"`
-	Version int            `json:"_version"` // only in search by id
-	Found   bool           `json:"found"`    // only in search by id
-	Score   float64        `json:"_score"`   // only in search
-	Source  ESSearchSource `json:"_source"`
+	ID        string              `json:"_id"`
+	Version   int                 `json:"_version"` // only in search by id
+	Found     bool                `json:"found"`    // only in search by id
+	Score     float64             `json:"_score"`   // only in search
+	Source    ESSearchSource      `json:"_source"`
+	Highlight map[string][]string `json:"highlight"` // only in search
 }
 type ESSearchSource struct {
 	SocietyID    uint32 `json:"societyId"`
@@ -106,11 +112,12 @@ type ESSearchAggregationBucket struct {
 }
 
 type HitData struct {
-	ID           string
-	SocietyID    uint32
-	RecordID     uint32
-	Role         model.Role
-	CollectionID uint32
+	ID             string
+	SocietyID      uint32
+	RecordID       uint32
+	Role           model.Role
+	CollectionID   uint32
+	ImageHighlight []string
 }
 
 func (api API) SearchByID(ctx context.Context, id string, req *SearchByIDRequest) (*model.SearchHit, error) {
@@ -267,6 +274,37 @@ func (api API) SearchDeleteByPost(ctx context.Context, id uint32) error {
 	return nil
 }
 
+// SearchDeleteBySociety deletes the index documents of every record of a society
+func (api API) SearchDeleteBySociety(ctx context.Context, societyID uint32) error {
+	search := Search{
+		Query: Query{
+			Term: map[string]TermQuery{
+				"societyId": {
+					Value: strconv.Itoa(int(societyID)),
+				},
+			},
+		},
+		Size: 1, // need to pass size > 0 or we won't delete any records
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(search); err != nil {
+		log.Printf("[ERROR] encoding delete by society query %v\n", err)
+		return NewError(err)
+	}
+	res, err := api.es.DeleteByQuery([]string{"records"}, &buf,
+		api.es.DeleteByQuery.WithContext(ctx),
+	)
+	if err != nil {
+		log.Printf("[ERROR] SearchDeleteBySociety %v", err)
+		return NewError(err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return NewError(fmt.Errorf("deleting index documents of society %d: %s", societyID, res.String()))
+	}
+	return nil
+}
+
 func (api API) SearchDeleteByID(ctx context.Context, id string) error {
 	// for testing only
 	res, err := api.es.Delete("records", id,
@@ -317,6 +355,9 @@ func (api *API) SearchImage(ctx context.Context, imgSocietyID, postID uint32, fi
 				LoginURL: society.LoginURL,
 			}, nil
 		}
+		// serve the redacted and watermarked derivative if the image has one
+		public := collection.Watermark != nil || len(post.ImageRedactionsFor(filePath)) > 0
+		return api.getPostImage(ctx, postID, filePath, thumbnail, public, expireSeconds)
 	}
 
 	return api.GetPostImage(ctx, postID, filePath, thumbnail, expireSeconds)
@@ -423,6 +464,7 @@ func (api API) Search(ctx context.Context, req *SearchRequest) (*model.SearchRes
 	}
 
 	// construct search hits
+	geoOrigin, originLat, originLon, miles := req.geoOrigin()
 	hits := []model.SearchHit{}
 	for _, hitData := range hitDatas {
 		// get record
@@ -470,6 +512,14 @@ func (api API) Search(ctx context.Context, req *SearchRequest) (*model.SearchRes
 		} else {
 			searchPerson = constructCatalogSearchPerson(collection.Mappings, hitData.Role, &record, maskDetails)
 		}
+		var imageHighlight []string
+		var distance *float64
+		if !maskDetails {
+			imageHighlight = hitData.ImageHighlight
+			if geoOrigin != nil {
+				distance = hitDistance(collection.Mappings, hitData.Role, &record, geoOrigin.eventTypes, originLat, originLon, miles)
+			}
+		}
 		hits = append(hits, model.SearchHit{
 			ID:             hitData.ID,
 			SocietyID:      hitData.SocietyID,
@@ -479,6 +529,8 @@ func (api API) Search(ctx context.Context, req *SearchRequest) (*model.SearchRes
 			CollectionID:   collection.ID,
 			PostID:         record.Post,
 			ImagePath:      record.Data[collection.ImagePathHeader],
+			ImageHighlight: imageHighlight,
+			Distance:       distance,
 		})
 	}
 
@@ -529,11 +581,12 @@ func getHitData(r ESSearchHit) (*HitData, error) {
 	}
 
 	return &HitData{
-		ID:           r.ID,
-		SocietyID:    r.Source.SocietyID,
-		RecordID:     uint32(rid),
-		Role:         role,
-		CollectionID: r.Source.CollectionID,
+		ID:             r.ID,
+		SocietyID:      r.Source.SocietyID,
+		RecordID:       uint32(rid),
+		Role:           role,
+		CollectionID:   r.Source.CollectionID,
+		ImageHighlight: r.Highlight["imageText"],
 	}, nil
 }
 
@@ -574,6 +627,9 @@ func constructRecordSearchPerson(mappings []model.CollectionMapping, role model.
 	} else {
 		name = fmt.Sprintf("%s %s", data["given"], data["surname"])
 	}
+	if data["nameSuffix"] != "" {
+		name += " " + data["nameSuffix"]
+	}
 
 	return model.SearchPerson{
 		Name:          name,
@@ -8,12 +8,14 @@ import (
 	"fmt"
 	"log"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/ourrootsorg/cms-server/stddate"
+	"github.com/ourrootsorg/cms-server/stdname"
 	"github.com/ourrootsorg/cms-server/stdplace"
 
 	"github.com/elastic/go-elasticsearch/v7/esutil"
@@ -23,6 +25,9 @@ import (
 
 const numWorkers = 2
 
+// fullNameField is the index field for columns holding full names, which are split into given names and surnames
+const fullNameField = "name"
+
 type GivenSurname struct {
 	given   string
 	surname string
@@ -249,12 +254,25 @@ func (api API) IndexPost(ctx context.Context, post *model.Post) error {
 		householdRecordsMap = getHouseholdRecordsMap(recordHouseholds, records.Records)
 	}
 
+	// read recognized image text for post; records in collections without an image path column, like catalogs,
+	// are matched by the text of all of the post's images
+	imageTexts, err := api.getImageTexts(ctx, societyID, post.ID)
+	if err != nil {
+		log.Printf("[ERROR] getImageTexts %v\n", err)
+		return err
+	}
+	postImageText := joinImageTexts(imageTexts)
+
 	for _, record := range records.Records {
 		var householdRecords []*model.Record
 		if collection.HouseholdNumberHeader != "" {
 			householdRecords = householdRecordsMap[record.Data[collection.HouseholdNumberHeader]]
 		}
-		err = indexRecord(&record, householdRecords, societyID, post, collection, categories, lastModified, &countSuccessful, bi)
+		imageText := postImageText
+		if collection.ImagePathHeader != "" {
+			imageText = imageTexts[record.Data[collection.ImagePathHeader]]
+		}
+		err = indexRecord(&record, householdRecords, imageText, societyID, post, collection, categories, lastModified, &countSuccessful, bi)
 		if err != nil {
 			log.Printf("[ERROR] Unexpected error %d: %v", record.ID, err)
 			return err
@@ -278,6 +296,49 @@ func (api API) IndexPost(ctx context.Context, post *model.Post) error {
 	return nil
 }
 
+// getImageTexts returns a map of image path to the text recognized in each of the post's images that has OCR output
+func (api API) getImageTexts(ctx context.Context, societyID, postID uint32) (map[string]string, error) {
+	paths, err := api.PostImagePaths(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	bucket, err := api.OpenBucket(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer bucket.Close()
+
+	result := map[string]string{}
+	for _, imagePath := range paths {
+		key, err := api.ImageStorageKey(ctx, postID, imagePath)
+		if err != nil {
+			return nil, err
+		}
+		imageOCR, err := readImageOCR(ctx, bucket, societyID, key)
+		if err != nil {
+			return nil, err
+		}
+		if imageOCR != nil && imageOCR.Text != "" {
+			result[imagePath] = imageOCR.Text
+		}
+	}
+	return result, nil
+}
+
+// joinImageTexts returns the text recognized in all of a post's images, in path order
+func joinImageTexts(imageTexts map[string]string) string {
+	paths := make([]string, 0, len(imageTexts))
+	for imagePath := range imageTexts {
+		paths = append(paths, imagePath)
+	}
+	sort.Strings(paths)
+	texts := make([]string, 0, len(paths))
+	for _, imagePath := range paths {
+		texts = append(texts, imageTexts[imagePath])
+	}
+	return strings.Join(texts, "\n")
+}
+
 func getHouseholdRecordsMap(recordHouseholds []model.RecordHousehold, records []model.Record) map[string][]*model.Record {
 	recordsMap := map[uint32]*model.Record{}
 	for ix := range records {
@@ -297,7 +358,7 @@ func getHouseholdRecordsMap(recordHouseholds []model.RecordHousehold, records []
 	return result
 }
 
-func indexRecord(record *model.Record, householdRecords []*model.Record, societyID uint32, post *model.Post, collection *model.Collection,
+func indexRecord(record *model.Record, householdRecords []*model.Record, imageText string, societyID uint32, post *model.Post, collection *model.Collection,
 	categories []model.Category, lastModified string, countSuccessful *uint64, bi esutil.BulkIndexer) error {
 
 	for role, suffix := range IndexRoles {
@@ -324,6 +385,13 @@ func indexRecord(record *model.Record, householdRecords []*model.Record, society
 			}
 			continue
 		}
+		// index patronymic stems and farm names for surnames searched with FuzzyNamePatronymic
+		if patronyms := stdname.Patronyms(data["surname"]); len(patronyms) > 0 {
+			ixRecord["surnamePatronym"] = patronyms
+		}
+		if data["farm"] != "" {
+			ixRecord["farm"] = data["farm"]
+		}
 
 		// get relatives' names
 		for _, relative := range model.Relatives {
@@ -341,6 +409,9 @@ func indexRecord(record *model.Record, householdRecords []*model.Record, society
 			surnames := unique(getNameParts(names, func(name GivenSurname) string { return name.surname }))
 			if len(givens) > 0 {
 				ixRecord[string(relative)+"Given"] = strings.Join(givens, " ")
+				if relative == model.FatherRelative {
+					ixRecord["fatherGivenStem"] = stdname.GivenStems(strings.Join(givens, " "))
+				}
 			}
 			if len(surnames) > 0 {
 				ixRecord[string(relative)+"Surname"] = strings.Join(surnames, " ")
@@ -348,6 +419,7 @@ func indexRecord(record *model.Record, householdRecords []*model.Record, society
 		}
 
 		// get events
+		var anyGeo []map[string]float64
 		for _, eventType := range model.EventTypes {
 			if data[string(eventType)+"Date"] != "" {
 				dates, years, valid := getDatesYears(data[string(eventType)+"Date_std"])
@@ -371,13 +443,28 @@ func indexRecord(record *model.Record, householdRecords []*model.Record, society
 				if len(placeLevels) > 3 {
 					ixRecord[string(eventType)+"Place4"] = placeLevels[3]
 				}
+				// index historical names of the place so they match as well as the current name
+				if len(placeLevels) > 0 {
+					addHistoricalPlaceLevels(ixRecord, string(eventType)+"Place", data[string(eventType)+"Place"+stdplace.HistSuffix])
+				}
+				if lat, lon, ok := stdplace.ParseGeo(data[string(eventType)+"Place"+stdplace.GeoSuffix]); ok {
+					geo := map[string]float64{"lat": lat, "lon": lon}
+					ixRecord[string(eventType)+"Geo"] = geo
+					anyGeo = append(anyGeo, geo)
+				}
 			}
 		}
+		if len(anyGeo) > 0 {
+			ixRecord["anyGeo"] = anyGeo
+		}
 
 		// keywords, title, author
 		ixRecord["keywords"] = data["keywords"]
 		ixRecord["book_title"] = data["title"]
 		ixRecord["book_author"] = data["author"]
+		if imageText != "" {
+			ixRecord["imageText"] = imageText
+		}
 
 		// get other data
 		var catNames []string
@@ -546,6 +633,40 @@ func getPlaceLevels(stdPlace string) []string {
 	return stdLevels
 }
 
+// addHistoricalPlaceLevels adds the levels of each historical full name to the place level fields
+func addHistoricalPlaceLevels(ixRecord map[string]interface{}, label, histPlaces string) {
+	if histPlaces == "" {
+		return
+	}
+	for _, histPlace := range strings.Split(histPlaces, stdplace.HistSeparator) {
+		for i, level := range getPlaceLevels(histPlace) {
+			if i >= 4 {
+				break
+			}
+			field := fmt.Sprintf("%s%d", label, i+1)
+			var values []string
+			switch v := ixRecord[field].(type) {
+			case string:
+				values = []string{v}
+			case []string:
+				values = v
+			}
+			if !containsString(values, level) {
+				ixRecord[field] = append(values, level)
+			}
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func getPlaceFacets(stdPlace string) []string {
 	var stdLevels []string
 	if stdPlace == "" {
@@ -580,11 +701,20 @@ func getDataForRole(mappings []model.CollectionMapping, record *model.Record, ro
 			(mapping.IxRole == string(role) || // get data for this role
 				mapping.IxField == "keywords" || // add keywords to everyone
 				(isSpouseRole(mapping.IxRole, role) && isMarriageField(mapping.IxField))) { // get marriage data for spouse too
+			if mapping.IxField == fullNameField {
+				name := parseFullName(mapping, record)
+				data["given"] = concat(data["given"], name.Given)
+				data["surname"] = concat(data["surname"], name.FullSurname())
+				data["nameSuffix"] = concat(data["nameSuffix"], name.Suffix)
+				continue
+			}
 			data[mapping.IxField] = concat(data[mapping.IxField], record.Data[mapping.Header])
 			if strings.HasSuffix(mapping.IxField, "Date") {
 				data[mapping.IxField+stddate.StdSuffix] = record.Data[mapping.Header+stddate.StdSuffix]
 			} else if strings.HasSuffix(mapping.IxField, "Place") {
 				data[mapping.IxField+stdplace.StdSuffix] = record.Data[mapping.Header+stdplace.StdSuffix]
+				data[mapping.IxField+stdplace.GeoSuffix] = record.Data[mapping.Header+stdplace.GeoSuffix]
+				data[mapping.IxField+stdplace.HistSuffix] = record.Data[mapping.Header+stdplace.HistSuffix]
 			}
 		}
 	}
@@ -650,6 +780,15 @@ func getNamesForRole(role model.Role, mappings []model.CollectionMapping, record
 			if mapping.IxField == "surname" && record.Data[mapping.Header] != "" {
 				surnames = append(surnames, record.Data[mapping.Header])
 			}
+			if mapping.IxField == fullNameField && record.Data[mapping.Header] != "" {
+				name := parseFullName(mapping, record)
+				if name.Given != "" {
+					givens = append(givens, name.Given)
+				}
+				if name.FullSurname() != "" {
+					surnames = append(surnames, name.FullSurname())
+				}
+			}
 		}
 	}
 	if len(givens) > 0 || len(surnames) > 0 {
@@ -663,6 +802,11 @@ func getNamesForRole(role model.Role, mappings []model.CollectionMapping, record
 	return names
 }
 
+// parseFullName splits the full name in a record column into its parts, using the order set in the mapping
+func parseFullName(mapping model.CollectionMapping, record *model.Record) stdname.Name {
+	return stdname.Parse(record.Data[mapping.Header], stdname.ParseOrder(mapping.NameOrder))
+}
+
 func getHouseholdNames(relToHeadHeader, genderHeader string, mappings []model.CollectionMapping, relative model.Relative,
 	relsToHead []model.HouseholdRelToHead, recordID uint32, householdRecords []*model.Record) []GivenSurname {
 
@@ -3,11 +3,15 @@ package api
 import (
 	"context"
 	"fmt"
+	"math"
+	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/stdname"
+	"github.com/ourrootsorg/cms-server/stdplace"
 	"github.com/ourrootsorg/cms-server/stdtext"
 	"github.com/ourrootsorg/cms-server/utils"
 )
@@ -25,6 +29,8 @@ type SearchRequest struct {
 	GivenFuzziness   int    `schema:"givenFuzziness"`
 	Surname          string `schema:"surname"`
 	SurnameFuzziness int    `schema:"surnameFuzziness"`
+	Farm             string `schema:"farm"`
+	FarmFuzziness    int    `schema:"farmFuzziness"`
 	// relatives
 	FatherGiven            string `schema:"fatherGiven"`
 	FatherGivenFuzziness   int    `schema:"fatherGivenFuzziness"`
@@ -63,10 +69,27 @@ type SearchRequest struct {
 	AnyDateFuzziness        int    `schema:"anyDateFuzziness"`
 	AnyPlace                string `schema:"anyPlace"` // match on any place
 	AnyPlaceFuzziness       int    `schema:"anyPlaceFuzziness"`
+	// geographic place search: near is "lat,lon", radius is a distance like 25km or 10mi, box is "top,left,bottom,right"
+	BirthPlaceNear       string `schema:"birthPlaceNear"`
+	BirthPlaceRadius     string `schema:"birthPlaceRadius"`
+	BirthPlaceBox        string `schema:"birthPlaceBox"`
+	MarriagePlaceNear    string `schema:"marriagePlaceNear"`
+	MarriagePlaceRadius  string `schema:"marriagePlaceRadius"`
+	MarriagePlaceBox     string `schema:"marriagePlaceBox"`
+	ResidencePlaceNear   string `schema:"residencePlaceNear"`
+	ResidencePlaceRadius string `schema:"residencePlaceRadius"`
+	ResidencePlaceBox    string `schema:"residencePlaceBox"`
+	DeathPlaceNear       string `schema:"deathPlaceNear"`
+	DeathPlaceRadius     string `schema:"deathPlaceRadius"`
+	DeathPlaceBox        string `schema:"deathPlaceBox"`
+	AnyPlaceNear         string `schema:"anyPlaceNear"` // match near any place
+	AnyPlaceRadius       string `schema:"anyPlaceRadius"`
+	AnyPlaceBox          string `schema:"anyPlaceBox"`
 	// other
-	Keywords string `schema:"keywords"`
-	Title    string `schema:"title"`
-	Author   string `schema:"author"`
+	Keywords  string `schema:"keywords"`
+	Title     string `schema:"title"`
+	Author    string `schema:"author"`
+	ImageText string `schema:"imageText"` // text recognized in the record's image
 	// facets and filters
 	CollectionPlace1Facet bool   `schema:"collectionPlace1Facet"`
 	CollectionPlace1      string `schema:"collectionPlace1"`
@@ -86,20 +109,32 @@ type SearchRequest struct {
 
 // int
 type Search struct {
-	Query  Query          `json:"query,omitempty"`
-	Aggs   map[string]Agg `json:"aggs,omitempty"`
-	Source []string       `json:"_source,omitempty"`
-	From   int            `json:"from,omitempty"`
-	Size   int            `json:"size"`
+	Query     Query          `json:"query,omitempty"`
+	Aggs      map[string]Agg `json:"aggs,omitempty"`
+	Highlight *Highlight     `json:"highlight,omitempty"`
+	Source    []string       `json:"_source,omitempty"`
+	From      int            `json:"from,omitempty"`
+	Size      int            `json:"size"`
+}
+type Highlight struct {
+	PreTags  []string                  `json:"pre_tags,omitempty"`
+	PostTags []string                  `json:"post_tags,omitempty"`
+	Fields   map[string]HighlightField `json:"fields"`
+}
+type HighlightField struct {
+	FragmentSize      int `json:"fragment_size,omitempty"`
+	NumberOfFragments int `json:"number_of_fragments,omitempty"`
 }
 type Query struct {
-	Bool     *BoolQuery            `json:"bool,omitempty"`
-	DisMax   *DisMaxQuery          `json:"dis_max,omitempty"`
-	Fuzzy    map[string]FuzzyQuery `json:"fuzzy,omitempty"`
-	Match    map[string]MatchQuery `json:"match,omitempty"`
-	Range    map[string]RangeQuery `json:"range,omitempty"`
-	Term     map[string]TermQuery  `json:"term,omitempty"`
-	Wildcard map[string]TermQuery  `json:"wildcard,omitempty"`
+	Bool           *BoolQuery             `json:"bool,omitempty"`
+	DisMax         *DisMaxQuery           `json:"dis_max,omitempty"`
+	Fuzzy          map[string]FuzzyQuery  `json:"fuzzy,omitempty"`
+	GeoBoundingBox map[string]interface{} `json:"geo_bounding_box,omitempty"`
+	GeoDistance    map[string]interface{} `json:"geo_distance,omitempty"`
+	Match          map[string]MatchQuery  `json:"match,omitempty"`
+	Range          map[string]RangeQuery  `json:"range,omitempty"`
+	Term           map[string]TermQuery   `json:"term,omitempty"`
+	Wildcard       map[string]TermQuery   `json:"wildcard,omitempty"`
 }
 type BoolQuery struct {
 	Must   []Query `json:"must,omitempty"`
@@ -188,8 +223,16 @@ func (api API) constructSearchQuery(ctx context.Context, req *SearchRequest) (*S
 		})
 	}
 
+	// farm name
+	shouldSubqueries, mustSubqueries, err := api.constructNameQueries(ctx, "farm", req.Farm, req.FarmFuzziness, model.SurnameType)
+	if err != nil {
+		return nil, err
+	}
+	shouldQueries = append(shouldQueries, shouldSubqueries...)
+	mustQueries = append(mustQueries, mustSubqueries...)
+
 	// relative names
-	shouldSubqueries, mustSubqueries, err := api.constructNameQueries(ctx, "fatherGiven", req.FatherGiven, req.FatherGivenFuzziness, model.GivenType)
+	shouldSubqueries, mustSubqueries, err = api.constructNameQueries(ctx, "fatherGiven", req.FatherGiven, req.FatherGivenFuzziness, model.GivenType)
 	if err != nil {
 		return nil, err
 	}
@@ -334,10 +377,34 @@ func (api API) constructSearchQuery(ctx context.Context, req *SearchRequest) (*S
 		}
 	}
 
+	// geographic places
+	for _, geoSearch := range req.geoSearches() {
+		geoQueries, err := constructGeoQueries(geoSearch.label, geoSearch.near, geoSearch.radius, geoSearch.box)
+		if err != nil {
+			return nil, err
+		}
+		filterQueries = append(filterQueries, geoQueries...)
+	}
+
 	// other
 	mustQueries = append(mustQueries, constructTextQueries("keywords", req.Keywords)...)
 	mustQueries = append(mustQueries, constructTextQueries("book_title", req.Title)...)
 	mustQueries = append(mustQueries, constructTextQueries("book_author", req.Author)...)
+	imageTextQueries := constructTextQueries("imageText", req.ImageText)
+	mustQueries = append(mustQueries, imageTextQueries...)
+	var highlight *Highlight
+	if len(imageTextQueries) > 0 {
+		highlight = &Highlight{
+			PreTags:  []string{HighlightPreTag},
+			PostTags: []string{HighlightPostTag},
+			Fields: map[string]HighlightField{
+				"imageText": {
+					FragmentSize:      100,
+					NumberOfFragments: 3,
+				},
+			},
+		}
+	}
 
 	// filters
 	filterQueries = append(filterQueries, constructFilterQueries("societyId", float64(societyID))...) // convert to float64 so tests pass
@@ -379,9 +446,10 @@ func (api API) constructSearchQuery(ctx context.Context, req *SearchRequest) (*S
 				Filter: filterQueries,
 			},
 		},
-		Aggs: aggs,
-		From: from,
-		Size: size,
+		Aggs:      aggs,
+		Highlight: highlight,
+		From:      from,
+		Size:      size,
 	}, nil
 }
 
@@ -393,6 +461,9 @@ const wildcardNameBoost = 0.5
 const broadNameBoost = 0.4
 const fuzzyNameBoost = 0.3
 const initialNameBoost = 0.2
+const patronymicNameBoost = 0.8
+const farmNameBoost = 0.7
+const fatherPatronymBoost = 0.5
 
 func (api API) constructNameQueries(ctx context.Context, label, value string, fuzziness int, nameType model.NameType) ([]Query, []Query, error) {
 	if len(value) == 0 {
@@ -498,6 +569,9 @@ func (api API) constructNameQueries(ctx context.Context, label, value string, fu
 				},
 			})
 		}
+		if fuzziness&FuzzyNamePatronymic > 0 && label == "surname" {
+			subqueries = append(subqueries, constructPatronymicQueries(v)...)
+		}
 
 		queries = append(queries, Query{
 			DisMax: &DisMaxQuery{
@@ -513,6 +587,37 @@ func (api API) constructNameQueries(ctx context.Context, label, value string, fu
 	}
 }
 
+// constructPatronymicQueries matches a principal's surname against other patronymics formed from the same father's name,
+// against the father's given name, and against farm names, since Nordic records may use any of them as the surname
+func constructPatronymicQueries(surname string) []Query {
+	queries := []Query{{
+		Match: map[string]MatchQuery{
+			"farm": {
+				Query: surname,
+				Boost: farmNameBoost,
+			},
+		},
+	}}
+	if stem := stdname.Patronym(surname); stem != "" {
+		queries = append(queries, Query{
+			Term: map[string]TermQuery{
+				"surnamePatronym": {
+					Value: stem,
+					Boost: patronymicNameBoost,
+				},
+			},
+		}, Query{
+			Term: map[string]TermQuery{
+				"fatherGivenStem": {
+					Value: stem,
+					Boost: fatherPatronymBoost,
+				},
+			},
+		})
+	}
+	return queries
+}
+
 const exactYearBoost = 0.7
 const rangeYearBoost = 0.3
 
@@ -699,6 +804,115 @@ func constructPlaceQueries(label, value string, fuzziness int) ([]Query, []Query
 	}
 }
 
+// DefaultGeoRadius is used when a place search specifies a point but not a radius
+const DefaultGeoRadius = "25km"
+
+type geoSearch struct {
+	label      string
+	eventTypes []model.EventType
+	near       string
+	radius     string
+	box        string
+}
+
+// geoSearches returns the geographic place searches in the request
+func (req *SearchRequest) geoSearches() []geoSearch {
+	var result []geoSearch
+	for _, gs := range []geoSearch{
+		{"birthGeo", []model.EventType{model.BirthEvent}, req.BirthPlaceNear, req.BirthPlaceRadius, req.BirthPlaceBox},
+		{"marriageGeo", []model.EventType{model.MarriageEvent}, req.MarriagePlaceNear, req.MarriagePlaceRadius, req.MarriagePlaceBox},
+		{"residenceGeo", []model.EventType{model.ResidenceEvent}, req.ResidencePlaceNear, req.ResidencePlaceRadius, req.ResidencePlaceBox},
+		{"deathGeo", []model.EventType{model.DeathEvent}, req.DeathPlaceNear, req.DeathPlaceRadius, req.DeathPlaceBox},
+		{"anyGeo", model.EventTypes, req.AnyPlaceNear, req.AnyPlaceRadius, req.AnyPlaceBox},
+	} {
+		if gs.near != "" || gs.box != "" {
+			result = append(result, gs)
+		}
+	}
+	return result
+}
+
+// geoOrigin returns the point and event types to measure hit distances from, along with whether distances are in miles
+func (req *SearchRequest) geoOrigin() (*geoSearch, float64, float64, bool) {
+	for _, gs := range req.geoSearches() {
+		if lat, lon, ok := stdplace.ParseGeo(gs.near); ok {
+			gs := gs
+			return &gs, lat, lon, strings.HasSuffix(strings.ToLower(strings.TrimSpace(gs.radius)), "mi")
+		}
+	}
+	return nil, 0, 0, false
+}
+
+// hitDistance returns the distance from lat,lon to the nearest place of the given event types for the role in the record
+func hitDistance(mappings []model.CollectionMapping, role model.Role, record *model.Record, eventTypes []model.EventType, lat, lon float64, miles bool) *float64 {
+	data := getDataForRole(mappings, record, role)
+	var result *float64
+	for _, eventType := range eventTypes {
+		placeLat, placeLon, ok := stdplace.ParseGeo(data[string(eventType)+"Place"+stdplace.GeoSuffix])
+		if !ok {
+			continue
+		}
+		d := stdplace.Distance(lat, lon, placeLat, placeLon)
+		if miles {
+			d = d / stdplace.KmPerMile
+		}
+		d = math.Round(d*10) / 10
+		if result == nil || d < *result {
+			result = &d
+		}
+	}
+	return result
+}
+
+func constructGeoQueries(label, near, radius, box string) ([]Query, error) {
+	var queries []Query
+	if near != "" {
+		lat, lon, ok := stdplace.ParseGeo(near)
+		if !ok {
+			return nil, NewHTTPError(fmt.Errorf("invalid %s point: %s", label, near), http.StatusBadRequest)
+		}
+		if radius == "" {
+			radius = DefaultGeoRadius
+		}
+		km, ok := stdplace.ParseDistance(radius)
+		if !ok {
+			return nil, NewHTTPError(fmt.Errorf("invalid %s radius: %s", label, radius), http.StatusBadRequest)
+		}
+		queries = append(queries, Query{
+			GeoDistance: map[string]interface{}{
+				"distance": strconv.FormatFloat(km, 'f', -1, 64) + "km",
+				label:      geoPoint(lat, lon),
+			},
+		})
+	}
+	if box != "" {
+		var coords []float64
+		for _, part := range strings.Split(box, ",") {
+			coord, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return nil, NewHTTPError(fmt.Errorf("invalid %s box: %s", label, box), http.StatusBadRequest)
+			}
+			coords = append(coords, coord)
+		}
+		if len(coords) != 4 || coords[0] < coords[2] || coords[0] > 90 || coords[2] < -90 {
+			return nil, NewHTTPError(fmt.Errorf("invalid %s box: %s", label, box), http.StatusBadRequest)
+		}
+		queries = append(queries, Query{
+			GeoBoundingBox: map[string]interface{}{
+				label: map[string]interface{}{
+					"top_left":     geoPoint(coords[0], coords[1]),
+					"bottom_right": geoPoint(coords[2], coords[3]),
+				},
+			},
+		})
+	}
+	return queries, nil
+}
+
+func geoPoint(lat, lon float64) map[string]interface{} {
+	return map[string]interface{}{"lat": lat, "lon": lon}
+}
+
 func constructTextQueries(label, value string) []Query {
 	if len(value) == 0 {
 		return nil
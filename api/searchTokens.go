@@ -0,0 +1,357 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+// DefaultSearchTokenExpiresIn is how many seconds a minted search token is valid if the request doesn't say
+const DefaultSearchTokenExpiresIn = 3600
+
+// MaxSearchTokenExpiresIn is the longest a minted search token can be valid, in seconds
+const MaxSearchTokenExpiresIn = 86400
+
+// searchKeyCacheTTL bounds how long search keys are cached, so keys deleted through other servers stop working
+const searchKeyCacheTTL = 5 * time.Minute
+
+type searchKeyCacheEntry struct {
+	searchKey model.SearchKey
+	expires   time.Time
+}
+
+// SearchTokenRequest asks for a search token for a user of the society's membership site
+type SearchTokenRequest struct {
+	// UserID is the user's ID on the membership site; 0 means the user isn't signed in and can search only public collections
+	UserID uint32 `json:"userId"`
+	// ExpiresIn is how many seconds the token is valid; it defaults to DefaultSearchTokenExpiresIn
+	ExpiresIn int `json:"expiresIn,omitempty" validate:"omitempty,min=1,max=86400"`
+}
+
+// SearchToken is a minted search token
+type SearchToken struct {
+	Token string `json:"token"`
+	// KID is the search key that signed the token, or empty if it was signed with the society's secret key
+	KID       string    `json:"kid,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// GetSearchKeys returns the society's search keys, newest first, without their secrets
+func (api API) GetSearchKeys(ctx context.Context) ([]model.SearchKey, error) {
+	if err := api.checkSearchKeysConfigured(); err != nil {
+		return nil, err
+	}
+	searchKeys, err := api.searchKeyPersister.SelectSearchKeys(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	for i := range searchKeys {
+		searchKeys[i].Secret = ""
+	}
+	return searchKeys, nil
+}
+
+// AddSearchKey adds a search key to the society; new tokens are signed with the newest key that has a secret.
+// Missing secrets and key pairs are generated, and the secret is returned only in this response.
+func (api API) AddSearchKey(ctx context.Context, body model.SearchKeyBody) (*model.SearchKey, error) {
+	if err := api.checkSearchKeysConfigured(); err != nil {
+		return nil, err
+	}
+	err := api.validate.Struct(body)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	body, err = completeSearchKey(body)
+	if err != nil {
+		return nil, NewHTTPError(err, http.StatusBadRequest)
+	}
+	kid, err := randomHex(8)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	in := model.SearchKeyIn{
+		SearchKeyBody: body,
+		KID:           kid,
+		SocietyID:     societyID,
+	}
+	searchKey, err := api.searchKeyPersister.InsertSearchKey(ctx, in)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntitySearchKey, EntityID: searchKey.ID, Name: searchKey.KID, Action: model.AuditActionCreate},
+		nil, searchKey)
+	return searchKey, nil
+}
+
+// DeleteSearchKey deletes a search key; tokens it signed are no longer accepted
+func (api API) DeleteSearchKey(ctx context.Context, kid string) error {
+	if err := api.checkSearchKeysConfigured(); err != nil {
+		return err
+	}
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return NewError(err)
+	}
+	old, _ := api.searchKeyPersister.SelectSearchKey(ctx, kid)
+	err = api.searchKeyPersister.DeleteSearchKey(ctx, kid)
+	if err != nil {
+		return NewError(err)
+	}
+	api.searchKeyCache.Remove(searchKeyCacheKey(societyID, kid))
+	auditBody := model.AuditEventBody{Entity: model.AuditEntitySearchKey, Name: kid, Action: model.AuditActionDelete}
+	if old != nil {
+		auditBody.EntityID = old.ID
+	}
+	api.audit(ctx, auditBody, old, nil)
+	return nil
+}
+
+// MintSearchToken returns a search token for a user of the society's membership site.
+// It is signed with the newest search key that has a secret, or with the society's secret key if there is none.
+func (api API) MintSearchToken(ctx context.Context, req SearchTokenRequest) (*SearchToken, error) {
+	err := api.validate.Struct(req)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	expiresIn := req.ExpiresIn
+	if expiresIn == 0 {
+		expiresIn = DefaultSearchTokenExpiresIn
+	}
+	now := time.Now()
+	result := &SearchToken{
+		ExpiresAt: now.Add(time.Duration(expiresIn) * time.Second).Truncate(time.Second),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": fmt.Sprintf("%d_%d", societyID, req.UserID),
+		"iat": now.Unix(),
+		"exp": result.ExpiresAt.Unix(),
+	})
+
+	var signingKey interface{}
+	if api.searchKeyPersister != nil {
+		searchKeys, err := api.searchKeyPersister.SelectSearchKeys(ctx)
+		if err != nil {
+			return nil, NewError(err)
+		}
+		for _, searchKey := range searchKeys {
+			if searchKey.CanSign() {
+				token.Method, signingKey, err = searchKeySigningKey(searchKey)
+				if err != nil {
+					return nil, NewError(err)
+				}
+				token.Header["alg"] = token.Method.Alg()
+				token.Header["kid"] = searchKey.KID
+				result.KID = searchKey.KID
+				break
+			}
+		}
+	}
+	if signingKey == nil {
+		society, err := api.GetSociety(ctx, societyID)
+		if err != nil {
+			return nil, err
+		}
+		if society.SecretKey == "" {
+			return nil, NewHTTPError(errors.New("the society has no search keys"), http.StatusBadRequest)
+		}
+		signingKey = []byte(society.SecretKey)
+	}
+
+	result.Token, err = token.SignedString(signingKey)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	return result, nil
+}
+
+// SearchTokenKey returns the key that verifies a search token for the society.
+// Tokens with a kid header are verified with that search key; tokens without one with the society's secret key.
+func (api API) SearchTokenKey(ctx context.Context, societyID uint32, token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		society, err := api.GetSociety(ctx, societyID)
+		if err != nil {
+			return nil, err
+		}
+		if society.SecretKey == "" {
+			return nil, errors.New("the society has no secret key")
+		}
+		return []byte(society.SecretKey), nil
+	}
+
+	if err := api.checkSearchKeysConfigured(); err != nil {
+		return nil, err
+	}
+	// cached keys would otherwise keep a trashed society's index searchable
+	if err := api.checkSocietyNotTrashed(ctx, societyID); err != nil {
+		return nil, err
+	}
+	var searchKey model.SearchKey
+	cacheKey := searchKeyCacheKey(societyID, kid)
+	value, ok := api.searchKeyCache.Get(cacheKey)
+	if ok {
+		var entry searchKeyCacheEntry
+		entry, ok = value.(searchKeyCacheEntry)
+		ok = ok && time.Now().Before(entry.expires)
+		searchKey = entry.searchKey
+	}
+	if !ok {
+		sk, err := api.searchKeyPersister.SelectSearchKey(utils.AddSocietyIDToContext(ctx, societyID), kid)
+		if err != nil {
+			return nil, err
+		}
+		searchKey = *sk
+		api.searchKeyCache.Add(cacheKey, searchKeyCacheEntry{searchKey: searchKey, expires: time.Now().Add(searchKeyCacheTTL)})
+	}
+	// the algorithm must be the key's, so a public key can't be used as an HMAC secret
+	if token.Method.Alg() != searchKey.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return searchKeyVerificationKey(searchKey)
+}
+
+func (api *API) checkSearchKeysConfigured() error {
+	if api.searchKeyPersister == nil {
+		return NewHTTPError(errors.New("search keys are not configured"), http.StatusNotImplemented)
+	}
+	return nil
+}
+
+func searchKeyCacheKey(societyID uint32, kid string) string {
+	return fmt.Sprintf("%d/%s", societyID, kid)
+}
+
+// completeSearchKey checks the secret and public key supplied for a search key, generating any that are missing
+func completeSearchKey(body model.SearchKeyBody) (model.SearchKeyBody, error) {
+	var err error
+	switch body.Algorithm {
+	case model.SearchKeyHS256:
+		if body.PublicKey != "" {
+			return body, errors.New("HS256 keys don't have a public key")
+		}
+		if body.Secret == "" {
+			body.Secret, err = randomHex(32)
+		}
+		return body, err
+	case model.SearchKeyRS256:
+		var key *rsa.PrivateKey
+		switch {
+		case body.Secret != "":
+			key, err = jwt.ParseRSAPrivateKeyFromPEM([]byte(body.Secret))
+		case body.PublicKey != "":
+			_, err = jwt.ParseRSAPublicKeyFromPEM([]byte(body.PublicKey))
+			return body, err
+		default:
+			key, err = rsa.GenerateKey(rand.Reader, 2048)
+			if err == nil {
+				body.Secret = encodePEM("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+			}
+		}
+		if err != nil {
+			return body, err
+		}
+		body.PublicKey, err = encodePublicKeyPEM(&key.PublicKey)
+		return body, err
+	case model.SearchKeyES256:
+		var key *ecdsa.PrivateKey
+		switch {
+		case body.Secret != "":
+			key, err = jwt.ParseECPrivateKeyFromPEM([]byte(body.Secret))
+		case body.PublicKey != "":
+			var publicKey *ecdsa.PublicKey
+			publicKey, err = jwt.ParseECPublicKeyFromPEM([]byte(body.PublicKey))
+			if err == nil && publicKey.Curve != elliptic.P256() {
+				err = errors.New("ES256 keys must use the P-256 curve")
+			}
+			return body, err
+		default:
+			key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			if err == nil {
+				var der []byte
+				der, err = x509.MarshalECPrivateKey(key)
+				body.Secret = encodePEM("EC PRIVATE KEY", der)
+			}
+		}
+		if err != nil {
+			return body, err
+		}
+		if key.Curve != elliptic.P256() {
+			return body, errors.New("ES256 keys must use the P-256 curve")
+		}
+		body.PublicKey, err = encodePublicKeyPEM(&key.PublicKey)
+		return body, err
+	}
+	return body, fmt.Errorf("unknown algorithm: %s", body.Algorithm)
+}
+
+// searchKeySigningKey returns the signing method and key for a search key that can sign
+func searchKeySigningKey(searchKey model.SearchKey) (jwt.SigningMethod, interface{}, error) {
+	switch searchKey.Algorithm {
+	case model.SearchKeyHS256:
+		return jwt.SigningMethodHS256, []byte(searchKey.Secret), nil
+	case model.SearchKeyRS256:
+		key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(searchKey.Secret))
+		return jwt.SigningMethodRS256, key, err
+	case model.SearchKeyES256:
+		key, err := jwt.ParseECPrivateKeyFromPEM([]byte(searchKey.Secret))
+		return jwt.SigningMethodES256, key, err
+	}
+	return nil, nil, fmt.Errorf("unknown algorithm: %s", searchKey.Algorithm)
+}
+
+// searchKeyVerificationKey returns the key that verifies tokens signed with a search key
+func searchKeyVerificationKey(searchKey model.SearchKey) (interface{}, error) {
+	switch searchKey.Algorithm {
+	case model.SearchKeyHS256:
+		return []byte(searchKey.Secret), nil
+	case model.SearchKeyRS256:
+		return jwt.ParseRSAPublicKeyFromPEM([]byte(searchKey.PublicKey))
+	case model.SearchKeyES256:
+		return jwt.ParseECPublicKeyFromPEM([]byte(searchKey.PublicKey))
+	}
+	return nil, fmt.Errorf("unknown algorithm: %s", searchKey.Algorithm)
+}
+
+func encodePublicKeyPEM(key interface{}) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	return encodePEM("PUBLIC KEY", der), nil
+}
+
+func encodePEM(blockType string, der []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}))
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-playground/validator/v10"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// searchKeyMock holds search keys in memory, oldest first
+type searchKeyMock struct {
+	searchKeys []model.SearchKey
+}
+
+func (sm *searchKeyMock) SelectSearchKeys(ctx context.Context) ([]model.SearchKey, error) {
+	societyID, _ := utils.GetSocietyIDFromContext(ctx)
+	var result []model.SearchKey
+	for i := len(sm.searchKeys) - 1; i >= 0; i-- {
+		if sm.searchKeys[i].SocietyID == societyID {
+			result = append(result, sm.searchKeys[i])
+		}
+	}
+	return result, nil
+}
+func (sm *searchKeyMock) SelectSearchKey(ctx context.Context, kid string) (*model.SearchKey, error) {
+	societyID, _ := utils.GetSocietyIDFromContext(ctx)
+	for _, searchKey := range sm.searchKeys {
+		if searchKey.SocietyID == societyID && searchKey.KID == kid {
+			return &searchKey, nil
+		}
+	}
+	return nil, model.NewError(model.ErrNotFound, kid)
+}
+func (sm *searchKeyMock) InsertSearchKey(ctx context.Context, in model.SearchKeyIn) (*model.SearchKey, error) {
+	searchKey := model.NewSearchKey(uint32(len(sm.searchKeys)+1), in)
+	sm.searchKeys = append(sm.searchKeys, searchKey)
+	return &searchKey, nil
+}
+func (sm *searchKeyMock) DeleteSearchKey(ctx context.Context, kid string) error {
+	for i, searchKey := range sm.searchKeys {
+		if searchKey.KID == kid {
+			sm.searchKeys = append(sm.searchKeys[:i], sm.searchKeys[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// societyMock holds societies in memory
+type societyMock struct {
+	societies map[uint32]model.Society
+}
+
+func (sm *societyMock) SelectSocietySummariesByID(ctx context.Context, ids []uint32) ([]model.SocietySummary, error) {
+	return nil, fmt.Errorf("SelectSocietySummariesByID not implemented")
+}
+func (sm *societyMock) SelectSocietySummary(ctx context.Context, id uint32) (*model.SocietySummary, error) {
+	society, ok := sm.societies[id]
+	if !ok {
+		return nil, model.NewError(model.ErrNotFound, fmt.Sprint(id))
+	}
+	return &model.SocietySummary{ID: society.ID, Name: society.Name}, nil
+}
+func (sm *societyMock) SelectSociety(ctx context.Context, id uint32) (*model.Society, error) {
+	society, ok := sm.societies[id]
+	if !ok {
+		return nil, model.NewError(model.ErrNotFound, fmt.Sprint(id))
+	}
+	return &society, nil
+}
+func (sm *societyMock) InsertSociety(ctx context.Context, in model.SocietyIn) (*model.Society, error) {
+	return nil, fmt.Errorf("InsertSociety not implemented")
+}
+func (sm *societyMock) UpdateSociety(ctx context.Context, in model.Society) (*model.Society, error) {
+	sm.societies[in.ID] = in
+	return &in, nil
+}
+func (sm *societyMock) DeleteSociety(ctx context.Context) error {
+	return fmt.Errorf("DeleteSociety not implemented")
+}
+
+func newSearchTokenTestAPI(t *testing.T) (*API, *searchKeyMock) {
+	searchKeyCache, err := lru.New2Q(100)
+	assert.NoError(t, err)
+	societyCache, err := lru.New2Q(100)
+	assert.NoError(t, err)
+	sm := &searchKeyMock{}
+	testAPI := &API{validate: validator.New(), searchKeyCache: searchKeyCache, societyCache: societyCache}
+	testAPI.SearchKeyPersister(sm).SocietyPersister(&societyMock{societies: map[uint32]model.Society{
+		7: model.NewSociety(7, model.NewSocietyIn("Test society", "legacy-secret", "")),
+	}})
+	return testAPI, sm
+}
+
+// verifySearchToken parses a search token the way the server does
+func verifySearchToken(ctx context.Context, testAPI *API, token string) (jwt.MapClaims, error) {
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		return testAPI.SearchTokenKey(ctx, 7, token)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parsed.Claims.(jwt.MapClaims), nil
+}
+
+func TestMintSearchToken(t *testing.T) {
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 7)
+	testAPI, sm := newSearchTokenTestAPI(t)
+
+	// without search keys, tokens are signed with the society's secret key
+	token, err := testAPI.MintSearchToken(ctx, SearchTokenRequest{UserID: 42})
+	assert.NoError(t, err)
+	assert.Empty(t, token.KID)
+	claims, err := verifySearchToken(ctx, testAPI, token.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "7_42", claims["sub"])
+	assert.Equal(t, float64(token.ExpiresAt.Unix()), claims["exp"])
+	legacyToken := token.Token
+
+	_, err = testAPI.MintSearchToken(ctx, SearchTokenRequest{UserID: 42, ExpiresIn: MaxSearchTokenExpiresIn + 1})
+	assert.Error(t, err)
+
+	for _, alg := range []string{model.SearchKeyHS256, model.SearchKeyRS256, model.SearchKeyES256} {
+		searchKey, err := testAPI.AddSearchKey(ctx, model.SearchKeyBody{Algorithm: alg})
+		assert.NoError(t, err, alg)
+		assert.NotEmpty(t, searchKey.Secret, alg)
+		assert.Equal(t, alg == model.SearchKeyHS256, searchKey.PublicKey == "", alg)
+
+		token, err := testAPI.MintSearchToken(ctx, SearchTokenRequest{UserID: 42})
+		assert.NoError(t, err, alg)
+		assert.Equal(t, searchKey.KID, token.KID, alg)
+		parsed, _ := jwt.Parse(token.Token, nil)
+		assert.Equal(t, alg, parsed.Header["alg"], alg)
+		claims, err := verifySearchToken(ctx, testAPI, token.Token)
+		assert.NoError(t, err, alg)
+		assert.Equal(t, "7_42", claims["sub"], alg)
+	}
+
+	// secrets aren't listed
+	searchKeys, err := testAPI.GetSearchKeys(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, searchKeys, 3)
+	for _, searchKey := range searchKeys {
+		assert.Empty(t, searchKey.Secret)
+	}
+
+	// tokens signed with older keys still verify until the key is deleted
+	hsKey := sm.searchKeys[0]
+	hsToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "7_1"}).SignedString([]byte(hsKey.Secret))
+	assert.NoError(t, err)
+	_, err = verifySearchToken(ctx, testAPI, hsToken)
+	assert.Error(t, err, "token without kid must use the society's secret key")
+	withKID := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "7_1"})
+	withKID.Header["kid"] = hsKey.KID
+	hsToken, err = withKID.SignedString([]byte(hsKey.Secret))
+	assert.NoError(t, err)
+	_, err = verifySearchToken(ctx, testAPI, hsToken)
+	assert.NoError(t, err)
+	err = testAPI.DeleteSearchKey(ctx, hsKey.KID)
+	assert.NoError(t, err)
+	_, err = verifySearchToken(ctx, testAPI, hsToken)
+	assert.Error(t, err)
+
+	// a public key can't be used as an HMAC secret
+	rsKey := sm.searchKeys[0]
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "7_1"})
+	confused.Header["kid"] = rsKey.KID
+	confusedToken, err := confused.SignedString([]byte(rsKey.PublicKey))
+	assert.NoError(t, err)
+	_, err = verifySearchToken(ctx, testAPI, confusedToken)
+	assert.Error(t, err)
+
+	// a public key alone verifies tokens signed elsewhere but can't sign
+	_, err = testAPI.AddSearchKey(ctx, model.SearchKeyBody{Algorithm: model.SearchKeyRS256, PublicKey: rsKey.PublicKey})
+	assert.NoError(t, err)
+	token, err = testAPI.MintSearchToken(ctx, SearchTokenRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, sm.searchKeys[1].KID, token.KID)
+	claims, err = verifySearchToken(ctx, testAPI, token.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "7_0", claims["sub"])
+
+	_, err = testAPI.AddSearchKey(ctx, model.SearchKeyBody{Algorithm: model.SearchKeyES256, PublicKey: "not a key"})
+	assert.Error(t, err)
+
+	// the society's secret key still works for tokens without a kid
+	_, err = verifySearchToken(ctx, testAPI, legacyToken)
+	assert.NoError(t, err)
+
+	// no token verifies once the society is in the trash, even with a cached key
+	testAPI.TrashPersister(&trashMock{})
+	delete(testAPI.societyPersister.(*societyMock).societies, 7)
+	testAPI.societyCache.Purge()
+	_, err = verifySearchToken(ctx, testAPI, token.Token)
+	assert.Error(t, err)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"net/http"
 	"os"
 	"testing"
 
@@ -11,6 +12,7 @@ import (
 
 	"github.com/ourrootsorg/cms-server/model"
 	"github.com/ourrootsorg/cms-server/persist"
+	"github.com/ourrootsorg/cms-server/stdname"
 	"gocloud.dev/postgres"
 
 	"github.com/stretchr/testify/assert"
@@ -102,6 +104,24 @@ func TestGetPlaceFacets(t *testing.T) {
 	}
 }
 
+func TestAddHistoricalPlaceLevels(t *testing.T) {
+	ixRecord := map[string]interface{}{
+		"birthPlace1": "Poland,",
+		"birthPlace2": "Poland,Lower Silesia,",
+		"birthPlace3": "Poland,Lower Silesia,Wroclaw",
+	}
+	addHistoricalPlaceLevels(ixRecord, "birthPlace", "Breslau, Silesia, Prussia|Breslau, Silesia, Germany")
+	assert.Equal(t, map[string]interface{}{
+		"birthPlace1": []string{"Poland,", "Prussia,", "Germany,"},
+		"birthPlace2": []string{"Poland,Lower Silesia,", "Prussia,Silesia,", "Germany,Silesia,"},
+		"birthPlace3": []string{"Poland,Lower Silesia,Wroclaw", "Prussia,Silesia,Breslau", "Germany,Silesia,Breslau"},
+	}, ixRecord)
+
+	ixRecord = map[string]interface{}{"birthPlace1": "Poland"}
+	addHistoricalPlaceLevels(ixRecord, "birthPlace", "")
+	assert.Equal(t, map[string]interface{}{"birthPlace1": "Poland"}, ixRecord)
+}
+
 func TestGetHouseholdNames(t *testing.T) {
 	relToHeadHeader := "Relationship"
 	genderHeader := "Sex"
@@ -235,6 +255,32 @@ func TestGetHouseholdNames(t *testing.T) {
 	}
 }
 
+func TestFullNameMapping(t *testing.T) {
+	mappings := []model.CollectionMapping{
+		{Header: "Name", IxRole: "principal", IxField: "name"},
+		{Header: "Father", IxRole: "father", IxField: "name", NameOrder: "surnameFirst"},
+	}
+	record := &model.Record{
+		RecordIn: model.RecordIn{
+			RecordBody: model.RecordBody{
+				Data: map[string]string{
+					"Name":   "Rev. Johann van der Berg Jr.",
+					"Father": "Berg Pieter",
+				},
+			},
+		},
+	}
+
+	data := getDataForRole(mappings, record, model.PrincipalRole)
+	assert.Equal(t, "Johann", data["given"])
+	assert.Equal(t, "van der Berg", data["surname"])
+	assert.Equal(t, []GivenSurname{{given: "Pieter", surname: "Berg"}}, getNames(mappings, record, []model.Role{model.FatherRole}))
+
+	person := constructRecordSearchPerson(mappings, model.PrincipalRole, record, false, true)
+	assert.Equal(t, "van der Berg, Johann Jr.", person.Name)
+	assert.Equal(t, []model.SearchRelationship{{Type: model.FatherRelative, Name: "Pieter Berg"}}, person.Relationships)
+}
+
 func TestSearchQuery(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping tests in short mode")
@@ -466,6 +512,37 @@ func doInternalSearchTests(t *testing.T,
 					"aggs":{"collectionPlace2":{"terms":{"field":"collectionPlace2","size":250}}},
 					"from":0,"size":10}`,
 		},
+		{
+			req: SearchRequest{
+				SocietyID: 1,
+				ImageText: "flintstone quarry",
+				Size:      10,
+			},
+			query: `{"query":{"bool":{"must":[
+					  {"match":{"imageText":{"query":"flintstone quarry","operator":"AND"}}}
+					],
+                    "filter":[{"term":{"societyId":{"value":1}}}]
+					}},
+					"highlight":{"pre_tags":["<em>"],"post_tags":["</em>"],"fields":{"imageText":{"fragment_size":100,"number_of_fragments":3}}},
+					"from":0,"size":10}`,
+		},
+		{
+			req: SearchRequest{
+				SocietyID:         1,
+				BirthPlaceNear:    "40.7128,-74.006",
+				BirthPlaceRadius:  "10mi",
+				ResidencePlaceBox: "42,-80,40,-72",
+				AnyPlaceNear:      "51.5,-0.12",
+				Size:              10,
+			},
+			query: `{"query":{"bool":{
+					"filter":[{"geo_distance":{"distance":"16.09344km","birthGeo":{"lat":40.7128,"lon":-74.006}}},
+                              {"geo_bounding_box":{"residenceGeo":{"top_left":{"lat":42,"lon":-80},"bottom_right":{"lat":40,"lon":-72}}}},
+                              {"geo_distance":{"distance":"25km","anyGeo":{"lat":51.5,"lon":-0.12}}},
+                              {"term":{"societyId":{"value":1}}}]
+					}},
+					"from":0,"size":10}`,
+		},
 	}
 
 	for i, test := range tests {
@@ -479,3 +556,106 @@ func doInternalSearchTests(t *testing.T,
 		assert.EqualValues(t, search, *result, i)
 	}
 }
+
+func TestPatronymicQueries(t *testing.T) {
+	var testApi API
+	should, must, err := testApi.constructNameQueries(context.TODO(), "surname", "Olsen", FuzzyNameExact|FuzzyNamePatronymic, model.SurnameType)
+	assert.NoError(t, err)
+	assert.Empty(t, should)
+	var expected []Query
+	json.Unmarshal([]byte(`[{"dis_max":{"queries":[
+		{"match":{"surname":{"query":"Olsen","boost":1}}},
+		{"match":{"farm":{"query":"Olsen","boost":0.7}}},
+		{"term":{"surnamePatronym":{"value":"ol","boost":0.8}}},
+		{"term":{"fatherGivenStem":{"value":"ol","boost":0.5}}}
+	]}}]`), &expected)
+	assert.EqualValues(t, expected, must)
+
+	// only the principal's surname is matched against patronymics
+	_, must, err = testApi.constructNameQueries(context.TODO(), "spouseSurname", "Olsen", FuzzyNameExact|FuzzyNamePatronymic, model.SurnameType)
+	assert.NoError(t, err)
+	assert.Len(t, must[0].DisMax.Queries, 1)
+
+	record := &model.Record{
+		RecordIn: model.RecordIn{
+			RecordBody: model.RecordBody{
+				Data: map[string]string{"Name": "Kari Olsdatter", "Father": "Ole", "Farm": "Haugen"},
+			},
+		},
+	}
+	mappings := []model.CollectionMapping{
+		{Header: "Name", IxRole: "principal", IxField: "name"},
+		{Header: "Father", IxRole: "father", IxField: "given"},
+		{Header: "Farm", IxRole: "principal", IxField: "farm"},
+	}
+	data := getDataForRole(mappings, record, model.PrincipalRole)
+	assert.Equal(t, []string{"ol"}, stdname.Patronyms(data["surname"]))
+	assert.Equal(t, "Haugen", data["farm"])
+}
+
+func TestConstructGeoQueries(t *testing.T) {
+	queries, err := constructGeoQueries("birthGeo", "40.7128,-74.006", "10mi", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []Query{{GeoDistance: map[string]interface{}{
+		"distance": "16.09344km",
+		"birthGeo": map[string]interface{}{"lat": 40.7128, "lon": -74.006},
+	}}}, queries)
+
+	queries, err = constructGeoQueries("anyGeo", "", "", "42,-80,40,-72")
+	assert.NoError(t, err)
+	assert.Equal(t, []Query{{GeoBoundingBox: map[string]interface{}{
+		"anyGeo": map[string]interface{}{
+			"top_left":     map[string]interface{}{"lat": 42.0, "lon": -80.0},
+			"bottom_right": map[string]interface{}{"lat": 40.0, "lon": -72.0},
+		},
+	}}}, queries)
+
+	for _, test := range []struct{ near, radius, box string }{
+		{near: "north"},
+		{near: "95,10"},
+		{near: "40,-74", radius: "far"},
+		{box: "40,-80,42,-72"},
+		{box: "42,-80,40"},
+	} {
+		_, err = constructGeoQueries("birthGeo", test.near, test.radius, test.box)
+		assert.Error(t, err, test)
+	}
+}
+
+func TestHitDistance(t *testing.T) {
+	mappings := []model.CollectionMapping{
+		{Header: "Birth Place", IxRole: string(model.PrincipalRole), IxField: "birthPlace"},
+		{Header: "Death Place", IxRole: string(model.PrincipalRole), IxField: "deathPlace"},
+	}
+	record := &model.Record{RecordIn: model.RecordIn{RecordBody: model.RecordBody{Data: map[string]string{
+		"Birth Place":     "New York",
+		"Birth Place_geo": "40.7128,-74.006",
+		"Death Place":     "Boston",
+		"Death Place_geo": "42.3601,-71.0589",
+	}}}}
+	d := hitDistance(mappings, model.PrincipalRole, record, []model.EventType{model.BirthEvent}, 40.7128, -74.006, false)
+	assert.NotNil(t, d)
+	assert.Equal(t, 0.0, *d)
+	d = hitDistance(mappings, model.PrincipalRole, record, []model.EventType{model.DeathEvent}, 40.7128, -74.006, true)
+	assert.NotNil(t, d)
+	assert.InDelta(t, 190.0, *d, 1)
+	d = hitDistance(mappings, model.PrincipalRole, record, model.EventTypes, 42.3601, -71.0589, false)
+	assert.Equal(t, 0.0, *d)
+	assert.Nil(t, hitDistance(mappings, model.FatherRole, record, model.EventTypes, 0, 0, false))
+}
+
+func TestJoinImageTexts(t *testing.T) {
+	assert.Equal(t, "", joinImageTexts(map[string]string{}))
+	assert.Equal(t, "page one\npage two", joinImageTexts(map[string]string{"p2.jpg": "page two", "p1.jpg": "page one"}))
+}
+
+func TestGetPostImageOCRInvalidPath(t *testing.T) {
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 1)
+	testAPI := &API{}
+	for _, filePath := range []string{"../2/a.jpg", "dir/../../2/a.jpg", "/1/images/2/a.jpg"} {
+		_, err := testAPI.GetPostImageOCR(ctx, 1, filePath, "")
+		if assert.Error(t, err, filePath) {
+			assert.Equal(t, http.StatusBadRequest, err.(*Error).HTTPStatus(), filePath)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/ourrootsorg/cms-server/model"
 	"github.com/ourrootsorg/cms-server/utils"
@@ -42,22 +43,25 @@ func (api API) GetSocietySummary(ctx context.Context) (*model.SocietySummary, er
 	return societySummary, nil
 }
 
-func (api API) GetSociety(ctx context.Context, id uint32) (*model.Society, error) {
-	var society *model.Society
+// societyCacheTTL bounds how long societies are cached, so societies trashed through other servers stop being served
+const societyCacheTTL = time.Minute
+
+type societyCacheEntry struct {
+	society model.Society
+	expires time.Time
+}
 
+func (api API) GetSociety(ctx context.Context, id uint32) (*model.Society, error) {
 	// look up in cache
 	cacheKey := fmt.Sprintf("%d", id)
 	soc, ok := api.societyCache.Get(cacheKey)
 	if ok {
-		var result model.Society
-		if result, ok = soc.(model.Society); ok {
-			society = &result
+		var entry societyCacheEntry
+		if entry, ok = soc.(societyCacheEntry); ok && time.Now().Before(entry.expires) {
+			//log.Printf("[DEBUG] Found user for key '%s' in cache: %#v", cacheKey, societyUser)
+			return &entry.society, nil
 		}
 	}
-	if ok {
-		//log.Printf("[DEBUG] Found user for key '%s' in cache: %#v", cacheKey, societyUser)
-		return society, nil
-	}
 
 	// read from database
 	society, err := api.societyPersister.SelectSociety(ctx, id)
@@ -66,7 +70,7 @@ func (api API) GetSociety(ctx context.Context, id uint32) (*model.Society, error
 	}
 
 	// add to cache
-	api.societyCache.Add(cacheKey, *society)
+	api.societyCache.Add(cacheKey, societyCacheEntry{society: *society, expires: time.Now().Add(societyCacheTTL)})
 
 	return society, nil
 }
@@ -110,10 +114,12 @@ func (api API) AddSociety(ctx context.Context, in model.SocietyIn) (*model.Socie
 		UserID:    user.ID,
 		SocietyID: society.ID,
 	}
-	_, err = api.societyUserPersister.InsertSocietyUser(sctx, societyUser)
+	admin, err := api.societyUserPersister.InsertSocietyUser(sctx, societyUser)
 	if err != nil {
 		return nil, NewError(err)
 	}
+	api.audit(sctx, model.AuditEventBody{Entity: model.AuditEntitySociety, EntityID: society.ID, Action: model.AuditActionCreate}, nil, society)
+	api.audit(sctx, model.AuditEventBody{Entity: model.AuditEntitySocietyUser, EntityID: admin.ID, Action: model.AuditActionCreate}, nil, admin)
 
 	return society, nil
 }
@@ -123,18 +129,50 @@ func (api API) UpdateSociety(ctx context.Context, in model.Society) (*model.Soci
 	if err != nil {
 		return nil, NewError(err)
 	}
+	old, _ := api.societyPersister.SelectSociety(ctx, in.ID)
 	society, err := api.societyPersister.UpdateSociety(ctx, in)
 	if err != nil {
 		return nil, NewError(err)
 	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntitySociety, EntityID: society.ID, Action: model.AuditActionUpdate}, old, society)
 	return society, nil
 }
 
 func (api API) DeleteSociety(ctx context.Context) error {
-	// TODO !!! lots of things to delete here
-	err := api.societyPersister.DeleteSociety(ctx)
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
 	if err != nil {
 		return NewError(err)
 	}
+	old, _ := api.societyPersister.SelectSociety(ctx, societyID)
+	if api.trashEnabled() {
+		// the trash purge deletes everything the society has
+		err = api.trashPersister.TrashSociety(ctx)
+		if err != nil {
+			return NewError(err)
+		}
+		// keep the society's users and search tokens out of this server now;
+		// other servers keep serving the society until their cached copy expires after societyCacheTTL
+		api.societyCache.Remove(fmt.Sprintf("%d", societyID))
+		if societyUsers, err := api.societyUserPersister.SelectSocietyUsers(ctx); err == nil {
+			for _, societyUser := range societyUsers {
+				api.societyUserCache.Remove(fmt.Sprintf("%d_%d", societyID, societyUser.UserID))
+			}
+		}
+		if api.searchKeyPersister != nil {
+			if searchKeys, err := api.searchKeyPersister.SelectSearchKeys(ctx); err == nil {
+				for _, searchKey := range searchKeys {
+					api.searchKeyCache.Remove(searchKeyCacheKey(societyID, searchKey.KID))
+				}
+			}
+		}
+	} else {
+		// TODO !!! lots of things to delete here
+		err = api.societyPersister.DeleteSociety(ctx)
+		if err != nil {
+			return NewError(err)
+		}
+	}
+	// the audit log is kept after the society is deleted
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntitySociety, EntityID: societyID, Action: model.AuditActionDelete}, old, nil)
 	return nil
 }
@@ -0,0 +1,255 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+// SocietyTemplateResult maps the template's category and collection IDs to the IDs they have in the society
+type SocietyTemplateResult struct {
+	Categories  map[uint32]uint32 `json:"categories"`
+	Collections map[uint32]uint32 `json:"collections"`
+	// PostMetadata lists the post metadata the template added to the society
+	PostMetadata []string `json:"postMetadata"`
+}
+
+// GetSocietyTemplates returns the templates of the society in the context, and the public templates of other societies
+func (api API) GetSocietyTemplates(ctx context.Context) ([]model.SocietyTemplate, error) {
+	if err := api.checkSocietyTemplatesConfigured(); err != nil {
+		return nil, err
+	}
+	templates, err := api.societyTemplatePersister.SelectSocietyTemplates(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	return templates, nil
+}
+
+// GetSocietyTemplate returns a template if it is public, belongs to the society in the context,
+// or belongs to a society the current user administers
+func (api API) GetSocietyTemplate(ctx context.Context, id uint32) (*model.SocietyTemplate, error) {
+	if err := api.checkSocietyTemplatesConfigured(); err != nil {
+		return nil, err
+	}
+	template, err := api.societyTemplatePersister.SelectOneSocietyTemplate(ctx, id)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	if !api.canUseSocietyTemplate(ctx, template) {
+		// don't reveal that other societies' private templates exist
+		return nil, NewError(model.NewError(model.ErrNotFound, strconv.Itoa(int(id))))
+	}
+	return template, nil
+}
+
+// ExportSocietyTemplate saves the categories, collection layouts and post metadata of the society in the context as a template
+func (api API) ExportSocietyTemplate(ctx context.Context, in model.SocietyTemplateExport) (*model.SocietyTemplate, error) {
+	if err := api.checkSocietyTemplatesConfigured(); err != nil {
+		return nil, err
+	}
+	err := api.validate.Struct(in)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	society, err := api.societyPersister.SelectSociety(ctx, societyID)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	categories, err := api.categoryPersister.SelectCategories(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	collections, err := api.collectionPersister.SelectCollections(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	// number categories and collections from 1 within the template
+	body := model.SocietyTemplateBody{
+		SocietyTemplateExport: in,
+		Categories:            make([]model.TemplateCategory, 0, len(categories)),
+		Collections:           make([]model.TemplateCollection, 0, len(collections)),
+		PostMetadata:          society.PostMetadata,
+	}
+	categoryIDs := map[uint32]uint32{}
+	for _, category := range categories {
+		categoryIDs[category.ID] = uint32(len(body.Categories) + 1)
+		body.Categories = append(body.Categories, model.TemplateCategory{ID: categoryIDs[category.ID], CategoryBody: category.CategoryBody})
+	}
+	for _, collection := range collections {
+		tc := model.TemplateCollection{ID: uint32(len(body.Collections) + 1), CollectionBody: collection.CollectionBody, Categories: []uint32{}}
+		// the location and watermark belong to the society, not the layout
+		tc.Location = ""
+		tc.Watermark = nil
+		for _, categoryID := range collection.Categories {
+			if id, ok := categoryIDs[categoryID]; ok {
+				tc.Categories = append(tc.Categories, id)
+			}
+		}
+		body.Collections = append(body.Collections, tc)
+	}
+
+	template, err := api.societyTemplatePersister.InsertSocietyTemplate(ctx, model.SocietyTemplateIn{SocietyTemplateBody: body, SocietyID: societyID})
+	if err != nil {
+		return nil, NewError(err)
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntitySocietyTemplate, EntityID: template.ID, Action: model.AuditActionCreate, Name: template.Name}, nil, template)
+	return template, nil
+}
+
+// DeleteSocietyTemplate deletes a template of the society in the context
+func (api API) DeleteSocietyTemplate(ctx context.Context, id uint32) error {
+	if err := api.checkSocietyTemplatesConfigured(); err != nil {
+		return err
+	}
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return NewError(err)
+	}
+	old, err := api.societyTemplatePersister.SelectOneSocietyTemplate(ctx, id)
+	if err != nil {
+		return NewError(err)
+	}
+	if old.SocietyID != societyID {
+		return NewError(model.NewError(model.ErrNotFound, strconv.Itoa(int(id))))
+	}
+	err = api.societyTemplatePersister.DeleteSocietyTemplate(ctx, id)
+	if err != nil {
+		return NewError(err)
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntitySocietyTemplate, EntityID: id, Action: model.AuditActionDelete, Name: old.Name}, old, nil)
+	return nil
+}
+
+// ApplySocietyTemplate adds a template's categories, collections and post metadata to the society in the context.
+// Categories, collections and post metadata that the society already has with the same name are reused, so a template
+// can be applied again after it has been extended.
+func (api API) ApplySocietyTemplate(ctx context.Context, id uint32) (*SocietyTemplateResult, error) {
+	template, err := api.GetSocietyTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	result := &SocietyTemplateResult{Categories: map[uint32]uint32{}, Collections: map[uint32]uint32{}, PostMetadata: []string{}}
+
+	categories, err := api.categoryPersister.SelectCategories(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	categoryIDs := map[string]uint32{}
+	for _, category := range categories {
+		categoryIDs[category.Name] = category.ID
+	}
+	for _, tc := range template.Categories {
+		if categoryID, ok := categoryIDs[tc.Name]; ok {
+			result.Categories[tc.ID] = categoryID
+			continue
+		}
+		category, err := api.AddCategory(ctx, model.CategoryIn{CategoryBody: tc.CategoryBody})
+		if err != nil {
+			return nil, err
+		}
+		categoryIDs[category.Name] = category.ID
+		result.Categories[tc.ID] = category.ID
+	}
+
+	collections, err := api.collectionPersister.SelectCollections(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	collectionIDs := map[string]uint32{}
+	for _, collection := range collections {
+		collectionIDs[collection.Name] = collection.ID
+	}
+	for _, tc := range template.Collections {
+		if collectionID, ok := collectionIDs[tc.Name]; ok {
+			result.Collections[tc.ID] = collectionID
+			continue
+		}
+		in := model.CollectionIn{CollectionBody: tc.CollectionBody, Categories: []uint32{}}
+		for _, templateCategoryID := range tc.Categories {
+			categoryID, ok := result.Categories[templateCategoryID]
+			if !ok {
+				return nil, NewHTTPError(fmt.Errorf("collection '%s' refers to category %d, which is not in the template", tc.Name, templateCategoryID), http.StatusBadRequest)
+			}
+			in.Categories = append(in.Categories, categoryID)
+		}
+		collection, err := api.AddCollection(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		collectionIDs[collection.Name] = collection.ID
+		result.Collections[tc.ID] = collection.ID
+	}
+
+	society, err := api.societyPersister.SelectSociety(ctx, societyID)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	names := map[string]bool{}
+	for _, pm := range society.PostMetadata {
+		names[pm.Name] = true
+	}
+	for _, pm := range template.PostMetadata {
+		if names[pm.Name] {
+			continue
+		}
+		names[pm.Name] = true
+		society.PostMetadata = append(society.PostMetadata, pm)
+		result.PostMetadata = append(result.PostMetadata, pm.Name)
+	}
+	if len(result.PostMetadata) > 0 {
+		if _, err := api.UpdateSociety(ctx, *society); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// canUseSocietyTemplate returns true if the template is public, belongs to the society in the context,
+// or belongs to a society the current user administers
+func (api API) canUseSocietyTemplate(ctx context.Context, template *model.SocietyTemplate) bool {
+	if template.Public {
+		return true
+	}
+	if template.SocietyID == 0 {
+		return false
+	}
+	if societyID, err := utils.GetSocietyIDFromContext(ctx); err == nil && societyID == template.SocietyID {
+		return true
+	}
+	user, err := utils.GetUserFromContext(ctx)
+	if err != nil || user == nil {
+		return false
+	}
+	societyUsers, err := api.societyUserPersister.SelectAllSocietyUsersByUser(ctx, user.ID)
+	if err != nil {
+		return false
+	}
+	for _, societyUser := range societyUsers {
+		if societyUser.SocietyID == template.SocietyID && societyUser.Level >= model.AuthAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func (api API) checkSocietyTemplatesConfigured() error {
+	if api.societyTemplatePersister == nil {
+		return NewHTTPError(errors.New("society templates are not configured"), http.StatusNotImplemented)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// categoryMock holds categories in memory
+type categoryMock struct {
+	categories []model.Category
+}
+
+func (cm *categoryMock) SelectCategories(ctx context.Context) ([]model.Category, error) {
+	return cm.categories, nil
+}
+func (cm *categoryMock) SelectCategoriesByID(ctx context.Context, ids []uint32) ([]model.Category, error) {
+	return nil, fmt.Errorf("SelectCategoriesByID not implemented")
+}
+func (cm *categoryMock) SelectOneCategory(ctx context.Context, id uint32) (*model.Category, error) {
+	for _, category := range cm.categories {
+		if category.ID == id {
+			return &category, nil
+		}
+	}
+	return nil, model.NewError(model.ErrNotFound, fmt.Sprint(id))
+}
+func (cm *categoryMock) InsertCategory(ctx context.Context, in model.CategoryIn) (*model.Category, error) {
+	category := model.NewCategory(uint32(100+len(cm.categories)), in)
+	cm.categories = append(cm.categories, category)
+	return &category, nil
+}
+func (cm *categoryMock) UpdateCategory(ctx context.Context, id uint32, in model.Category) (*model.Category, error) {
+	return nil, fmt.Errorf("UpdateCategory not implemented")
+}
+func (cm *categoryMock) DeleteCategory(ctx context.Context, id uint32) error {
+	return fmt.Errorf("DeleteCategory not implemented")
+}
+
+// societyTemplateMock holds society templates in memory
+type societyTemplateMock struct {
+	templates []model.SocietyTemplate
+}
+
+func (tm *societyTemplateMock) SelectSocietyTemplates(ctx context.Context) ([]model.SocietyTemplate, error) {
+	societyID, _ := utils.GetSocietyIDFromContext(ctx)
+	templates := make([]model.SocietyTemplate, 0)
+	for _, template := range tm.templates {
+		if template.SocietyID == societyID || template.Public {
+			templates = append(templates, template)
+		}
+	}
+	return templates, nil
+}
+func (tm *societyTemplateMock) SelectOneSocietyTemplate(ctx context.Context, id uint32) (*model.SocietyTemplate, error) {
+	for _, template := range tm.templates {
+		if template.ID == id {
+			return &template, nil
+		}
+	}
+	return nil, model.NewError(model.ErrNotFound, fmt.Sprint(id))
+}
+func (tm *societyTemplateMock) InsertSocietyTemplate(ctx context.Context, in model.SocietyTemplateIn) (*model.SocietyTemplate, error) {
+	template := model.SocietyTemplate{ID: uint32(len(tm.templates) + 1), SocietyTemplateIn: in}
+	tm.templates = append(tm.templates, template)
+	return &template, nil
+}
+func (tm *societyTemplateMock) DeleteSocietyTemplate(ctx context.Context, id uint32) error {
+	for i, template := range tm.templates {
+		if template.ID == id {
+			tm.templates = append(tm.templates[:i], tm.templates[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func TestSocietyTemplates(t *testing.T) {
+	tm := &societyTemplateMock{}
+	sum := &societyUserMock{}
+	county := model.SettingsPostMetadata{Name: "county", Type: "string"}
+	year := model.SettingsPostMetadata{Name: "year", Type: "number"}
+	census := model.CollectionBody{Name: "1870 Census", Location: "Cook County", CollectionType: model.CollectionTypeRecords,
+		Fields: []model.CollectionField{{Header: "Name"}}, Mappings: []model.CollectionMapping{{Header: "Name", DbField: "Name", IxRole: "principal", IxField: "name"}},
+		CitationTemplate: "{{Name}}", HouseholdNumberHeader: "Household"}
+
+	// society 7 exports its layout
+	source := &API{validate: validator.New()}
+	source.SocietyTemplatePersister(tm).SocietyUserPersister(sum).
+		CategoryPersister(&categoryMock{categories: []model.Category{
+			model.NewCategory(31, model.CategoryIn{CategoryBody: model.CategoryBody{Name: "Census"}}),
+			model.NewCategory(32, model.CategoryIn{CategoryBody: model.CategoryBody{Name: "Vital"}}),
+		}}).
+		CollectionPersister(&collectionMock{collections: []model.Collection{
+			model.NewCollection(41, model.CollectionIn{CollectionBody: census, Categories: []uint32{31, 32}}),
+		}}).
+		SocietyPersister(&societyMock{societies: map[uint32]model.Society{
+			7: {ID: 7, SocietyIn: model.SocietyIn{SocietyBody: model.SocietyBody{Name: "Source", PostMetadata: []model.SettingsPostMetadata{county, year}}}},
+		}})
+	ctx := utils.AddSocietyIDToContext(userContext("admin@example.com", 10), 7)
+	_, err := source.ExportSocietyTemplate(ctx, model.SocietyTemplateExport{})
+	assert.Equal(t, http.StatusBadRequest, err.(*Error).HTTPStatus())
+	template, err := source.ExportSocietyTemplate(ctx, model.SocietyTemplateExport{Name: "County records"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(7), template.SocietyID)
+	assert.Equal(t, []uint32{1, 2}, template.Collections[0].Categories)
+	assert.Equal(t, "", template.Collections[0].Location)
+	assert.Equal(t, "Household", template.Collections[0].HouseholdNumberHeader)
+
+	// society 8 already has a Vital category and county post metadata
+	societies := &societyMock{societies: map[uint32]model.Society{
+		8: {ID: 8, SocietyIn: model.SocietyIn{SocietyBody: model.SocietyBody{Name: "Target", PostMetadata: []model.SettingsPostMetadata{county}}}},
+	}}
+	categories := &categoryMock{categories: []model.Category{
+		model.NewCategory(20, model.CategoryIn{CategoryBody: model.CategoryBody{Name: "Vital"}}),
+	}}
+	collections := &collectionMock{}
+	target := &API{validate: validator.New()}
+	target.SocietyTemplatePersister(tm).SocietyUserPersister(sum).
+		CategoryPersister(categories).CollectionPersister(collections).SocietyPersister(societies)
+	ctx = utils.AddSocietyIDToContext(userContext("other@example.com", 11), 8)
+
+	// private templates can only be used by the admins of the society that exported them
+	_, err = target.ApplySocietyTemplate(ctx, template.ID)
+	assert.Equal(t, http.StatusNotFound, err.(*Error).HTTPStatus())
+	templates, err := target.GetSocietyTemplates(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, templates, 0)
+	sum.societyUsers = append(sum.societyUsers, model.SocietyUser{ID: 1, SocietyUserIn: model.SocietyUserIn{
+		SocietyUserBody: model.SocietyUserBody{Level: model.AuthAdmin}, UserID: 11, SocietyID: 7}})
+
+	result, err := target.ApplySocietyTemplate(ctx, template.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint32]uint32{1: 101, 2: 20}, result.Categories)
+	assert.Equal(t, []string{"year"}, result.PostMetadata)
+	assert.Len(t, collections.collections, 1)
+	assert.Equal(t, []uint32{101, 20}, collections.collections[0].Categories)
+	assert.Equal(t, "{{Name}}", collections.collections[0].CitationTemplate)
+	assert.Equal(t, []model.SettingsPostMetadata{county, year}, societies.societies[8].PostMetadata)
+
+	// applying again reuses what the first application created
+	result, err = target.ApplySocietyTemplate(ctx, template.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint32]uint32{1: 1}, result.Collections)
+	assert.Len(t, result.PostMetadata, 0)
+	assert.Len(t, categories.categories, 2)
+	assert.Len(t, collections.collections, 1)
+
+	// only the exporting society can delete its templates
+	err = target.DeleteSocietyTemplate(ctx, template.ID)
+	assert.Equal(t, http.StatusNotFound, err.(*Error).HTTPStatus())
+	err = source.DeleteSocietyTemplate(utils.AddSocietyIDToContext(userContext("admin@example.com", 10), 7), template.ID)
+	assert.NoError(t, err)
+	assert.Len(t, tm.templates, 0)
+}
@@ -78,6 +78,7 @@ func (api API) UpdateSocietyUserEmail(ctx context.Context, id uint32, in Society
 	if err != nil {
 		return nil, NewError(err)
 	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntitySocietyUser, EntityID: id, Action: model.AuditActionUpdate}, oldSocietyUser, societyUser)
 	return &SocietyUserEmail{
 		SocietyUser: *societyUser,
 		UserEmail:   in.UserEmail,
@@ -92,6 +93,10 @@ func (api API) GetSocietyUserByUser(ctx context.Context, userID uint32) (*model.
 	if err != nil {
 		return nil, err
 	}
+	// nobody can use a society in the trash
+	if err := api.checkSocietyNotTrashed(ctx, societyID); err != nil {
+		return nil, err
+	}
 	cacheKey := fmt.Sprintf("%d_%d", societyID, userID)
 	u, ok := api.societyUserCache.Get(cacheKey)
 	if ok {
@@ -140,6 +145,7 @@ func (api API) AddSocietyUser(ctx context.Context, body model.SocietyUserBody) (
 	if err != nil {
 		return nil, NewError(err)
 	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntitySocietyUser, EntityID: societyUser.ID, Action: model.AuditActionCreate}, nil, societyUser)
 
 	return societyUser, nil
 }
@@ -161,5 +167,12 @@ func (api API) DeleteSocietyUser(ctx context.Context, id uint32) error {
 	if err != nil {
 		return NewError(err)
 	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntitySocietyUser, EntityID: id, Action: model.AuditActionDelete}, societyUser, nil)
+	// grants would otherwise apply again if the user rejoined
+	if api.collectionGrantPersister != nil {
+		if err := api.collectionGrantPersister.DeleteCollectionGrantsByUser(ctx, societyUser.UserID); err != nil {
+			return NewError(err)
+		}
+	}
 	return nil
 }
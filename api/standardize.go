@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/stddate"
+)
+
+// MaxStandardizeBatch is the maximum number of values that can be standardized in one request
+const MaxStandardizeBatch = 5000
+
+const standardizeWorkers = 20
+
+// PlaceStandardizeRequest is place text to standardize
+type PlaceStandardizeRequest struct {
+	Text string `json:"text"`
+	// DefaultContainingPlace is used to choose between ambiguous places, like a collection's location
+	DefaultContainingPlace string `json:"defaultContainingPlace,omitempty"`
+	// Year is the year of the event, used to match historical names and jurisdictions
+	Year int `json:"year,omitempty"`
+}
+
+// PlaceLevel is a level in the hierarchy of a standardized place
+type PlaceLevel struct {
+	ID        uint32            `json:"id"`
+	Name      string            `json:"name"`
+	Types     model.StringSlice `json:"types,omitempty"`
+	Latitude  float32           `json:"latitude,omitempty"`
+	Longitude float32           `json:"longitude,omitempty"`
+}
+
+// StandardizedPlace is the result of standardizing place text
+type StandardizedPlace struct {
+	Text  string `json:"text"`
+	Found bool   `json:"found"`
+	// ID is 0 if some levels of the text didn't match a place; FullName then starts with the unmatched levels
+	ID        uint32  `json:"id,omitempty"`
+	FullName  string  `json:"fullName,omitempty"`
+	Latitude  float32 `json:"latitude,omitempty"`
+	Longitude float32 `json:"longitude,omitempty"`
+	// Hierarchy lists the matched place and the places it is located in, ending with the country
+	Hierarchy  []PlaceLevel           `json:"hierarchy,omitempty"`
+	Unresolved bool                   `json:"unresolved,omitempty"`
+	Candidates []model.PlaceCandidate `json:"candidates,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// DateStandardizeRequest is date text to standardize
+type DateStandardizeRequest struct {
+	Text string `json:"text"`
+}
+
+// StandardizedDatePart is one of the dates in a standardized date
+type StandardizedDatePart struct {
+	Year       int    `json:"year"`
+	Month      int    `json:"month,omitempty"`
+	Day        int    `json:"day,omitempty"`
+	Modifier   string `json:"modifier,omitempty"`
+	Quality    string `json:"quality,omitempty"`
+	DoubleDate bool   `json:"doubleDate,omitempty"`
+	// Calendar is the calendar the date was written in; dates are always converted to the Gregorian calendar
+	Calendar string `json:"calendar,omitempty"`
+}
+
+// StandardizedDate is the result of standardizing date text
+type StandardizedDate struct {
+	Text  string `json:"text"`
+	Found bool   `json:"found"`
+	// Type is empty for a single date, Range for a date range, or Two for two separate dates
+	Type   string                `json:"type,omitempty"`
+	First  *StandardizedDatePart `json:"first,omitempty"`
+	Second *StandardizedDatePart `json:"second,omitempty"`
+	// Encoded is the date as stored in records: the date followed by the range of dates it could be, as yyyymmdd
+	Encoded   string `json:"encoded,omitempty"`
+	StartDate string `json:"startDate,omitempty"`
+	EndDate   string `json:"endDate,omitempty"`
+}
+
+// StandardizePlaces standardizes a batch of place text
+func (api *API) StandardizePlaces(ctx context.Context, reqs []PlaceStandardizeRequest) ([]StandardizedPlace, error) {
+	if api.placeStandardizer == nil {
+		return nil, NewHTTPError(errors.New("place standardization is not configured"), http.StatusNotImplemented)
+	}
+	if err := checkStandardizeBatch(len(reqs)); err != nil {
+		return nil, err
+	}
+	results := make([]StandardizedPlace, len(reqs))
+	ixs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < standardizeWorkers && i < len(reqs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ix := range ixs {
+				results[ix] = api.standardizePlace(ctx, reqs[ix])
+			}
+		}()
+	}
+	for ix := range reqs {
+		ixs <- ix
+	}
+	close(ixs)
+	wg.Wait()
+	return results, nil
+}
+
+// StandardizeDates standardizes a batch of date text
+func (api *API) StandardizeDates(ctx context.Context, reqs []DateStandardizeRequest) ([]StandardizedDate, error) {
+	if err := checkStandardizeBatch(len(reqs)); err != nil {
+		return nil, err
+	}
+	results := make([]StandardizedDate, len(reqs))
+	for ix, req := range reqs {
+		results[ix] = standardizeDate(req.Text)
+	}
+	return results, nil
+}
+
+func checkStandardizeBatch(n int) error {
+	if n > MaxStandardizeBatch {
+		return NewHTTPError(fmt.Errorf("at most %d values can be standardized at once; got %d", MaxStandardizeBatch, n), http.StatusBadRequest)
+	}
+	return nil
+}
+
+func (api *API) standardizePlace(ctx context.Context, req PlaceStandardizeRequest) StandardizedPlace {
+	result := StandardizedPlace{Text: req.Text}
+	match, err := api.placeStandardizer.Match(ctx, req.Text, req.DefaultContainingPlace, req.Year)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Unresolved = match.Unresolved
+	if len(match.Candidates) > 1 {
+		result.Candidates = match.Candidates
+	}
+	place := match.Place
+	if place == nil {
+		return result
+	}
+	result.Found = true
+	result.ID = place.ID
+	result.FullName = place.FullName
+	result.Latitude = place.Latitude
+	result.Longitude = place.Longitude
+
+	id := place.ID
+	if id == 0 {
+		id = place.LocatedInID
+	}
+	for depth := 0; id != 0 && depth < maxPlaceDepth; depth++ {
+		level, err := api.placeStandardizer.GetPlace(id)
+		if err != nil {
+			result.Error = err.Error()
+			break
+		}
+		result.Hierarchy = append(result.Hierarchy, PlaceLevel{
+			ID:        level.ID,
+			Name:      level.Name,
+			Types:     level.Types,
+			Latitude:  level.Latitude,
+			Longitude: level.Longitude,
+		})
+		id = level.LocatedInID
+	}
+	return result
+}
+
+func standardizeDate(text string) StandardizedDate {
+	result := StandardizedDate{Text: text}
+	cd := stddate.Standardize(text)
+	if cd == nil {
+		return result
+	}
+	result.Found = true
+	result.Type = cd.Type.String()
+	result.First = standardizedDatePart(cd.First)
+	result.StartDate = cd.First.StartYearMmDd()
+	result.EndDate = cd.First.EndYearMmDd()
+	if cd.Type != stddate.CompoundNone {
+		result.Second = standardizedDatePart(cd.Second)
+		result.EndDate = cd.Second.EndYearMmDd()
+	}
+	result.Encoded = cd.Encode()
+	return result
+}
+
+func standardizedDatePart(d stddate.Date) *StandardizedDatePart {
+	return &StandardizedDatePart{
+		Year:       d.Year,
+		Month:      d.Month,
+		Day:        d.Day,
+		Modifier:   d.Modifier.String(),
+		Quality:    d.Quality.String(),
+		DoubleDate: d.Double == stddate.DoubleDate,
+		Calendar:   d.Calendar.String(),
+	}
+}
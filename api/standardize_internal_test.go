@@ -0,0 +1,34 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStandardizeDates(t *testing.T) {
+	testAPI := &API{}
+	results, err := testAPI.StandardizeDates(context.TODO(), []DateStandardizeRequest{
+		{Text: "abt 1900"},
+		{Text: "1 Jan 1900 - 5 Mar 1902"},
+		{Text: "not a date"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, StandardizedDate{
+		Text:      "abt 1900",
+		Found:     true,
+		First:     &StandardizedDatePart{Year: 1900, Modifier: "About"},
+		Encoded:   "19000000,18990101-19011231",
+		StartDate: "18990101",
+		EndDate:   "19011231",
+	}, results[0])
+	assert.Equal(t, "Range", results[1].Type)
+	assert.Equal(t, 1902, results[1].Second.Year)
+	assert.Equal(t, "19000101", results[1].StartDate)
+	assert.Equal(t, "19020305", results[1].EndDate)
+	assert.False(t, results[2].Found)
+
+	_, err = testAPI.StandardizeDates(context.TODO(), make([]DateStandardizeRequest, MaxStandardizeBatch+1))
+	assert.Equal(t, 400, err.(*Error).HTTPStatus())
+}
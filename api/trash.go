@@ -0,0 +1,283 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+	"gocloud.dev/blob"
+)
+
+// DefaultTrashRetention is how long deleted societies, collections and posts stay in the trash before they are purged
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// GetTrash returns the trashed collections and posts of the society in the context
+func (api API) GetTrash(ctx context.Context) ([]model.TrashItem, error) {
+	if err := api.checkTrashConfigured(); err != nil {
+		return nil, err
+	}
+	items, err := api.trashPersister.SelectTrash(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	// omit items in collections the user's grants hide; trashed collections can't be read, so only users without grants see them
+	if grantedPermissions(ctx) != nil {
+		readableCols := map[uint32]bool{}
+		visible := make([]model.TrashItem, 0, len(items))
+		for _, item := range items {
+			collectionID := item.ID
+			if item.Entity == model.TrashEntityPost {
+				collectionID = item.CollectionID
+			}
+			readable, ok := readableCols[collectionID]
+			if !ok {
+				err := api.checkCollectionIDLevel(ctx, collectionID, model.AuthReader)
+				if e, isError := err.(*Error); err != nil && !(isError && (e.HTTPStatus() == http.StatusForbidden || e.HTTPStatus() == http.StatusNotFound)) {
+					return nil, err
+				}
+				readable = err == nil
+				readableCols[collectionID] = readable
+			}
+			if readable {
+				visible = append(visible, item)
+			}
+		}
+		items = visible
+	}
+	return api.withPurgeTimes(items), nil
+}
+
+// GetTrashedSocieties returns the trashed societies the current user administers
+func (api API) GetTrashedSocieties(ctx context.Context) ([]model.TrashItem, error) {
+	if err := api.checkTrashConfigured(); err != nil {
+		return nil, err
+	}
+	user, err := utils.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	societyUsers, err := api.societyUserPersister.SelectAllSocietyUsersByUser(ctx, user.ID)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	var ids []uint32
+	for _, societyUser := range societyUsers {
+		if societyUser.Level >= model.AuthAdmin {
+			ids = append(ids, societyUser.SocietyID)
+		}
+	}
+	if len(ids) == 0 {
+		return []model.TrashItem{}, nil
+	}
+	items, err := api.trashPersister.SelectTrashedSocieties(ctx, ids)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	return api.withPurgeTimes(items), nil
+}
+
+// RestoreSociety takes the society in the context out of the trash.
+// The society can't be reached through its routes while it is trashed, so its admins are checked here.
+func (api API) RestoreSociety(ctx context.Context) (*model.Society, error) {
+	if err := api.checkTrashConfigured(); err != nil {
+		return nil, err
+	}
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	user, err := utils.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	if !api.isPlatformAdmin(user.ID) {
+		societyUsers, err := api.societyUserPersister.SelectAllSocietyUsersByUser(ctx, user.ID)
+		if err != nil {
+			return nil, NewError(err)
+		}
+		admin := false
+		for _, societyUser := range societyUsers {
+			if societyUser.SocietyID == societyID && societyUser.Level >= model.AuthAdmin {
+				admin = true
+			}
+		}
+		if !admin {
+			return nil, NewHTTPError(fmt.Errorf("only admins can restore society %d", societyID), http.StatusForbidden)
+		}
+	}
+	if err := api.trashPersister.RestoreSociety(ctx); err != nil {
+		return nil, NewError(err)
+	}
+	society, err := api.societyPersister.SelectSociety(ctx, societyID)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntitySociety, EntityID: societyID, Action: model.AuditActionRestore}, nil, society)
+	return society, nil
+}
+
+// RestoreCollection takes one of the society's collections out of the trash.
+// Trashed collections have no categories to check grants against, so only society editors can restore them.
+func (api API) RestoreCollection(ctx context.Context, id uint32) (*model.Collection, error) {
+	if err := api.checkTrashConfigured(); err != nil {
+		return nil, err
+	}
+	if grantedPermissions(ctx) != nil {
+		return nil, NewHTTPError(fmt.Errorf("collection grants don't allow restoring collection %d", id), http.StatusForbidden)
+	}
+	if err := api.trashPersister.RestoreCollection(ctx, id); err != nil {
+		return nil, NewError(err)
+	}
+	collection, err := api.collectionPersister.SelectOneCollection(ctx, id)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityCollection, EntityID: id, Action: model.AuditActionRestore}, nil, collection)
+	return collection, nil
+}
+
+// RestorePost takes one of the society's posts out of the trash; its collection must not be in the trash
+func (api API) RestorePost(ctx context.Context, id uint32) (*model.Post, error) {
+	if err := api.checkTrashConfigured(); err != nil {
+		return nil, err
+	}
+	post, err := api.trashPersister.SelectTrashedPost(ctx, id)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	collection, err := api.collectionPersister.SelectOneCollection(ctx, post.Collection)
+	if model.ErrNotFound.Matches(err) {
+		return nil, NewHTTPError(fmt.Errorf("collection %d of post %d is in the trash; restore the collection first", post.Collection, id),
+			http.StatusConflict)
+	}
+	if err != nil {
+		return nil, NewError(err)
+	}
+	if err := checkCollectionLevel(ctx, collection.ID, collection.Categories, model.AuthEditor); err != nil {
+		return nil, err
+	}
+	if err := api.trashPersister.RestorePost(ctx, id); err != nil {
+		return nil, NewError(err)
+	}
+	api.audit(ctx, model.AuditEventBody{Entity: model.AuditEntityPost, EntityID: id, Action: model.AuditActionRestore, Name: post.Name}, nil, post)
+	return post, nil
+}
+
+// PurgeTrash deletes the societies, collections and posts that have been in the trash longer than the retention window,
+// along with their records, households, index documents and blobs. It returns how many items were purged.
+// Items that fail to purge are logged and stay in the trash to be purged on the next run.
+func (api API) PurgeTrash(ctx context.Context) (int, error) {
+	if !api.trashEnabled() {
+		return 0, nil
+	}
+	items, err := api.trashPersister.SelectExpiredTrash(ctx, time.Now().Add(-api.trashRetention))
+	if err != nil {
+		return 0, NewError(err)
+	}
+	count := 0
+	for _, item := range items {
+		sctx := utils.AddSocietyIDToContext(ctx, item.SocietyID)
+		var err error
+		switch item.Entity {
+		case model.TrashEntityPost:
+			err = api.purgeTrashedPost(sctx, item.ID)
+		case model.TrashEntityCollection:
+			err = api.trashPersister.PurgeCollection(sctx, item.ID)
+		case model.TrashEntitySociety:
+			err = api.purgeTrashedSociety(sctx, item.ID)
+		}
+		if err != nil {
+			log.Printf("[ERROR] purging %s %d of society %d: %v", item.Entity, item.ID, item.SocietyID, err)
+			continue
+		}
+		log.Printf("[INFO] purged %s %d of society %d", item.Entity, item.ID, item.SocietyID)
+		count++
+	}
+	return count, nil
+}
+
+func (api API) purgeTrashedPost(ctx context.Context, id uint32) error {
+	post, err := api.trashPersister.SelectTrashedPost(ctx, id)
+	if err != nil {
+		return err
+	}
+	return api.purgePost(ctx, post)
+}
+
+// purgeTrashedSociety removes the society's index documents and blobs before its rows,
+// so a failure leaves the society in the trash to be purged again
+func (api API) purgeTrashedSociety(ctx context.Context, societyID uint32) error {
+	if api.es != nil {
+		if err := api.SearchDeleteBySociety(ctx, societyID); err != nil {
+			return err
+		}
+	}
+	if err := api.deleteSocietyContent(ctx, societyID); err != nil {
+		return err
+	}
+	return api.trashPersister.PurgeSociety(ctx)
+}
+
+// deleteSocietyContent deletes every blob the society has stored
+func (api API) deleteSocietyContent(ctx context.Context, societyID uint32) error {
+	bucket, err := api.OpenBucket(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer bucket.Close()
+	li := bucket.List(&blob.ListOptions{
+		Prefix: fmt.Sprintf("/%d/", societyID),
+	})
+	var errs []string
+	for {
+		obj, err := li.Next(ctx)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return NewError(err)
+		}
+		if err := bucket.Delete(ctx, obj.Key); err != nil {
+			errs = append(errs, fmt.Sprintf("error deleting %s: %v", obj.Key, err))
+		}
+	}
+	if len(errs) > 0 {
+		return NewError(errors.New(strings.Join(errs, "; ")))
+	}
+	return nil
+}
+
+// checkSocietyNotTrashed returns a not found error if the society is in the trash.
+// Societies are cached for societyCacheTTL, so a society trashed through another server is caught once that expires.
+func (api API) checkSocietyNotTrashed(ctx context.Context, societyID uint32) error {
+	if api.trashPersister == nil {
+		return nil
+	}
+	_, err := api.GetSociety(ctx, societyID)
+	return err
+}
+
+// trashEnabled returns true if deletes move societies, collections and posts to the trash instead of deleting them
+func (api API) trashEnabled() bool {
+	return api.trashPersister != nil && api.trashRetention > 0
+}
+
+func (api API) withPurgeTimes(items []model.TrashItem) []model.TrashItem {
+	for i := range items {
+		items[i].PurgeTime = items[i].DeleteTime.Add(api.trashRetention)
+	}
+	return items
+}
+
+func (api API) checkTrashConfigured() error {
+	if !api.trashEnabled() {
+		return NewHTTPError(errors.New("the trash is not configured"), http.StatusNotImplemented)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// postMock holds posts in memory
+type postMock struct {
+	posts []model.Post
+}
+
+func (pm *postMock) SelectPosts(ctx context.Context) ([]model.Post, error) {
+	return pm.posts, nil
+}
+func (pm *postMock) SelectOnePost(ctx context.Context, id uint32) (*model.Post, error) {
+	for _, post := range pm.posts {
+		if post.ID == id {
+			return &post, nil
+		}
+	}
+	return nil, model.NewError(model.ErrNotFound, fmt.Sprint(id))
+}
+func (pm *postMock) InsertPost(ctx context.Context, in model.PostIn) (*model.Post, error) {
+	return nil, fmt.Errorf("InsertPost not implemented")
+}
+func (pm *postMock) UpdatePost(ctx context.Context, id uint32, in model.Post) (*model.Post, error) {
+	return nil, fmt.Errorf("UpdatePost not implemented")
+}
+func (pm *postMock) DeletePost(ctx context.Context, id uint32) error {
+	return fmt.Errorf("DeletePost not implemented")
+}
+
+// trashMock holds trashed items in memory
+type trashMock struct {
+	items  []model.TrashItem
+	posts  []model.Post
+	purged []uint32
+}
+
+func (tm *trashMock) SelectTrash(ctx context.Context) ([]model.TrashItem, error) {
+	return tm.items, nil
+}
+func (tm *trashMock) SelectTrashedSocieties(ctx context.Context, ids []uint32) ([]model.TrashItem, error) {
+	return nil, fmt.Errorf("SelectTrashedSocieties not implemented")
+}
+func (tm *trashMock) SelectExpiredTrash(ctx context.Context, before time.Time) ([]model.TrashItem, error) {
+	var items []model.TrashItem
+	for _, item := range tm.items {
+		if item.DeleteTime.Before(before) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+func (tm *trashMock) SelectTrashedPost(ctx context.Context, id uint32) (*model.Post, error) {
+	for _, post := range tm.posts {
+		if post.ID == id {
+			return &post, nil
+		}
+	}
+	return nil, model.NewError(model.ErrNotFound, fmt.Sprint(id))
+}
+func (tm *trashMock) TrashSociety(ctx context.Context) error {
+	return fmt.Errorf("TrashSociety not implemented")
+}
+func (tm *trashMock) TrashCollection(ctx context.Context, id uint32) error {
+	tm.items = append(tm.items, model.TrashItem{Entity: model.TrashEntityCollection, ID: id, DeleteTime: time.Now()})
+	return nil
+}
+func (tm *trashMock) TrashPost(ctx context.Context, id uint32) error {
+	return fmt.Errorf("TrashPost not implemented")
+}
+func (tm *trashMock) RestoreSociety(ctx context.Context) error {
+	return fmt.Errorf("RestoreSociety not implemented")
+}
+func (tm *trashMock) RestoreCollection(ctx context.Context, id uint32) error {
+	return fmt.Errorf("RestoreCollection not implemented")
+}
+func (tm *trashMock) RestorePost(ctx context.Context, id uint32) error {
+	for i, item := range tm.items {
+		if item.Entity == model.TrashEntityPost && item.ID == id {
+			tm.items = append(tm.items[:i], tm.items[i+1:]...)
+			return nil
+		}
+	}
+	return model.NewError(model.ErrNotFound, fmt.Sprint(id))
+}
+func (tm *trashMock) PurgeCollection(ctx context.Context, id uint32) error {
+	tm.purged = append(tm.purged, id)
+	return nil
+}
+func (tm *trashMock) PurgeSociety(ctx context.Context) error {
+	return fmt.Errorf("PurgeSociety not implemented")
+}
+
+func TestTrash(t *testing.T) {
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 1)
+	tm := &trashMock{}
+	testAPI := &API{validate: validator.New()}
+	testAPI.CollectionPersister(&collectionMock{collections: []model.Collection{
+		model.NewCollection(1, model.CollectionIn{CollectionBody: model.CollectionBody{Name: "Census"}}),
+		model.NewCollection(2, model.CollectionIn{CollectionBody: model.CollectionBody{Name: "Empty"}}),
+	}}).PostPersister(&postMock{posts: []model.Post{
+		model.NewPost(5, model.PostIn{PostBody: model.PostBody{Name: "1870"}, Collection: 1}),
+	}})
+
+	// without a trash persister the trash isn't available
+	_, err := testAPI.GetTrash(ctx)
+	assert.Equal(t, http.StatusNotImplemented, err.(*Error).HTTPStatus())
+
+	testAPI.TrashPersister(tm).TrashRetention(time.Hour)
+
+	// a collection with posts can't be trashed
+	err = testAPI.DeleteCollection(ctx, 1)
+	assert.Equal(t, http.StatusConflict, err.(*Error).HTTPStatus())
+	assert.Empty(t, tm.items)
+
+	// an empty collection goes to the trash
+	err = testAPI.DeleteCollection(ctx, 2)
+	assert.NoError(t, err)
+	items, err := testAPI.GetTrash(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(items))
+	assert.Equal(t, uint32(2), items[0].ID)
+	assert.Equal(t, items[0].DeleteTime.Add(time.Hour), items[0].PurgeTime)
+
+	// a post can't be restored into a collection that isn't live
+	tm.posts = []model.Post{model.NewPost(6, model.PostIn{PostBody: model.PostBody{Name: "1880"}, Collection: 3})}
+	_, err = testAPI.RestorePost(ctx, 6)
+	assert.Equal(t, http.StatusConflict, err.(*Error).HTTPStatus())
+	_, err = testAPI.RestorePost(ctx, 7)
+	assert.Equal(t, http.StatusNotFound, err.(*Error).HTTPStatus())
+
+	// only items trashed before the retention window are purged
+	count, err := testAPI.PurgeTrash(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+	tm.items[0].DeleteTime = time.Now().Add(-2 * time.Hour)
+	count, err = testAPI.PurgeTrash(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []uint32{2}, tm.purged)
+}
+
+func TestCheckSocietyNotTrashed(t *testing.T) {
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 7)
+	societyCache, err := lru.New2Q(100)
+	assert.NoError(t, err)
+	sm := &societyMock{societies: map[uint32]model.Society{7: {ID: 7}}}
+	testAPI := &API{validate: validator.New(), societyCache: societyCache}
+	testAPI.SocietyPersister(sm).TrashPersister(&trashMock{})
+	assert.NoError(t, testAPI.checkSocietyNotTrashed(ctx, 7))
+
+	// another server trashes the society; this server notices once its cached copy expires
+	delete(sm.societies, 7)
+	assert.NoError(t, testAPI.checkSocietyNotTrashed(ctx, 7))
+	entry, _ := societyCache.Get("7")
+	societyCache.Add("7", societyCacheEntry{society: entry.(societyCacheEntry).society, expires: time.Now().Add(-time.Second)})
+	err = testAPI.checkSocietyNotTrashed(ctx, 7)
+	assert.Equal(t, http.StatusNotFound, err.(*Error).HTTPStatus())
+}
+
+func TestGetTrashGrants(t *testing.T) {
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 1)
+	testAPI := &API{validate: validator.New()}
+	testAPI.CollectionGrantPersister(&collectionGrantMock{grants: []model.CollectionGrant{
+		model.NewCollectionGrant(1, model.CollectionGrantIn{
+			CollectionGrantBody: model.CollectionGrantBody{Level: model.AuthEditor}, UserID: 5, CollectionID: 1}),
+	}}).CollectionPersister(&collectionMock{collections: []model.Collection{
+		model.NewCollection(1, model.CollectionIn{Categories: []uint32{10}}),
+		model.NewCollection(2, model.CollectionIn{Categories: []uint32{11}}),
+	}}).TrashPersister(&trashMock{items: []model.TrashItem{
+		{Entity: model.TrashEntityPost, ID: 5, CollectionID: 1},
+		{Entity: model.TrashEntityPost, ID: 6, CollectionID: 2},
+		{Entity: model.TrashEntityCollection, ID: 3},
+	}}).TrashRetention(time.Hour)
+
+	// without grants everything in the trash is listed
+	items, err := testAPI.GetTrash(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+
+	// a user granted only collection 1 doesn't see posts in collection 2 or trashed collections
+	permissions, err := testAPI.GetPermissions(ctx, 5, model.AuthGuest)
+	assert.NoError(t, err)
+	items, err = testAPI.GetTrash(utils.AddPermissionsToContext(ctx, permissions))
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, uint32(5), items[0].ID)
+	}
+}
@@ -2,11 +2,15 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
 	"github.com/ourrootsorg/go-oidc"
 	"golang.org/x/oauth2"
 )
@@ -16,20 +20,45 @@ type OIDCProvider interface {
 	UserInfo(ctx context.Context, tokenSource oauth2.TokenSource) (*oidc.UserInfo, error)
 }
 
-// RetrieveUser constructs or retrieves a User, either from the database or cache
-func (api API) RetrieveUser(ctx context.Context, provider OIDCProvider, token *oidc.IDToken, rawToken string) (*model.User, bool, error) {
-	var user model.User
+// DefaultUserRefreshInterval is how long a user's identity provider claims are used before they're looked up again
+const DefaultUserRefreshInterval = 15 * time.Minute
+
+// cachedUser records which user an issuer and subject belong to, and when their claims were last looked up.
+// The user itself isn't cached, so that deactivating a user takes effect immediately on every server.
+type cachedUser struct {
+	id        uint32
+	refreshed time.Time
+}
+
+// OIDCClaims names the UserInfo claims that hold a user's name, email, and whether the email is verified,
+// for identity providers that don't use the standard claims; empty names use the standard claims
+type OIDCClaims struct {
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified string `json:"email_verified,omitempty"`
+}
+
+// RetrieveUser constructs or retrieves a User from the database, refreshing their name and email
+// from the identity provider when they haven't been looked up for the refresh interval
+func (api API) RetrieveUser(ctx context.Context, provider OIDCProvider, claims OIDCClaims, token *oidc.IDToken, rawToken string) (*model.User, bool, error) {
 	cacheKey := token.Issuer + "|" + token.Subject
-	u, ok := api.userCache.Get(cacheKey)
-	if ok {
-		user, ok = u.(model.User)
-	}
-	if ok {
-		//log.Printf("[DEBUG] Found user for key '%s' in cache: %#v", cacheKey, user)
-		return &user, false, nil
+	if u, ok := api.userCache.Get(cacheKey); ok {
+		if cached, ok := u.(cachedUser); ok && time.Since(cached.refreshed) < api.userRefreshInterval {
+			users, err := api.userPersister.SelectUsersByID(ctx, []uint32{cached.id})
+			if err != nil {
+				return nil, false, NewError(err)
+			}
+			if len(users) == 1 {
+				if !users[0].Enabled {
+					return nil, false, NewHTTPError(fmt.Errorf("User '%d' is not enabled", cached.id), http.StatusForbidden)
+				}
+				return &users[0], false, nil
+			}
+		}
+		api.userCache.Remove(cacheKey)
 	}
-	// No user in cache, so look up their info and check the database
-	log.Printf("[DEBUG] No key '%s' in cache, so looking up UserInfo", cacheKey)
+	// No current user in cache, so look up their info and check the database
+	log.Printf("[DEBUG] No current key '%s' in cache, so looking up UserInfo", cacheKey)
 	oauth2Token := &oauth2.Token{
 		AccessToken: rawToken,
 		TokenType:   "bearer",
@@ -49,11 +78,22 @@ func (api API) RetrieveUser(ctx context.Context, provider OIDCProvider, token *o
 		log.Printf("[INFO] Error getting claims: %v", err)
 	}
 	log.Printf("[DEBUG] Claims: %#v", userClaims)
-	name := userClaims["name"]
-	if name == nil {
+	nameClaim := "name"
+	if claims.Name != "" {
+		nameClaim = claims.Name
+	}
+	name, hasName := userClaims[nameClaim].(string)
+	if !hasName {
 		name = "<Unknown>"
 	}
-	ui, err := model.NewUserIn(name.(string), userInfo.Email, userInfo.EmailVerified, token.Issuer, token.Subject)
+	email, emailVerified := userInfo.Email, userInfo.EmailVerified
+	if claims.Email != "" {
+		email, _ = userClaims[claims.Email].(string)
+	}
+	if claims.EmailVerified != "" {
+		emailVerified, _ = userClaims[claims.EmailVerified].(bool)
+	}
+	ui, err := model.NewUserIn(name, email, emailVerified, token.Issuer, token.Subject)
 	if err != nil {
 		return nil, false, NewHTTPError(fmt.Errorf("Failed to construct User: %v", err), http.StatusUnauthorized)
 	}
@@ -68,9 +108,166 @@ func (api API) RetrieveUser(ctx context.Context, provider OIDCProvider, token *o
 	if e != nil {
 		return nil, false, NewHTTPError(fmt.Errorf("Failed to retrieve user: %v", e), http.StatusUnauthorized)
 	}
-	// TODO: RetrieveUser doesn't update an existing user if attributes change.
-	// We should probably compare `up.UserBody` to `ui.UserBody` and do an update if they're not equal.
-	log.Printf("[DEBUG] Adding user '%#v' to cache with key '%s'", *up, cacheKey)
-	api.userCache.Add(cacheKey, *up)
+	// only the identity the user was created with keeps their name and email up to date,
+	// so that identities linked to the user don't overwrite each other's claims
+	if !isNew && up.Issuer == ui.Issuer && up.Subject == ui.Subject {
+		up = api.syncUser(ctx, up, ui, hasName)
+	}
+	log.Printf("[DEBUG] Adding user '%d' to cache with key '%s'", up.ID, cacheKey)
+	api.userCache.Add(cacheKey, cachedUser{id: up.ID, refreshed: time.Now()})
 	return up, isNew, nil
 }
+
+// syncUser saves changes to the user's claims; the name is only updated if the identity provider supplied one.
+// Failing to save the changes doesn't stop the user from signing in, so the stored user is returned on failure.
+func (api API) syncUser(ctx context.Context, user *model.User, ui model.UserIn, hasName bool) *model.User {
+	changed := *user
+	if hasName {
+		changed.Name = ui.Name
+	}
+	changed.Email = ui.Email
+	changed.EmailConfirmed = ui.EmailConfirmed
+	if changed.UserBody == user.UserBody {
+		return user
+	}
+	log.Printf("[DEBUG] Updating user '%d' from UserInfo", user.ID)
+	updated, err := api.userPersister.UpdateUser(ctx, user.ID, changed)
+	if err != nil {
+		log.Printf("[ERROR] Failed to update user '%d': %v", user.ID, err)
+		return user
+	}
+	return updated
+}
+
+// DeactivateUser disables a user on every society and removes them from all of their societies.
+// Only platform admins can deactivate users.
+func (api API) DeactivateUser(ctx context.Context, id uint32) (*model.User, error) {
+	admin, err := utils.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	if !api.isPlatformAdmin(admin.ID) {
+		return nil, NewHTTPError(errors.New("only platform admins can deactivate users"), http.StatusForbidden)
+	}
+	if admin.ID == id {
+		return nil, NewHTTPError(errors.New("cannot deactivate yourself"), http.StatusBadRequest)
+	}
+	users, err := api.userPersister.SelectUsersByID(ctx, []uint32{id})
+	if err != nil {
+		return nil, NewError(err)
+	}
+	if len(users) == 0 {
+		return nil, NewError(model.NewError(model.ErrNotFound, strconv.Itoa(int(id))))
+	}
+	user := &users[0]
+	if user.Enabled {
+		disabled := *user
+		disabled.Enabled = false
+		user, err = api.userPersister.UpdateUser(ctx, id, disabled)
+		if err != nil {
+			return nil, NewError(err)
+		}
+	}
+	// remove the user from each society, just as a society admin would
+	societyUsers, err := api.societyUserPersister.SelectAllSocietyUsersByUser(ctx, id)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	for _, societyUser := range societyUsers {
+		sctx := utils.AddSocietyIDToContext(ctx, societyUser.SocietyID)
+		if err := api.societyUserPersister.DeleteSocietyUser(sctx, societyUser.ID); err != nil {
+			return nil, NewError(err)
+		}
+		api.societyUserCache.Remove(fmt.Sprintf("%d_%d", societyUser.SocietyID, id))
+		api.audit(sctx, model.AuditEventBody{Entity: model.AuditEntitySocietyUser, EntityID: societyUser.ID, Action: model.AuditActionDelete},
+			&societyUser, nil)
+		if api.collectionGrantPersister != nil {
+			if err := api.collectionGrantPersister.DeleteCollectionGrantsByUser(sctx, id); err != nil {
+				return nil, NewError(err)
+			}
+		}
+	}
+	return user, nil
+}
+
+// GetUserIdentities returns the identities linked to the current user
+func (api API) GetUserIdentities(ctx context.Context) ([]model.UserIdentity, error) {
+	if err := api.checkUserIdentitiesConfigured(); err != nil {
+		return nil, err
+	}
+	user, err := utils.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	identities, err := api.userIdentityPersister.SelectUserIdentities(ctx, user.ID)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	return identities, nil
+}
+
+// LinkUserIdentity links the identity in a verified token from one of the trusted issuers to the current user,
+// so that the identity signs in as the current user. Identities that already sign in as a user can't be linked.
+func (api API) LinkUserIdentity(ctx context.Context, token *oidc.IDToken) (*model.UserIdentity, error) {
+	if err := api.checkUserIdentitiesConfigured(); err != nil {
+		return nil, err
+	}
+	user, err := utils.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	in := model.UserIdentityIn{
+		UserIdentityBody: model.UserIdentityBody{Issuer: token.Issuer, Subject: token.Subject},
+		UserID:           user.ID,
+	}
+	if err := api.validate.Struct(in); err != nil {
+		return nil, NewError(err)
+	}
+	identity, err := api.userIdentityPersister.InsertUserIdentity(ctx, in)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	return identity, nil
+}
+
+// DeleteUserIdentity unlinks one of the current user's identities.
+// Other servers may continue to accept the identity until their cached copy is refreshed.
+func (api API) DeleteUserIdentity(ctx context.Context, id uint32) error {
+	if err := api.checkUserIdentitiesConfigured(); err != nil {
+		return err
+	}
+	user, err := utils.GetUserFromContext(ctx)
+	if err != nil {
+		return NewError(err)
+	}
+	identities, err := api.userIdentityPersister.SelectUserIdentities(ctx, user.ID)
+	if err != nil {
+		return NewError(err)
+	}
+	for _, identity := range identities {
+		if identity.ID == id {
+			if err := api.userIdentityPersister.DeleteUserIdentity(ctx, user.ID, id); err != nil {
+				return NewError(err)
+			}
+			api.userCache.Remove(identity.Issuer + "|" + identity.Subject)
+			return nil
+		}
+	}
+	return NewError(model.NewError(model.ErrNotFound, strconv.Itoa(int(id))))
+}
+
+func (api API) checkUserIdentitiesConfigured() error {
+	if api.userIdentityPersister == nil {
+		return NewHTTPError(errors.New("linked identities are not configured"), http.StatusNotImplemented)
+	}
+	return nil
+}
+
+func (api API) isPlatformAdmin(userID uint32) bool {
+	for _, id := range api.platformAdmins {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/go-oidc"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+// userMock holds users in memory
+type userMock struct {
+	users []model.User
+}
+
+func (um *userMock) RetrieveUser(ctx context.Context, in model.UserIn) (*model.User, bool, error) {
+	for _, user := range um.users {
+		if user.Issuer == in.Issuer && user.Subject == in.Subject {
+			if !user.Enabled {
+				return nil, false, model.NewError(model.ErrOther, fmt.Sprintf("User '%d' is not enabled", user.ID))
+			}
+			return &user, false, nil
+		}
+	}
+	user := model.NewUser(uint32(len(um.users)+1), in)
+	user.LastUpdateTime = time.Now()
+	um.users = append(um.users, user)
+	return &user, true, nil
+}
+func (um *userMock) SelectUsersByID(ctx context.Context, ids []uint32) ([]model.User, error) {
+	users := make([]model.User, 0)
+	for _, user := range um.users {
+		for _, id := range ids {
+			if user.ID == id {
+				users = append(users, user)
+			}
+		}
+	}
+	return users, nil
+}
+func (um *userMock) UpdateUser(ctx context.Context, id uint32, in model.User) (*model.User, error) {
+	for i, user := range um.users {
+		if user.ID == id {
+			if !user.LastUpdateTime.Equal(in.LastUpdateTime) {
+				return nil, model.NewError(model.ErrConcurrentUpdate, user.LastUpdateTime.String(), in.LastUpdateTime.String())
+			}
+			in.LastUpdateTime = time.Now()
+			um.users[i] = in
+			return &in, nil
+		}
+	}
+	return nil, model.NewError(model.ErrNotFound, fmt.Sprint(id))
+}
+
+// providerMock returns its user info and counts how often it was asked
+type providerMock struct {
+	userInfo oidc.UserInfo
+	calls    int
+}
+
+func (pm *providerMock) UserInfo(ctx context.Context, tokenSource oauth2.TokenSource) (*oidc.UserInfo, error) {
+	pm.calls++
+	userInfo := pm.userInfo
+	return &userInfo, nil
+}
+
+// userIdentityMock holds linked identities in memory
+type userIdentityMock struct {
+	users      *userMock
+	identities []model.UserIdentity
+}
+
+func (im *userIdentityMock) SelectUserIdentities(ctx context.Context, userID uint32) ([]model.UserIdentity, error) {
+	identities := make([]model.UserIdentity, 0)
+	for _, identity := range im.identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+func (im *userIdentityMock) InsertUserIdentity(ctx context.Context, in model.UserIdentityIn) (*model.UserIdentity, error) {
+	for _, user := range im.users.users {
+		if user.Issuer == in.Issuer && user.Subject == in.Subject {
+			return nil, model.NewError(model.ErrConflict)
+		}
+	}
+	for _, identity := range im.identities {
+		if identity.Issuer == in.Issuer && identity.Subject == in.Subject {
+			return nil, model.NewError(model.ErrConflict)
+		}
+	}
+	identity := model.UserIdentity{ID: uint32(len(im.identities) + 1), UserIdentityIn: in}
+	im.identities = append(im.identities, identity)
+	return &identity, nil
+}
+func (im *userIdentityMock) DeleteUserIdentity(ctx context.Context, userID, id uint32) error {
+	for i, identity := range im.identities {
+		if identity.UserID == userID && identity.ID == id {
+			im.identities = append(im.identities[:i], im.identities[i+1:]...)
+			return nil
+		}
+	}
+	return model.NewError(model.ErrNotFound, fmt.Sprint(id))
+}
+
+func newUserTestAPI(t *testing.T) (*API, *userMock) {
+	um := &userMock{}
+	testAPI := &API{validate: validator.New(), userRefreshInterval: time.Hour}
+	var err error
+	testAPI.userCache, err = lru.New2Q(100)
+	assert.NoError(t, err)
+	testAPI.societyUserCache, err = lru.New2Q(100)
+	assert.NoError(t, err)
+	testAPI.UserPersister(um)
+	return testAPI, um
+}
+
+func TestRetrieveUser(t *testing.T) {
+	testAPI, um := newUserTestAPI(t)
+	provider := &providerMock{userInfo: oidc.UserInfo{Email: "old@example.com", EmailVerified: true}}
+	token := &oidc.IDToken{Issuer: "https://issuer.example.com", Subject: "user1"}
+
+	user, isNew, err := testAPI.RetrieveUser(context.TODO(), provider, OIDCClaims{}, token, "token")
+	assert.NoError(t, err)
+	assert.True(t, isNew)
+	assert.Equal(t, "old@example.com", user.Email)
+	assert.Equal(t, 1, provider.calls)
+	// keep the name the user had, since the provider doesn't supply one
+	um.users[0].Name = "Somebody"
+
+	// within the refresh interval, claims aren't looked up again
+	provider.userInfo.Email = "new@example.com"
+	user, isNew, err = testAPI.RetrieveUser(context.TODO(), provider, OIDCClaims{}, token, "token")
+	assert.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Equal(t, "old@example.com", user.Email)
+	assert.Equal(t, 1, provider.calls)
+
+	// after the refresh interval, changed claims are saved
+	testAPI.UserRefreshInterval(0)
+	user, _, err = testAPI.RetrieveUser(context.TODO(), provider, OIDCClaims{}, token, "token")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, provider.calls)
+	assert.Equal(t, "new@example.com", user.Email)
+	assert.Equal(t, "Somebody", user.Name)
+	assert.Equal(t, "new@example.com", um.users[0].Email)
+
+	// a disabled user is blocked right away, even though they're cached
+	testAPI.UserRefreshInterval(time.Hour)
+	um.users[0].Enabled = false
+	_, _, err = testAPI.RetrieveUser(context.TODO(), provider, OIDCClaims{}, token, "token")
+	assert.Equal(t, http.StatusForbidden, err.(*Error).HTTPStatus())
+	assert.Equal(t, 2, provider.calls)
+}
+
+func TestDeactivateUser(t *testing.T) {
+	testAPI, um := newUserTestAPI(t)
+	um.users = []model.User{
+		{ID: 10, UserBody: model.UserBody{Email: "admin@example.com", Enabled: true}},
+		{ID: 11, UserBody: model.UserBody{Email: "user@example.com", Enabled: true}},
+	}
+	sm := &societyUserMock{societyUsers: []model.SocietyUser{
+		{ID: 1, SocietyUserIn: model.SocietyUserIn{SocietyUserBody: model.SocietyUserBody{Level: model.AuthAdmin}, UserID: 10, SocietyID: 7}},
+		{ID: 2, SocietyUserIn: model.SocietyUserIn{SocietyUserBody: model.SocietyUserBody{Level: model.AuthReader}, UserID: 11, SocietyID: 7}},
+		{ID: 3, SocietyUserIn: model.SocietyUserIn{SocietyUserBody: model.SocietyUserBody{Level: model.AuthEditor}, UserID: 11, SocietyID: 8}},
+	}}
+	am := &auditMock{}
+	testAPI.SocietyUserPersister(sm).AuditPersister(am)
+	ctx := userContext("admin@example.com", 10)
+
+	// only platform admins can deactivate users
+	_, err := testAPI.DeactivateUser(ctx, 11)
+	assert.Equal(t, http.StatusForbidden, err.(*Error).HTTPStatus())
+
+	testAPI.PlatformAdmins([]uint32{10})
+	_, err = testAPI.DeactivateUser(ctx, 10)
+	assert.Equal(t, http.StatusBadRequest, err.(*Error).HTTPStatus())
+	_, err = testAPI.DeactivateUser(ctx, 99)
+	assert.Equal(t, http.StatusNotFound, err.(*Error).HTTPStatus())
+
+	user, err := testAPI.DeactivateUser(ctx, 11)
+	assert.NoError(t, err)
+	assert.False(t, user.Enabled)
+	assert.False(t, um.users[1].Enabled)
+	// the user is removed from both of their societies, and each society's audit log records it
+	assert.Len(t, sm.societyUsers, 1)
+	assert.Equal(t, uint32(10), sm.societyUsers[0].UserID)
+	assert.Len(t, am.events, 2)
+	assert.Equal(t, uint32(7), am.events[0].SocietyID)
+	assert.Equal(t, uint32(8), am.events[1].SocietyID)
+	assert.Equal(t, model.AuditActionDelete, am.events[1].Action)
+
+	// deactivating again is harmless
+	_, err = testAPI.DeactivateUser(ctx, 11)
+	assert.NoError(t, err)
+}
+
+func TestRetrieveUserClaims(t *testing.T) {
+	// an identity provider whose UserInfo uses its own claim names
+	var issuer string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch req.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"issuer":            issuer,
+				"userinfo_endpoint": issuer + "/userinfo",
+				"jwks_uri":          issuer + "/jwks",
+			})
+		case "/userinfo":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"sub": "user1", "preferred_username": "Somebody", "mail": "somebody@example.com", "mail_verified": true,
+			})
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer server.Close()
+	issuer = server.URL
+	provider, err := oidc.NewProvider(context.TODO(), issuer)
+	assert.NoError(t, err)
+
+	testAPI, _ := newUserTestAPI(t)
+	claims := OIDCClaims{Name: "preferred_username", Email: "mail", EmailVerified: "mail_verified"}
+	user, _, err := testAPI.RetrieveUser(context.TODO(), provider, claims, &oidc.IDToken{Issuer: issuer, Subject: "user1"}, "token")
+	assert.NoError(t, err)
+	assert.Equal(t, "Somebody", user.Name)
+	assert.Equal(t, "somebody@example.com", user.Email)
+	assert.True(t, user.EmailConfirmed)
+}
+
+func TestUserIdentities(t *testing.T) {
+	testAPI, um := newUserTestAPI(t)
+	ctx := userContext("somebody@example.com", 1)
+	_, err := testAPI.GetUserIdentities(ctx)
+	assert.Equal(t, http.StatusNotImplemented, err.(*Error).HTTPStatus())
+
+	im := &userIdentityMock{users: um}
+	testAPI.UserIdentityPersister(im)
+	provider := &providerMock{userInfo: oidc.UserInfo{Email: "somebody@example.com"}}
+	primary := &oidc.IDToken{Issuer: "https://issuer.example.com", Subject: "user1"}
+	other := &oidc.IDToken{Issuer: "https://society.example.org", Subject: "member1"}
+	user, _, err := testAPI.RetrieveUser(context.TODO(), provider, OIDCClaims{}, primary, "token")
+	assert.NoError(t, err)
+	ctx = userContext(user.Email, user.ID)
+
+	identity, err := testAPI.LinkUserIdentity(ctx, other)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, identity.UserID)
+	identities, err := testAPI.GetUserIdentities(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, identities, 1)
+
+	// the identity a user was created with can't be linked to another user
+	_, err = testAPI.LinkUserIdentity(userContext("other@example.com", 2), primary)
+	assert.Equal(t, http.StatusConflict, err.(*Error).HTTPStatus())
+
+	err = testAPI.DeleteUserIdentity(ctx, 99)
+	assert.Equal(t, http.StatusNotFound, err.(*Error).HTTPStatus())
+	err = testAPI.DeleteUserIdentity(ctx, identity.ID)
+	assert.NoError(t, err)
+	identities, err = testAPI.GetUserIdentities(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, identities, 0)
+}
@@ -96,7 +96,7 @@ func doUserTests(t *testing.T,
 		TokenType:   "bearer",
 	})).Once().Return(&ui, nil)
 
-	user, _, errors := testApi.RetrieveUser(ctx, &provider, &token, rawToken)
+	user, _, errors := testApi.RetrieveUser(ctx, &provider, api.OIDCClaims{}, &token, rawToken)
 	assert.Nil(t, errors)
 	// assert.Equal(t, expectedUser.ID, user.ID)
 	assert.Equal(t, expectedUser.Name, user.Name)
@@ -108,7 +108,7 @@ func doUserTests(t *testing.T,
 	// provider.AssertExpectations(t)
 
 	// Second time through, in DB and cache
-	user, _, errors = testApi.RetrieveUser(ctx, &provider, &token, rawToken)
+	user, _, errors = testApi.RetrieveUser(ctx, &provider, api.OIDCClaims{}, &token, rawToken)
 	assert.Nil(t, errors)
 	// assert.Equal(t, expectedUser.ID, user.ID)
 	assert.Equal(t, expectedUser.Name, user.Name)
@@ -130,7 +130,7 @@ func doUserTests(t *testing.T,
 		TokenType:   "bearer",
 	})).Once().Return(&ui, nil)
 
-	user, _, errors = testApi.RetrieveUser(ctx, &provider, &token, rawToken)
+	user, _, errors = testApi.RetrieveUser(ctx, &provider, api.OIDCClaims{}, &token, rawToken)
 	assert.Nil(t, errors)
 	// assert.Equal(t, expectedUser.ID, user.ID)
 	assert.Equal(t, expectedUser.Name, user.Name)
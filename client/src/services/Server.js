@@ -45,12 +45,24 @@ export default {
       }
     });
   },
+  apiKeysCreate(societyId, apiKey) {
+    return post(`/societies/${societyId}/api-keys`, apiKey);
+  },
+  apiKeysDelete(societyId, id) {
+    return del(`/societies/${societyId}/api-keys/${id}`);
+  },
+  apiKeysGetAll(societyId) {
+    return get(`/societies/${societyId}/api-keys`);
+  },
   currentUser() {
     return get(`/current_user`);
   },
   invitationsCreate(societyId, invitation) {
     return post(`/societies/${societyId}/invitations`, invitation);
   },
+  invitationsCreateBulk(societyId, csv) {
+    return post(`/societies/${societyId}/invitations/bulk`, csv, { headers: { "Content-Type": "text/csv" } });
+  },
   invitationsDelete(societyId, id) {
     return del(`/societies/${societyId}/invitations/${id}`);
   },
@@ -10,6 +10,7 @@ import * as societySummaries from "./modules/societySummaries.js";
 import * as societies from "./modules/societies.js";
 import * as societyUsers from "./modules/societyUsers.js";
 import * as invitations from "./modules/invitations.js";
+import * as apiKeys from "./modules/apiKeys.js";
 
 Vue.use(Vuex);
 
@@ -24,7 +25,8 @@ export default new Vuex.Store({
     societySummaries,
     societies,
     societyUsers,
-    invitations
+    invitations,
+    apiKeys
   },
   state: {},
   mutations: {},
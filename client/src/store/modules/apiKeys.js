@@ -0,0 +1,79 @@
+import Server from "@/services/Server.js";
+
+export const state = {
+  apiKeysList: []
+};
+
+export const mutations = {
+  API_KEYS_ADD(state, apiKey) {
+    state.apiKeysList.push(apiKey);
+  },
+  API_KEYS_SET(state, apiKeys) {
+    state.apiKeysList = apiKeys;
+  },
+  API_KEYS_REMOVE(state, id) {
+    state.apiKeysList = state.apiKeysList.filter(apiKey => apiKey.id !== id);
+  }
+};
+
+export const actions = {
+  apiKeysCreate({ commit, dispatch, rootGetters }, apiKey) {
+    return Server.apiKeysCreate(rootGetters.currentSocietyId, apiKey)
+      .then(response => {
+        // the key itself is only returned when it is created, so don't keep it in the list
+        let { key, ...created } = response.data;
+        commit("API_KEYS_ADD", created);
+        const notification = {
+          type: "success",
+          message: "Your API key has been created - copy it now, it won't be shown again"
+        };
+        dispatch("notificationsAdd", notification, { root: true });
+        return key;
+      })
+      .catch(error => {
+        const notification = {
+          error,
+          type: "error",
+          message: "There was a problem creating your API key: " + error.message
+        };
+        dispatch("notificationsAdd", notification, { root: true });
+        throw error;
+      });
+  },
+  apiKeysGetAll({ commit, dispatch, rootGetters }) {
+    return Server.apiKeysGetAll(rootGetters.currentSocietyId)
+      .then(response => {
+        commit("API_KEYS_SET", response.data);
+        return response.data;
+      })
+      .catch(error => {
+        const notification = {
+          error,
+          type: "error",
+          message: "There was a problem reading API keys: " + error.message
+        };
+        dispatch("notificationsAdd", notification, { root: true });
+        throw error;
+      });
+  },
+  apiKeysDelete({ commit, dispatch, rootGetters }, id) {
+    return Server.apiKeysDelete(rootGetters.currentSocietyId, id)
+      .then(() => {
+        commit("API_KEYS_REMOVE", id);
+        const notification = {
+          type: "success",
+          message: "Your API key has been revoked"
+        };
+        dispatch("notificationsAdd", notification, { root: true });
+      })
+      .catch(error => {
+        const notification = {
+          error,
+          type: "error",
+          message: "There was a problem revoking the API key: " + error.message
+        };
+        dispatch("notificationsAdd", notification, { root: true });
+        throw error;
+      });
+  }
+};
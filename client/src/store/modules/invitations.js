@@ -27,7 +27,9 @@ export const actions = {
         commit("INVITATIONS_ADD", response.data);
         const notification = {
           type: "success",
-          message: "Your invitation has been created - remember to send the URL to the invitee"
+          message: invitation.email
+            ? "Your invitation has been created"
+            : "Your invitation has been created - remember to send the URL to the invitee"
         };
         dispatch("notificationsAdd", notification, { root: true });
         return response.data;
@@ -42,6 +44,34 @@ export const actions = {
         throw error;
       });
   },
+  invitationsCreateBulk({ dispatch, rootGetters }, csv) {
+    return Server.invitationsCreateBulk(rootGetters.currentSocietyId, csv)
+      .then(response => {
+        const failed = response.data.filter(result => result.error);
+        const notification = failed.length
+          ? {
+              type: "error",
+              message:
+                `${response.data.length - failed.length} invitations were created; ${failed.length} failed: ` +
+                failed.map(result => `row ${result.row}: ${result.error}`).join("; ")
+            }
+          : {
+              type: "success",
+              message: `${response.data.length} invitations were created`
+            };
+        dispatch("notificationsAdd", notification, { root: true });
+        return dispatch("invitationsGetAll").then(() => response.data);
+      })
+      .catch(error => {
+        const notification = {
+          error,
+          type: "error",
+          message: "There was a problem creating your invitations: " + error.message
+        };
+        dispatch("notificationsAdd", notification, { root: true });
+        throw error;
+      });
+  },
   invitationsGetAll({ commit, dispatch, rootGetters }) {
     return Server.invitationsGetAll(rootGetters.currentSocietyId)
       .then(response => {
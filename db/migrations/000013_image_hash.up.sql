@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS image_hash (
+    id  SERIAL PRIMARY KEY,
+    body JSONB,
+    post_id INTEGER REFERENCES post (id) NOT NULL,
+    path TEXT NOT NULL,
+    sha256 TEXT NOT NULL,
+    society_id INTEGER REFERENCES society (id) NOT NULL,
+    insert_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_update_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX idx_image_hash_post_path ON image_hash (post_id, path);
+CREATE INDEX idx_image_hash_society_sha256 ON image_hash (society_id, sha256);
+CREATE INDEX idx_image_hash_society_storage_key ON image_hash (society_id, (body->>'storageKey'));
+GRANT USAGE, SELECT on SEQUENCE image_hash_id_seq to ourroots;
+GRANT SELECT, INSERT, UPDATE, DELETE ON image_hash TO ourroots;
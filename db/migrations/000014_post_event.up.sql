@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS post_event (
+    id  SERIAL PRIMARY KEY,
+    body JSONB,
+    post_id INTEGER REFERENCES post (id) NOT NULL,
+    society_id INTEGER REFERENCES society (id) NOT NULL,
+    insert_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_post_event_post ON post_event (post_id, id);
+GRANT USAGE, SELECT on SEQUENCE post_event_id_seq to ourroots;
+GRANT SELECT, INSERT, DELETE ON post_event TO ourroots;
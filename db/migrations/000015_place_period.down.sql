@@ -0,0 +1 @@
+ALTER TABLE place DROP COLUMN IF EXISTS periods;
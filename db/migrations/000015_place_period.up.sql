@@ -0,0 +1 @@
+ALTER TABLE place ADD COLUMN IF NOT EXISTS periods JSONB NOT NULL DEFAULT '[]';
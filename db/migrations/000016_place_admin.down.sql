@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS place_alt_name;
+DELETE FROM place_word WHERE society_id <> 0;
+ALTER TABLE place_word DROP CONSTRAINT IF EXISTS place_word_pkey;
+ALTER TABLE place_word DROP COLUMN IF EXISTS society_id;
+ALTER TABLE place_word ADD PRIMARY KEY (word);
+DELETE FROM place WHERE society_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_place_society_located_in;
+ALTER TABLE place DROP COLUMN IF EXISTS society_id;
+DROP SEQUENCE IF EXISTS place_id_seq;
@@ -0,0 +1,16 @@
+CREATE SEQUENCE IF NOT EXISTS place_id_seq START 1000000000;
+ALTER TABLE place ADD COLUMN IF NOT EXISTS society_id INTEGER REFERENCES society (id);
+CREATE INDEX IF NOT EXISTS idx_place_society_located_in ON place (society_id, located_in_id);
+ALTER TABLE place_word ADD COLUMN IF NOT EXISTS society_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE place_word DROP CONSTRAINT IF EXISTS place_word_pkey;
+ALTER TABLE place_word ADD PRIMARY KEY (word, society_id);
+CREATE TABLE IF NOT EXISTS place_alt_name (
+    society_id INTEGER REFERENCES society (id) NOT NULL,
+    place_id INTEGER REFERENCES place (id) NOT NULL,
+    alt_names JSONB NOT NULL DEFAULT '[]',
+    insert_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_update_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (society_id, place_id)
+);
+GRANT USAGE, SELECT on SEQUENCE place_id_seq to ourroots;
+GRANT SELECT, INSERT, UPDATE, DELETE ON place_alt_name TO ourroots;
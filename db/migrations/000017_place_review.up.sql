@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS place_review (
+    id  SERIAL PRIMARY KEY,
+    society_id INTEGER REFERENCES society (id) NOT NULL,
+    text VARCHAR NOT NULL,
+    body JSONB,
+    insert_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_update_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (society_id, text)
+);
+CREATE INDEX idx_place_review_status ON place_review (society_id, (body->>'status'));
+GRANT USAGE, SELECT on SEQUENCE place_review_id_seq to ourroots;
+GRANT SELECT, INSERT, UPDATE, DELETE ON place_review TO ourroots;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS society_name_variants;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS society_name_variants (
+    society_id INTEGER REFERENCES society (id) NOT NULL,
+    name_type SMALLINT NOT NULL,
+    name TEXT NOT NULL,
+    added JSONB,
+    removed JSONB,
+    insert_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_update_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (society_id, name_type, name)
+);
+GRANT SELECT, INSERT, UPDATE, DELETE ON society_name_variants TO ourroots;
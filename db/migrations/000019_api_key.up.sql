@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS api_key (
+    id  SERIAL PRIMARY KEY,
+    body JSONB,
+    prefix TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    society_id INTEGER REFERENCES society (id) NOT NULL,
+    created_by INTEGER NOT NULL DEFAULT 0,
+    last_used_time TIMESTAMP WITH TIME ZONE,
+    insert_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_update_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX idx_api_key_hash ON api_key (hash);
+CREATE INDEX idx_api_key_society ON api_key (society_id);
+GRANT USAGE, SELECT on SEQUENCE api_key_id_seq to ourroots;
+GRANT SELECT, INSERT, UPDATE, DELETE ON api_key TO ourroots;
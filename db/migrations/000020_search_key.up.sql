@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS search_key (
+    id  SERIAL PRIMARY KEY,
+    body JSONB,
+    kid TEXT NOT NULL,
+    society_id INTEGER REFERENCES society (id) NOT NULL,
+    insert_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_update_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX idx_search_key_society_kid ON search_key (society_id, kid);
+GRANT USAGE, SELECT on SEQUENCE search_key_id_seq to ourroots;
+GRANT SELECT, INSERT, UPDATE, DELETE ON search_key TO ourroots;
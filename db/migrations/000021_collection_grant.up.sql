@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS collection_grant (
+    id  SERIAL PRIMARY KEY,
+    body JSONB,
+    user_id INTEGER REFERENCES cms_user (id) NOT NULL,
+    society_id INTEGER REFERENCES society (id) NOT NULL,
+    collection_id INTEGER REFERENCES collection (id) ON DELETE CASCADE,
+    category_id INTEGER REFERENCES category (id) ON DELETE CASCADE,
+    insert_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_update_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((collection_id IS NULL) <> (category_id IS NULL))
+);
+CREATE UNIQUE INDEX idx_collection_grant_collection ON collection_grant (society_id, user_id, collection_id) WHERE collection_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_collection_grant_category ON collection_grant (society_id, user_id, category_id) WHERE category_id IS NOT NULL;
+GRANT USAGE, SELECT on SEQUENCE collection_grant_id_seq to ourroots;
+GRANT SELECT, INSERT, UPDATE, DELETE ON collection_grant TO ourroots;
@@ -0,0 +1 @@
+ALTER TABLE invitation ADD COLUMN IF NOT EXISTS uses INTEGER NOT NULL DEFAULT 0;
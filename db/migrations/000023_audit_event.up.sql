@@ -0,0 +1,11 @@
+-- society_id has no foreign key so that the log outlives a deleted society
+CREATE TABLE IF NOT EXISTS audit_event (
+    id  SERIAL PRIMARY KEY,
+    body JSONB,
+    society_id INTEGER NOT NULL,
+    insert_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_audit_event_society ON audit_event (society_id, id);
+CREATE INDEX idx_audit_event_entity ON audit_event (society_id, (body->>'entity'), id);
+GRANT USAGE, SELECT on SEQUENCE audit_event_id_seq to ourroots;
+GRANT SELECT, INSERT ON audit_event TO ourroots;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS user_identity (
+    id  SERIAL PRIMARY KEY,
+    body JSONB,
+    user_id INTEGER REFERENCES cms_user (id) NOT NULL,
+    insert_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_update_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX idx_user_identity_iss_sub ON user_identity ((body->>'iss'), (body->>'sub'));
+CREATE INDEX idx_user_identity_user ON user_identity (user_id);
+GRANT USAGE, SELECT on SEQUENCE user_identity_id_seq to ourroots;
+GRANT SELECT, INSERT, DELETE ON user_identity TO ourroots;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS rate_limit (
+    key TEXT NOT NULL,
+    window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (key, window_start)
+);
+CREATE INDEX idx_rate_limit_window_start ON rate_limit (window_start);
+GRANT SELECT, INSERT, UPDATE, DELETE ON rate_limit TO ourroots;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS society_template (
+    id  SERIAL PRIMARY KEY,
+    body JSONB,
+    society_id INTEGER REFERENCES society (id) ON DELETE SET NULL,
+    insert_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_update_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_society_template_society_id ON society_template (society_id);
+GRANT USAGE, SELECT on SEQUENCE society_template_id_seq to ourroots;
+GRANT SELECT, INSERT, UPDATE, DELETE ON society_template TO ourroots;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_post_delete_time;
+DROP INDEX IF EXISTS idx_collection_delete_time;
+DROP INDEX IF EXISTS idx_society_delete_time;
+ALTER TABLE post DROP COLUMN IF EXISTS delete_time;
+ALTER TABLE collection DROP COLUMN IF EXISTS delete_time;
+ALTER TABLE society DROP COLUMN IF EXISTS delete_time;
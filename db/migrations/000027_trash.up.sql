@@ -0,0 +1,6 @@
+ALTER TABLE society ADD COLUMN IF NOT EXISTS delete_time TIMESTAMP WITH TIME ZONE;
+ALTER TABLE collection ADD COLUMN IF NOT EXISTS delete_time TIMESTAMP WITH TIME ZONE;
+ALTER TABLE post ADD COLUMN IF NOT EXISTS delete_time TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_society_delete_time ON society (delete_time) WHERE delete_time IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_collection_delete_time ON collection (delete_time) WHERE delete_time IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_post_delete_time ON post (delete_time) WHERE delete_time IS NOT NULL;
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/ourrootsorg/cms-server/api"
+	"github.com/ourrootsorg/cms-server/model"
+)
+
+// redactionPixelSize is the approximate number of pixels averaged together when blurring a redacted region
+const redactionPixelSize = 16
+
+// redactImage blurs the redacted regions of an image
+func redactImage(img image.Image, redactions []model.ImageRedaction) *image.NRGBA {
+	dst := imaging.Clone(img)
+	width := float64(dst.Bounds().Dx())
+	height := float64(dst.Bounds().Dy())
+	for _, redaction := range redactions {
+		r := image.Rect(
+			int(math.Floor(redaction.Left*width)),
+			int(math.Floor(redaction.Top*height)),
+			int(math.Ceil((redaction.Left+redaction.Width)*width)),
+			int(math.Ceil((redaction.Top+redaction.Height)*height)),
+		).Intersect(dst.Bounds())
+		if r.Empty() {
+			continue
+		}
+		// pixelate then smooth so the region can't be read
+		region := imaging.Crop(dst, r)
+		small := imaging.Resize(region, maxInt(1, r.Dx()/redactionPixelSize), maxInt(1, r.Dy()/redactionPixelSize), imaging.Box)
+		blurred := imaging.Resize(small, r.Dx(), r.Dy(), imaging.Linear)
+		dst = imaging.Paste(dst, blurred, r.Min)
+	}
+	return dst
+}
+
+// watermarkImage tiles the watermark text across an image
+func watermarkImage(img image.Image, watermark *model.ImageWatermark) *image.NRGBA {
+	dst := imaging.Clone(img)
+	opacity := watermark.Opacity
+	if opacity == 0 {
+		opacity = model.DefaultWatermarkOpacity
+	}
+
+	// draw the text with a drop shadow so it shows on both light and dark images, then scale it to a third of the image width
+	face := basicfont.Face7x13
+	textWidth := font.MeasureString(face, watermark.Text).Ceil()
+	if textWidth == 0 {
+		return dst
+	}
+	stamp := image.NewNRGBA(image.Rect(0, 0, textWidth+1, face.Height+1))
+	for _, layer := range []struct {
+		c      color.Color
+		offset int
+	}{{color.Black, 1}, {color.White, 0}} {
+		d := font.Drawer{
+			Dst:  stamp,
+			Src:  image.NewUniform(layer.c),
+			Face: face,
+			Dot:  fixed.P(layer.offset, face.Ascent+layer.offset),
+		}
+		d.DrawString(watermark.Text)
+	}
+	scaled := imaging.Resize(stamp, maxInt(1, dst.Bounds().Dx()/3), 0, imaging.Linear)
+
+	stepX := scaled.Bounds().Dx() * 3 / 2
+	stepY := maxInt(1, scaled.Bounds().Dy()*4)
+	for row, y := 0, 0; y < dst.Bounds().Dy(); row, y = row+1, y+stepY {
+		// offset alternate rows
+		x := -(row % 2) * stepX / 2
+		for ; x < dst.Bounds().Dx(); x += stepX {
+			dst = imaging.Overlay(dst, scaled, image.Pt(x, y), opacity)
+		}
+	}
+	return dst
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// writePublicImages writes the redacted and watermarked derivatives of an image and its thumbnail under fullImagePath,
+// or removes them if the image has no redactions and the collection has no watermark
+func writePublicImages(ctx context.Context, bucket *blob.Bucket, img image.Image, fullImagePath string, redactions []model.ImageRedaction, watermark *model.ImageWatermark) error {
+	publicKey := fullImagePath + model.ImagePublicSuffix
+	publicThumbKey := fullImagePath + model.ImageThumbnailSuffix + model.ImagePublicSuffix
+	if len(redactions) == 0 && watermark == nil {
+		for _, key := range []string{publicKey, publicThumbKey} {
+			if err := bucket.Delete(ctx, key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+				return fmt.Errorf("delete %s %v", key, err)
+			}
+		}
+		return nil
+	}
+
+	public := redactImage(img, redactions)
+	if watermark != nil {
+		public = watermarkImage(public, watermark)
+	}
+	if err := writeJPEG(ctx, bucket, publicKey, public, model.ImagePublicQuality); err != nil {
+		return err
+	}
+	thumb := imaging.Resize(public, model.ImageThumbnailWidth, model.ImageThumbnailHeight, imaging.Box)
+	return writeJPEG(ctx, bucket, publicThumbKey, thumb, model.ImageThumbnailQuality)
+}
+
+func writeJPEG(ctx context.Context, bucket *blob.Bucket, key string, img image.Image, quality int) error {
+	writer, err := bucket.NewWriter(ctx, key, &blob.WriterOptions{
+		ContentType: "image/jpeg",
+	})
+	if err != nil {
+		return fmt.Errorf("start write %s %v", key, err)
+	}
+	err = imaging.Encode(writer, img, imaging.JPEG, imaging.JPEGQuality(quality))
+	closeErr := writer.Close()
+	if err != nil || closeErr != nil {
+		return fmt.Errorf("write %s %v close %v", key, err, closeErr)
+	}
+	return nil
+}
+
+// postImageSettings returns the collection watermark and the post so derivatives can be generated for the post's images
+func postImageSettings(ctx context.Context, ap *api.API, postID uint32) (*model.Post, *model.ImageWatermark, error) {
+	post, err := ap.GetPost(ctx, postID)
+	if err != nil {
+		return nil, nil, err
+	}
+	collection, err := ap.GetCollection(ctx, post.Collection)
+	if err != nil {
+		return nil, nil, err
+	}
+	return post, collection.Watermark, nil
+}
+
+// processDerivativesMessage regenerates the redacted and watermarked derivatives of one or all of a post's images
+func processDerivativesMessage(ctx context.Context, ap *api.API, msg model.ImagesWriterMsg) error {
+	log.Printf("[DEBUG] ImagesWriter Generating Derivatives PostID: %d Path %s", msg.PostID, msg.ImagePath)
+
+	post, watermark, err := postImageSettings(ctx, ap, msg.PostID)
+	if err != nil {
+		log.Printf("[ERROR] processDerivativesMessage read post %d %v\n", msg.PostID, err)
+		return err
+	}
+	paths := []string{msg.ImagePath}
+	if msg.ImagePath == "" {
+		if paths, err = ap.PostImagePaths(ctx, msg.PostID); err != nil {
+			log.Printf("[ERROR] processDerivativesMessage list images %d %v\n", msg.PostID, err)
+			return err
+		}
+	}
+
+	bucket, err := ap.OpenBucket(ctx, false)
+	if err != nil {
+		log.Printf("[ERROR] OpenBucket %v\n", err)
+		return api.NewError(err)
+	}
+	defer bucket.Close()
+
+	var errs []string
+	for _, path := range paths {
+		key, err := ap.ImageStorageKey(ctx, msg.PostID, path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("storage key %s %v", path, err))
+			continue
+		}
+		imgBytes, err := bucket.ReadAll(ctx, fmt.Sprintf("/%d/%s", msg.SocietyID, key))
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("read %s %v", key, err))
+			continue
+		}
+		img, err := imaging.Decode(bytes.NewReader(imgBytes), imaging.AutoOrientation(true))
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("decode %s %v", key, err))
+			continue
+		}
+		fullImagePath := fmt.Sprintf("/%d/%s", msg.SocietyID, fmt.Sprintf(api.ImagesPrefix, msg.PostID)+path)
+		if err := writePublicImages(ctx, bucket, img, fullImagePath, post.ImageRedactionsFor(path), watermark); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		log.Printf("[ERROR] processDerivativesMessage %s\n", strings.Join(errs, "\n"))
+		return api.NewError(fmt.Errorf("processDerivativesMessage %s", strings.Join(errs, "; ")))
+	}
+	return nil
+}
@@ -2,10 +2,14 @@ package main
 
 import (
 	"archive/zip"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"image"
 	"io"
 	"io/ioutil"
 	"log"
@@ -28,6 +32,7 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/ocr"
 	"github.com/ourrootsorg/cms-server/persist/dynamo"
 
 	"github.com/ourrootsorg/cms-server/persist"
@@ -45,7 +50,16 @@ const (
 
 const numWorkers = 10
 
-func processThumbnailMessage(ctx context.Context, ap *api.API, msg model.ImagesWriterMsg) error {
+// options holds the optional image processing features
+type options struct {
+	ocrEngine ocr.Engine
+	// detectDuplicates records content and perceptual hashes for each image so duplicates can be reported
+	detectDuplicates bool
+	// dedupe stores exact duplicates of images already in the society by reference instead of writing them again
+	dedupe bool
+}
+
+func processThumbnailMessage(ctx context.Context, ap *api.API, ocrEngine ocr.Engine, msg model.ImagesWriterMsg) error {
 	log.Printf("[DEBUG] ImagesWriter Generating Thumbnail PostID: %d Path %s", msg.PostID, msg.ImagePath)
 
 	fullImagePath := fmt.Sprintf("/%d/%s", msg.SocietyID, msg.ImagePath)
@@ -68,7 +82,12 @@ func processThumbnailMessage(ctx context.Context, ap *api.API, msg model.ImagesW
 		return api.NewError(fmt.Errorf("processThumbnailMessage read image %v", err))
 	}
 	defer reader.Close()
-	img, err := imaging.Decode(reader, imaging.AutoOrientation(true))
+	imgBytes, err := ioutil.ReadAll(reader)
+	if err != nil {
+		log.Printf("[ERROR] processThumbnailMessage read image %#v\n", err)
+		return api.NewError(fmt.Errorf("processThumbnailMessage read image %v", err))
+	}
+	img, err := imaging.Decode(bytes.NewReader(imgBytes), imaging.AutoOrientation(true))
 	if err != nil {
 		log.Printf("[ERROR] processThumbnailMessage decode %#v\n", err)
 		return api.NewError(fmt.Errorf("processThumbnailMessage decode image %v", err))
@@ -128,10 +147,136 @@ func processThumbnailMessage(ctx context.Context, ap *api.API, msg model.ImagesW
 		return api.NewError(fmt.Errorf("processThumbnailMessage write thumb dimensions %v close %v", err, closeErr))
 	}
 
+	// generate redacted and watermarked derivatives when the image is written under the post's own key by unzip;
+	// images stored under a shared key get their derivatives from a separate derivatives message
+	postPrefix := fmt.Sprintf(api.ImagesPrefix, msg.PostID)
+	if strings.HasPrefix(msg.ImagePath, postPrefix) {
+		relativePath := strings.TrimPrefix(msg.ImagePath, postPrefix)
+		post, watermark, err := postImageSettings(ctx, ap, msg.PostID)
+		if err != nil {
+			log.Printf("[ERROR] processThumbnailMessage read post %d %v\n", msg.PostID, err)
+			return err
+		}
+		if err = writePublicImages(ctx, bucket, img, fullImagePath, post.ImageRedactionsFor(relativePath), watermark); err != nil {
+			log.Printf("[ERROR] processThumbnailMessage write public images %v\n", err)
+			return api.NewError(fmt.Errorf("processThumbnailMessage write public images %v", err))
+		}
+	}
+
+	// recognize text
+	if ocrEngine != nil {
+		// OCR is best-effort; don't fail the image if it can't be recognized
+		if err = writeImageOCR(ctx, bucket, ocrEngine, fullImagePath, imgBytes); err != nil {
+			log.Printf("[ERROR] processThumbnailMessage OCR %s %v\n", fullImagePath, err)
+		}
+	}
+
 	return nil
 }
 
-func unzipImages(ctx context.Context, ap *api.API, msg model.ImagesWriterMsg) error {
+// writeImageOCR recognizes the text in an image and writes it alongside the image
+func writeImageOCR(ctx context.Context, bucket *blob.Bucket, ocrEngine ocr.Engine, fullImagePath string, imgBytes []byte) error {
+	result, err := ocrEngine.Recognize(ctx, imgBytes)
+	if err != nil {
+		return err
+	}
+	writer, err := bucket.NewWriter(ctx, fullImagePath+model.ImageOCRSuffix, &blob.WriterOptions{
+		ContentType: "application/json",
+	})
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(writer).Encode(result)
+	closeErr := writer.Close()
+	if err != nil || closeErr != nil {
+		return fmt.Errorf("write image OCR %v close %v", err, closeErr)
+	}
+	return nil
+}
+
+// differenceHash returns a 64-bit perceptual hash of an image: each bit records whether a pixel
+// in a 9x8 grayscale reduction of the image is brighter than its neighbor to the right
+func differenceHash(img image.Image) uint64 {
+	small := imaging.Resize(imaging.Grayscale(img), 9, 8, imaging.Box)
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			left := small.Pix[small.PixOffset(x, y)]
+			right := small.Pix[small.PixOffset(x+1, y)]
+			hash <<= 1
+			if left > right {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// sharedImagesPrefix holds images stored by content because their post's own key is still referenced by other posts
+const sharedImagesPrefix = "images/sha256/"
+
+// hashImage computes the content and perceptual hashes of an image and chooses the key under which its bytes are stored.
+// If dedupe is set and an identical image is already stored in the society, the image is stored by reference
+// and stored is false. Otherwise the caller must write the image bytes under the returned StorageKey, which is name
+// unless other images are still stored by reference to different bytes under name.
+// The caller records the hashes once the bytes are stored.
+func hashImage(ctx context.Context, ap *api.API, opts options, postID uint32, path, name string, fileBytes []byte) (in model.ImageHashIn, stored bool, err error) {
+	sum := sha256.Sum256(fileBytes)
+	sha := hex.EncodeToString(sum[:])
+	storageKey := name
+	stored = true
+	if opts.dedupe {
+		existing, err := ap.GetImageHashesBySHA256(ctx, sha)
+		if err != nil {
+			return in, false, err
+		}
+		for _, imageHash := range existing {
+			if imageHash.StorageKey != name {
+				log.Printf("[DEBUG] Storing %s by reference to %s", name, imageHash.StorageKey)
+				storageKey = imageHash.StorageKey
+				stored = false
+				break
+			}
+		}
+	}
+	if stored {
+		// don't overwrite bytes that other images are stored by reference to
+		referencing, err := ap.GetImageHashesByStorageKey(ctx, name)
+		if err != nil {
+			return in, false, err
+		}
+		for _, imageHash := range referencing {
+			if imageHash.SHA256 != sha && (imageHash.Post != postID || imageHash.Path != path) {
+				storageKey = sharedImagesPrefix + sha
+				log.Printf("[DEBUG] Storing %s under %s because post %d references %s", name, storageKey, imageHash.Post, name)
+				break
+			}
+		}
+	}
+	var phash string
+	if img, err := imaging.Decode(bytes.NewReader(fileBytes)); err == nil {
+		phash = model.FormatPHash(differenceHash(img))
+	} else {
+		log.Printf("[INFO] Can't compute perceptual hash for %s: %v", name, err)
+	}
+	return model.NewImageHashIn(postID, path, sha, phash, storageKey), stored, nil
+}
+
+// sendDerivativesMessage asks for the redacted and watermarked derivatives of a post's image to be generated
+func sendDerivativesMessage(ctx context.Context, topic *pubsub.Topic, societyID, postID uint32, path string) error {
+	body, err := json.Marshal(model.ImagesWriterMsg{
+		Action:    model.ImagesWriterActionGenerateDerivatives,
+		SocietyID: societyID,
+		PostID:    postID,
+		ImagePath: path,
+	})
+	if err != nil {
+		return err
+	}
+	return topic.Send(ctx, &pubsub.Message{Body: body})
+}
+
+func unzipImages(ctx context.Context, ap *api.API, opts options, msg model.ImagesWriterMsg) error {
 	// open bucket
 	bucket, err := ap.OpenBucket(ctx, false)
 	if err != nil {
@@ -181,18 +326,55 @@ func unzipImages(ctx context.Context, ap *api.API, msg model.ImagesWriterMsg) er
 					continue
 				}
 				name := fmt.Sprintf(api.ImagesPrefix, msg.PostID) + f.Name
-				fullName := fmt.Sprintf("/%d/%s", msg.SocietyID, name)
+				storageKey := name
+				var imageHash *model.ImageHashIn
+				if opts.detectDuplicates || opts.dedupe {
+					in, stored, errs := hashImage(ctx, ap, opts, msg.PostID, f.Name, name, fileBytes)
+					if errs != nil {
+						log.Printf("[ERROR] Error hashing %s: %v", f.Name, errs)
+						out <- errs
+						continue
+					}
+					if !stored {
+						// the image and its thumbnail already exist under another key, but derivatives are specific to this post
+						if _, errs = ap.AddImageHash(ctx, in); errs != nil {
+							log.Printf("[ERROR] Error recording hashes for %s: %v", f.Name, errs)
+							out <- errs
+							continue
+						}
+						out <- sendDerivativesMessage(ctx, imagesWriterTopic, msg.SocietyID, msg.PostID, f.Name)
+						continue
+					}
+					storageKey = in.StorageKey
+					imageHash = &in
+				}
+				fullName := fmt.Sprintf("/%d/%s", msg.SocietyID, storageKey)
 				errs = bucket.WriteAll(ctx, fullName, fileBytes, nil)
 				if errs != nil {
 					out <- errs
 					continue
 				}
+				// record the hashes only once the bytes they point to are stored
+				if imageHash != nil {
+					if _, errs = ap.AddImageHash(ctx, *imageHash); errs != nil {
+						log.Printf("[ERROR] Error recording hashes for %s: %v", f.Name, errs)
+						out <- errs
+						continue
+					}
+				}
+				if storageKey != name {
+					// thumbnails are generated under the storage key, but derivatives are specific to this post
+					if errs = sendDerivativesMessage(ctx, imagesWriterTopic, msg.SocietyID, msg.PostID, f.Name); errs != nil {
+						out <- errs
+						continue
+					}
+				}
 				// send a message to generate a thumbnail
 				msg := model.ImagesWriterMsg{
 					Action:    model.ImagesWriterActionGenerateThumbnail,
 					SocietyID: msg.SocietyID,
 					PostID:    msg.PostID,
-					ImagePath: name,
+					ImagePath: storageKey,
 				}
 				body, errs := json.Marshal(msg)
 				if errs != nil {
@@ -247,7 +429,7 @@ func unzipImages(ctx context.Context, ap *api.API, msg model.ImagesWriterMsg) er
 	return errs
 }
 
-func processUnzipMessage(ctx context.Context, ap *api.API, msg model.ImagesWriterMsg) error {
+func processUnzipMessage(ctx context.Context, ap *api.API, opts options, msg model.ImagesWriterMsg) error {
 	log.Printf("[DEBUG] ImagesWriter Unzipping PostID: %d", msg.PostID)
 
 	// read post
@@ -269,7 +451,18 @@ func processUnzipMessage(ctx context.Context, ap *api.API, msg model.ImagesWrite
 	}
 
 	// do the work
-	unzipErrs := unzipImages(ctx, ap, msg)
+	ap.AddPostEvent(ctx, post.ID, model.PostEventBody{Type: model.PostEventTypeWorkerStart, Worker: "imageswriter"})
+	start := time.Now()
+	unzipErrs := unzipImages(ctx, ap, opts, msg)
+	finish := model.PostEventBody{
+		Type:     model.PostEventTypeWorkerFinish,
+		Worker:   "imageswriter",
+		Duration: time.Since(start).Milliseconds(),
+	}
+	if unzipErrs != nil {
+		finish.Error = unzipErrs.Error()
+	}
+	ap.AddPostEvent(ctx, post.ID, finish)
 
 	// get post again, in case there were any changes in the meantime
 	post, errs = ap.GetPost(ctx, msg.PostID)
@@ -293,7 +486,7 @@ func processUnzipMessage(ctx context.Context, ap *api.API, msg model.ImagesWrite
 	return errs
 }
 
-func processMessage(ctx context.Context, ap *api.API, rawMsg []byte) error {
+func processMessage(ctx context.Context, ap *api.API, opts options, rawMsg []byte) error {
 	var msg model.ImagesWriterMsg
 	err := json.Unmarshal(rawMsg, &msg)
 	if err != nil {
@@ -305,9 +498,11 @@ func processMessage(ctx context.Context, ap *api.API, rawMsg []byte) error {
 
 	switch msg.Action {
 	case model.ImagesWriterActionUnzip:
-		return processUnzipMessage(sctx, ap, msg)
+		return processUnzipMessage(sctx, ap, opts, msg)
 	case model.ImagesWriterActionGenerateThumbnail:
-		return processThumbnailMessage(sctx, ap, msg)
+		return processThumbnailMessage(sctx, ap, opts.ocrEngine, msg)
+	case model.ImagesWriterActionGenerateDerivatives:
+		return processDerivativesMessage(sctx, ap, msg)
 	default:
 		log.Printf("[ERROR] Discarding message with unknown action '%s': %v", string(rawMsg), err)
 		return nil // Don't return an error, because parsing will never succeed
@@ -320,14 +515,15 @@ func isRetryable(err error) bool {
 }
 
 type lambdaHandler struct {
-	ap *api.API
+	ap   *api.API
+	opts options
 }
 
 func (h lambdaHandler) handler(ctx context.Context, sqsEvent events.SQSEvent) error {
 	var err error
 	for _, message := range sqsEvent.Records {
 		// process message
-		err = processMessage(ctx, h.ap, []byte(message.Body))
+		err = processMessage(ctx, h.ap, h.opts, []byte(message.Body))
 		if err != nil {
 			log.Printf("[ERROR] Error processing message %v", err)
 			// TODO shouldn't this be break so we fail as soon as a message fails?
@@ -401,7 +597,10 @@ func main() {
 		log.Printf("[INFO] Connected to %s\n", dbURL.Host)
 		p := persist.NewPostgresPersister(db)
 		ap.
-			PostPersister(p)
+			CollectionPersister(p).
+			PostPersister(p).
+			ImageHashPersister(p).
+			PostEventPersister(p)
 		// ImagePersister(p)
 		log.Print("[INFO] Using PostgresPersister")
 	} else {
@@ -414,14 +613,30 @@ func main() {
 			log.Fatalf("[FATAL] Error creating DynamoDB persister: %v", err)
 		}
 		ap.
+			CollectionPersister(p).
 			PostPersister(p)
+		// ImageHashPersister(p)
+		// PostEventPersister(p)
 		// ImagePersister(p)
 		log.Print("[INFO] Using DynamoDBPersister")
+		if env.DetectDuplicateImages || env.DedupeImages {
+			log.Fatal("[FATAL] DETECT_DUPLICATE_IMAGES and DEDUPE_IMAGES require DATABASE_URL")
+		}
+	}
+
+	ocrEngine, err := ocr.NewEngine(env.OCREngine, env.TesseractPath, env.OCRLanguage)
+	if err != nil {
+		log.Fatalf("[FATAL] Error creating OCR engine: %v", err)
+	}
+	opts := options{
+		ocrEngine:        ocrEngine,
+		detectDuplicates: env.DetectDuplicateImages,
+		dedupe:           env.DedupeImages,
 	}
 
 	if env.IsLambda {
 		log.Println("[DEBUG] using lambdaHandler")
-		h := lambdaHandler{ap: ap}
+		h := lambdaHandler{ap: ap, opts: opts}
 		lambda.Start(h.handler)
 	} else {
 		log.Println("[DEBUG] Listening to queue")
@@ -440,7 +655,7 @@ func main() {
 			}
 			log.Printf("[DEBUG] Received message '%s'", string(msg.Body))
 			// process message
-			errs := processMessage(ctx, ap, msg.Body)
+			errs := processMessage(ctx, ap, opts, msg.Body)
 			if errs != nil {
 				log.Printf("[ERROR] Processing message %v\n", errs)
 				continue
@@ -468,6 +683,11 @@ type Env struct {
 	BlobStoreDisableSSL    bool   `env:"BLOB_STORE_DISABLE_SSL"`
 	PubSubRecordsWriterURL string `env:"PUB_SUB_RECORDSWRITER_URL" validate:"required,url"`
 	PubSubImagesWriterURL  string `env:"PUB_SUB_IMAGESWRITER_URL" validate:"required,url"`
+	OCREngine              string `env:"OCR_ENGINE" validate:"omitempty,eq=none|eq=tesseract"`
+	TesseractPath          string `env:"TESSERACT_PATH"`
+	OCRLanguage            string `env:"OCR_LANGUAGE"`
+	DetectDuplicateImages  bool   `env:"DETECT_DUPLICATE_IMAGES"`
+	DedupeImages           bool   `env:"DEDUPE_IMAGES"`
 }
 
 // ParseEnv parses and validates environment variables and stores them in the Env structure
@@ -495,6 +715,8 @@ func ParseEnv() (*Env, error) {
 				errs += fmt.Sprintf("  Invalid PUB_SUB_RECORDSWRITER_URL: '%v'is not a valid URL\n", fe.Value())
 			case "PUB_SUB_IMAGESWRITER_URL":
 				errs += fmt.Sprintf("  Invalid PUB_SUB_IMAGESWRITER_URL: '%v'is not a valid URL\n", fe.Value())
+			case "OCR_ENGINE":
+				errs += fmt.Sprintf("  Invalid OCR_ENGINE: '%v', valid values are 'none' or 'tesseract'\n", fe.Value())
 			}
 		}
 		return nil, errors.New(errs)
@@ -4,7 +4,10 @@ import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"image"
+	"image/color"
 	"io"
 	"io/ioutil"
 	"log"
@@ -19,6 +22,7 @@ import (
 	"github.com/ourrootsorg/cms-server/model"
 	"github.com/ourrootsorg/cms-server/persist"
 	"gocloud.dev/blob"
+	"gocloud.dev/blob/memblob"
 	"gocloud.dev/postgres"
 
 	"github.com/ourrootsorg/cms-server/api"
@@ -139,6 +143,7 @@ func TestImagesWriter(t *testing.T) {
 		suffix := strings.TrimPrefix(obj.Key, prefix)
 		suffix = strings.TrimSuffix(suffix, model.ImageDimensionsSuffix)
 		suffix = strings.TrimSuffix(suffix, model.ImageThumbnailSuffix)
+		suffix = strings.TrimSuffix(suffix, model.ImageOCRSuffix)
 		assert.True(t, zipNames[suffix], suffix)
 	}
 
@@ -299,6 +304,123 @@ func TestPostImage(t *testing.T) {
 	assert.Nil(t, errors)
 }
 
+type fakeOCREngine struct {
+	result *model.ImageOCR
+}
+
+func (e fakeOCREngine) Recognize(ctx context.Context, image []byte) (*model.ImageOCR, error) {
+	return e.result, nil
+}
+
+func TestWriteImageOCR(t *testing.T) {
+	ctx := context.TODO()
+	bucket := memblob.OpenBucket(nil)
+	defer bucket.Close()
+
+	expected := &model.ImageOCR{
+		Text: "John Smith",
+		Words: []model.OCRWord{
+			{Text: "John", Left: 10, Top: 20, Width: 80, Height: 30, Confidence: 96},
+			{Text: "Smith", Left: 100, Top: 20, Width: 120, Height: 30, Confidence: 91},
+		},
+	}
+	imagePath := "/1/images/1/page1.jpg"
+	err := writeImageOCR(ctx, bucket, fakeOCREngine{result: expected}, imagePath, []byte("image"))
+	assert.NoError(t, err)
+
+	b, err := bucket.ReadAll(ctx, imagePath+model.ImageOCRSuffix)
+	assert.NoError(t, err)
+	var actual model.ImageOCR
+	err = json.Unmarshal(b, &actual)
+	assert.NoError(t, err)
+	assert.Equal(t, *expected, actual)
+}
+
+func TestDifferenceHash(t *testing.T) {
+	gradient := func(brightness int, flip bool) image.Image {
+		img := image.NewGray(image.Rect(0, 0, 90, 80))
+		for y := 0; y < 80; y++ {
+			for x := 0; x < 90; x++ {
+				v := x*2 + y + brightness
+				if flip {
+					v = (89-x)*2 + y + brightness
+				}
+				img.SetGray(x, y, color.Gray{Y: uint8(v)})
+			}
+		}
+		return img
+	}
+	original := model.FormatPHash(differenceHash(gradient(0, false)))
+	brighter := model.FormatPHash(differenceHash(gradient(20, false)))
+	flipped := model.FormatPHash(differenceHash(gradient(0, true)))
+	assert.LessOrEqual(t, model.PHashDistance(original, brighter), model.NearDuplicateDistance)
+	assert.Greater(t, model.PHashDistance(original, flipped), model.NearDuplicateDistance)
+}
+
+func TestRedactImage(t *testing.T) {
+	// vertical stripes, so blurring a region changes it
+	img := image.NewGray(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			img.SetGray(x, y, color.Gray{Y: uint8((x % 2) * 255)})
+		}
+	}
+	redacted := redactImage(img, []model.ImageRedaction{{Path: "a.jpg", Left: 0.5, Top: 0, Width: 0.5, Height: 0.5}})
+	// outside the redaction is unchanged
+	assert.Equal(t, uint8(255), redacted.NRGBAAt(1, 75).R)
+	assert.Equal(t, uint8(0), redacted.NRGBAAt(2, 75).R)
+	// inside the redaction the stripes are averaged away
+	for _, x := range []int{150, 151} {
+		r := redacted.NRGBAAt(x, 25).R
+		assert.True(t, r > 64 && r < 192, "pixel %d is %d", x, r)
+	}
+}
+
+func TestWatermarkImage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 300, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			img.SetGray(x, y, color.Gray{Y: 128})
+		}
+	}
+	watermarked := watermarkImage(img, &model.ImageWatermark{Text: "Society", Opacity: 1})
+	changed := 0
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			if watermarked.NRGBAAt(x, y).R != 128 {
+				changed++
+			}
+		}
+	}
+	assert.True(t, changed > 0)
+	assert.Equal(t, img.Bounds(), watermarked.Bounds())
+}
+
+func TestWritePublicImages(t *testing.T) {
+	ctx := context.TODO()
+	bucket := memblob.OpenBucket(nil)
+	defer bucket.Close()
+	img := image.NewGray(image.Rect(0, 0, 320, 200))
+	imagePath := "/1/images/1/page1.jpg"
+
+	err := writePublicImages(ctx, bucket, img, imagePath, nil, &model.ImageWatermark{Text: "Society"})
+	assert.NoError(t, err)
+	for _, key := range []string{imagePath + model.ImagePublicSuffix, imagePath + model.ImageThumbnailSuffix + model.ImagePublicSuffix} {
+		exists, err := bucket.Exists(ctx, key)
+		assert.NoError(t, err)
+		assert.True(t, exists, key)
+	}
+
+	// removing the watermark removes the derivatives
+	err = writePublicImages(ctx, bucket, img, imagePath, nil, nil)
+	assert.NoError(t, err)
+	for _, key := range []string{imagePath + model.ImagePublicSuffix, imagePath + model.ImageThumbnailSuffix + model.ImagePublicSuffix} {
+		exists, err := bucket.Exists(ctx, key)
+		assert.NoError(t, err)
+		assert.False(t, exists, key)
+	}
+}
+
 func createTestCategory(ctx context.Context, t *testing.T, p model.CategoryPersister) *model.Category {
 	in, err := model.NewCategoryIn("Test")
 	assert.NoError(t, err)
@@ -323,3 +445,67 @@ func deleteTestCollection(ctx context.Context, t *testing.T, p model.CollectionP
 	e := p.DeleteCollection(ctx, collection.ID)
 	assert.Nil(t, e)
 }
+
+// imageHashMock holds image hashes in memory
+type imageHashMock struct {
+	imageHashes []model.ImageHash
+}
+
+func (im *imageHashMock) SelectImageHashes(ctx context.Context) ([]model.ImageHash, error) {
+	return im.imageHashes, nil
+}
+func (im *imageHashMock) SelectImageHashesForPost(ctx context.Context, postID uint32) ([]model.ImageHash, error) {
+	return nil, fmt.Errorf("SelectImageHashesForPost not implemented")
+}
+func (im *imageHashMock) SelectImageHashesBySHA256(ctx context.Context, sha256 string) ([]model.ImageHash, error) {
+	var imageHashes []model.ImageHash
+	for _, imageHash := range im.imageHashes {
+		if imageHash.SHA256 == sha256 {
+			imageHashes = append(imageHashes, imageHash)
+		}
+	}
+	return imageHashes, nil
+}
+func (im *imageHashMock) SelectImageHashesByStorageKey(ctx context.Context, storageKey string) ([]model.ImageHash, error) {
+	var imageHashes []model.ImageHash
+	for _, imageHash := range im.imageHashes {
+		if imageHash.StorageKey == storageKey {
+			imageHashes = append(imageHashes, imageHash)
+		}
+	}
+	return imageHashes, nil
+}
+func (im *imageHashMock) SelectOneImageHash(ctx context.Context, postID uint32, path string) (*model.ImageHash, error) {
+	return nil, fmt.Errorf("SelectOneImageHash not implemented")
+}
+func (im *imageHashMock) UpsertImageHash(ctx context.Context, in model.ImageHashIn) (*model.ImageHash, error) {
+	return nil, fmt.Errorf("UpsertImageHash not implemented")
+}
+func (im *imageHashMock) DeleteImageHashesForPost(ctx context.Context, postID uint32) error {
+	return fmt.Errorf("DeleteImageHashesForPost not implemented")
+}
+
+func TestHashImage(t *testing.T) {
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 1)
+	testAPI, err := api.NewAPI()
+	assert.NoError(t, err)
+	defer testAPI.Close()
+	// post 6 is stored by reference to post 5's image
+	testAPI.ImageHashPersister(&imageHashMock{imageHashes: []model.ImageHash{
+		{ImageHashIn: model.NewImageHashIn(5, "a.jpg", "old", "", "images/5/a.jpg")},
+		{ImageHashIn: model.NewImageHashIn(6, "b.jpg", "old", "", "images/5/a.jpg")},
+	}})
+	opts := options{dedupe: true}
+
+	// a new image under an unreferenced key is stored under the post's own key
+	in, stored, err := hashImage(ctx, testAPI, opts, 7, "c.jpg", "images/7/c.jpg", []byte("new"))
+	assert.NoError(t, err)
+	assert.True(t, stored)
+	assert.Equal(t, "images/7/c.jpg", in.StorageKey)
+
+	// re-uploading post 5's image doesn't overwrite the bytes post 6 references
+	in, stored, err = hashImage(ctx, testAPI, opts, 5, "a.jpg", "images/5/a.jpg", []byte("new"))
+	assert.NoError(t, err)
+	assert.True(t, stored)
+	assert.Equal(t, sharedImagesPrefix+in.SHA256, in.StorageKey)
+}
@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+)
+
+// LogMailer writes messages to a file instead of sending them, or to the log if there is no file;
+// it is meant for development and tests
+type LogMailer struct {
+	path string
+	from string
+	mu   sync.Mutex
+}
+
+// NewLogMailer returns a mailer that appends messages to the file at path, or logs them if path is empty
+func NewLogMailer(path, from string) *LogMailer {
+	return &LogMailer{path: path, from: from}
+}
+
+// Send writes a message
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	if m.path == "" {
+		log.Printf("[INFO] mail to %s subject %q\n%s", msg.To, msg.Subject, msg.Body)
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(formatMessage(m.from, msg), "\r\n\r\n"...))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
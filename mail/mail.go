@@ -0,0 +1,33 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+)
+
+// Message is an email message
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends email messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewMailer returns the mailer with the specified name, or nil if name is empty or "none".
+// smtpURL is used by the smtp mailer, and logFile by the log mailer.
+func NewMailer(name, smtpURL, from, logFile string) (Mailer, error) {
+	switch name {
+	case "", "none":
+		return nil, nil
+	case "smtp":
+		return NewSMTPMailer(smtpURL, from)
+	case "log":
+		return NewLogMailer(logFile, from), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %s", name)
+	}
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// APIKeyPersister defines methods needed to persist API keys
+type APIKeyPersister interface {
+	SelectAPIKeys(ctx context.Context) ([]APIKey, error)
+	SelectAPIKeyByHash(ctx context.Context, hash string) (*APIKey, error)
+	InsertAPIKey(ctx context.Context, in APIKeyIn) (*APIKey, error)
+	UpdateAPIKeyLastUsed(ctx context.Context, id uint32, lastUsedTime time.Time) error
+	DeleteAPIKey(ctx context.Context, id uint32) error
+}
+
+// APIKeyBody is the JSON part of the APIKey object
+type APIKeyBody struct {
+	Name  string    `json:"name" validate:"required"`
+	Level AuthLevel `json:"level" validate:"required,min=1,max=4"`
+}
+
+// Value makes APIKeyBody implement the driver.Valuer interface.
+func (cb APIKeyBody) Value() (driver.Value, error) {
+	return json.Marshal(cb)
+}
+
+// Scan makes APIKeyBody implement the sql.Scanner interface.
+func (cb *APIKeyBody) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, &cb)
+}
+
+// APIKeyIn is the payload to create an APIKey
+type APIKeyIn struct {
+	APIKeyBody
+	// Prefix is the start of the key, shown so administrators can tell keys apart
+	Prefix string `json:"prefix" validate:"required"`
+	// Hash is the SHA-256 hash of the key; the key itself is never stored
+	Hash      string `json:"-" validate:"required"`
+	SocietyID uint32 `json:"societyId" validate:"required"`
+	// CreatedBy is the ID of the user who created the key
+	CreatedBy uint32 `json:"createdBy"`
+}
+
+// APIKey lets a script act on a society at a fixed AuthLevel without an OIDC token
+type APIKey struct {
+	ID uint32 `json:"id,omitempty" example:"999" validate:"required,omitempty"`
+	APIKeyIn
+	LastUsedTime   *time.Time `json:"last_used_time,omitempty"`
+	InsertTime     time.Time  `json:"insert_time,omitempty"`
+	LastUpdateTime time.Time  `json:"last_update_time,omitempty"`
+}
+
+// NewAPIKey constructs an APIKey from an id and an APIKeyIn
+func NewAPIKey(id uint32, in APIKeyIn) APIKey {
+	now := time.Now()
+	return APIKey{
+		ID:             id,
+		APIKeyIn:       in,
+		InsertTime:     now,
+		LastUpdateTime: now,
+	}
+}
+
+// APIKeySecret is returned when an APIKey is created; it is the only time the key itself is available
+type APIKeySecret struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// AuditPersister defines methods needed to persist the audit log
+type AuditPersister interface {
+	SelectAuditEvents(ctx context.Context, filter AuditFilter) ([]AuditEvent, error)
+	InsertAuditEvent(ctx context.Context, in AuditEventIn) (*AuditEvent, error)
+}
+
+// AuditAction identifies what was done to an entity
+type AuditAction string
+
+// Audit actions; publish and unpublish are post updates that request a change to the post's publication.
+// Deleting a society, collection or post moves it to the trash when the trash is configured; restore takes it out again.
+const (
+	AuditActionCreate    AuditAction = "create"
+	AuditActionUpdate    AuditAction = "update"
+	AuditActionDelete    AuditAction = "delete"
+	AuditActionPublish   AuditAction = "publish"
+	AuditActionUnpublish AuditAction = "unpublish"
+	AuditActionOverride  AuditAction = "override"
+	AuditActionMerge     AuditAction = "merge"
+	AuditActionResolve   AuditAction = "resolve"
+	AuditActionAccept    AuditAction = "accept"
+	AuditActionRestore   AuditAction = "restore"
+)
+
+// Audited entity types
+const (
+	AuditEntityCategory        = "category"
+	AuditEntityCollection      = "collection"
+	AuditEntityPost            = "post"
+	AuditEntityRecord          = "record"
+	AuditEntityRecordHousehold = "recordHousehold"
+	AuditEntityPlace           = "place"
+	AuditEntityPlaceReview     = "placeReview"
+	AuditEntityNameVariants    = "nameVariants"
+	AuditEntitySociety         = "society"
+	AuditEntitySocietyUser     = "societyUser"
+	AuditEntityInvitation      = "invitation"
+	AuditEntityAPIKey          = "apiKey"
+	AuditEntitySearchKey       = "searchKey"
+	AuditEntityCollectionGrant = "collectionGrant"
+	AuditEntitySocietyTemplate = "societyTemplate"
+)
+
+// AuditChange is the old and new JSON value of a changed field; From is empty for created fields and To for removed ones
+type AuditChange struct {
+	From json.RawMessage `json:"from,omitempty"`
+	To   json.RawMessage `json:"to,omitempty"`
+}
+
+// AuditEventBody is the JSON body of an AuditEvent
+type AuditEventBody struct {
+	// User is the ID of the user who made the change
+	User uint32 `json:"user,omitempty"`
+	// APIKey is the ID of the API key the change was made with, if any
+	APIKey   uint32      `json:"apiKey,omitempty"`
+	Entity   string      `json:"entity" validate:"required"`
+	EntityID uint32      `json:"entityId,omitempty"`
+	Action   AuditAction `json:"action" validate:"required"`
+	// Name identifies entities that don't have a numeric ID, such as name variants and search keys
+	Name      string                 `json:"name,omitempty"`
+	Changes   map[string]AuditChange `json:"changes,omitempty"`
+	RequestID string                 `json:"requestId,omitempty"`
+}
+
+// Value makes AuditEventBody implement the driver.Valuer interface.
+func (cb AuditEventBody) Value() (driver.Value, error) {
+	return json.Marshal(cb)
+}
+
+// Scan makes AuditEventBody implement the sql.Scanner interface.
+func (cb *AuditEventBody) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, &cb)
+}
+
+// AuditEventIn is the payload to create an AuditEvent
+type AuditEventIn struct {
+	AuditEventBody
+	SocietyID uint32 `json:"societyId" validate:"required"`
+}
+
+// AuditEvent is an entry in a society's audit log; events are never updated or deleted
+type AuditEvent struct {
+	ID uint32 `json:"id,omitempty" example:"999" validate:"required,omitempty" dynamodbav:"pk,string"`
+	// Type and SortKey place the event in its society's partition of the DynamoDB index
+	Type    string `json:"-" dynamodbav:"sk"`
+	SortKey string `json:"-" dynamodbav:"altSort"`
+	AuditEventIn
+	InsertTime time.Time `json:"insert_time,omitempty"`
+}
+
+// AuditFilter selects audit events; zero-valued fields don't filter.
+// Events are returned newest first, and Before pages through them by returning only events with lower IDs.
+type AuditFilter struct {
+	User     uint32
+	Entity   string
+	EntityID uint32
+	Action   AuditAction
+	From     *time.Time
+	To       *time.Time
+	Before   uint32
+	Limit    int
+}
+
+// Matches returns true if the event passes the filter, ignoring Before and Limit
+func (f AuditFilter) Matches(event AuditEvent) bool {
+	return (f.User == 0 || event.User == f.User) &&
+		(f.Entity == "" || event.Entity == f.Entity) &&
+		(f.EntityID == 0 || event.EntityID == f.EntityID) &&
+		(f.Action == "" || event.Action == f.Action) &&
+		(f.From == nil || !event.InsertTime.Before(*f.From)) &&
+		(f.To == nil || event.InsertTime.Before(*f.To))
+}
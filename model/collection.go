@@ -49,13 +49,40 @@ type CollectionBody struct {
 	Location                    string              `json:"location,omitempty"`
 	CollectionType              CollectionType      `json:"type" validate:"required"`
 	Fields                      []CollectionField   `json:"fields"`
-	Mappings                    []CollectionMapping `json:"mappings"`
+	Mappings                    []CollectionMapping `json:"mappings" validate:"dive"`
 	CitationTemplate            string              `json:"citation_template,omitempty"`
 	ImagePathHeader             string              `json:"imagePathHeader,omitempty"`
 	HouseholdNumberHeader       string              `json:"householdNumberHeader,omitempty"`
 	HouseholdRelationshipHeader string              `json:"householdRelationshipHeader,omitempty"`
 	GenderHeader                string              `json:"genderHeader,omitempty"`
 	PrivacyLevel                PrivacyLevel        `json:"privacyLevel"`
+	// Watermark is applied to the collection's images when they are served to users who are not logged in
+	Watermark *ImageWatermark `json:"watermark,omitempty" validate:"omitempty"`
+	// DateOrder is how numeric dates like 5/3/1900 are written in the collection's records
+	DateOrder DateOrder `json:"dateOrder,omitempty" validate:"omitempty,oneof=DMY MDY YMD auto"`
+}
+
+// DateOrder is the order of the day, month and year in numeric dates
+type DateOrder string
+
+const (
+	// DateOrderUnknown treats numeric dates whose day could also be a month as ambiguous
+	DateOrderUnknown DateOrder = ""
+	DateOrderDMY     DateOrder = "DMY"
+	DateOrderMDY     DateOrder = "MDY"
+	DateOrderYMD     DateOrder = "YMD"
+	// DateOrderAuto detects the order from the values in each date column when records are loaded
+	DateOrderAuto DateOrder = "auto"
+)
+
+// DefaultWatermarkOpacity is used when a watermark doesn't specify an opacity
+const DefaultWatermarkOpacity = 0.3
+
+// ImageWatermark describes text tiled across an image
+type ImageWatermark struct {
+	Text string `json:"text" validate:"required"`
+	// Opacity ranges from 0 (use the default) to 1 (opaque)
+	Opacity float64 `json:"opacity,omitempty" validate:"gte=0,lte=1"`
 }
 
 type CollectionField struct {
@@ -67,6 +94,8 @@ type CollectionMapping struct {
 	DbField string `json:"dbField"`
 	IxRole  string `json:"ixRole"`
 	IxField string `json:"ixField"`
+	// NameOrder is how full names are written when IxField is name: surnameFirst, surnameLast, or empty to detect the order
+	NameOrder string `json:"nameOrder,omitempty" validate:"omitempty,oneof=surnameFirst surnameLast"`
 }
 
 // Value makes CollectionBody implement the driver.Valuer interface.
@@ -0,0 +1,49 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermissions(t *testing.T) {
+	grants := []model.CollectionGrant{
+		{CollectionGrantIn: model.CollectionGrantIn{CollectionGrantBody: model.CollectionGrantBody{Level: model.AuthContributor}, CollectionID: 1}},
+		{CollectionGrantIn: model.CollectionGrantIn{CollectionGrantBody: model.CollectionGrantBody{Level: model.AuthGuest}, CollectionID: 2}},
+		{CollectionGrantIn: model.CollectionGrantIn{CollectionGrantBody: model.CollectionGrantBody{Level: model.AuthGuest}, CategoryID: 10}},
+		{CollectionGrantIn: model.CollectionGrantIn{CollectionGrantBody: model.CollectionGrantBody{Level: model.AuthEditor}, CategoryID: 11}},
+	}
+	p := model.NewPermissions(model.AuthReader, grants)
+	assert.True(t, p.HasGrants())
+	assert.Equal(t, model.AuthLevel(model.AuthEditor), p.MaxLevel())
+
+	tests := []struct {
+		collectionID uint32
+		categories   []uint32
+		level        model.AuthLevel
+	}{
+		{1, nil, model.AuthContributor},
+		// a collection grant wins over category grants
+		{1, []uint32{11}, model.AuthContributor},
+		// grants can lower the society level
+		{2, []uint32{11}, model.AuthGuest},
+		{3, []uint32{10}, model.AuthGuest},
+		// the highest category grant wins
+		{3, []uint32{10, 11}, model.AuthEditor},
+		{3, []uint32{12}, model.AuthReader},
+		{3, nil, model.AuthReader},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.level, p.CollectionLevel(test.collectionID, test.categories), "%d %v", test.collectionID, test.categories)
+	}
+
+	// admins aren't affected by grants
+	p = model.NewPermissions(model.AuthAdmin, grants)
+	assert.False(t, p.HasGrants())
+	assert.Equal(t, model.AuthLevel(model.AuthAdmin), p.CollectionLevel(2, nil))
+
+	p = model.NewPermissions(model.AuthContributor, nil)
+	assert.False(t, p.HasGrants())
+	assert.Equal(t, model.AuthLevel(model.AuthContributor), p.MaxLevel())
+}
@@ -0,0 +1,132 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math/bits"
+	"strconv"
+	"time"
+)
+
+// NearDuplicateDistance is the maximum number of differing perceptual hash bits for two images to be considered near-duplicates
+const NearDuplicateDistance = 6
+
+// ImageHashPersister defines methods needed to persist image hashes
+type ImageHashPersister interface {
+	SelectImageHashes(ctx context.Context) ([]ImageHash, error)
+	SelectImageHashesForPost(ctx context.Context, postID uint32) ([]ImageHash, error)
+	SelectImageHashesBySHA256(ctx context.Context, sha256 string) ([]ImageHash, error)
+	SelectImageHashesByStorageKey(ctx context.Context, storageKey string) ([]ImageHash, error)
+	SelectOneImageHash(ctx context.Context, postID uint32, path string) (*ImageHash, error)
+	UpsertImageHash(ctx context.Context, in ImageHashIn) (*ImageHash, error)
+	DeleteImageHashesForPost(ctx context.Context, postID uint32) error
+}
+
+// ImageHashBody is the JSON body of an ImageHash
+type ImageHashBody struct {
+	SHA256 string `json:"sha256" validate:"required"`
+	// PHash is a 64-bit perceptual (difference) hash, hex-encoded
+	PHash string `json:"phash"`
+	// StorageKey is the key of the blob that holds the image bytes; it is a different post's image when storage has been deduplicated
+	StorageKey string `json:"storageKey" validate:"required"`
+}
+
+// Value makes ImageHashBody implement the driver.Valuer interface.
+func (cb ImageHashBody) Value() (driver.Value, error) {
+	return json.Marshal(cb)
+}
+
+// Scan makes ImageHashBody implement the sql.Scanner interface.
+func (cb *ImageHashBody) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, &cb)
+}
+
+// ImageHashIn is the payload to create or update an ImageHash
+type ImageHashIn struct {
+	ImageHashBody
+	Post uint32 `json:"post" example:"999" validate:"required"`
+	Path string `json:"path" validate:"required"`
+}
+
+// ImageHash holds the content and perceptual hashes of an image in a post
+type ImageHash struct {
+	ID uint32 `json:"id,omitempty" example:"999" validate:"required,omitempty"`
+	ImageHashIn
+	InsertTime     time.Time `json:"insert_time,omitempty"`
+	LastUpdateTime time.Time `json:"last_update_time,omitempty"`
+}
+
+// NewImageHashIn constructs an ImageHashIn
+func NewImageHashIn(postID uint32, path, sha256, phash, storageKey string) ImageHashIn {
+	return ImageHashIn{
+		ImageHashBody: ImageHashBody{
+			SHA256:     sha256,
+			PHash:      phash,
+			StorageKey: storageKey,
+		},
+		Post: postID,
+		Path: path,
+	}
+}
+
+// ImageDuplicate reports that an image in a post duplicates another image in the society
+type ImageDuplicate struct {
+	Path          string `json:"path"`
+	DuplicatePost uint32 `json:"duplicatePost"`
+	DuplicatePath string `json:"duplicatePath"`
+	Exact         bool   `json:"exact"`
+	Distance      int    `json:"distance"`
+	Deduplicated  bool   `json:"deduplicated"`
+}
+
+// FormatPHash hex-encodes a perceptual hash
+func FormatPHash(hash uint64) string {
+	return strconv.FormatUint(hash, 16)
+}
+
+// PHashDistance returns the number of bits that differ between two hex-encoded perceptual hashes, or -1 if either is invalid
+func PHashDistance(a, b string) int {
+	ha, err := strconv.ParseUint(a, 16, 64)
+	if err != nil {
+		return -1
+	}
+	hb, err := strconv.ParseUint(b, 16, 64)
+	if err != nil {
+		return -1
+	}
+	return bits.OnesCount64(ha ^ hb)
+}
+
+// FindImageDuplicates compares the images in a post against the images in the society and returns exact and near duplicates
+func FindImageDuplicates(postHashes, societyHashes []ImageHash) []ImageDuplicate {
+	result := []ImageDuplicate{}
+	for _, ph := range postHashes {
+		for _, sh := range societyHashes {
+			if sh.Post == ph.Post && sh.Path == ph.Path {
+				continue
+			}
+			exact := sh.SHA256 == ph.SHA256
+			distance := PHashDistance(ph.PHash, sh.PHash)
+			if exact {
+				distance = 0
+			} else if distance < 0 || distance > NearDuplicateDistance {
+				continue
+			}
+			result = append(result, ImageDuplicate{
+				Path:          ph.Path,
+				DuplicatePost: sh.Post,
+				DuplicatePath: sh.Path,
+				Exact:         exact,
+				Distance:      distance,
+				Deduplicated:  ph.StorageKey == sh.StorageKey,
+			})
+		}
+	}
+	return result
+}
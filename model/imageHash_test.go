@@ -0,0 +1,34 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPHashDistance(t *testing.T) {
+	assert.Equal(t, 0, model.PHashDistance("ff00", "ff00"))
+	assert.Equal(t, 1, model.PHashDistance("ff00", "ff01"))
+	assert.Equal(t, 16, model.PHashDistance(model.FormatPHash(0xffff), model.FormatPHash(0)))
+	assert.Equal(t, -1, model.PHashDistance("xyz", "ff00"))
+}
+
+func TestFindImageDuplicates(t *testing.T) {
+	postHashes := []model.ImageHash{
+		{ImageHashIn: model.NewImageHashIn(2, "a.jpg", "aaa", "ff00", "images/2/a.jpg")},
+		{ImageHashIn: model.NewImageHashIn(2, "b.jpg", "bbb", "f0f0", "images/1/x.jpg")},
+		{ImageHashIn: model.NewImageHashIn(2, "c.jpg", "ccc", "0000", "images/2/c.jpg")},
+	}
+	societyHashes := append([]model.ImageHash{
+		{ImageHashIn: model.NewImageHashIn(1, "x.jpg", "bbb", "f0f0", "images/1/x.jpg")},
+		{ImageHashIn: model.NewImageHashIn(1, "y.jpg", "yyy", "ff03", "images/1/y.jpg")},
+		{ImageHashIn: model.NewImageHashIn(1, "z.jpg", "zzz", "00ff", "images/1/z.jpg")},
+	}, postHashes...)
+
+	dups := model.FindImageDuplicates(postHashes, societyHashes)
+	assert.Equal(t, []model.ImageDuplicate{
+		{Path: "a.jpg", DuplicatePost: 1, DuplicatePath: "y.jpg", Exact: false, Distance: 2},
+		{Path: "b.jpg", DuplicatePost: 1, DuplicatePath: "x.jpg", Exact: true, Distance: 0, Deduplicated: true},
+	}, dups)
+}
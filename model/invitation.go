@@ -14,6 +14,8 @@ type InvitationPersister interface {
 	SelectInvitations(ctx context.Context) ([]Invitation, error)
 	SelectOneInvitation(ctx context.Context, id uint32) (*Invitation, error)
 	InsertInvitation(ctx context.Context, in InvitationIn) (*Invitation, error)
+	// IncrementInvitationUses records a use of an invitation; it returns ErrNotFound if the invitation already has maxUses uses
+	IncrementInvitationUses(ctx context.Context, id uint32, maxUses int) error
 	DeleteInvitation(ctx context.Context, id uint32) error
 }
 
@@ -21,6 +23,12 @@ type InvitationPersister interface {
 type InvitationBody struct {
 	Level AuthLevel `json:"level" validate:"required"`
 	Name  string    `json:"name" validate:"required"`
+	// Email, if set, is the only address that can accept the invitation, and the address it is sent to
+	Email string `json:"email,omitempty" validate:"omitempty,email"`
+	// ExpiresAt is when the invitation can no longer be accepted
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// MaxUses is how many people can accept the invitation; 0 means 1
+	MaxUses int `json:"maxUses,omitempty" validate:"min=0"`
 }
 
 // Value makes InvitationBody implement the driver.Valuer interface.
@@ -61,6 +69,8 @@ type Invitation struct {
 	ID uint32 `json:"id,omitempty" example:"999" validate:"required,omitempty"`
 	//Type string `json:"-" dynamodbav:"sk"`
 	InvitationIn
+	// Uses is how many times the invitation has been accepted
+	Uses           int       `json:"uses"`
 	InsertTime     time.Time `json:"insert_time,omitempty"`
 	LastUpdateTime time.Time `json:"last_update_time,omitempty"`
 }
@@ -75,3 +85,16 @@ func NewInvitation(id uint32, in InvitationIn) Invitation {
 		LastUpdateTime: now,
 	}
 }
+
+// AllowedUses returns how many times the invitation can be accepted
+func (i Invitation) AllowedUses() int {
+	if i.MaxUses <= 0 {
+		return 1
+	}
+	return i.MaxUses
+}
+
+// Expired returns true if the invitation can't be accepted anymore, either because it has expired or been used up
+func (i Invitation) Expired(now time.Time) bool {
+	return (i.ExpiresAt != nil && !now.Before(*i.ExpiresAt)) || i.Uses >= i.AllowedUses()
+}
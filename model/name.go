@@ -12,11 +12,43 @@ const (
 	SurnameType
 )
 
+func (nt NameType) String() string {
+	switch nt {
+	case GivenType:
+		return "given"
+	case SurnameType:
+		return "surname"
+	default:
+		return "ERROR"
+	}
+}
+
+// ParseNameType returns the name type for "given" or "surname"
+func ParseNameType(s string) (NameType, bool) {
+	switch s {
+	case "given":
+		return GivenType, true
+	case "surname":
+		return SurnameType, true
+	default:
+		return 0, false
+	}
+}
+
 // NamePersister defines methods needed to persist places
 type NamePersister interface {
 	SelectNameVariants(ctx context.Context, nameType NameType, name string) (*NameVariants, error)
 }
 
+// NameAdminPersister defines methods needed to maintain a society's name dictionary, which is layered over the global one
+// All methods are scoped to the society in the context
+type NameAdminPersister interface {
+	SelectSocietyNameVariants(ctx context.Context, nameType NameType, name string) (*SocietyNameVariants, error)
+	SelectAllSocietyNameVariants(ctx context.Context, nameType NameType) ([]SocietyNameVariants, error)
+	UpsertSocietyNameVariants(ctx context.Context, nameType NameType, in SocietyNameVariants) (*SocietyNameVariants, error)
+	DeleteSocietyNameVariants(ctx context.Context, nameType NameType, name string) error
+}
+
 // NameVariants holds name variants
 type NameVariants struct {
 	Name           string      `json:"name" dynamodbav:"pk"`
@@ -25,3 +57,20 @@ type NameVariants struct {
 	InsertTime     time.Time   `json:"insert_time,omitempty"`
 	LastUpdateTime time.Time   `json:"last_update_time,omitempty"`
 }
+
+// SocietyNameVariants holds a society's changes to the global variants of a name
+type SocietyNameVariants struct {
+	Name string `json:"name"`
+	// Added holds variants the society uses in addition to the global variants
+	Added StringSlice `json:"added"`
+	// Removed holds global variants the society doesn't want to match
+	Removed        StringSlice `json:"removed"`
+	InsertTime     time.Time   `json:"insert_time,omitempty"`
+	LastUpdateTime time.Time   `json:"last_update_time,omitempty"`
+}
+
+// NameVariantsEdit adds variants to or removes variants from a name in a society's dictionary
+// Variants are applied to search queries rather than indexed, so published posts don't need to be reindexed after an edit
+type NameVariantsEdit struct {
+	Variants StringSlice `json:"variants" validate:"required,min=1,dive,required"`
+}
@@ -16,6 +16,20 @@ type PlacePersister interface {
 	SelectPlacesByFullNamePrefix(ctx context.Context, prefix string, count int) ([]Place, error)
 	SelectPlaceWord(ctx context.Context, word string) (*PlaceWord, error)
 	SelectPlaceWordsByWord(ctx context.Context, words []string) ([]PlaceWord, error)
+	SelectSocietyPlaceWordsByWord(ctx context.Context, societyID uint32, words []string) ([]PlaceWord, error)
+}
+
+// PlaceAdminPersister defines methods needed to maintain a society's additions to the gazetteer
+// All methods are scoped to the society in the context
+type PlaceAdminPersister interface {
+	InsertPlace(ctx context.Context, in Place) (*Place, error)
+	UpdatePlace(ctx context.Context, id uint32, in Place) (*Place, error)
+	DeletePlace(ctx context.Context, id uint32) error
+	SelectPlaceChildren(ctx context.Context, id uint32) ([]Place, error)
+	SelectPlaceAltNames(ctx context.Context, placeID uint32) (StringSlice, error)
+	UpdatePlaceAltNames(ctx context.Context, placeID uint32, altNames StringSlice) error
+	AddPlaceWordID(ctx context.Context, word string, id uint32) error
+	RemovePlaceWordID(ctx context.Context, word string, id uint32) error
 }
 
 type StringSlice []string
@@ -23,22 +37,110 @@ type Uint32Slice []uint32
 
 // Place holds information about a place
 type Place struct {
-	ID               uint32      `json:"id" dynamodbav:"pk,string"`
-	Type             string      `json:"-" dynamodbav:"sk"`
-	AltSort          string      `json:"-" dynamodbav:"altSort"`
-	Name             string      `json:"name"`
-	FullName         string      `json:"fullName"`
-	AltNames         StringSlice `json:"altNames"`
-	Types            StringSlice `json:"types"`
-	LocatedInID      uint32      `json:"locatedInId"`
-	AlsoLocatedInIDs Uint32Slice `json:"alsoLocatedInIds"`
-	Level            int         `json:"level"`
-	CountryID        uint32      `json:"countryId"`
-	Latitude         float32     `json:"latitude"`
-	Longitude        float32     `json:"longitude"`
-	Count            int         `json:"count"`
-	InsertTime       time.Time   `json:"insert_time,omitempty"`
-	LastUpdateTime   time.Time   `json:"last_update_time,omitempty"`
+	ID               uint32       `json:"id" dynamodbav:"pk,string"`
+	Type             string       `json:"-" dynamodbav:"sk"`
+	AltSort          string       `json:"-" dynamodbav:"altSort"`
+	Name             string       `json:"name"`
+	FullName         string       `json:"fullName"`
+	AltNames         StringSlice  `json:"altNames"`
+	Types            StringSlice  `json:"types"`
+	LocatedInID      uint32       `json:"locatedInId"`
+	AlsoLocatedInIDs Uint32Slice  `json:"alsoLocatedInIds"`
+	Level            int          `json:"level"`
+	CountryID        uint32       `json:"countryId"`
+	Latitude         float32      `json:"latitude"`
+	Longitude        float32      `json:"longitude"`
+	Count            int          `json:"count"`
+	Periods          PlacePeriods `json:"periods,omitempty" dynamodbav:"periods,omitempty"`
+	SocietyID        uint32       `json:"societyId,omitempty" dynamodbav:"-"`     // set for places added by a society
+	LocalAltNames    StringSlice  `json:"localAltNames,omitempty" dynamodbav:"-"` // alternate names added by the society
+	InsertTime       time.Time    `json:"insert_time,omitempty"`
+	LastUpdateTime   time.Time    `json:"last_update_time,omitempty"`
+}
+
+// PlacePeriod holds the name and parent of a place during a historical period
+// FromYear and ToYear are inclusive; 0 means the period is open-ended
+type PlacePeriod struct {
+	Name        string      `json:"name,omitempty"`
+	AltNames    StringSlice `json:"altNames,omitempty"`
+	LocatedInID uint32      `json:"locatedInId,omitempty"`
+	FromYear    int         `json:"fromYear,omitempty"`
+	ToYear      int         `json:"toYear,omitempty"`
+}
+
+type PlacePeriods []PlacePeriod
+
+// Contains returns true if year falls within the period
+func (pp PlacePeriod) Contains(year int) bool {
+	return (pp.FromYear == 0 || year >= pp.FromYear) && (pp.ToYear == 0 || year <= pp.ToYear)
+}
+
+// ParentIDs returns the IDs of the places this place was located in during year, or all parents if year is 0
+// The current parent is used when no historical period covering year sets a parent
+func (p Place) ParentIDs(year int) []uint32 {
+	var ids []uint32
+	add := func(id uint32) {
+		if id == 0 {
+			return
+		}
+		for _, existing := range ids {
+			if existing == id {
+				return
+			}
+		}
+		ids = append(ids, id)
+	}
+	inPeriod := false
+	for _, period := range p.Periods {
+		if year == 0 || period.Contains(year) {
+			add(period.LocatedInID)
+			// a period that only renames the place keeps its usual parent
+			inPeriod = inPeriod || (year != 0 && period.LocatedInID != 0)
+		}
+	}
+	if !inPeriod {
+		add(p.LocatedInID)
+	}
+	for _, id := range p.AlsoLocatedInIDs {
+		add(id)
+	}
+	return ids
+}
+
+// PlaceIn is the payload to add or update a society's place
+type PlaceIn struct {
+	Name             string       `json:"name" validate:"required"`
+	AltNames         StringSlice  `json:"altNames"`
+	Types            StringSlice  `json:"types"`
+	LocatedInID      uint32       `json:"locatedInId"`
+	AlsoLocatedInIDs Uint32Slice  `json:"alsoLocatedInIds"`
+	Latitude         float32      `json:"latitude" validate:"min=-90,max=90"`
+	Longitude        float32      `json:"longitude" validate:"min=-180,max=180"`
+	Periods          PlacePeriods `json:"periods,omitempty"`
+}
+
+// PlaceMerge is the payload to merge a society's place into another place
+type PlaceMerge struct {
+	IntoID uint32 `json:"intoId" validate:"required"`
+}
+
+// PlaceAltNames is the payload to set a society's alternate names for a place
+type PlaceAltNames struct {
+	AltNames StringSlice `json:"altNames"`
+}
+
+// AllNames returns the name and alternate names of the place, including historical and society-local names
+func (p Place) AllNames() []string {
+	names := []string{p.Name}
+	names = append(names, p.AltNames...)
+	names = append(names, p.LocalAltNames...)
+	for _, period := range p.Periods {
+		if period.Name != "" {
+			names = append(names, period.Name)
+		}
+		names = append(names, period.AltNames...)
+	}
+	return names
 }
 
 // PlaceWord holds the IDs of all places that have that word in their name or alt name
@@ -93,6 +195,20 @@ func (cb *StringSlice) Scan(value interface{}) error {
 	return json.Unmarshal(b, &cb)
 }
 
+// Value makes PlacePeriods implement the driver.Valuer interface.
+func (cb PlacePeriods) Value() (driver.Value, error) {
+	return json.Marshal(cb)
+}
+
+// Scan makes PlacePeriods implement the sql.Scanner interface.
+func (cb *PlacePeriods) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, &cb)
+}
+
 // Value makes Uint32Slice implement the driver.Valuer interface.
 func (cb Uint32Slice) Value() (driver.Value, error) {
 	return json.Marshal(cb)
@@ -0,0 +1,113 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// PlaceReviewPersister defines methods needed to persist the place review queue
+// All methods are scoped to the society in the context
+type PlaceReviewPersister interface {
+	SelectPlaceReviews(ctx context.Context, status PlaceReviewStatus) ([]PlaceReview, error)
+	SelectPlaceReview(ctx context.Context, id uint32) (*PlaceReview, error)
+	SelectPlaceReviewByText(ctx context.Context, text string) (*PlaceReview, error)
+	InsertPlaceReview(ctx context.Context, in PlaceReviewIn) (*PlaceReview, error)
+	UpdatePlaceReview(ctx context.Context, id uint32, in PlaceReview) (*PlaceReview, error)
+}
+
+// PlaceReviewStatus is the status of a place review
+type PlaceReviewStatus string
+
+const (
+	// PlaceReviewStatusPending means an editor hasn't reviewed the place text yet
+	PlaceReviewStatusPending PlaceReviewStatus = "Pending"
+	// PlaceReviewStatusResolved means an editor has confirmed or overridden the place for the text
+	PlaceReviewStatusResolved PlaceReviewStatus = "Resolved"
+)
+
+// ValidPlaceReviewStatus returns true if status is a known place review status
+func ValidPlaceReviewStatus(status PlaceReviewStatus) bool {
+	return status == PlaceReviewStatusPending || status == PlaceReviewStatusResolved
+}
+
+// PlaceReviewReason is why place text was queued for review
+type PlaceReviewReason string
+
+const (
+	// PlaceReviewReasonUnresolved means some or all of the text didn't match a place
+	PlaceReviewReasonUnresolved PlaceReviewReason = "Unresolved"
+	// PlaceReviewReasonAmbiguous means the text matched more than one place
+	PlaceReviewReasonAmbiguous PlaceReviewReason = "Ambiguous"
+)
+
+// PlaceCandidate is a place considered when standardizing ambiguous text
+type PlaceCandidate struct {
+	PlaceID  uint32 `json:"placeId"`
+	FullName string `json:"fullName"`
+	Score    int    `json:"score"`
+}
+
+// PlaceReviewBody is the JSON body of a PlaceReview
+type PlaceReviewBody struct {
+	Reason                 PlaceReviewReason `json:"reason"`
+	Status                 PlaceReviewStatus `json:"status"`
+	DefaultContainingPlace string            `json:"defaultContainingPlace,omitempty"`
+	// StandardizedPlaceID is the place chosen by the standardizer, or 0 if it didn't choose one
+	StandardizedPlaceID uint32           `json:"standardizedPlaceId,omitempty"`
+	StandardizedName    string           `json:"standardizedName,omitempty"`
+	Candidates          []PlaceCandidate `json:"candidates,omitempty"`
+	// PlaceID is the place an editor chose for the text, or 0 to leave it unstandardized; set when Status is Resolved
+	PlaceID uint32 `json:"placeId,omitempty"`
+	// Occurrences is the number of record fields loaded with the text
+	Occurrences int `json:"occurrences"`
+	// PostOccurrences is the number of record fields loaded with the text for each post
+	PostOccurrences map[uint32]int `json:"postOccurrences"`
+	ResolvedBy      uint32         `json:"resolvedBy,omitempty"`
+}
+
+// Value makes PlaceReviewBody implement the driver.Valuer interface.
+func (cb PlaceReviewBody) Value() (driver.Value, error) {
+	return json.Marshal(cb)
+}
+
+// Scan makes PlaceReviewBody implement the sql.Scanner interface.
+func (cb *PlaceReviewBody) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, &cb)
+}
+
+// PlaceReviewIn is the payload to create a PlaceReview
+type PlaceReviewIn struct {
+	PlaceReviewBody
+	// Text is the place text as it appears in records, normalized with NormalizePlaceText
+	Text string `json:"text" validate:"required"`
+}
+
+// PlaceReview is a place text that didn't standardize cleanly, queued for an editor to review
+type PlaceReview struct {
+	ID uint32 `json:"id,omitempty" example:"999" validate:"required,omitempty"`
+	PlaceReviewIn
+	InsertTime     time.Time `json:"insert_time,omitempty"`
+	LastUpdateTime time.Time `json:"last_update_time,omitempty"`
+}
+
+// PlaceReviewDecision is the payload for an editor to confirm or override the place for a place review
+type PlaceReviewDecision struct {
+	// PlaceID is the place to use for the text, or 0 to leave the text unstandardized
+	PlaceID uint32 `json:"placeId"`
+}
+
+var placeTextSpaces = regexp.MustCompile(`\s+`)
+
+// NormalizePlaceText normalizes place text so that decisions apply regardless of case or spacing
+func NormalizePlaceText(text string) string {
+	return placeTextSpaces.ReplaceAllString(strings.ToLower(strings.TrimSpace(text)), " ")
+}
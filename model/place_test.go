@@ -0,0 +1,26 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaceParentIDs(t *testing.T) {
+	place := model.Place{
+		LocatedInID:      1,
+		AlsoLocatedInIDs: model.Uint32Slice{2},
+		Periods: model.PlacePeriods{
+			{LocatedInID: 3, FromYear: 1815, ToYear: 1871},
+			{LocatedInID: 4, FromYear: 1872, ToYear: 1945},
+			{Name: "Old Name", FromYear: 1600, ToYear: 1750},
+		},
+	}
+	assert.Equal(t, []uint32{3, 4, 1, 2}, place.ParentIDs(0))
+	assert.Equal(t, []uint32{3, 2}, place.ParentIDs(1850))
+	assert.Equal(t, []uint32{4, 2}, place.ParentIDs(1945))
+	assert.Equal(t, []uint32{1, 2}, place.ParentIDs(1950))
+	// a period that only renames the place keeps the current parent
+	assert.Equal(t, []uint32{1, 2}, place.ParentIDs(1700))
+}
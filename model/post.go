@@ -123,6 +123,9 @@ type ImagesWriterAction string
 const (
 	ImagesWriterActionUnzip             ImagesWriterAction = "unzip"
 	ImagesWriterActionGenerateThumbnail ImagesWriterAction = "thumb"
+	// ImagesWriterActionGenerateDerivatives regenerates the redacted and watermarked derivatives of the image at ImagePath,
+	// or of all of the post's images if ImagePath is empty
+	ImagesWriterActionGenerateDerivatives ImagesWriterAction = "derivatives"
 )
 
 const ImageDimensionsSuffix = "__dimensions.json"
@@ -136,6 +139,74 @@ type ImageDimensions struct {
 	Width  int `json:"width"`
 }
 
+const ImageOCRSuffix = "__ocr.json"
+
+// ImagePublicSuffix is appended to the key of an image or thumbnail to get its redacted and watermarked derivative,
+// which is served to users who are not logged in
+const ImagePublicSuffix = "__public.jpg"
+const ImagePublicQuality = 85
+
+// ImageRedaction is a rectangle to blur in an image when it is served to users who are not logged in;
+// coordinates are fractions of the image width and height so they apply to thumbnails as well
+type ImageRedaction struct {
+	Path   string  `json:"path" validate:"required"`
+	Left   float64 `json:"left" validate:"gte=0,lte=1"`
+	Top    float64 `json:"top" validate:"gte=0,lte=1"`
+	Width  float64 `json:"width" validate:"gt=0,lte=1"`
+	Height float64 `json:"height" validate:"gt=0,lte=1"`
+}
+
+// ImageOCR holds the text recognized in an image along with the bounding box of each word
+type ImageOCR struct {
+	Text  string    `json:"text"`
+	Words []OCRWord `json:"words"`
+}
+
+// OCRWord is a single recognized word and its bounding box in image pixels
+type OCRWord struct {
+	Text       string  `json:"text"`
+	Left       int     `json:"left"`
+	Top        int     `json:"top"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	Confidence float64 `json:"confidence"`
+	Highlight  bool    `json:"highlight,omitempty"`
+}
+
+// ValidPostStatus returns true if status is a known post status
+func ValidPostStatus(status PostStatus) bool {
+	for _, s := range []PostStatus{PostStatusDraft, PostStatusToPublish, PostStatusPublishing, PostStatusPublishComplete,
+		PostStatusPublishError, PostStatusPublished, PostStatusToUnpublish, PostStatusUnpublishing, PostStatusUnpublishComplete,
+		PostStatusUnpublishError, PostStatusError} {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidRecordsStatus returns true if status is a known records status
+func ValidRecordsStatus(status RecordsStatus) bool {
+	for _, s := range []RecordsStatus{RecordsStatusDefault, RecordsStatusToLoad, RecordsStatusLoading, RecordsStatusLoadComplete,
+		RecordsStatusLoadError, RecordsStatusError} {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidImagesStatus returns true if status is a known images status
+func ValidImagesStatus(status ImagesStatus) bool {
+	for _, s := range []ImagesStatus{ImagesStatusDefault, ImagesStatusToLoad, ImagesStatusLoading, ImagesStatusLoadComplete,
+		ImagesStatusLoadError, ImagesStatusError} {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
 // UserAcceptedPostStatus returns true if status can be submitted by a user
 func UserAcceptedPostStatus(status PostStatus) bool {
 	for _, s := range []PostStatus{PostStatusDraft, PostStatusToPublish, PostStatusPublished, PostStatusToUnpublish, PostStatusError} {
@@ -263,6 +334,19 @@ type PostBody struct {
 	ImagesKeys    StringSet              `json:"imagesKeys"`
 	ImagesStatus  ImagesStatus           `json:"imagesStatus"`
 	ImagesError   string                 `json:"imagesError"`
+	// ImageRedactions are applied to the post's images when they are served to users who are not logged in
+	ImageRedactions []ImageRedaction `json:"imageRedactions,omitempty" validate:"omitempty,dive"`
+}
+
+// ImageRedactionsFor returns the redactions for the image at path
+func (cb PostBody) ImageRedactionsFor(path string) []ImageRedaction {
+	var redactions []ImageRedaction
+	for _, redaction := range cb.ImageRedactions {
+		if redaction.Path == path {
+			redactions = append(redactions, redaction)
+		}
+	}
+	return redactions
 }
 
 // Value makes PostBody implement the driver.Valuer interface.
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// PostEventPersister defines methods needed to persist post events
+type PostEventPersister interface {
+	SelectPostEvents(ctx context.Context, postID uint32) ([]PostEvent, error)
+	InsertPostEvent(ctx context.Context, in PostEventIn) (*PostEvent, error)
+	DeletePostEvents(ctx context.Context, postID uint32) error
+}
+
+// PostEventType identifies what happened to a post
+type PostEventType string
+
+const (
+	// PostEventTypeStatus records a status change
+	PostEventTypeStatus PostEventType = "status"
+	// PostEventTypeOverride records an administrator forcing a post status
+	PostEventTypeOverride PostEventType = "override"
+	// PostEventTypeWorkerStart records a worker starting to process a post
+	PostEventTypeWorkerStart PostEventType = "workerStart"
+	// PostEventTypeWorkerFinish records a worker finishing processing a post, successfully or not
+	PostEventTypeWorkerFinish PostEventType = "workerFinish"
+)
+
+// PostEventBody is the JSON body of a PostEvent
+type PostEventBody struct {
+	Type PostEventType `json:"type" validate:"required"`
+	// Field is the status field that changed: postStatus, recordsStatus or imagesStatus
+	Field  string `json:"field,omitempty"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	User   uint32 `json:"user,omitempty"`
+	// Resent is set when the worker message for the new status was sent again
+	Resent bool `json:"resent,omitempty"`
+	// Worker is recordswriter, imageswriter or publisher
+	Worker      string `json:"worker,omitempty"`
+	RecordCount int    `json:"recordCount,omitempty"`
+	// Duration is how long the worker took, in milliseconds
+	Duration int64  `json:"durationMs,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Value makes PostEventBody implement the driver.Valuer interface.
+func (cb PostEventBody) Value() (driver.Value, error) {
+	return json.Marshal(cb)
+}
+
+// Scan makes PostEventBody implement the sql.Scanner interface.
+func (cb *PostEventBody) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, &cb)
+}
+
+// PostEventIn is the payload to create a PostEvent
+type PostEventIn struct {
+	PostEventBody
+	Post uint32 `json:"post" example:"999" validate:"required"`
+}
+
+// PostEvent is an entry in a post's event log; events are never updated
+type PostEvent struct {
+	ID uint32 `json:"id,omitempty" example:"999" validate:"required,omitempty"`
+	PostEventIn
+	InsertTime time.Time `json:"insert_time,omitempty"`
+}
+
+// NewPostEventIn constructs a PostEventIn
+func NewPostEventIn(postID uint32, body PostEventBody) PostEventIn {
+	return PostEventIn{
+		PostEventBody: body,
+		Post:          postID,
+	}
+}
+
+// PostStatusOverride is the payload for an administrator to force a post's statuses;
+// only the statuses that are set are changed
+type PostStatusOverride struct {
+	PostStatus    *PostStatus    `json:"postStatus,omitempty"`
+	RecordsStatus *RecordsStatus `json:"recordsStatus,omitempty"`
+	ImagesStatus  *ImagesStatus  `json:"imagesStatus,omitempty"`
+	Reason        string         `json:"reason" validate:"required"`
+	// Resend sends the worker message for any status that is set to Publication Requested, Unpublication Requested or Load Requested
+	Resend bool `json:"resend,omitempty"`
+}
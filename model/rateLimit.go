@@ -0,0 +1,13 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimitPersister defines methods needed to share rate limit counts between servers
+type RateLimitPersister interface {
+	// IncrementRateLimit adds one to the count for key in the window that starts at windowStart, and returns the new count
+	IncrementRateLimit(ctx context.Context, key string, windowStart time.Time) (int, error)
+	DeleteRateLimitsBefore(ctx context.Context, before time.Time) error
+}
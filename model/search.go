@@ -101,6 +101,8 @@ type SearchHit struct {
 	CollectionLocation string         `json:"collectionLocation,omitempty"` // only returned on search by id
 	Citation           string         `json:"citation,omitempty"`           // only returned on search by id
 	Household          []SearchRecord `json:"household,omitempty"`          // only returned on search by id
+	ImageHighlight     []string       `json:"imageHighlight,omitempty"`     // fragments of image text matching the query
+	Distance           *float64       `json:"distance,omitempty"`           // distance from the searched point, in the units of the search radius
 }
 type SearchPerson struct {
 	Name          string               `json:"name"`
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// SearchKeyPersister defines methods needed to persist the keys that sign search tokens
+type SearchKeyPersister interface {
+	SelectSearchKeys(ctx context.Context) ([]SearchKey, error)
+	SelectSearchKey(ctx context.Context, kid string) (*SearchKey, error)
+	InsertSearchKey(ctx context.Context, in SearchKeyIn) (*SearchKey, error)
+	DeleteSearchKey(ctx context.Context, kid string) error
+}
+
+// Search token signing algorithms
+const (
+	SearchKeyHS256 = "HS256"
+	SearchKeyRS256 = "RS256"
+	SearchKeyES256 = "ES256"
+)
+
+// SearchKeyBody is the JSON part of the SearchKey object
+type SearchKeyBody struct {
+	Algorithm string `json:"alg" validate:"required,oneof=HS256 RS256 ES256"`
+	// Secret is the HMAC secret for HS256, or the PEM-encoded private key for RS256 and ES256.
+	// It is generated if it isn't supplied, and returned only when the key is created.
+	Secret string `json:"secret,omitempty"`
+	// PublicKey is the PEM-encoded public key for RS256 and ES256.
+	// A public key supplied without a secret verifies tokens signed elsewhere, but can't be used to mint tokens.
+	PublicKey string `json:"publicKey,omitempty"`
+}
+
+// Value makes SearchKeyBody implement the driver.Valuer interface.
+func (cb SearchKeyBody) Value() (driver.Value, error) {
+	return json.Marshal(cb)
+}
+
+// Scan makes SearchKeyBody implement the sql.Scanner interface.
+func (cb *SearchKeyBody) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, &cb)
+}
+
+// SearchKeyIn is the payload to create a SearchKey
+type SearchKeyIn struct {
+	SearchKeyBody
+	// KID identifies the key in the kid header of the tokens it signs
+	KID       string `json:"kid" validate:"required"`
+	SocietyID uint32 `json:"societyId" validate:"required"`
+}
+
+// SearchKey signs or verifies search tokens for a society.
+// A society can have several keys so that a new key can be added before an old one is deleted.
+type SearchKey struct {
+	ID uint32 `json:"id,omitempty" example:"999" validate:"required,omitempty"`
+	SearchKeyIn
+	InsertTime     time.Time `json:"insert_time,omitempty"`
+	LastUpdateTime time.Time `json:"last_update_time,omitempty"`
+}
+
+// NewSearchKey constructs a SearchKey from an id and a SearchKeyIn
+func NewSearchKey(id uint32, in SearchKeyIn) SearchKey {
+	now := time.Now()
+	return SearchKey{
+		ID:             id,
+		SearchKeyIn:    in,
+		InsertTime:     now,
+		LastUpdateTime: now,
+	}
+}
+
+// CanSign returns true if the key can mint tokens, rather than only verify them
+func (k SearchKey) CanSign() bool {
+	return k.Secret != ""
+}
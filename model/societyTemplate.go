@@ -0,0 +1,78 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// SocietyTemplatePersister defines methods needed to persist society templates
+type SocietyTemplatePersister interface {
+	// SelectSocietyTemplates selects the society's templates and the public templates of other societies
+	SelectSocietyTemplates(ctx context.Context) ([]SocietyTemplate, error)
+	SelectOneSocietyTemplate(ctx context.Context, id uint32) (*SocietyTemplate, error)
+	InsertSocietyTemplate(ctx context.Context, in SocietyTemplateIn) (*SocietyTemplate, error)
+	// DeleteSocietyTemplate deletes one of the society's templates
+	DeleteSocietyTemplate(ctx context.Context, id uint32) error
+}
+
+// SocietyTemplateExport is the payload to export a society as a template
+type SocietyTemplateExport struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description,omitempty"`
+	// Public templates can be used by every society; others only by the society that exported them and its admins
+	Public bool `json:"public"`
+}
+
+// SocietyTemplateBody is the JSON part of the SocietyTemplate object
+type SocietyTemplateBody struct {
+	SocietyTemplateExport
+	Categories   []TemplateCategory     `json:"categories" validate:"dive"`
+	Collections  []TemplateCollection   `json:"collections" validate:"dive"`
+	PostMetadata []SettingsPostMetadata `json:"postMetadata" validate:"dive"`
+}
+
+// TemplateCategory is a category in a template; its ID is only meaningful within the template
+type TemplateCategory struct {
+	ID uint32 `json:"id" validate:"required"`
+	CategoryBody
+}
+
+// TemplateCollection is a collection in a template; its ID and categories refer to the template's categories
+type TemplateCollection struct {
+	ID uint32 `json:"id" validate:"required"`
+	CollectionBody
+	Categories []uint32 `json:"categories" validate:"required"`
+}
+
+// Value makes SocietyTemplateBody implement the driver.Valuer interface.
+func (cb SocietyTemplateBody) Value() (driver.Value, error) {
+	return json.Marshal(cb)
+}
+
+// Scan makes SocietyTemplateBody implement the sql.Scanner interface.
+func (cb *SocietyTemplateBody) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, &cb)
+}
+
+// SocietyTemplateIn is the payload to create a SocietyTemplate
+type SocietyTemplateIn struct {
+	SocietyTemplateBody
+	// SocietyID is the society that exported the template, or 0 if the society has been deleted
+	SocietyID uint32 `json:"societyId"`
+}
+
+// SocietyTemplate holds the categories, collection layouts and post metadata of a society,
+// so that other societies can start with them
+type SocietyTemplate struct {
+	ID uint32 `json:"id,omitempty" example:"999" validate:"required,omitempty"`
+	SocietyTemplateIn
+	InsertTime     time.Time `json:"insert_time,omitempty"`
+	LastUpdateTime time.Time `json:"last_update_time,omitempty"`
+}
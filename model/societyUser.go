@@ -5,6 +5,9 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -18,8 +21,21 @@ const (
 	AuthAdmin
 )
 
+var authLevelNames = [...]string{"Guest", "Reader", "Contributor", "Editor", "Admin"}
+
 func (l AuthLevel) String() string {
-	return [...]string{"Guest", "Reader", "Contributor", "Editor", "Admin"}[l]
+	return authLevelNames[l]
+}
+
+// ParseAuthLevel parses a level name like Editor, in any case, or a level number
+func ParseAuthLevel(s string) (AuthLevel, error) {
+	s = strings.TrimSpace(s)
+	for i, name := range authLevelNames {
+		if strings.EqualFold(s, name) || s == strconv.Itoa(i) {
+			return AuthLevel(i), nil
+		}
+	}
+	return AuthGuest, fmt.Errorf("unknown level '%s'", s)
 }
 
 // SocietyUserPersister defines methods needed to persist SocietyUsers
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// TrashPersister defines methods needed to move societies, collections and posts to the trash, restore them, and purge them
+type TrashPersister interface {
+	// SelectTrash selects the society's trashed collections and posts, most recently trashed first
+	SelectTrash(ctx context.Context) ([]TrashItem, error)
+	// SelectTrashedSocieties selects the societies among ids that are in the trash
+	SelectTrashedSocieties(ctx context.Context, ids []uint32) ([]TrashItem, error)
+	// SelectExpiredTrash selects the items of all societies that were trashed before the time;
+	// posts come before collections, and collections before societies, so each can be purged in order
+	SelectExpiredTrash(ctx context.Context, before time.Time) ([]TrashItem, error)
+	SelectTrashedPost(ctx context.Context, id uint32) (*Post, error)
+	TrashSociety(ctx context.Context) error
+	TrashCollection(ctx context.Context, id uint32) error
+	TrashPost(ctx context.Context, id uint32) error
+	RestoreSociety(ctx context.Context) error
+	RestoreCollection(ctx context.Context, id uint32) error
+	RestorePost(ctx context.Context, id uint32) error
+	// PurgeCollection deletes a trashed collection; its posts must have been purged first
+	PurgeCollection(ctx context.Context, id uint32) error
+	// PurgeSociety deletes a trashed society and everything the database holds for it, except its audit log
+	PurgeSociety(ctx context.Context) error
+}
+
+// TrashEntity is the type of a trashed item
+type TrashEntity string
+
+// Trashed item types
+const (
+	TrashEntitySociety    TrashEntity = "society"
+	TrashEntityCollection TrashEntity = "collection"
+	TrashEntityPost       TrashEntity = "post"
+)
+
+// TrashItem is a society, collection or post in the trash
+type TrashItem struct {
+	Entity    TrashEntity `json:"entity"`
+	ID        uint32      `json:"id"`
+	SocietyID uint32      `json:"societyId"`
+	Name      string      `json:"name"`
+	// CollectionID is the collection of a trashed post
+	CollectionID uint32    `json:"collectionId,omitempty"`
+	DeleteTime   time.Time `json:"deleteTime"`
+	// PurgeTime is when the item will be deleted for good
+	PurgeTime time.Time `json:"purgeTime"`
+}
@@ -12,10 +12,10 @@ import (
 type UserPersister interface {
 	RetrieveUser(ctx context.Context, in UserIn) (*User, bool, error)
 	SelectUsersByID(ctx context.Context, ids []uint32) ([]User, error)
+	UpdateUser(ctx context.Context, id uint32, in User) (*User, error)
 	// SelectUsers(ctx context.Context) ([]User, error)
 	// SelectOneUser(ctx context.Context, id string) (User, error)
 	// InsertUser(ctx context.Context, in UserIn) (User, error)
-	// UpdateUser(ctx context.Context, id string, body User) (User, error)
 	// DeleteUser(ctx context.Context, id string) error
 }
 
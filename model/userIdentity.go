@@ -0,0 +1,50 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// UserIdentityPersister defines methods needed to persist the identities linked to users
+type UserIdentityPersister interface {
+	SelectUserIdentities(ctx context.Context, userID uint32) ([]UserIdentity, error)
+	InsertUserIdentity(ctx context.Context, in UserIdentityIn) (*UserIdentity, error)
+	DeleteUserIdentity(ctx context.Context, userID, id uint32) error
+}
+
+// UserIdentityBody is the JSON part of the UserIdentity object
+type UserIdentityBody struct {
+	Issuer  string `json:"iss" validate:"required,url"`
+	Subject string `json:"sub" validate:"required"`
+}
+
+// Value makes UserIdentityBody implement the driver.Valuer interface.
+func (cb UserIdentityBody) Value() (driver.Value, error) {
+	return json.Marshal(cb)
+}
+
+// Scan makes UserIdentityBody implement the sql.Scanner interface.
+func (cb *UserIdentityBody) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, &cb)
+}
+
+// UserIdentityIn is the payload to link an identity to a user
+type UserIdentityIn struct {
+	UserIdentityBody
+	UserID uint32 `json:"userId" validate:"required"`
+}
+
+// UserIdentity is an issuer and subject, in addition to the one the user was created with, that signs in as the user
+type UserIdentity struct {
+	ID uint32 `json:"id,omitempty" example:"999" validate:"required,omitempty"`
+	UserIdentityIn
+	InsertTime     time.Time `json:"insert_time,omitempty"`
+	LastUpdateTime time.Time `json:"last_update_time,omitempty"`
+}
@@ -0,0 +1,47 @@
+package ocr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/stdtext"
+)
+
+// Engine recognizes text in an image
+type Engine interface {
+	Recognize(ctx context.Context, image []byte) (*model.ImageOCR, error)
+}
+
+// NewEngine returns the engine with the specified name, or nil if name is empty or "none"
+func NewEngine(name, tesseractPath, language string) (Engine, error) {
+	switch name {
+	case "", "none":
+		return nil, nil
+	case "tesseract":
+		return NewTesseractEngine(tesseractPath, language), nil
+	default:
+		return nil, fmt.Errorf("unknown OCR engine %s", name)
+	}
+}
+
+// Highlight marks the words in ocr that match any of the words in query
+func Highlight(ocr *model.ImageOCR, query string) {
+	terms := map[string]bool{}
+	for _, term := range strings.Fields(query) {
+		if term = normalize(term); term != "" {
+			terms[term] = true
+		}
+	}
+	for i := range ocr.Words {
+		ocr.Words[i].Highlight = terms[normalize(ocr.Words[i].Text)]
+	}
+}
+
+func normalize(word string) string {
+	word = strings.TrimFunc(stdtext.AsciiFold(strings.ToLower(word)), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	return word
+}
@@ -0,0 +1,50 @@
+package ocr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTSV = "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+	"1\t1\t0\t0\t0\t0\t0\t0\t800\t600\t-1\t\n" +
+	"4\t1\t1\t1\t1\t0\t10\t20\t300\t30\t-1\t\n" +
+	"5\t1\t1\t1\t1\t1\t10\t20\t80\t30\t96.5\tJohn\n" +
+	"5\t1\t1\t1\t1\t2\t100\t20\t120\t30\t91\tSmith,\n" +
+	"5\t1\t1\t1\t2\t1\t10\t60\t90\t30\t88\tBorn\n" +
+	"5\t1\t1\t1\t2\t2\t110\t60\t60\t30\t-1\t \n" +
+	"5\t1\t1\t1\t2\t3\t180\t60\t70\t30\t90\t1880\n"
+
+func TestParseTSV(t *testing.T) {
+	result, err := parseTSV(strings.NewReader(testTSV))
+	assert.NoError(t, err)
+	assert.Equal(t, "John Smith,\nBorn 1880", result.Text)
+	assert.Equal(t, 4, len(result.Words))
+	assert.Equal(t, model.OCRWord{Text: "Smith,", Left: 100, Top: 20, Width: 120, Height: 30, Confidence: 91}, result.Words[1])
+}
+
+func TestHighlight(t *testing.T) {
+	result, err := parseTSV(strings.NewReader(testTSV))
+	assert.NoError(t, err)
+	Highlight(result, "smith 1880")
+	var highlighted []string
+	for _, word := range result.Words {
+		if word.Highlight {
+			highlighted = append(highlighted, word.Text)
+		}
+	}
+	assert.Equal(t, []string{"Smith,", "1880"}, highlighted)
+}
+
+func TestNewEngine(t *testing.T) {
+	engine, err := NewEngine("", "", "")
+	assert.NoError(t, err)
+	assert.Nil(t, engine)
+	engine, err = NewEngine("tesseract", "", "")
+	assert.NoError(t, err)
+	assert.IsType(t, &TesseractEngine{}, engine)
+	_, err = NewEngine("unknown", "", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package ocr
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/ourrootsorg/cms-server/model"
+)
+
+const tesseractWordLevel = 5
+
+// TesseractEngine runs the tesseract command-line program
+type TesseractEngine struct {
+	path     string
+	language string
+}
+
+// NewTesseractEngine constructs a TesseractEngine; path defaults to "tesseract" and language to "eng"
+func NewTesseractEngine(path, language string) *TesseractEngine {
+	if path == "" {
+		path = "tesseract"
+	}
+	if language == "" {
+		language = "eng"
+	}
+	return &TesseractEngine{
+		path:     path,
+		language: language,
+	}
+}
+
+// Recognize pipes the image to tesseract and parses the tsv output
+func (e *TesseractEngine) Recognize(ctx context.Context, image []byte) (*model.ImageOCR, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.path, "stdin", "stdout", "-l", e.language, "tsv")
+	cmd.Stdin = bytes.NewReader(image)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("tesseract %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseTSV(&stdout)
+}
+
+// parseTSV parses tesseract tsv output:
+// level page_num block_num par_num line_num word_num left top width height conf text
+func parseTSV(r io.Reader) (*model.ImageOCR, error) {
+	result := &model.ImageOCR{Words: []model.OCRWord{}}
+	var text strings.Builder
+	var lastLine string
+	scanner := bufio.NewScanner(r)
+	first := true
+	for scanner.Scan() {
+		if first {
+			// skip header
+			first = false
+			continue
+		}
+		cols := strings.Split(scanner.Text(), "\t")
+		if len(cols) < 12 {
+			continue
+		}
+		level, err := strconv.Atoi(cols[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid tsv level %s", cols[0])
+		}
+		word := strings.TrimSpace(cols[11])
+		if level != tesseractWordLevel || word == "" {
+			continue
+		}
+		var box [4]int
+		for i := range box {
+			box[i], err = strconv.Atoi(cols[6+i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid tsv box %v", cols[6:10])
+			}
+		}
+		conf, err := strconv.ParseFloat(cols[10], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tsv confidence %s", cols[10])
+		}
+		// start a new line of text when block, paragraph, or line changes
+		line := strings.Join(cols[1:5], ".")
+		if text.Len() > 0 {
+			if line != lastLine {
+				text.WriteString("\n")
+			} else {
+				text.WriteString(" ")
+			}
+		}
+		lastLine = line
+		text.WriteString(word)
+		result.Words = append(result.Words, model.OCRWord{
+			Text:       word,
+			Left:       box[0],
+			Top:        box[1],
+			Width:      box[2],
+			Height:     box[3],
+			Confidence: conf,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	result.Text = text.String()
+	return result, nil
+}
@@ -0,0 +1,116 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+const auditType = "audit"
+
+// auditSortKey zero-pads the ID so that the index sorts events in the order they were inserted
+func auditSortKey(id uint32) string {
+	return fmt.Sprintf("%010d", id)
+}
+
+func auditPartition(societyID uint32) string {
+	return auditType + idSeparator + strconv.FormatInt(int64(societyID), 10)
+}
+
+// SelectAuditEvents selects the society's audit events that match the filter, newest first
+func (p Persister) SelectAuditEvents(ctx context.Context, filter model.AuditFilter) ([]model.AuditEvent, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keyCondition := skName + " = :sk"
+	values := map[string]*dynamodb.AttributeValue{
+		":sk": {S: aws.String(auditPartition(societyID))},
+	}
+	if filter.Before > 0 {
+		keyCondition += " AND " + gsiSkName + " < :before"
+		values[":before"] = &dynamodb.AttributeValue{S: aws.String(auditSortKey(filter.Before))}
+	}
+	qi := &dynamodb.QueryInput{
+		TableName:                 p.tableName,
+		IndexName:                 aws.String(gsiName),
+		KeyConditionExpression:    aws.String(keyCondition),
+		ExpressionAttributeValues: values,
+		ScanIndexForward:          aws.Bool(false),
+	}
+	// the remaining criteria are applied here rather than in a filter expression so that times are compared as times
+	events := make([]model.AuditEvent, 0)
+	for {
+		batch := make([]model.AuditEvent, 0)
+		qo, err := p.svc.Query(qi)
+		if err != nil {
+			log.Printf("[ERROR] Failed to get audit events. qi: %#v err: %v", qi, err)
+			return events, model.NewError(model.ErrOther, err.Error())
+		}
+		err = dynamodbattribute.UnmarshalListOfMaps(qo.Items, &batch)
+		if err != nil {
+			log.Printf("[ERROR] Failed to unmarshal audit events. qo: %#v err: %v", qo, err)
+			return events, model.NewError(model.ErrOther, err.Error())
+		}
+		for _, event := range batch {
+			if filter.From != nil && event.InsertTime.Before(*filter.From) {
+				// events are newest first, so the rest are too old as well
+				return events, nil
+			}
+			if !filter.Matches(event) {
+				continue
+			}
+			events = append(events, event)
+			if filter.Limit > 0 && len(events) >= filter.Limit {
+				return events, nil
+			}
+		}
+		if qo.LastEvaluatedKey == nil {
+			break
+		}
+		qi.ExclusiveStartKey = qo.LastEvaluatedKey
+	}
+	return events, nil
+}
+
+// InsertAuditEvent inserts an AuditEvent
+func (p Persister) InsertAuditEvent(ctx context.Context, in model.AuditEventIn) (*model.AuditEvent, error) {
+	var event model.AuditEvent
+	var err error
+	event.ID, err = p.GetSequenceValue()
+	if err != nil {
+		return nil, model.NewError(model.ErrOther, err.Error())
+	}
+	event.Type = auditPartition(in.SocietyID)
+	event.SortKey = auditSortKey(event.ID)
+	event.AuditEventIn = in
+	event.InsertTime = time.Now().Truncate(0)
+
+	avs, err := dynamodbattribute.MarshalMap(event)
+	if err != nil {
+		log.Printf("[ERROR] Failed to marshal audit event %#v: %v", event, err)
+		return nil, model.NewError(model.ErrOther, err.Error())
+	}
+	pii := &dynamodb.PutItemInput{
+		TableName:           p.tableName,
+		Item:                avs,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"), // Make duplicate insert fail
+	}
+	_, err = p.svc.PutItem(pii)
+	if err != nil {
+		if compareToAWSError(err, dynamodb.ErrCodeConditionalCheckFailedException) {
+			return nil, model.NewError(model.ErrOther, fmt.Sprintf("Insert failed. Audit event ID %d already exists", event.ID))
+		}
+		log.Printf("[ERROR] Failed to put audit event %#v. pii: %#v err: %v", event, pii, err)
+		return nil, model.NewError(model.ErrOther, err.Error())
+	}
+	return &event, nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/ourrootsorg/cms-server/model"
 	"github.com/ourrootsorg/cms-server/persist/dynamo"
+	"github.com/ourrootsorg/cms-server/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -411,6 +412,50 @@ func TestSequences(t *testing.T) {
 	}
 }
 
+func TestAuditEvents(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping tests in short mode")
+	}
+	p, teardown := setupTestCase(t)
+	defer teardown(t)
+
+	// use a society of our own, since audit events can't be deleted
+	societyID, err := p.GetSequenceValue()
+	assert.NoError(t, err)
+	ctx := utils.AddSocietyIDToContext(context.TODO(), societyID)
+	var ids []uint32
+	for _, action := range []model.AuditAction{model.AuditActionCreate, model.AuditActionUpdate, model.AuditActionUpdate, model.AuditActionDelete} {
+		event, err := p.InsertAuditEvent(ctx, model.AuditEventIn{
+			AuditEventBody: model.AuditEventBody{User: 1, Entity: model.AuditEntityCategory, EntityID: 2, Action: action},
+			SocietyID:      societyID,
+		})
+		assert.NoError(t, err)
+		ids = append(ids, event.ID)
+	}
+
+	// newest first
+	events, err := p.SelectAuditEvents(ctx, model.AuditFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, events, 4)
+	assert.Equal(t, ids[3], events[0].ID)
+	assert.Equal(t, model.AuditActionDelete, events[0].Action)
+
+	events, err = p.SelectAuditEvents(ctx, model.AuditFilter{Action: model.AuditActionUpdate, Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, ids[2], events[0].ID)
+
+	events, err = p.SelectAuditEvents(ctx, model.AuditFilter{Action: model.AuditActionUpdate, Before: ids[2]})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, ids[1], events[0].ID)
+
+	// other societies' events aren't returned
+	events, err = p.SelectAuditEvents(utils.AddSocietyIDToContext(context.TODO(), societyID+1), model.AuditFilter{})
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
+
 func TestPlaces(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping tests in short mode")
@@ -142,6 +142,11 @@ func (p Persister) SelectPlaceWord(ctx context.Context, word string) (*model.Pla
 	return &placeWord, nil
 }
 
+// SelectSocietyPlaceWordsByWord returns no words, since societies can't add places to the DynamoDB gazetteer
+func (p Persister) SelectSocietyPlaceWordsByWord(ctx context.Context, societyID uint32, words []string) ([]model.PlaceWord, error) {
+	return make([]model.PlaceWord, 0), nil
+}
+
 // SelectPlaceWordsByWord selects multiple PlaceWord objects by word
 func (p Persister) SelectPlaceWordsByWord(ctx context.Context, words []string) ([]model.PlaceWord, error) {
 	placeWords := make([]model.PlaceWord, 0)
@@ -345,8 +350,8 @@ func (p Persister) LoadPlaceData(rd io.Reader) error {
 			log.Printf("[ERROR] " + msg)
 			return errors.New(msg)
 		}
-		if len(record) != 12 {
-			msg := fmt.Sprintf("Expected 12 fields, found %d in record(%#v)", len(record), record)
+		if len(record) != 12 && len(record) != 13 {
+			msg := fmt.Sprintf("Expected 12 or 13 fields, found %d in record(%#v)", len(record), record)
 			log.Printf("[ERROR] " + msg)
 			return errors.New(msg)
 		}
@@ -390,6 +395,14 @@ func (p Persister) LoadPlaceData(rd io.Reader) error {
 		if err != nil {
 			return err
 		}
+		var periods model.PlacePeriods
+		if len(record) > 12 && record[12] != "" {
+			if err := json.Unmarshal([]byte(record[12]), &periods); err != nil {
+				msg := fmt.Sprintf("Unable to decode Periods (%s): %v", record[12], err)
+				log.Printf("[ERROR] " + msg)
+				return errors.New(msg)
+			}
+		}
 
 		place := model.Place{
 			ID:               id,
@@ -406,6 +419,7 @@ func (p Persister) LoadPlaceData(rd io.Reader) error {
 			Latitude:         latitude,
 			Longitude:        longitude,
 			Count:            int(count),
+			Periods:          periods,
 			InsertTime:       now,
 			LastUpdateTime:   now,
 		}
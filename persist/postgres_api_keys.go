@@ -0,0 +1,93 @@
+package persist
+
+import (
+	"context"
+	"time"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+const selectAPIKey = "SELECT id, body, prefix, hash, society_id, created_by, last_used_time, insert_time, last_update_time FROM api_key "
+
+// SelectAPIKeys selects all API keys for the society
+func (p PostgresPersister) SelectAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	apiKeys := make([]model.APIKey, 0)
+	rows, err := p.db.QueryContext(ctx, selectAPIKey+"WHERE society_id = $1 ORDER BY id", societyID)
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		apiKey, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, translateError(err, nil, nil, "")
+		}
+		apiKeys = append(apiKeys, *apiKey)
+	}
+	return apiKeys, nil
+}
+
+// SelectAPIKeyByHash selects the API key with the hash in any society
+func (p PostgresPersister) SelectAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	apiKey, err := scanAPIKey(p.db.QueryRowContext(ctx, selectAPIKey+"WHERE hash = $1", hash))
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	return apiKey, nil
+}
+
+// InsertAPIKey inserts an APIKeyIn into the database and returns the inserted APIKey
+func (p PostgresPersister) InsertAPIKey(ctx context.Context, in model.APIKeyIn) (*model.APIKey, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	apiKey, err := scanAPIKey(p.db.QueryRowContext(ctx,
+		"INSERT INTO api_key (body, prefix, hash, society_id, created_by) VALUES ($1, $2, $3, $4, $5) "+
+			"RETURNING id, body, prefix, hash, society_id, created_by, last_used_time, insert_time, last_update_time",
+		in.APIKeyBody, in.Prefix, in.Hash, societyID, in.CreatedBy))
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	return apiKey, nil
+}
+
+// UpdateAPIKeyLastUsed records when an API key was last used
+func (p PostgresPersister) UpdateAPIKeyLastUsed(ctx context.Context, id uint32, lastUsedTime time.Time) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE api_key SET last_used_time = $1 WHERE id = $2", lastUsedTime, id)
+	return translateError(err, &id, nil, "")
+}
+
+// DeleteAPIKey deletes an API key, revoking it
+func (p PostgresPersister) DeleteAPIKey(ctx context.Context, id uint32) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx, "DELETE FROM api_key WHERE society_id = $1 AND id = $2", societyID, id)
+	return translateError(err, &id, nil, "")
+}
+
+func scanAPIKey(row rowScanner) (*model.APIKey, error) {
+	var apiKey model.APIKey
+	err := row.Scan(
+		&apiKey.ID,
+		&apiKey.APIKeyBody,
+		&apiKey.Prefix,
+		&apiKey.Hash,
+		&apiKey.SocietyID,
+		&apiKey.CreatedBy,
+		&apiKey.LastUsedTime,
+		&apiKey.InsertTime,
+		&apiKey.LastUpdateTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
@@ -0,0 +1,50 @@
+package persist
+
+import (
+	"context"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+// SelectAuditEvents selects the society's audit events that match the filter, newest first
+func (p PostgresPersister) SelectAuditEvents(ctx context.Context, filter model.AuditFilter) ([]model.AuditEvent, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var limit interface{}
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+	rows, err := p.db.QueryContext(ctx, "SELECT id, body, society_id, insert_time FROM audit_event "+
+		"WHERE society_id = $1 AND ($2 = 0 OR (body->>'user')::int = $2) AND ($3 = '' OR body->>'entity' = $3) "+
+		"AND ($4 = 0 OR (body->>'entityId')::int = $4) AND ($5 = '' OR body->>'action' = $5) "+
+		"AND ($6::timestamptz IS NULL OR insert_time >= $6) AND ($7::timestamptz IS NULL OR insert_time < $7) "+
+		"AND ($8 = 0 OR id < $8) ORDER BY id DESC LIMIT $9",
+		societyID, filter.User, filter.Entity, filter.EntityID, string(filter.Action), filter.From, filter.To, filter.Before, limit)
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	defer rows.Close()
+	auditEvents := make([]model.AuditEvent, 0)
+	for rows.Next() {
+		var auditEvent model.AuditEvent
+		err := rows.Scan(&auditEvent.ID, &auditEvent.AuditEventBody, &auditEvent.SocietyID, &auditEvent.InsertTime)
+		if err != nil {
+			return nil, translateError(err, nil, nil, "")
+		}
+		auditEvents = append(auditEvents, auditEvent)
+	}
+	return auditEvents, nil
+}
+
+// InsertAuditEvent inserts an AuditEvent
+func (p PostgresPersister) InsertAuditEvent(ctx context.Context, in model.AuditEventIn) (*model.AuditEvent, error) {
+	var auditEvent model.AuditEvent
+	err := p.db.QueryRowContext(ctx,
+		"INSERT INTO audit_event (body, society_id) VALUES ($1, $2) RETURNING id, body, society_id, insert_time",
+		in.AuditEventBody, in.SocietyID).
+		Scan(&auditEvent.ID, &auditEvent.AuditEventBody, &auditEvent.SocietyID, &auditEvent.InsertTime)
+	return &auditEvent, translateError(err, nil, nil, "")
+}
@@ -0,0 +1,108 @@
+package persist
+
+import (
+	"context"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+const collectionGrantColumns = "id, body, user_id, society_id, COALESCE(collection_id, 0), COALESCE(category_id, 0), insert_time, last_update_time"
+const selectCollectionGrant = "SELECT " + collectionGrantColumns + " FROM collection_grant "
+
+// SelectCollectionGrants selects all collection grants for the society
+func (p PostgresPersister) SelectCollectionGrants(ctx context.Context) ([]model.CollectionGrant, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.selectCollectionGrants(ctx, selectCollectionGrant+"WHERE society_id = $1 ORDER BY id", societyID)
+}
+
+// SelectCollectionGrantsByUser selects the society's collection grants for a user
+func (p PostgresPersister) SelectCollectionGrantsByUser(ctx context.Context, userID uint32) ([]model.CollectionGrant, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.selectCollectionGrants(ctx, selectCollectionGrant+"WHERE society_id = $1 AND user_id = $2 ORDER BY id", societyID, userID)
+}
+
+func (p PostgresPersister) selectCollectionGrants(ctx context.Context, query string, args ...interface{}) ([]model.CollectionGrant, error) {
+	grants := make([]model.CollectionGrant, 0)
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		grant, err := scanCollectionGrant(rows)
+		if err != nil {
+			return nil, translateError(err, nil, nil, "")
+		}
+		grants = append(grants, *grant)
+	}
+	return grants, nil
+}
+
+// InsertCollectionGrant inserts a CollectionGrantIn into the database and returns the inserted CollectionGrant
+func (p PostgresPersister) InsertCollectionGrant(ctx context.Context, in model.CollectionGrantIn) (*model.CollectionGrant, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var collectionID, categoryID interface{}
+	refID, refType := in.CollectionID, "collection"
+	if in.CollectionID != 0 {
+		collectionID = in.CollectionID
+	} else {
+		categoryID = in.CategoryID
+		refID, refType = in.CategoryID, "category"
+	}
+	grant, err := scanCollectionGrant(p.db.QueryRowContext(ctx,
+		"INSERT INTO collection_grant (body, user_id, society_id, collection_id, category_id) VALUES ($1, $2, $3, $4, $5) "+
+			"RETURNING "+collectionGrantColumns,
+		in.CollectionGrantBody, in.UserID, societyID, collectionID, categoryID))
+	if err != nil {
+		return nil, translateError(err, nil, &refID, refType)
+	}
+	return grant, nil
+}
+
+// DeleteCollectionGrant deletes a collection grant
+func (p PostgresPersister) DeleteCollectionGrant(ctx context.Context, id uint32) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx, "DELETE FROM collection_grant WHERE society_id = $1 AND id = $2", societyID, id)
+	return translateError(err, &id, nil, "")
+}
+
+// DeleteCollectionGrantsByUser deletes the society's collection grants for a user
+func (p PostgresPersister) DeleteCollectionGrantsByUser(ctx context.Context, userID uint32) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx, "DELETE FROM collection_grant WHERE society_id = $1 AND user_id = $2", societyID, userID)
+	return translateError(err, nil, nil, "")
+}
+
+func scanCollectionGrant(row rowScanner) (*model.CollectionGrant, error) {
+	var grant model.CollectionGrant
+	err := row.Scan(
+		&grant.ID,
+		&grant.CollectionGrantBody,
+		&grant.UserID,
+		&grant.SocietyID,
+		&grant.CollectionID,
+		&grant.CategoryID,
+		&grant.InsertTime,
+		&grant.LastUpdateTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
@@ -23,7 +23,7 @@ func (p PostgresPersister) SelectCollections(ctx context.Context) ([]model.Colle
 	rows, err := p.db.QueryContext(ctx,
 		`SELECT id, array_agg(cc.category_id), body, insert_time, last_update_time
 			   FROM collection LEFT JOIN collection_category cc ON id = cc.collection_id 
-			   WHERE society_id=$1 GROUP BY id`, societyID)
+			   WHERE society_id=$1 AND delete_time IS NULL GROUP BY id`, societyID)
 	if err != nil {
 		return nil, translateError(err, nil, nil, "")
 	}
@@ -61,12 +61,12 @@ func (p PostgresPersister) SelectCollectionsByID(ctx context.Context, ids []uint
 		rows, err = p.db.QueryContext(ctx,
 			`SELECT id, array_agg(cc.category_id), body, insert_time, last_update_time
 			   FROM collection LEFT JOIN collection_category cc ON id = cc.collection_id 
-			   WHERE society_id=$1 AND id = ANY($2) GROUP BY id`, societyID, pq.Array(ids))
+			   WHERE society_id=$1 AND id = ANY($2) AND delete_time IS NULL GROUP BY id`, societyID, pq.Array(ids))
 	} else {
 		rows, err = p.db.QueryContext(ctx,
 			`SELECT id, array_agg(cc.category_id), body, insert_time, last_update_time
 			   FROM collection LEFT JOIN collection_category cc ON id = cc.collection_id 
-			   WHERE id = ANY($1) GROUP BY id`, pq.Array(ids))
+			   WHERE id = ANY($1) AND delete_time IS NULL GROUP BY id`, pq.Array(ids))
 	}
 	if err != nil {
 		return nil, translateError(err, nil, nil, "")
@@ -99,7 +99,7 @@ func (p PostgresPersister) SelectOneCollection(ctx context.Context, id uint32) (
 	err = p.db.QueryRowContext(ctx,
 		`SELECT id, array_agg(cc.category_id), body, insert_time, last_update_time
 			   FROM collection LEFT JOIN collection_category cc ON id = cc.collection_id 
-			   WHERE society_id=$1 AND id = $2 GROUP BY id`, societyID, id).Scan(
+			   WHERE society_id=$1 AND id = $2 AND delete_time IS NULL GROUP BY id`, societyID, id).Scan(
 		&collection.ID,
 		pq.Array(&categories),
 		&collection.CollectionBody,
@@ -0,0 +1,141 @@
+package persist
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+const imageHashColumns = "id, body, post_id, path, insert_time, last_update_time"
+
+// SelectImageHashes selects all image hashes for the society
+func (p PostgresPersister) SelectImageHashes(ctx context.Context) ([]model.ImageHash, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := p.db.QueryContext(ctx, "SELECT "+imageHashColumns+" FROM image_hash WHERE society_id = $1", societyID)
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	return scanImageHashes(rows)
+}
+
+// SelectImageHashesForPost selects the image hashes for a post
+func (p PostgresPersister) SelectImageHashesForPost(ctx context.Context, postID uint32) ([]model.ImageHash, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := p.db.QueryContext(ctx, "SELECT "+imageHashColumns+" FROM image_hash WHERE society_id = $1 AND post_id = $2 ORDER BY path",
+		societyID, postID)
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	return scanImageHashes(rows)
+}
+
+// SelectImageHashesBySHA256 selects the image hashes in the society having the specified content hash
+func (p PostgresPersister) SelectImageHashesBySHA256(ctx context.Context, sha256 string) ([]model.ImageHash, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := p.db.QueryContext(ctx, "SELECT "+imageHashColumns+" FROM image_hash WHERE society_id = $1 AND sha256 = $2 ORDER BY id",
+		societyID, sha256)
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	return scanImageHashes(rows)
+}
+
+// SelectImageHashesByStorageKey selects the image hashes in the society whose image bytes are stored under storageKey
+func (p PostgresPersister) SelectImageHashesByStorageKey(ctx context.Context, storageKey string) ([]model.ImageHash, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := p.db.QueryContext(ctx, "SELECT "+imageHashColumns+" FROM image_hash WHERE society_id = $1 AND body->>'storageKey' = $2",
+		societyID, storageKey)
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	return scanImageHashes(rows)
+}
+
+// SelectOneImageHash selects the image hash for an image in a post
+func (p PostgresPersister) SelectOneImageHash(ctx context.Context, postID uint32, path string) (*model.ImageHash, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var imageHash model.ImageHash
+	err = p.db.QueryRowContext(ctx, "SELECT "+imageHashColumns+" FROM image_hash WHERE society_id = $1 AND post_id = $2 AND path = $3",
+		societyID, postID, path).Scan(
+		&imageHash.ID,
+		&imageHash.ImageHashBody,
+		&imageHash.Post,
+		&imageHash.Path,
+		&imageHash.InsertTime,
+		&imageHash.LastUpdateTime,
+	)
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	return &imageHash, nil
+}
+
+// UpsertImageHash inserts or replaces the image hash for an image in a post
+func (p PostgresPersister) UpsertImageHash(ctx context.Context, in model.ImageHashIn) (*model.ImageHash, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var imageHash model.ImageHash
+	err = p.db.QueryRowContext(ctx,
+		"INSERT INTO image_hash (body, post_id, path, sha256, society_id) VALUES ($1, $2, $3, $4, $5) "+
+			"ON CONFLICT (post_id, path) DO UPDATE SET body = $1, sha256 = $4, last_update_time = CURRENT_TIMESTAMP "+
+			"RETURNING "+imageHashColumns,
+		in.ImageHashBody, in.Post, in.Path, in.SHA256, societyID).Scan(
+		&imageHash.ID,
+		&imageHash.ImageHashBody,
+		&imageHash.Post,
+		&imageHash.Path,
+		&imageHash.InsertTime,
+		&imageHash.LastUpdateTime,
+	)
+	return &imageHash, translateError(err, nil, &in.Post, "post")
+}
+
+// DeleteImageHashesForPost deletes the image hashes for a post
+func (p PostgresPersister) DeleteImageHashesForPost(ctx context.Context, postID uint32) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx, "DELETE FROM image_hash WHERE society_id = $1 AND post_id = $2", societyID, postID)
+	return translateError(err, &postID, nil, "")
+}
+
+func scanImageHashes(rows *sql.Rows) ([]model.ImageHash, error) {
+	defer rows.Close()
+	imageHashes := make([]model.ImageHash, 0)
+	for rows.Next() {
+		var imageHash model.ImageHash
+		err := rows.Scan(
+			&imageHash.ID,
+			&imageHash.ImageHashBody,
+			&imageHash.Post,
+			&imageHash.Path,
+			&imageHash.InsertTime,
+			&imageHash.LastUpdateTime,
+		)
+		if err != nil {
+			return nil, translateError(err, nil, nil, "")
+		}
+		imageHashes = append(imageHashes, imageHash)
+	}
+	return imageHashes, nil
+}
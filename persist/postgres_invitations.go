@@ -13,12 +13,13 @@ import (
 func (p PostgresPersister) SelectInvitationByCode(ctx context.Context, code string) (*model.Invitation, error) {
 	var invitation model.Invitation
 	log.Printf("[DEBUG] code: %s", code)
-	err := p.db.QueryRowContext(ctx, "SELECT id, body, code, society_id, insert_time, last_update_time FROM invitation "+
+	err := p.db.QueryRowContext(ctx, "SELECT id, body, code, society_id, uses, insert_time, last_update_time FROM invitation "+
 		"WHERE code=$1", code).Scan(
 		&invitation.ID,
 		&invitation.InvitationBody,
 		&invitation.Code,
 		&invitation.SocietyID,
+		&invitation.Uses,
 		&invitation.InsertTime,
 		&invitation.LastUpdateTime,
 	)
@@ -36,7 +37,7 @@ func (p PostgresPersister) SelectInvitations(ctx context.Context) ([]model.Invit
 	}
 	invitations := make([]model.Invitation, 0)
 
-	rows, err := p.db.QueryContext(ctx, "SELECT id, body, code, society_id, insert_time, last_update_time FROM invitation "+
+	rows, err := p.db.QueryContext(ctx, "SELECT id, body, code, society_id, uses, insert_time, last_update_time FROM invitation "+
 		"WHERE society_id = $1", societyID)
 	if err != nil {
 		return nil, translateError(err, nil, nil, "")
@@ -44,7 +45,7 @@ func (p PostgresPersister) SelectInvitations(ctx context.Context) ([]model.Invit
 	defer rows.Close()
 	for rows.Next() {
 		var invitation model.Invitation
-		err := rows.Scan(&invitation.ID, &invitation.InvitationBody, &invitation.Code, &invitation.SocietyID, &invitation.InsertTime, &invitation.LastUpdateTime)
+		err := rows.Scan(&invitation.ID, &invitation.InvitationBody, &invitation.Code, &invitation.SocietyID, &invitation.Uses, &invitation.InsertTime, &invitation.LastUpdateTime)
 		if err != nil {
 			return nil, translateError(err, nil, nil, "")
 		}
@@ -61,12 +62,13 @@ func (p PostgresPersister) SelectOneInvitation(ctx context.Context, id uint32) (
 	}
 	var invitation model.Invitation
 	log.Printf("[DEBUG] id: %d", id)
-	err = p.db.QueryRowContext(ctx, "SELECT id, body, code, society_id, insert_time, last_update_time FROM invitation "+
+	err = p.db.QueryRowContext(ctx, "SELECT id, body, code, society_id, uses, insert_time, last_update_time FROM invitation "+
 		"WHERE society_id = $1 AND id=$2", societyID, id).Scan(
 		&invitation.ID,
 		&invitation.InvitationBody,
 		&invitation.Code,
 		&invitation.SocietyID,
+		&invitation.Uses,
 		&invitation.InsertTime,
 		&invitation.LastUpdateTime,
 	)
@@ -84,18 +86,27 @@ func (p PostgresPersister) InsertInvitation(ctx context.Context, in model.Invita
 	}
 	var invitation model.Invitation
 	row := p.db.QueryRowContext(ctx, "INSERT INTO invitation (body, code, society_id) VALUES ($1, $2, $3) "+
-		"RETURNING id, body, code, society_id, insert_time, last_update_time", in.InvitationBody, in.Code, societyID)
+		"RETURNING id, body, code, society_id, uses, insert_time, last_update_time", in.InvitationBody, in.Code, societyID)
 	err = row.Scan(
 		&invitation.ID,
 		&invitation.InvitationBody,
 		&invitation.Code,
 		&invitation.SocietyID,
+		&invitation.Uses,
 		&invitation.InsertTime,
 		&invitation.LastUpdateTime,
 	)
 	return &invitation, translateError(err, nil, nil, "")
 }
 
+// IncrementInvitationUses adds one to the uses of an Invitation, unless it has already been used maxUses times
+func (p PostgresPersister) IncrementInvitationUses(ctx context.Context, id uint32, maxUses int) error {
+	var uses int
+	err := p.db.QueryRowContext(ctx, "UPDATE invitation SET uses = uses + 1, last_update_time = CURRENT_TIMESTAMP "+
+		"WHERE id = $1 AND uses < $2 RETURNING uses", id, maxUses).Scan(&uses)
+	return translateError(err, &id, nil, "")
+}
+
 // DeleteInvitation deletes a Invitation
 func (p PostgresPersister) DeleteInvitation(ctx context.Context, id uint32) error {
 	societyID, err := utils.GetSocietyIDFromContext(ctx)
@@ -2,9 +2,11 @@ package persist
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
 	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
 )
 
 // SelectNameVariants selects the NameVariants object if it exists or returns ErrNoRows
@@ -28,3 +30,78 @@ func (p PostgresPersister) SelectNameVariants(ctx context.Context, nameType mode
 	)
 	return &nameVariants, translateError(err, nil, nil, "")
 }
+
+const societyNameVariantsColumns = "name, added, removed, insert_time, last_update_time"
+
+func scanSocietyNameVariants(row rowScanner) (*model.SocietyNameVariants, error) {
+	var nameVariants model.SocietyNameVariants
+	err := row.Scan(&nameVariants.Name, &nameVariants.Added, &nameVariants.Removed,
+		&nameVariants.InsertTime, &nameVariants.LastUpdateTime)
+	if err != nil {
+		return nil, err
+	}
+	return &nameVariants, nil
+}
+
+// SelectSocietyNameVariants selects the society's changes to the variants of a name or returns ErrNotFound
+func (p PostgresPersister) SelectSocietyNameVariants(ctx context.Context, nameType model.NameType, name string) (*model.SocietyNameVariants, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nameVariants, err := scanSocietyNameVariants(p.db.QueryRowContext(ctx,
+		"SELECT "+societyNameVariantsColumns+" FROM society_name_variants "+
+			"WHERE society_id = $1 AND name_type = $2 AND name = $3", societyID, nameType, name))
+	if err == sql.ErrNoRows {
+		return nil, model.NewError(model.ErrNotFound, name)
+	}
+	return nameVariants, translateError(err, nil, nil, "")
+}
+
+// SelectAllSocietyNameVariants selects all of the society's changes to the variants of names of the specified type
+func (p PostgresPersister) SelectAllSocietyNameVariants(ctx context.Context, nameType model.NameType) ([]model.SocietyNameVariants, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := p.db.QueryContext(ctx, "SELECT "+societyNameVariantsColumns+" FROM society_name_variants "+
+		"WHERE society_id = $1 AND name_type = $2 ORDER BY name", societyID, nameType)
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	defer rows.Close()
+	result := make([]model.SocietyNameVariants, 0)
+	for rows.Next() {
+		nameVariants, err := scanSocietyNameVariants(rows)
+		if err != nil {
+			return nil, translateError(err, nil, nil, "")
+		}
+		result = append(result, *nameVariants)
+	}
+	return result, nil
+}
+
+// UpsertSocietyNameVariants inserts or replaces the society's changes to the variants of a name
+func (p PostgresPersister) UpsertSocietyNameVariants(ctx context.Context, nameType model.NameType, in model.SocietyNameVariants) (*model.SocietyNameVariants, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nameVariants, err := scanSocietyNameVariants(p.db.QueryRowContext(ctx,
+		"INSERT INTO society_name_variants (society_id, name_type, name, added, removed) VALUES ($1, $2, $3, $4, $5) "+
+			"ON CONFLICT (society_id, name_type, name) DO UPDATE SET added = $4, removed = $5, last_update_time = CURRENT_TIMESTAMP "+
+			"RETURNING "+societyNameVariantsColumns,
+		societyID, nameType, in.Name, in.Added, in.Removed))
+	return nameVariants, translateError(err, nil, nil, "")
+}
+
+// DeleteSocietyNameVariants deletes the society's changes to the variants of a name
+func (p PostgresPersister) DeleteSocietyNameVariants(ctx context.Context, nameType model.NameType, name string) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx, "DELETE FROM society_name_variants WHERE society_id = $1 AND name_type = $2 AND name = $3",
+		societyID, nameType, name)
+	return translateError(err, nil, nil, "")
+}
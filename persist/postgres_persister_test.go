@@ -2,6 +2,7 @@ package persist_test
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"log"
 	"testing"
@@ -144,7 +145,7 @@ func TestSelectCollections(t *testing.T) {
 
 	mock.ExpectQuery(
 		"SELECT id, array_agg(cc.category_id), body, insert_time, last_update_time " +
-			"FROM collection LEFT JOIN collection_category cc ON id = cc.collection_id WHERE society_id=$1 GROUP BY id").
+			"FROM collection LEFT JOIN collection_category cc ON id = cc.collection_id WHERE society_id=$1 AND delete_time IS NULL GROUP BY id").
 		WithArgs(1).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "body", "insert_time", "last_update_time"}).
 			AddRow(1, "{1}", js, now, now).
@@ -177,7 +178,7 @@ func TestSelectOneCollection(t *testing.T) {
 	now := time.Now()
 	mock.ExpectQuery(
 		"SELECT id, array_agg(cc.category_id), body, insert_time, last_update_time "+
-			"FROM collection LEFT JOIN collection_category cc ON id = cc.collection_id WHERE society_id=$1 AND id = $2 GROUP BY id").
+			"FROM collection LEFT JOIN collection_category cc ON id = cc.collection_id WHERE society_id=$1 AND id = $2 AND delete_time IS NULL GROUP BY id").
 		WithArgs(1, 1).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "body", "insert_time", "last_update_time"}).
 			AddRow(1, "{1}", js, now, now))
@@ -239,7 +240,7 @@ func TestUpdateCollection(t *testing.T) {
 	mock.ExpectBegin()
 	mock.ExpectQuery(
 		"SELECT id, array_agg(cc.category_id), body, insert_time, last_update_time "+
-			"FROM collection LEFT JOIN collection_category cc ON id = cc.collection_id WHERE society_id=$1 AND id = $2 GROUP BY id").
+			"FROM collection LEFT JOIN collection_category cc ON id = cc.collection_id WHERE society_id=$1 AND id = $2 AND delete_time IS NULL GROUP BY id").
 		WithArgs(1, 1).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "body", "insert_time", "last_update_time"}).
 			AddRow(1, "{1}", jsExist, now, now))
@@ -277,7 +278,7 @@ func TestDeleteCollection(t *testing.T) {
 	mock.ExpectBegin()
 	mock.ExpectQuery(
 		"SELECT id, array_agg(cc.category_id), body, insert_time, last_update_time "+
-			"FROM collection LEFT JOIN collection_category cc ON id = cc.collection_id WHERE society_id=$1 AND id = $2 GROUP BY id").
+			"FROM collection LEFT JOIN collection_category cc ON id = cc.collection_id WHERE society_id=$1 AND id = $2 AND delete_time IS NULL GROUP BY id").
 		WithArgs(1, 1).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "body", "insert_time", "last_update_time"}).
 			AddRow(1, "{1}", jsExist, now, now))
@@ -291,6 +292,199 @@ func TestDeleteCollection(t *testing.T) {
 	assert.Nil(t, e)
 }
 
+func TestSelectAuditEvents(t *testing.T) {
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 1)
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+	p := persist.NewPostgresPersister(db)
+
+	body := model.AuditEventBody{User: 3, Entity: model.AuditEntityPost, EntityID: 4, Action: model.AuditActionPublish}
+	js, err := json.Marshal(body)
+	assert.NoError(t, err)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT id, body, society_id, insert_time FROM audit_event "+
+		"WHERE society_id = $1 AND ($2 = 0 OR (body->>'user')::int = $2) AND ($3 = '' OR body->>'entity' = $3) "+
+		"AND ($4 = 0 OR (body->>'entityId')::int = $4) AND ($5 = '' OR body->>'action' = $5) "+
+		"AND ($6::timestamptz IS NULL OR insert_time >= $6) AND ($7::timestamptz IS NULL OR insert_time < $7) "+
+		"AND ($8 = 0 OR id < $8) ORDER BY id DESC LIMIT $9").
+		WithArgs(1, 0, model.AuditEntityPost, 0, "", now, nil, 10, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "body", "society_id", "insert_time"}).
+			AddRow(9, js, 1, now))
+
+	events, e := p.SelectAuditEvents(ctx, model.AuditFilter{Entity: model.AuditEntityPost, From: &now, Before: 10, Limit: 5})
+	assert.Nil(t, e)
+	assert.Len(t, events, 1)
+	assert.Equal(t, uint32(9), events[0].ID)
+	assert.Equal(t, uint32(1), events[0].SocietyID)
+	assert.Equal(t, body, events[0].AuditEventBody)
+}
+
+func TestUpdateUser(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+	p := persist.NewPostgresPersister(db)
+	now := time.Now()
+	in := model.User{ID: 1, UserBody: model.UserBody{Name: "Somebody", Email: "new@example.com", Issuer: "https://issuer.example.com", Subject: "user1"},
+		InsertTime: now, LastUpdateTime: now}
+	js, err := json.Marshal(in.UserBody)
+	assert.NoError(t, err)
+
+	later := now.Add(time.Minute)
+	mock.ExpectQuery("UPDATE cms_user SET body = $1, last_update_time = CURRENT_TIMESTAMP WHERE id = $2 AND last_update_time = $3 RETURNING id, body, insert_time, last_update_time").
+		WithArgs([]byte(js), 1, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "body", "insert_time", "last_update_time"}).AddRow(1, js, now, later))
+
+	u, e := p.UpdateUser(context.TODO(), 1, in)
+	assert.Nil(t, e)
+	assert.Equal(t, uint32(1), u.ID)
+	assert.Equal(t, in.UserBody, u.UserBody)
+	assert.Equal(t, later, u.LastUpdateTime)
+}
+
+func TestInsertUserIdentity(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+	p := persist.NewPostgresPersister(db)
+	in := model.UserIdentityIn{UserIdentityBody: model.UserIdentityBody{Issuer: "https://issuer.example.com", Subject: "user2"}, UserID: 1}
+	js, err := json.Marshal(in.UserIdentityBody)
+	assert.NoError(t, err)
+	now := time.Now()
+	query := "INSERT INTO user_identity (body, user_id) SELECT $1, $2 " +
+		"WHERE NOT EXISTS (SELECT 1 FROM cms_user WHERE body->>'iss' = $3 AND body->>'sub' = $4) " +
+		"RETURNING id, body, user_id, insert_time, last_update_time"
+
+	mock.ExpectQuery(query).
+		WithArgs([]byte(js), 1, in.Issuer, in.Subject).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "body", "user_id", "insert_time", "last_update_time"}).AddRow(5, js, 1, now, now))
+	identity, e := p.InsertUserIdentity(context.TODO(), in)
+	assert.Nil(t, e)
+	assert.Equal(t, uint32(5), identity.ID)
+	assert.Equal(t, in, identity.UserIdentityIn)
+
+	// identities that a user was created with can't be linked to another user
+	mock.ExpectQuery(query).
+		WithArgs([]byte(js), 1, in.Issuer, in.Subject).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "body", "user_id", "insert_time", "last_update_time"}))
+	_, e = p.InsertUserIdentity(context.TODO(), in)
+	assert.True(t, model.ErrConflict.Matches(e))
+}
+
+func TestIncrementRateLimit(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+	p := persist.NewPostgresPersister(db)
+	windowStart := time.Now().Truncate(time.Minute)
+
+	mock.ExpectQuery("INSERT INTO rate_limit (key, window_start, count) VALUES ($1, $2, 1) "+
+		"ON CONFLICT (key, window_start) DO UPDATE SET count = rate_limit.count + 1 RETURNING count").
+		WithArgs("ip:1.2.3.4/1m0s", windowStart).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	count, e := p.IncrementRateLimit(context.TODO(), "ip:1.2.3.4/1m0s", windowStart)
+	assert.Nil(t, e)
+	assert.Equal(t, 3, count)
+}
+
+func TestSocietyTemplates(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+	p := persist.NewPostgresPersister(db)
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 7)
+	now := time.Now()
+	body := model.SocietyTemplateBody{
+		SocietyTemplateExport: model.SocietyTemplateExport{Name: "Census"},
+		Categories:            []model.TemplateCategory{{ID: 1, CategoryBody: model.CategoryBody{Name: "Census"}}},
+	}
+	js, err := json.Marshal(body)
+	assert.NoError(t, err)
+
+	mock.ExpectQuery("INSERT INTO society_template (body, society_id) VALUES ($1, $2) "+
+		"RETURNING id, body, COALESCE(society_id, 0), insert_time, last_update_time").
+		WithArgs(body, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "body", "society_id", "insert_time", "last_update_time"}).
+			AddRow(1, js, 7, now, now))
+	template, e := p.InsertSocietyTemplate(ctx, model.SocietyTemplateIn{SocietyTemplateBody: body})
+	assert.Nil(t, e)
+	assert.Equal(t, uint32(7), template.SocietyID)
+	assert.Equal(t, "Census", template.Categories[0].Name)
+
+	// templates of deleted societies have no society
+	mock.ExpectQuery("SELECT id, body, COALESCE(society_id, 0), insert_time, last_update_time FROM society_template " +
+		"WHERE society_id = $1 OR (body->>'public')::boolean ORDER BY body->>'name', id").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "body", "society_id", "insert_time", "last_update_time"}).
+			AddRow(1, js, 7, now, now).
+			AddRow(2, js, 0, now, now))
+	templates, e := p.SelectSocietyTemplates(ctx)
+	assert.Nil(t, e)
+	assert.Len(t, templates, 2)
+	assert.Equal(t, uint32(0), templates[1].SocietyID)
+
+	mock.ExpectQuery("SELECT id, body, COALESCE(society_id, 0), insert_time, last_update_time FROM society_template WHERE id = $1").
+		WithArgs(3).
+		WillReturnError(sql.ErrNoRows)
+	_, e = p.SelectOneSocietyTemplate(ctx, 3)
+	assert.Equal(t, model.ErrNotFound, e.(*model.Error).Code)
+
+	mock.ExpectExec("DELETE FROM society_template WHERE society_id = $1 AND id = $2").
+		WithArgs(7, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	e = p.DeleteSocietyTemplate(ctx, 1)
+	assert.Nil(t, e)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTrash(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+	p := persist.NewPostgresPersister(db)
+	ctx := utils.AddSocietyIDToContext(context.TODO(), 7)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT 'post' AS entity, id, society_id, COALESCE(body->>'name', '') AS name, collection_id, delete_time FROM post " +
+		"WHERE society_id = $1 AND delete_time IS NOT NULL UNION ALL " +
+		"SELECT 'collection', id, society_id, COALESCE(body->>'name', ''), 0, delete_time FROM collection " +
+		"WHERE society_id = $1 AND delete_time IS NOT NULL ORDER BY delete_time DESC, id DESC").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"entity", "id", "society_id", "name", "collection_id", "delete_time"}).
+			AddRow("post", 3, 7, "1870", 2, now).
+			AddRow("collection", 2, 7, "Census", 0, now))
+	items, e := p.SelectTrash(ctx)
+	assert.Nil(t, e)
+	assert.Len(t, items, 2)
+	assert.Equal(t, model.TrashEntityPost, items[0].Entity)
+	assert.Equal(t, uint32(2), items[0].CollectionID)
+
+	// posts that are already in the trash can't be trashed again
+	mock.ExpectExec("UPDATE post SET delete_time = CURRENT_TIMESTAMP WHERE society_id = $1 AND id = $2 AND delete_time IS NULL").
+		WithArgs(7, 3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	e = p.TrashPost(ctx, 3)
+	assert.Equal(t, model.ErrNotFound, e.(*model.Error).Code)
+
+	mock.ExpectExec("UPDATE collection SET delete_time = NULL WHERE society_id = $1 AND id = $2 AND delete_time IS NOT NULL").
+		WithArgs(7, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	e = p.RestoreCollection(ctx, 2)
+	assert.Nil(t, e)
+
+	// a society that was restored isn't purged
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM society WHERE id = $1 AND delete_time IS NOT NULL FOR UPDATE").
+		WithArgs(7).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	e = p.PurgeSociety(ctx)
+	assert.Equal(t, model.ErrNotFound, e.(*model.Error).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func makeCategoryIn(t *testing.T) model.CategoryIn {
 	in, e := model.NewCategoryIn("Test Category")
 	assert.Nil(t, e)
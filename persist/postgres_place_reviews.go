@@ -0,0 +1,110 @@
+package persist
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+const placeReviewColumns = "id, text, body, insert_time, last_update_time"
+
+func scanPlaceReview(row rowScanner) (*model.PlaceReview, error) {
+	var placeReview model.PlaceReview
+	err := row.Scan(&placeReview.ID, &placeReview.Text, &placeReview.PlaceReviewBody,
+		&placeReview.InsertTime, &placeReview.LastUpdateTime)
+	if err != nil {
+		return nil, err
+	}
+	return &placeReview, nil
+}
+
+// SelectPlaceReviews selects the society's place reviews with the specified status, most frequent first
+// If status is empty, all place reviews are selected
+func (p PostgresPersister) SelectPlaceReviews(ctx context.Context, status model.PlaceReviewStatus) ([]model.PlaceReview, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := p.db.QueryContext(ctx, "SELECT "+placeReviewColumns+" FROM place_review "+
+		"WHERE society_id = $1 AND ($2 = '' OR body->>'status' = $2) "+
+		"ORDER BY (body->>'occurrences')::int DESC, id", societyID, string(status))
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	defer rows.Close()
+	placeReviews := make([]model.PlaceReview, 0)
+	for rows.Next() {
+		placeReview, err := scanPlaceReview(rows)
+		if err != nil {
+			return nil, translateError(err, nil, nil, "")
+		}
+		placeReviews = append(placeReviews, *placeReview)
+	}
+	return placeReviews, nil
+}
+
+// SelectPlaceReview selects a place review
+func (p PostgresPersister) SelectPlaceReview(ctx context.Context, id uint32) (*model.PlaceReview, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	placeReview, err := scanPlaceReview(p.db.QueryRowContext(ctx,
+		"SELECT "+placeReviewColumns+" FROM place_review WHERE society_id = $1 AND id = $2", societyID, id))
+	return placeReview, translateError(err, &id, nil, "")
+}
+
+// SelectPlaceReviewByText selects the place review for normalized place text
+func (p PostgresPersister) SelectPlaceReviewByText(ctx context.Context, text string) (*model.PlaceReview, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	placeReview, err := scanPlaceReview(p.db.QueryRowContext(ctx,
+		"SELECT "+placeReviewColumns+" FROM place_review WHERE society_id = $1 AND text = $2", societyID, text))
+	if err == sql.ErrNoRows {
+		return nil, model.NewError(model.ErrNotFound, text)
+	}
+	return placeReview, translateError(err, nil, nil, "")
+}
+
+// InsertPlaceReview inserts a PlaceReview
+// If the society already has a place review for the text, ErrConflict is returned
+func (p PostgresPersister) InsertPlaceReview(ctx context.Context, in model.PlaceReviewIn) (*model.PlaceReview, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	placeReview, err := scanPlaceReview(p.db.QueryRowContext(ctx,
+		"INSERT INTO place_review (society_id, text, body) VALUES ($1, $2, $3) "+
+			"ON CONFLICT (society_id, text) DO NOTHING RETURNING "+placeReviewColumns,
+		societyID, in.Text, in.PlaceReviewBody))
+	if err == sql.ErrNoRows {
+		return nil, model.NewError(model.ErrConflict)
+	}
+	return placeReview, translateError(err, nil, nil, "")
+}
+
+// UpdatePlaceReview updates a PlaceReview
+func (p PostgresPersister) UpdatePlaceReview(ctx context.Context, id uint32, in model.PlaceReview) (*model.PlaceReview, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	placeReview, err := scanPlaceReview(p.db.QueryRowContext(ctx,
+		`UPDATE place_review SET body = $1, last_update_time = CURRENT_TIMESTAMP
+		 WHERE society_id = $2 AND id = $3 AND last_update_time = $4
+		 RETURNING `+placeReviewColumns,
+		in.PlaceReviewBody, societyID, id, in.LastUpdateTime))
+	if err != nil && err == sql.ErrNoRows {
+		// Either the row doesn't exist or it has a non-matching update time
+		curr, e := p.SelectPlaceReview(ctx, id)
+		if e != nil {
+			return nil, e
+		}
+		return nil, model.NewError(model.ErrConcurrentUpdate, curr.LastUpdateTime.String(), in.LastUpdateTime.String())
+	}
+	return placeReview, translateError(err, &id, nil, "")
+}
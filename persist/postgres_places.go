@@ -2,16 +2,21 @@ package persist
 
 import (
 	"context"
+	"database/sql"
 	"regexp"
 	"strings"
 
 	"github.com/lib/pq"
 
 	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
 )
 
 const PlaceSettingsID = 1
 
+const placeColumns = "id, name, full_name, alt_names, types, located_in_id, also_located_in_ids, level, country_id, latitude, longitude, count, periods, " +
+	"COALESCE(society_id, 0), insert_time, last_update_time"
+
 // SelectPlaceSettings selects the PlaceSettings object if it exists or returns ErrNoRows
 func (p PostgresPersister) SelectPlaceSettings(ctx context.Context) (*model.PlaceSettings, error) {
 	var placeSettings model.PlaceSettings
@@ -26,113 +31,47 @@ func (p PostgresPersister) SelectPlaceSettings(ctx context.Context) (*model.Plac
 
 // SelectPlace selects the Place object if it exists or returns ErrNoRows
 func (p PostgresPersister) SelectPlace(ctx context.Context, id uint32) (*model.Place, error) {
-	var place model.Place
-	err := p.db.QueryRowContext(ctx, "SELECT id, name, full_name, alt_names, types, located_in_id, also_located_in_ids, level, country_id, latitude, longitude, count, insert_time, last_update_time "+
-		"FROM place WHERE id=$1", id).Scan(
-		&place.ID,
-		&place.Name,
-		&place.FullName,
-		&place.AltNames,
-		&place.Types,
-		&place.LocatedInID,
-		&place.AlsoLocatedInIDs,
-		&place.Level,
-		&place.CountryID,
-		&place.Latitude,
-		&place.Longitude,
-		&place.Count,
-		&place.InsertTime,
-		&place.LastUpdateTime,
-	)
-	return &place, translateError(err, &id, nil, "")
+	place, err := scanPlace(p.db.QueryRowContext(ctx, "SELECT "+placeColumns+" FROM place WHERE id=$1", id))
+	return place, translateError(err, &id, nil, "")
 }
 
 // SelectPlacesByID selects multiple Place objects by ID
 func (p PostgresPersister) SelectPlacesByID(ctx context.Context, ids []uint32) ([]model.Place, error) {
-	places := make([]model.Place, 0)
 	if len(ids) == 0 {
-		return places, nil
+		return make([]model.Place, 0), nil
 	}
-	rows, err := p.db.QueryContext(ctx, "SELECT id, name, full_name, alt_names, types, located_in_id, also_located_in_ids, level, country_id, latitude, longitude, count, insert_time, last_update_time "+
-		"FROM place WHERE id = ANY($1)", pq.Array(ids))
+	rows, err := p.db.QueryContext(ctx, "SELECT "+placeColumns+" FROM place WHERE id = ANY($1)", pq.Array(ids))
 	if err != nil {
 		return nil, translateError(err, nil, nil, "")
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var place model.Place
-		err := rows.Scan(
-			&place.ID,
-			&place.Name,
-			&place.FullName,
-			&place.AltNames,
-			&place.Types,
-			&place.LocatedInID,
-			&place.AlsoLocatedInIDs,
-			&place.Level,
-			&place.CountryID,
-			&place.Latitude,
-			&place.Longitude,
-			&place.Count,
-			&place.InsertTime,
-			&place.LastUpdateTime,
-		)
-		if err != nil {
-			return nil, translateError(err, nil, nil, "")
-		}
-		places = append(places, place)
-	}
-	return places, nil
+	return scanPlaces(rows)
 }
 
 var placeRegexp = regexp.MustCompile("\\s*,\\s*")
 
 // SelectPlacesByFullNamePrefix selects multiple Place objects by a prefix
+// Places added by the society in the context are included along with global places
 func (p PostgresPersister) SelectPlacesByFullNamePrefix(ctx context.Context, prefix string, count int) ([]model.Place, error) {
-	places := make([]model.Place, 0)
 	if prefix == "" {
-		return places, nil
+		return make([]model.Place, 0), nil
 	}
 	search := strings.Join(placeRegexp.Split(prefix, -1), "%")
 	if !strings.HasSuffix(search, "%") {
 		search += "%"
 	}
-	rows, err := p.db.QueryContext(ctx, "SELECT id, name, full_name, alt_names, types, located_in_id, also_located_in_ids, level, country_id, latitude, longitude, count, insert_time, last_update_time "+
-		"FROM place WHERE full_name ilike $1 ORDER BY count DESC LIMIT $2", search, count)
+	societyID, _ := utils.GetSocietyIDFromContext(ctx)
+	rows, err := p.db.QueryContext(ctx, "SELECT "+placeColumns+" FROM place "+
+		"WHERE full_name ilike $1 AND (society_id IS NULL OR society_id = $3) ORDER BY count DESC LIMIT $2", search, count, societyID)
 	if err != nil {
 		return nil, translateError(err, nil, nil, "")
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var place model.Place
-		err := rows.Scan(
-			&place.ID,
-			&place.Name,
-			&place.FullName,
-			&place.AltNames,
-			&place.Types,
-			&place.LocatedInID,
-			&place.AlsoLocatedInIDs,
-			&place.Level,
-			&place.CountryID,
-			&place.Latitude,
-			&place.Longitude,
-			&place.Count,
-			&place.InsertTime,
-			&place.LastUpdateTime,
-		)
-		if err != nil {
-			return nil, translateError(err, nil, nil, "")
-		}
-		places = append(places, place)
-	}
-	return places, nil
+	return scanPlaces(rows)
 }
 
-// SelectPlaceWord selects the PlaceWord object if it exists or returns ErrNoRows
+// SelectPlaceWord selects the global PlaceWord object if it exists or returns ErrNoRows
 func (p PostgresPersister) SelectPlaceWord(ctx context.Context, word string) (*model.PlaceWord, error) {
 	var placeWord model.PlaceWord
-	err := p.db.QueryRowContext(ctx, "SELECT word, ids, insert_time, last_update_time FROM place_word WHERE word=$1", word).Scan(
+	err := p.db.QueryRowContext(ctx, "SELECT word, ids, insert_time, last_update_time FROM place_word WHERE word=$1 AND society_id=0", word).Scan(
 		&placeWord.Word,
 		&placeWord.IDs,
 		&placeWord.InsertTime,
@@ -141,13 +80,19 @@ func (p PostgresPersister) SelectPlaceWord(ctx context.Context, word string) (*m
 	return &placeWord, translateError(err, nil, nil, "")
 }
 
-// SelectPlaceWordsByWord selects multiple PlaceWord objects by word
+// SelectPlaceWordsByWord selects multiple global PlaceWord objects by word
 func (p PostgresPersister) SelectPlaceWordsByWord(ctx context.Context, words []string) ([]model.PlaceWord, error) {
+	return p.SelectSocietyPlaceWordsByWord(ctx, 0, words)
+}
+
+// SelectSocietyPlaceWordsByWord selects multiple PlaceWord objects added by a society by word
+func (p PostgresPersister) SelectSocietyPlaceWordsByWord(ctx context.Context, societyID uint32, words []string) ([]model.PlaceWord, error) {
 	placeWords := make([]model.PlaceWord, 0)
 	if len(words) == 0 {
 		return placeWords, nil
 	}
-	rows, err := p.db.QueryContext(ctx, "SELECT word, ids, insert_time, last_update_time FROM place_word WHERE word = ANY($1)", pq.Array(words))
+	rows, err := p.db.QueryContext(ctx, "SELECT word, ids, insert_time, last_update_time FROM place_word WHERE word = ANY($1) AND society_id = $2",
+		pq.Array(words), societyID)
 	if err != nil {
 		return nil, translateError(err, nil, nil, "")
 	}
@@ -167,3 +112,165 @@ func (p PostgresPersister) SelectPlaceWordsByWord(ctx context.Context, words []s
 	}
 	return placeWords, nil
 }
+
+// InsertPlace inserts a place for the society in the context
+// Top-level places (CountryID 0) become their own country
+func (p PostgresPersister) InsertPlace(ctx context.Context, in model.Place) (*model.Place, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	place, err := scanPlace(p.db.QueryRowContext(ctx,
+		"WITH next AS (SELECT nextval('place_id_seq') AS id) "+
+			"INSERT INTO place (id, name, full_name, alt_names, types, located_in_id, also_located_in_ids, level, country_id, latitude, longitude, periods, society_id) "+
+			"SELECT next.id, $1, $2, $3, $4, $5, $6, $7, CASE WHEN $8 = 0 THEN next.id ELSE $8 END, $9, $10, $11, $12 FROM next "+
+			"RETURNING "+placeColumns,
+		in.Name, in.FullName, in.AltNames, in.Types, in.LocatedInID, in.AlsoLocatedInIDs, in.Level, in.CountryID,
+		in.Latitude, in.Longitude, in.Periods, societyID))
+	return place, translateError(err, nil, &in.LocatedInID, "place")
+}
+
+// UpdatePlace updates a place added by the society in the context
+func (p PostgresPersister) UpdatePlace(ctx context.Context, id uint32, in model.Place) (*model.Place, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	place, err := scanPlace(p.db.QueryRowContext(ctx,
+		"UPDATE place SET name = $1, full_name = $2, alt_names = $3, types = $4, located_in_id = $5, also_located_in_ids = $6, "+
+			"level = $7, country_id = CASE WHEN $8 = 0 THEN id ELSE $8 END, latitude = $9, longitude = $10, periods = $11, last_update_time = CURRENT_TIMESTAMP "+
+			"WHERE id = $12 AND society_id = $13 RETURNING "+placeColumns,
+		in.Name, in.FullName, in.AltNames, in.Types, in.LocatedInID, in.AlsoLocatedInIDs, in.Level, in.CountryID,
+		in.Latitude, in.Longitude, in.Periods, id, societyID))
+	return place, translateError(err, &id, &in.LocatedInID, "place")
+}
+
+// DeletePlace deletes a place added by the society in the context along with the society's alternate names for it
+func (p PostgresPersister) DeletePlace(ctx context.Context, id uint32) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err = p.db.ExecContext(ctx, "DELETE FROM place_alt_name WHERE society_id = $1 AND place_id = $2", societyID, id); err != nil {
+		return translateError(err, &id, nil, "")
+	}
+	_, err = p.db.ExecContext(ctx, "DELETE FROM place WHERE id = $1 AND society_id = $2", id, societyID)
+	return translateError(err, &id, nil, "")
+}
+
+// SelectPlaceChildren selects the places added by the society in the context that are located in a place
+func (p PostgresPersister) SelectPlaceChildren(ctx context.Context, id uint32) ([]model.Place, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := p.db.QueryContext(ctx, "SELECT "+placeColumns+" FROM place WHERE society_id = $1 AND located_in_id = $2", societyID, id)
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	return scanPlaces(rows)
+}
+
+// SelectPlaceAltNames selects the alternate names the society in the context has given a place
+func (p PostgresPersister) SelectPlaceAltNames(ctx context.Context, placeID uint32) (model.StringSlice, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var altNames model.StringSlice
+	err = p.db.QueryRowContext(ctx, "SELECT alt_names FROM place_alt_name WHERE society_id = $1 AND place_id = $2", societyID, placeID).
+		Scan(&altNames)
+	if err == sql.ErrNoRows {
+		return model.StringSlice{}, nil
+	}
+	return altNames, translateError(err, &placeID, nil, "")
+}
+
+// UpdatePlaceAltNames sets the alternate names the society in the context has given a place
+func (p PostgresPersister) UpdatePlaceAltNames(ctx context.Context, placeID uint32, altNames model.StringSlice) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if len(altNames) == 0 {
+		_, err = p.db.ExecContext(ctx, "DELETE FROM place_alt_name WHERE society_id = $1 AND place_id = $2", societyID, placeID)
+		return translateError(err, &placeID, nil, "")
+	}
+	_, err = p.db.ExecContext(ctx,
+		"INSERT INTO place_alt_name (society_id, place_id, alt_names) VALUES ($1, $2, $3) "+
+			"ON CONFLICT (society_id, place_id) DO UPDATE SET alt_names = $3, last_update_time = CURRENT_TIMESTAMP",
+		societyID, placeID, altNames)
+	return translateError(err, nil, &placeID, "place")
+}
+
+// AddPlaceWordID adds a place ID to the society's entry for a word
+func (p PostgresPersister) AddPlaceWordID(ctx context.Context, word string, id uint32) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx,
+		"INSERT INTO place_word (word, society_id, ids) VALUES ($1, $2, jsonb_build_array($3::int)) "+
+			"ON CONFLICT (word, society_id) DO UPDATE SET "+
+			"ids = CASE WHEN place_word.ids @> jsonb_build_array($3::int) THEN place_word.ids ELSE place_word.ids || jsonb_build_array($3::int) END, "+
+			"last_update_time = CURRENT_TIMESTAMP",
+		word, societyID, id)
+	return translateError(err, nil, nil, "")
+}
+
+// RemovePlaceWordID removes a place ID from the society's entry for a word, removing the entry if it is empty
+func (p PostgresPersister) RemovePlaceWordID(ctx context.Context, word string, id uint32) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx,
+		"UPDATE place_word SET ids = COALESCE((SELECT jsonb_agg(e) FROM jsonb_array_elements(ids) e WHERE e <> to_jsonb($3::int)), '[]'::jsonb), "+
+			"last_update_time = CURRENT_TIMESTAMP WHERE word = $1 AND society_id = $2",
+		word, societyID, id)
+	if err != nil {
+		return translateError(err, nil, nil, "")
+	}
+	_, err = p.db.ExecContext(ctx, "DELETE FROM place_word WHERE word = $1 AND society_id = $2 AND ids = '[]'::jsonb", word, societyID)
+	return translateError(err, nil, nil, "")
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPlace(row rowScanner) (*model.Place, error) {
+	var place model.Place
+	err := row.Scan(
+		&place.ID,
+		&place.Name,
+		&place.FullName,
+		&place.AltNames,
+		&place.Types,
+		&place.LocatedInID,
+		&place.AlsoLocatedInIDs,
+		&place.Level,
+		&place.CountryID,
+		&place.Latitude,
+		&place.Longitude,
+		&place.Count,
+		&place.Periods,
+		&place.SocietyID,
+		&place.InsertTime,
+		&place.LastUpdateTime,
+	)
+	return &place, err
+}
+
+func scanPlaces(rows *sql.Rows) ([]model.Place, error) {
+	defer rows.Close()
+	places := make([]model.Place, 0)
+	for rows.Next() {
+		place, err := scanPlace(rows)
+		if err != nil {
+			return nil, translateError(err, nil, nil, "")
+		}
+		places = append(places, *place)
+	}
+	return places, nil
+}
@@ -0,0 +1,56 @@
+package persist
+
+import (
+	"context"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+// SelectPostEvents selects the events for a post, oldest first
+func (p PostgresPersister) SelectPostEvents(ctx context.Context, postID uint32) ([]model.PostEvent, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := p.db.QueryContext(ctx, "SELECT id, body, post_id, insert_time FROM post_event WHERE society_id = $1 AND post_id = $2 ORDER BY id",
+		societyID, postID)
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	defer rows.Close()
+	postEvents := make([]model.PostEvent, 0)
+	for rows.Next() {
+		var postEvent model.PostEvent
+		err := rows.Scan(&postEvent.ID, &postEvent.PostEventBody, &postEvent.Post, &postEvent.InsertTime)
+		if err != nil {
+			return nil, translateError(err, nil, nil, "")
+		}
+		postEvents = append(postEvents, postEvent)
+	}
+	return postEvents, nil
+}
+
+// InsertPostEvent inserts a PostEvent
+func (p PostgresPersister) InsertPostEvent(ctx context.Context, in model.PostEventIn) (*model.PostEvent, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var postEvent model.PostEvent
+	err = p.db.QueryRowContext(ctx,
+		"INSERT INTO post_event (body, post_id, society_id) VALUES ($1, $2, $3) RETURNING id, body, post_id, insert_time",
+		in.PostEventBody, in.Post, societyID).
+		Scan(&postEvent.ID, &postEvent.PostEventBody, &postEvent.Post, &postEvent.InsertTime)
+	return &postEvent, translateError(err, nil, &in.Post, "post")
+}
+
+// DeletePostEvents deletes the events for a post
+func (p PostgresPersister) DeletePostEvents(ctx context.Context, postID uint32) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx, "DELETE FROM post_event WHERE society_id = $1 AND post_id = $2", societyID, postID)
+	return translateError(err, &postID, nil, "")
+}
@@ -17,7 +17,7 @@ func (p PostgresPersister) SelectPosts(ctx context.Context) ([]model.Post, error
 		return nil, err
 	}
 	rows, err := p.db.QueryContext(ctx, "SELECT id, collection_id, body, insert_time, last_update_time FROM post "+
-		"WHERE society_id=$1", societyID)
+		"WHERE society_id=$1 AND delete_time IS NULL", societyID)
 	if err != nil {
 		return nil, translateError(err, nil, nil, "")
 	}
@@ -42,7 +42,7 @@ func (p PostgresPersister) SelectOnePost(ctx context.Context, id uint32) (*model
 	}
 	var post model.Post
 	err = p.db.QueryRowContext(ctx, "SELECT id, collection_id, body, insert_time, last_update_time FROM post "+
-		"WHERE society_id=$1 AND id=$2", societyID, id).Scan(
+		"WHERE society_id=$1 AND id=$2 AND delete_time IS NULL", societyID, id).Scan(
 		&post.ID,
 		&post.Collection,
 		&post.PostBody,
@@ -86,7 +86,7 @@ func (p PostgresPersister) UpdatePost(ctx context.Context, id uint32, in model.P
 	var post model.Post
 	err = p.db.QueryRowContext(ctx,
 		`UPDATE post SET body = $1, collection_id = $2, last_update_time = CURRENT_TIMESTAMP
-		 WHERE society_id=$3 AND id = $4 AND last_update_time = $5
+		 WHERE society_id=$3 AND id = $4 AND last_update_time = $5 AND delete_time IS NULL
 		 RETURNING id, collection_id, body, insert_time, last_update_time`,
 		in.PostBody, in.Collection, societyID, id, in.LastUpdateTime).
 		Scan(
@@ -99,7 +99,7 @@ func (p PostgresPersister) UpdatePost(ctx context.Context, id uint32, in model.P
 	if err != nil && err == sql.ErrNoRows {
 		// Either non-existent or last_update_time didn't match
 		c, _ := p.SelectOnePost(ctx, id)
-		if c.ID == id {
+		if c != nil && c.ID == id {
 			// Row exists, so it must be a non-matching update time
 			return nil, model.NewError(model.ErrConcurrentUpdate, c.LastUpdateTime.String(), in.LastUpdateTime.String())
 		}
@@ -0,0 +1,21 @@
+package persist
+
+import (
+	"context"
+	"time"
+)
+
+// IncrementRateLimit adds one to the count for key in the window that starts at windowStart, and returns the new count
+func (p PostgresPersister) IncrementRateLimit(ctx context.Context, key string, windowStart time.Time) (int, error) {
+	var count int
+	err := p.db.QueryRowContext(ctx, "INSERT INTO rate_limit (key, window_start, count) VALUES ($1, $2, 1) "+
+		"ON CONFLICT (key, window_start) DO UPDATE SET count = rate_limit.count + 1 RETURNING count",
+		key, windowStart).Scan(&count)
+	return count, translateError(err, nil, nil, "")
+}
+
+// DeleteRateLimitsBefore deletes the counts for windows that started before the specified time
+func (p PostgresPersister) DeleteRateLimitsBefore(ctx context.Context, before time.Time) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM rate_limit WHERE window_start < $1", before)
+	return translateError(err, nil, nil, "")
+}
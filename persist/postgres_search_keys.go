@@ -0,0 +1,87 @@
+package persist
+
+import (
+	"context"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+const selectSearchKey = "SELECT id, body, kid, society_id, insert_time, last_update_time FROM search_key "
+
+// SelectSearchKeys selects the search keys for the society, newest first
+func (p PostgresPersister) SelectSearchKeys(ctx context.Context) ([]model.SearchKey, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	searchKeys := make([]model.SearchKey, 0)
+	rows, err := p.db.QueryContext(ctx, selectSearchKey+"WHERE society_id = $1 ORDER BY insert_time DESC, id DESC", societyID)
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		searchKey, err := scanSearchKey(rows)
+		if err != nil {
+			return nil, translateError(err, nil, nil, "")
+		}
+		searchKeys = append(searchKeys, *searchKey)
+	}
+	return searchKeys, nil
+}
+
+// SelectSearchKey selects the society's search key with the kid
+func (p PostgresPersister) SelectSearchKey(ctx context.Context, kid string) (*model.SearchKey, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	searchKey, err := scanSearchKey(p.db.QueryRowContext(ctx, selectSearchKey+"WHERE society_id = $1 AND kid = $2", societyID, kid))
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	return searchKey, nil
+}
+
+// InsertSearchKey inserts a SearchKeyIn into the database and returns the inserted SearchKey
+func (p PostgresPersister) InsertSearchKey(ctx context.Context, in model.SearchKeyIn) (*model.SearchKey, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	searchKey, err := scanSearchKey(p.db.QueryRowContext(ctx,
+		"INSERT INTO search_key (body, kid, society_id) VALUES ($1, $2, $3) "+
+			"RETURNING id, body, kid, society_id, insert_time, last_update_time",
+		in.SearchKeyBody, in.KID, societyID))
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	return searchKey, nil
+}
+
+// DeleteSearchKey deletes the society's search key with the kid
+func (p PostgresPersister) DeleteSearchKey(ctx context.Context, kid string) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx, "DELETE FROM search_key WHERE society_id = $1 AND kid = $2", societyID, kid)
+	return translateError(err, nil, nil, "")
+}
+
+func scanSearchKey(row rowScanner) (*model.SearchKey, error) {
+	var searchKey model.SearchKey
+	err := row.Scan(
+		&searchKey.ID,
+		&searchKey.SearchKeyBody,
+		&searchKey.KID,
+		&searchKey.SocietyID,
+		&searchKey.InsertTime,
+		&searchKey.LastUpdateTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &searchKey, nil
+}
@@ -17,7 +17,7 @@ func (p PostgresPersister) SelectSocietySummariesByID(ctx context.Context, ids [
 	societySummaries := make([]model.SocietySummary, 0)
 
 	rows, err := p.db.QueryContext(ctx, "SELECT id, body, insert_time, last_update_time FROM society "+
-		"WHERE id = ANY($1)", pq.Array(ids))
+		"WHERE id = ANY($1) AND delete_time IS NULL", pq.Array(ids))
 	if err != nil {
 		return nil, translateError(err, nil, nil, "")
 	}
@@ -44,7 +44,7 @@ func (p PostgresPersister) SelectSocietySummary(ctx context.Context, id uint32)
 	var society model.Society
 	log.Printf("[DEBUG] id: %d", id)
 	err := p.db.QueryRowContext(ctx, "SELECT id, body, insert_time, last_update_time FROM society "+
-		"WHERE id=$1", id).Scan(
+		"WHERE id=$1 AND delete_time IS NULL", id).Scan(
 		&society.ID,
 		&society.SocietyBody,
 		&society.InsertTime,
@@ -61,7 +61,7 @@ func (p PostgresPersister) SelectSociety(ctx context.Context, id uint32) (*model
 	var society model.Society
 	log.Printf("[DEBUG] id: %d", id)
 	err := p.db.QueryRowContext(ctx, "SELECT id, body, insert_time, last_update_time FROM society "+
-		"WHERE id=$1", id).Scan(
+		"WHERE id=$1 AND delete_time IS NULL", id).Scan(
 		&society.ID,
 		&society.SocietyBody,
 		&society.InsertTime,
@@ -95,7 +95,7 @@ func (p PostgresPersister) UpdateSociety(ctx context.Context, in model.Society)
 	}
 	var society model.Society
 	err = p.db.QueryRowContext(ctx, "UPDATE society SET body = $1, last_update_time = CURRENT_TIMESTAMP "+
-		"WHERE id = $2 AND last_update_time = $3 RETURNING id, body, insert_time, last_update_time",
+		"WHERE id = $2 AND last_update_time = $3 AND delete_time IS NULL RETURNING id, body, insert_time, last_update_time",
 		in.SocietyBody, societyID, in.LastUpdateTime).
 		Scan(
 			&society.ID,
@@ -0,0 +1,83 @@
+package persist
+
+import (
+	"context"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+const selectSocietyTemplate = "SELECT id, body, COALESCE(society_id, 0), insert_time, last_update_time FROM society_template "
+
+// SelectSocietyTemplates selects the society's templates and the public templates of other societies, by name
+func (p PostgresPersister) SelectSocietyTemplates(ctx context.Context) ([]model.SocietyTemplate, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]model.SocietyTemplate, 0)
+	rows, err := p.db.QueryContext(ctx, selectSocietyTemplate+
+		"WHERE society_id = $1 OR (body->>'public')::boolean ORDER BY body->>'name', id", societyID)
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		template, err := scanSocietyTemplate(rows)
+		if err != nil {
+			return nil, translateError(err, nil, nil, "")
+		}
+		templates = append(templates, *template)
+	}
+	return templates, nil
+}
+
+// SelectOneSocietyTemplate selects a society template, whichever society it belongs to
+func (p PostgresPersister) SelectOneSocietyTemplate(ctx context.Context, id uint32) (*model.SocietyTemplate, error) {
+	template, err := scanSocietyTemplate(p.db.QueryRowContext(ctx, selectSocietyTemplate+"WHERE id = $1", id))
+	if err != nil {
+		return nil, translateError(err, &id, nil, "")
+	}
+	return template, nil
+}
+
+// InsertSocietyTemplate inserts a SocietyTemplateIn into the database and returns the inserted SocietyTemplate
+func (p PostgresPersister) InsertSocietyTemplate(ctx context.Context, in model.SocietyTemplateIn) (*model.SocietyTemplate, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	template, err := scanSocietyTemplate(p.db.QueryRowContext(ctx,
+		"INSERT INTO society_template (body, society_id) VALUES ($1, $2) "+
+			"RETURNING id, body, COALESCE(society_id, 0), insert_time, last_update_time",
+		in.SocietyTemplateBody, societyID))
+	if err != nil {
+		return nil, translateError(err, nil, &societyID, "society")
+	}
+	return template, nil
+}
+
+// DeleteSocietyTemplate deletes one of the society's templates
+func (p PostgresPersister) DeleteSocietyTemplate(ctx context.Context, id uint32) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx, "DELETE FROM society_template WHERE society_id = $1 AND id = $2", societyID, id)
+	return translateError(err, &id, nil, "")
+}
+
+func scanSocietyTemplate(row rowScanner) (*model.SocietyTemplate, error) {
+	var template model.SocietyTemplate
+	err := row.Scan(
+		&template.ID,
+		&template.SocietyTemplateBody,
+		&template.SocietyID,
+		&template.InsertTime,
+		&template.LastUpdateTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
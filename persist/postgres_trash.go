@@ -0,0 +1,233 @@
+package persist
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+const selectTrashedPosts = "SELECT 'post' AS entity, id, society_id, COALESCE(body->>'name', '') AS name, collection_id, delete_time FROM post "
+const selectTrashedCollections = "SELECT 'collection', id, society_id, COALESCE(body->>'name', ''), 0, delete_time FROM collection "
+const selectTrashedSocieties = "SELECT 'society' AS entity, id, id, COALESCE(body->>'name', '') AS name, 0, delete_time FROM society "
+
+// SelectTrash selects the society's trashed collections and posts, most recently trashed first
+func (p PostgresPersister) SelectTrash(ctx context.Context) ([]model.TrashItem, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.selectTrashItems(ctx,
+		selectTrashedPosts+"WHERE society_id = $1 AND delete_time IS NOT NULL UNION ALL "+
+			selectTrashedCollections+"WHERE society_id = $1 AND delete_time IS NOT NULL "+
+			"ORDER BY delete_time DESC, id DESC", societyID)
+}
+
+// SelectTrashedSocieties selects the societies among ids that are in the trash
+func (p PostgresPersister) SelectTrashedSocieties(ctx context.Context, ids []uint32) ([]model.TrashItem, error) {
+	return p.selectTrashItems(ctx, selectTrashedSocieties+"WHERE id = ANY($1) AND delete_time IS NOT NULL ORDER BY delete_time DESC, id DESC", pq.Array(ids))
+}
+
+// SelectExpiredTrash selects the items of all societies that were trashed before the time;
+// posts come before collections, and collections before societies
+func (p PostgresPersister) SelectExpiredTrash(ctx context.Context, before time.Time) ([]model.TrashItem, error) {
+	return p.selectTrashItems(ctx,
+		"SELECT * FROM ("+
+			selectTrashedPosts+"WHERE delete_time < $1 UNION ALL "+
+			selectTrashedCollections+"WHERE delete_time < $1 UNION ALL "+
+			selectTrashedSocieties+"WHERE delete_time < $1"+
+			") trash ORDER BY CASE entity WHEN 'post' THEN 0 WHEN 'collection' THEN 1 ELSE 2 END, delete_time, id", before)
+}
+
+func (p PostgresPersister) selectTrashItems(ctx context.Context, query string, args ...interface{}) ([]model.TrashItem, error) {
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	defer rows.Close()
+	items := make([]model.TrashItem, 0)
+	for rows.Next() {
+		var item model.TrashItem
+		err := rows.Scan(&item.Entity, &item.ID, &item.SocietyID, &item.Name, &item.CollectionID, &item.DeleteTime)
+		if err != nil {
+			return nil, translateError(err, nil, nil, "")
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// SelectTrashedPost selects a post of the society that is in the trash
+func (p PostgresPersister) SelectTrashedPost(ctx context.Context, id uint32) (*model.Post, error) {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var post model.Post
+	err = p.db.QueryRowContext(ctx, "SELECT id, collection_id, body, insert_time, last_update_time FROM post "+
+		"WHERE society_id=$1 AND id=$2 AND delete_time IS NOT NULL", societyID, id).Scan(
+		&post.ID,
+		&post.Collection,
+		&post.PostBody,
+		&post.InsertTime,
+		&post.LastUpdateTime,
+	)
+	if err != nil {
+		return nil, translateError(err, &id, nil, "")
+	}
+	return &post, nil
+}
+
+// TrashSociety moves the society to the trash
+func (p PostgresPersister) TrashSociety(ctx context.Context) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	return p.setDeleteTime(ctx, societyID, "UPDATE society SET delete_time = CURRENT_TIMESTAMP WHERE id = $1 AND delete_time IS NULL", societyID)
+}
+
+// TrashCollection moves one of the society's collections to the trash
+func (p PostgresPersister) TrashCollection(ctx context.Context, id uint32) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	return p.setDeleteTime(ctx, id, "UPDATE collection SET delete_time = CURRENT_TIMESTAMP WHERE society_id = $1 AND id = $2 AND delete_time IS NULL",
+		societyID, id)
+}
+
+// TrashPost moves one of the society's posts to the trash
+func (p PostgresPersister) TrashPost(ctx context.Context, id uint32) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	return p.setDeleteTime(ctx, id, "UPDATE post SET delete_time = CURRENT_TIMESTAMP WHERE society_id = $1 AND id = $2 AND delete_time IS NULL",
+		societyID, id)
+}
+
+// RestoreSociety takes the society out of the trash
+func (p PostgresPersister) RestoreSociety(ctx context.Context) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	return p.setDeleteTime(ctx, societyID, "UPDATE society SET delete_time = NULL WHERE id = $1 AND delete_time IS NOT NULL", societyID)
+}
+
+// RestoreCollection takes one of the society's collections out of the trash
+func (p PostgresPersister) RestoreCollection(ctx context.Context, id uint32) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	return p.setDeleteTime(ctx, id, "UPDATE collection SET delete_time = NULL WHERE society_id = $1 AND id = $2 AND delete_time IS NOT NULL",
+		societyID, id)
+}
+
+// RestorePost takes one of the society's posts out of the trash
+func (p PostgresPersister) RestorePost(ctx context.Context, id uint32) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	return p.setDeleteTime(ctx, id, "UPDATE post SET delete_time = NULL WHERE society_id = $1 AND id = $2 AND delete_time IS NOT NULL",
+		societyID, id)
+}
+
+// setDeleteTime runs an update of delete_time, returning ErrNotFound for id if no row was updated
+func (p PostgresPersister) setDeleteTime(ctx context.Context, id uint32, query string, args ...interface{}) error {
+	res, err := p.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return translateError(err, nil, nil, "")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return model.NewError(model.ErrNotFound, strconv.Itoa(int(id)))
+	}
+	return nil
+}
+
+// PurgeCollection deletes one of the society's trashed collections; its posts must have been purged first
+func (p PostgresPersister) PurgeCollection(ctx context.Context, id uint32) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	// create a transaction so collection and collection_category stay in sync
+	tx, err := p.db.Begin()
+	if err != nil {
+		return translateError(err, &id, nil, "")
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM collection_category WHERE collection_id = "+
+		"(SELECT id FROM collection WHERE society_id = $1 AND id = $2 AND delete_time IS NOT NULL)", societyID, id)
+	if err != nil {
+		return translateError(err, &id, nil, "")
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM collection WHERE society_id = $1 AND id = $2 AND delete_time IS NOT NULL", societyID, id)
+	if err != nil {
+		return translateError(err, &id, nil, "")
+	}
+	err = tx.Commit()
+	return translateError(err, &id, nil, "")
+}
+
+// purgeSocietyQueries delete everything the database holds for a society, children before parents.
+// The audit log is kept, and templates the society exported lose their society.
+var purgeSocietyQueries = []string{
+	"DELETE FROM record_household WHERE society_id = $1",
+	"DELETE FROM record WHERE society_id = $1",
+	"DELETE FROM image_hash WHERE society_id = $1",
+	"DELETE FROM post_event WHERE society_id = $1",
+	"DELETE FROM post WHERE society_id = $1",
+	"DELETE FROM collection_grant WHERE society_id = $1",
+	"DELETE FROM collection_category WHERE collection_id IN (SELECT id FROM collection WHERE society_id = $1)",
+	"DELETE FROM collection WHERE society_id = $1",
+	"DELETE FROM category WHERE society_id = $1",
+	"DELETE FROM place_alt_name WHERE society_id = $1 OR place_id IN (SELECT id FROM place WHERE society_id = $1)",
+	"DELETE FROM place_word WHERE society_id = $1",
+	"DELETE FROM place WHERE society_id = $1",
+	"DELETE FROM place_review WHERE society_id = $1",
+	"DELETE FROM society_name_variants WHERE society_id = $1",
+	"DELETE FROM api_key WHERE society_id = $1",
+	"DELETE FROM search_key WHERE society_id = $1",
+	"DELETE FROM invitation WHERE society_id = $1",
+	"DELETE FROM society_user WHERE society_id = $1",
+}
+
+// PurgeSociety deletes the trashed society and everything the database holds for it, except its audit log
+func (p PostgresPersister) PurgeSociety(ctx context.Context) error {
+	societyID, err := utils.GetSocietyIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	// delete everything in one transaction, so a failed purge leaves the society in the trash to be purged again
+	tx, err := p.db.Begin()
+	if err != nil {
+		return translateError(err, &societyID, nil, "")
+	}
+	defer tx.Rollback()
+
+	// make sure the society is still in the trash, and keep it from being restored while it is purged
+	var id uint32
+	err = tx.QueryRowContext(ctx, "SELECT id FROM society WHERE id = $1 AND delete_time IS NOT NULL FOR UPDATE", societyID).Scan(&id)
+	if err != nil {
+		return translateError(err, &societyID, nil, "")
+	}
+	for _, query := range purgeSocietyQueries {
+		if _, err := tx.ExecContext(ctx, query, societyID); err != nil {
+			return translateError(err, &societyID, nil, "")
+		}
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM society WHERE id = $1", societyID)
+	if err != nil {
+		return translateError(err, &societyID, nil, "")
+	}
+	err = tx.Commit()
+	return translateError(err, &societyID, nil, "")
+}
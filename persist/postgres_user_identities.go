@@ -0,0 +1,54 @@
+package persist
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/ourrootsorg/cms-server/model"
+)
+
+// SelectUserIdentities selects the identities linked to a user
+func (p PostgresPersister) SelectUserIdentities(ctx context.Context, userID uint32) ([]model.UserIdentity, error) {
+	rows, err := p.db.QueryContext(ctx, "SELECT id, body, user_id, insert_time, last_update_time FROM user_identity "+
+		"WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, translateError(err, nil, nil, "")
+	}
+	defer rows.Close()
+	identities := make([]model.UserIdentity, 0)
+	for rows.Next() {
+		var identity model.UserIdentity
+		err := rows.Scan(&identity.ID, &identity.UserIdentityBody, &identity.UserID, &identity.InsertTime, &identity.LastUpdateTime)
+		if err != nil {
+			return nil, translateError(err, nil, nil, "")
+		}
+		identities = append(identities, identity)
+	}
+	return identities, nil
+}
+
+// InsertUserIdentity links an identity to a user.
+// If the identity is already linked to a user, or a user was created with it, ErrConflict is returned.
+func (p PostgresPersister) InsertUserIdentity(ctx context.Context, in model.UserIdentityIn) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	err := p.db.QueryRowContext(ctx,
+		"INSERT INTO user_identity (body, user_id) SELECT $1, $2 "+
+			"WHERE NOT EXISTS (SELECT 1 FROM cms_user WHERE body->>'iss' = $3 AND body->>'sub' = $4) "+
+			"RETURNING id, body, user_id, insert_time, last_update_time",
+		in.UserIdentityBody, in.UserID, in.Issuer, in.Subject).
+		Scan(&identity.ID, &identity.UserIdentityBody, &identity.UserID, &identity.InsertTime, &identity.LastUpdateTime)
+	if err == sql.ErrNoRows {
+		return nil, model.NewError(model.ErrConflict)
+	}
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+		return nil, model.NewError(model.ErrConflict)
+	}
+	return &identity, translateError(err, nil, &in.UserID, "user")
+}
+
+// DeleteUserIdentity unlinks one of a user's identities
+func (p PostgresPersister) DeleteUserIdentity(ctx context.Context, userID, id uint32) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM user_identity WHERE user_id = $1 AND id = $2", userID, id)
+	return translateError(err, &id, nil, "")
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strconv"
 
 	"github.com/lib/pq"
 
@@ -14,13 +15,15 @@ import (
 // User persistence mthods
 
 // RetrieveUser either retrieves a user record from the database, or creates the record if it doesn't
-// already exist. Returns the new user and true if it was added
+// already exist. The user is found by the issuer and subject they were created with, or by an identity
+// linked to them. Returns the new user and true if it was added
 func (p PostgresPersister) RetrieveUser(ctx context.Context, in model.UserIn) (*model.User, bool, error) {
 	var user model.User
 	log.Printf("[DEBUG] Looking up subject '%s' in database", in.Subject)
 	err := p.db.QueryRowContext(ctx, `SELECT id, body, insert_time, last_update_time
 		FROM cms_user
-		WHERE body->>'iss'=$1 AND body->>'sub'=$2`, in.Issuer, in.Subject).
+		WHERE (body->>'iss'=$1 AND body->>'sub'=$2)
+		   OR id IN (SELECT user_id FROM user_identity WHERE body->>'iss'=$1 AND body->>'sub'=$2)`, in.Issuer, in.Subject).
 		Scan(
 			&user.ID,
 			&user.UserBody,
@@ -82,3 +85,27 @@ func (p PostgresPersister) SelectUsersByID(ctx context.Context, ids []uint32) ([
 	}
 	return users, nil
 }
+
+// UpdateUser updates a user's body; the issuer and subject identify the user, so they shouldn't change
+func (p PostgresPersister) UpdateUser(ctx context.Context, id uint32, in model.User) (*model.User, error) {
+	var user model.User
+	err := p.db.QueryRowContext(ctx, "UPDATE cms_user SET body = $1, last_update_time = CURRENT_TIMESTAMP "+
+		"WHERE id = $2 AND last_update_time = $3 RETURNING id, body, insert_time, last_update_time",
+		in.UserBody, id, in.LastUpdateTime).
+		Scan(
+			&user.ID,
+			&user.UserBody,
+			&user.InsertTime,
+			&user.LastUpdateTime,
+		)
+	if err != nil && err == sql.ErrNoRows {
+		// Either non-existent or last_update_time didn't match
+		users, _ := p.SelectUsersByID(ctx, []uint32{id})
+		if len(users) == 1 {
+			// Row exists, so it must be a non-matching update time
+			return nil, model.NewError(model.ErrConcurrentUpdate, users[0].LastUpdateTime.String(), in.LastUpdateTime.String())
+		}
+		return nil, model.NewError(model.ErrNotFound, strconv.Itoa(int(id)))
+	}
+	return &user, translateError(err, &id, nil, "")
+}
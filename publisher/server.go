@@ -53,7 +53,10 @@ func indexPost(ctx context.Context, ap *api.API, msg model.PublisherMsg) error {
 	}
 
 	// do the work
+	ap.AddPostEvent(ctx, post.ID, model.PostEventBody{Type: model.PostEventTypeWorkerStart, Worker: "publisher"})
+	start := time.Now()
 	errs = ap.IndexPost(ctx, post)
+	addFinishEvent(ctx, ap, post.ID, start, errs)
 	if errs != nil {
 		log.Printf("[ERROR] Error calling IndexPost on %d: %v", post.ID, errs)
 		post.PostStatus = model.PostStatusPublishError
@@ -92,7 +95,10 @@ func unindexPost(ctx context.Context, ap *api.API, msg model.PublisherMsg) error
 	}
 
 	// do the work
+	ap.AddPostEvent(ctx, post.ID, model.PostEventBody{Type: model.PostEventTypeWorkerStart, Worker: "publisher"})
+	start := time.Now()
 	errs = ap.SearchDeleteByPost(ctx, msg.PostID)
+	addFinishEvent(ctx, ap, post.ID, start, errs)
 	if errs != nil {
 		log.Printf("[ERROR] Error calling SearchDeleteByPost on %d: %v", msg.PostID, errs)
 		post.PostStatus = model.PostStatusUnpublishError
@@ -111,6 +117,19 @@ func unindexPost(ctx context.Context, ap *api.API, msg model.PublisherMsg) error
 	return errs
 }
 
+// addFinishEvent records how long the publisher took and whether it failed
+func addFinishEvent(ctx context.Context, ap *api.API, postID uint32, start time.Time, err error) {
+	finish := model.PostEventBody{
+		Type:     model.PostEventTypeWorkerFinish,
+		Worker:   "publisher",
+		Duration: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		finish.Error = err.Error()
+	}
+	ap.AddPostEvent(ctx, postID, finish)
+}
+
 func processMessage(ctx context.Context, ap *api.API, rawMsg []byte) error {
 	var msg model.PublisherMsg
 	err := json.Unmarshal(rawMsg, &msg)
@@ -226,7 +245,8 @@ func main() {
 			CategoryPersister(p).
 			CollectionPersister(p).
 			PostPersister(p).
-			RecordPersister(p)
+			RecordPersister(p).
+			PostEventPersister(p)
 		log.Print("[INFO] Using PostgresPersister")
 	} else {
 		sess, err := session.NewSession()
@@ -242,6 +262,7 @@ func main() {
 			CollectionPersister(p).
 			PostPersister(p).
 			RecordPersister(p)
+			// PostEventPersister(p)
 		log.Print("[INFO] Using DynamoDBPersister")
 	}
 
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryCounter struct {
+	windowStart time.Time
+	count       int
+	expires     time.Time
+}
+
+// MemoryStore counts requests in memory, so each server has its own counts
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*memoryCounter
+	nextSweep time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: map[string]*memoryCounter{}}
+}
+
+// Increment adds one to the count for key in the window that starts at windowStart
+func (s *MemoryStore) Increment(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	// forget counters for past windows now and then, so that one-off clients don't accumulate
+	if now.After(s.nextSweep) {
+		for k, c := range s.counters {
+			if now.After(c.expires) {
+				delete(s.counters, k)
+			}
+		}
+		s.nextSweep = now.Add(time.Minute)
+	}
+	c, ok := s.counters[key]
+	if !ok || !c.windowStart.Equal(windowStart) {
+		c = &memoryCounter{windowStart: windowStart, expires: windowStart.Add(window)}
+		s.counters[key] = c
+	}
+	c.count++
+	return c.count, nil
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ourrootsorg/cms-server/model"
+)
+
+// expiredCountersAge is the least time counters are kept before they're deleted
+const expiredCountersAge = 48 * time.Hour
+
+// PersisterStore counts requests in a database, so that every server shares the same counts
+type PersisterStore struct {
+	persister   model.RateLimitPersister
+	countersAge time.Duration
+	mu          sync.Mutex
+	nextCleanup time.Time
+}
+
+// NewPersisterStore returns a store that counts requests using p.
+// Counters are kept until maxWindow, the longest window of any limit, has passed, so no window is cut short.
+func NewPersisterStore(p model.RateLimitPersister, maxWindow time.Duration) *PersisterStore {
+	countersAge := expiredCountersAge
+	if maxWindow > countersAge {
+		countersAge = maxWindow
+	}
+	return &PersisterStore{persister: p, countersAge: countersAge}
+}
+
+// Increment adds one to the count for key in the window that starts at windowStart
+func (s *PersisterStore) Increment(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int, error) {
+	s.cleanup(ctx)
+	return s.persister.IncrementRateLimit(ctx, key, windowStart)
+}
+
+// cleanup deletes old counters about once an hour
+func (s *PersisterStore) cleanup(ctx context.Context) {
+	now := time.Now()
+	s.mu.Lock()
+	if now.Before(s.nextCleanup) {
+		s.mu.Unlock()
+		return
+	}
+	s.nextCleanup = now.Add(time.Hour)
+	s.mu.Unlock()
+	if err := s.persister.DeleteRateLimitsBefore(ctx, now.Add(-s.countersAge)); err != nil {
+		log.Printf("[ERROR] deleting expired rate limit counters: %v", err)
+	}
+}
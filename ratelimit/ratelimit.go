@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ourrootsorg/cms-server/model"
+)
+
+// Limit allows Requests requests in each Window; the zero Limit allows any number of requests
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Unlimited returns true if the limit doesn't restrict requests
+func (l Limit) Unlimited() bool {
+	return l.Requests <= 0 || l.Window <= 0
+}
+
+// ParseLimit parses a limit written as requests/window, such as 100/1m; an empty string is unlimited
+func ParseLimit(s string) (Limit, error) {
+	if s == "" {
+		return Limit{}, nil
+	}
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("limit %s must be requests/window, such as 100/1m", s)
+	}
+	requests, err := strconv.Atoi(parts[0])
+	if err != nil || requests < 1 {
+		return Limit{}, fmt.Errorf("limit %s must allow at least 1 request", s)
+	}
+	window, err := time.ParseDuration(parts[1])
+	if err != nil || window < time.Second {
+		return Limit{}, fmt.Errorf("limit %s must have a window of at least 1s", s)
+	}
+	return Limit{Requests: requests, Window: window}, nil
+}
+
+// Store counts requests in fixed windows
+type Store interface {
+	// Increment adds one to the count for key in the window that starts at windowStart, and returns the new count
+	Increment(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int, error)
+}
+
+// NewStore returns the store with the specified name.
+// The memory store counts requests on this server only; the postgres store shares counts between servers using p,
+// keeping them for at least maxWindow, the longest window of any limit.
+func NewStore(name string, p model.RateLimitPersister, maxWindow time.Duration) (Store, error) {
+	switch name {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "postgres":
+		if p == nil {
+			return nil, fmt.Errorf("the postgres rate limit store requires DATABASE_URL")
+		}
+		return NewPersisterStore(p, maxWindow), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %s", name)
+	}
+}
+
+// Limiter applies limits to requests, counting them in a Store
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+// NewLimiter returns a Limiter that counts requests in store
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store, now: time.Now}
+}
+
+// Allow counts a request against key. If the request is over the limit, it returns false and how long until the limit resets.
+// Requests are allowed if the store fails, so that an outage of a shared store doesn't take searches down with it.
+func (l *Limiter) Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration) {
+	if limit.Unlimited() {
+		return true, 0
+	}
+	now := l.now()
+	windowStart := now.Truncate(limit.Window)
+	count, err := l.store.Increment(ctx, key+"/"+limit.Window.String(), windowStart, limit.Window)
+	if err != nil {
+		log.Printf("[ERROR] counting request for rate limit %s: %v", key, err)
+		return true, 0
+	}
+	if count > limit.Requests {
+		return false, windowStart.Add(limit.Window).Sub(now)
+	}
+	return true, 0
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("100/1m")
+	assert.NoError(t, err)
+	assert.Equal(t, Limit{Requests: 100, Window: time.Minute}, limit)
+	limit, err = ParseLimit("")
+	assert.NoError(t, err)
+	assert.True(t, limit.Unlimited())
+	for _, s := range []string{"100", "0/1m", "x/1m", "100/1ms", "100/soon"} {
+		_, err = ParseLimit(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	store, err := NewStore("", nil, 0)
+	assert.NoError(t, err)
+	assert.IsType(t, &MemoryStore{}, store)
+	_, err = NewStore("postgres", nil, 0)
+	assert.Error(t, err)
+	store, err = NewStore("postgres", &persisterMock{counts: map[string]int{}}, time.Minute)
+	assert.NoError(t, err)
+	assert.IsType(t, &PersisterStore{}, store)
+	_, err = NewStore("redis", nil, 0)
+	assert.Error(t, err)
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 10, 0, time.UTC)
+	l := NewLimiter(NewMemoryStore())
+	l.now = func() time.Time { return now }
+	limit := Limit{Requests: 2, Window: time.Minute}
+
+	for i := 0; i < 2; i++ {
+		ok, _ := l.Allow(context.TODO(), "ip:1.2.3.4", limit)
+		assert.True(t, ok)
+	}
+	ok, retryAfter := l.Allow(context.TODO(), "ip:1.2.3.4", limit)
+	assert.False(t, ok)
+	assert.Equal(t, 50*time.Second, retryAfter)
+	// other keys have their own counts
+	ok, _ = l.Allow(context.TODO(), "ip:5.6.7.8", limit)
+	assert.True(t, ok)
+	// and the count starts over in the next window
+	now = now.Add(time.Minute)
+	ok, _ = l.Allow(context.TODO(), "ip:1.2.3.4", limit)
+	assert.True(t, ok)
+
+	// requests are unlimited without a limit, or when the store fails
+	ok, _ = l.Allow(context.TODO(), "ip:1.2.3.4", Limit{})
+	assert.True(t, ok)
+	l = NewLimiter(&persisterMock{err: errors.New("database is down")})
+	ok, _ = l.Allow(context.TODO(), "ip:1.2.3.4", Limit{Requests: 1, Window: time.Minute})
+	assert.True(t, ok)
+}
+
+func TestPersisterStore(t *testing.T) {
+	pm := &persisterMock{counts: map[string]int{}}
+	s := NewPersisterStore(pm, time.Minute)
+	windowStart := time.Now().Truncate(time.Minute)
+	count, err := s.Increment(context.TODO(), "society:1", windowStart, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	count, err = s.Increment(context.TODO(), "society:1", windowStart, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	// old counts are cleaned up once an hour
+	assert.Equal(t, 1, pm.cleanups)
+	assert.WithinDuration(t, time.Now().Add(-expiredCountersAge), pm.cleanedBefore, time.Minute)
+
+	// counts for long windows are kept until the window ends
+	s = NewPersisterStore(pm, 7*24*time.Hour)
+	_, err = s.Increment(context.TODO(), "anonymous:1", windowStart, 7*24*time.Hour)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(-7*24*time.Hour), pm.cleanedBefore, time.Minute)
+}
+
+// persisterMock counts in memory
+type persisterMock struct {
+	counts        map[string]int
+	cleanups      int
+	cleanedBefore time.Time
+	err           error
+}
+
+func (pm *persisterMock) Increment(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int, error) {
+	return pm.IncrementRateLimit(ctx, key, windowStart)
+}
+func (pm *persisterMock) IncrementRateLimit(ctx context.Context, key string, windowStart time.Time) (int, error) {
+	if pm.err != nil {
+		return 0, pm.err
+	}
+	k := key + windowStart.String()
+	pm.counts[k]++
+	return pm.counts[k], nil
+}
+func (pm *persisterMock) DeleteRateLimitsBefore(ctx context.Context, before time.Time) error {
+	pm.cleanups++
+	pm.cleanedBefore = before
+	return nil
+}
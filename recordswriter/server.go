@@ -15,6 +15,7 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ourrootsorg/cms-server/stdtext"
@@ -63,6 +64,68 @@ type recordIndex struct {
 	ix       int
 }
 
+type placeDecision struct {
+	place    *model.Place
+	reviewed bool
+}
+
+// placeReviews caches editors' decisions about place text and collects the place text in a post that needs review
+type placeReviews struct {
+	sync.Mutex
+	decisions map[string]placeDecision
+	reviews   map[string]*model.PlaceReviewIn
+}
+
+func newPlaceReviews() *placeReviews {
+	return &placeReviews{
+		decisions: map[string]placeDecision{},
+		reviews:   map[string]*model.PlaceReviewIn{},
+	}
+}
+
+// reviewedPlace returns the place an editor chose for text, if any
+func (pr *placeReviews) reviewedPlace(ctx context.Context, ap *api.API, text string) (*model.Place, bool) {
+	text = model.NormalizePlaceText(text)
+	pr.Lock()
+	decision, ok := pr.decisions[text]
+	pr.Unlock()
+	if ok {
+		return decision.place, decision.reviewed
+	}
+	place, reviewed, err := ap.ReviewedPlace(ctx, text)
+	if err != nil {
+		log.Printf("[ERROR] Reviewed place %s %v\n", text, err)
+		return nil, false
+	}
+	pr.Lock()
+	pr.decisions[text] = placeDecision{place: place, reviewed: reviewed}
+	pr.Unlock()
+	return place, reviewed
+}
+
+// add records an occurrence of text that needs review
+func (pr *placeReviews) add(text, defaultContainingPlace string, match *stdplace.Match) {
+	text = model.NormalizePlaceText(text)
+	pr.Lock()
+	defer pr.Unlock()
+	review, ok := pr.reviews[text]
+	if !ok {
+		review = &model.PlaceReviewIn{Text: text}
+		review.DefaultContainingPlace = defaultContainingPlace
+		review.Reason = model.PlaceReviewReasonAmbiguous
+		if match.Unresolved {
+			review.Reason = model.PlaceReviewReasonUnresolved
+		}
+		if match.Place != nil {
+			review.StandardizedPlaceID = match.Place.ID
+			review.StandardizedName = match.Place.FullName
+		}
+		review.Candidates = match.Candidates
+		pr.reviews[text] = review
+	}
+	review.Occurrences++
+}
+
 func cleanHeader(header string) string {
 	return stdtext.AsciiFold(strings.ToLower(strings.ReplaceAll(header, " ", "")))
 }
@@ -115,13 +178,14 @@ func compareHeaders(collHeaders []string, postHeaders []string) ([]string, []str
 	return extraHeaders, missingHeaders
 }
 
-func loadRecords(ctx context.Context, ap *api.API, post *model.Post) error {
+// loadRecords loads the records for a post and returns the number of records loaded
+func loadRecords(ctx context.Context, ap *api.API, post *model.Post) (int, error) {
 	log.Printf("[DEBUG] Processing file %s\n", post.RecordsKey)
 	// read collection for post
 	collection, errs := ap.GetCollection(ctx, post.Collection)
 	if errs != nil {
 		log.Printf("[ERROR] GetCollection %v\n", errs)
-		return errs
+		return 0, errs
 	}
 
 	// identify date and place fields
@@ -134,12 +198,26 @@ func loadRecords(ctx context.Context, ap *api.API, post *model.Post) error {
 			placeFields[mapping.Header] = true
 		}
 	}
+	// identify the date of the event for each place field so places can be standardized as of that date
+	placeDateFields := map[string]string{}
+	for _, placeMapping := range collection.Mappings {
+		if !strings.HasSuffix(placeMapping.IxField, "Place") {
+			continue
+		}
+		for _, dateMapping := range collection.Mappings {
+			if dateMapping.IxRole == placeMapping.IxRole &&
+				dateMapping.IxField == strings.TrimSuffix(placeMapping.IxField, "Place")+"Date" {
+				placeDateFields[placeMapping.Header] = dateMapping.Header
+				break
+			}
+		}
+	}
 
 	// open bucket
 	bucket, err := ap.OpenBucket(ctx, false)
 	if err != nil {
 		log.Printf("[ERROR] OpenBucket %v\n", err)
-		return api.NewError(err)
+		return 0, api.NewError(err)
 	}
 	defer bucket.Close()
 
@@ -147,7 +225,7 @@ func loadRecords(ctx context.Context, ap *api.API, post *model.Post) error {
 	societyID, err := utils.GetSocietyIDFromContext(ctx)
 	if err != nil {
 		log.Printf("[ERROR] Missing society ID %v\n", err)
-		return api.NewError(err)
+		return 0, api.NewError(err)
 	}
 	key := fmt.Sprintf("/%d/%s", societyID, post.RecordsKey)
 
@@ -155,7 +233,7 @@ func loadRecords(ctx context.Context, ap *api.API, post *model.Post) error {
 	bs, err := bucket.ReadAll(ctx, key)
 	if err != nil {
 		log.Printf("[ERROR] ReadAll %v\n", err)
-		return api.NewError(err)
+		return 0, api.NewError(err)
 	}
 
 	// read CSV
@@ -165,7 +243,7 @@ func loadRecords(ctx context.Context, ap *api.API, post *model.Post) error {
 	records, err := r.ReadAll()
 	if err != nil {
 		log.Printf("[ERROR] reading file: %v\n", err)
-		return api.NewError(err)
+		return 0, api.NewError(err)
 	}
 
 	// convert records into datas map
@@ -199,7 +277,7 @@ func loadRecords(ctx context.Context, ap *api.API, post *model.Post) error {
 				msg := fmt.Sprintf("%s %s", extraHeadersMsg, missingHeadersMsg)
 				log.Printf("[DEBUG] error: %s", msg)
 				err := fmt.Errorf(msg)
-				return api.NewHTTPError(err, http.StatusBadRequest)
+				return 0, api.NewHTTPError(err, http.StatusBadRequest)
 			}
 			continue
 		}
@@ -215,39 +293,71 @@ func loadRecords(ctx context.Context, ap *api.API, post *model.Post) error {
 	errs = ap.DeleteRecordHouseholdsForPost(ctx, post.ID)
 	if errs != nil {
 		log.Printf("[ERROR] DeleteRecordHouseholdsForPost on %d: %v\n", post.ID, errs)
-		return errs
+		return 0, errs
 	}
 
 	// delete any previous records for post
 	errs = ap.DeleteRecordsForPost(ctx, post.ID)
 	if errs != nil {
 		log.Printf("[ERROR] DeleteRecordsForPost on %d: %v\n", post.ID, errs)
-		return errs
+		return 0, errs
+	}
+
+	// read numeric dates in the collection's date order, detecting the order of each date column if asked
+	dateOrders := map[string]stddate.DateOrder{}
+	for key := range dateFields {
+		if collection.DateOrder == model.DateOrderAuto {
+			var values []string
+			for _, data := range datas {
+				values = append(values, data[key])
+			}
+			dateOrders[key] = stddate.DetectDateOrder(values)
+			log.Printf("[DEBUG] detected date order %s for %s", dateOrders[key], key)
+		} else {
+			dateOrders[key] = stddate.ParseDateOrder(string(collection.DateOrder))
+		}
 	}
 
 	// set up workers
+	reviews := newPlaceReviews()
 	in := make(chan workerIn)
 	out := make(chan workerOut)
 	for i := 0; i < numWorkers; i++ {
 		go func(in chan workerIn, out chan workerOut) {
 			for msg := range in {
+				// standardize dates first so places can be standardized as of the event year
+				years := map[string]int{}
+				var placeKeys []string
 				for key := range msg.data {
 					if dateFields[key] {
 						var std string
-						if d := stddate.Standardize(msg.data[key]); d != nil {
+						if d := stddate.StandardizeWithOrder(msg.data[key], dateOrders[key]); d != nil {
 							std = d.Encode()
+							years[key] = d.First.Year
 						}
 						msg.data[key+stddate.StdSuffix] = std
 					}
 					if placeFields[key] {
-						var std string
-						place, err := ap.StandardizePlace(ctx, msg.data[key], collection.Location)
+						placeKeys = append(placeKeys, key)
+					}
+				}
+				for _, key := range placeKeys {
+					text := msg.data[key]
+					// use an editor's decision for the text if there is one
+					place, reviewed := reviews.reviewedPlace(ctx, ap, text)
+					if !reviewed {
+						match, err := ap.MatchPlace(ctx, text, collection.Location, years[placeDateFields[key]])
 						if err != nil {
-							log.Printf("[ERROR] Standardize place %s %v\n", msg.data[key], err)
-						} else if place != nil {
-							std = place.FullName
+							log.Printf("[ERROR] Standardize place %s %v\n", text, err)
+						} else {
+							place = match.Place
+							if strings.TrimSpace(text) != "" && match.NeedsReview() {
+								reviews.add(text, collection.Location, match)
+							}
 						}
-						msg.data[key+stdplace.StdSuffix] = std
+					}
+					for k, v := range ap.StandardizedPlaceData(ctx, key, place) {
+						msg.data[k] = v
 					}
 				}
 
@@ -297,6 +407,7 @@ func loadRecords(ctx context.Context, ap *api.API, post *model.Post) error {
 	// wait for workers to complete and gather household information (if any)
 	households := map[string][]recordIndex{}
 	errs = nil
+	var recordCount int
 	for i := 0; i < len(datas); i++ {
 		result := <-out
 		if result.errs != nil {
@@ -304,6 +415,7 @@ func loadRecords(ctx context.Context, ap *api.API, post *model.Post) error {
 			errs = result.errs
 			continue
 		}
+		recordCount++
 		if collection.HouseholdNumberHeader != "" {
 			householdID := result.data[collection.HouseholdNumberHeader]
 			if householdID != "" {
@@ -313,6 +425,13 @@ func loadRecords(ctx context.Context, ap *api.API, post *model.Post) error {
 	}
 	close(out)
 
+	// queue place text that needs review
+	for _, review := range reviews.reviews {
+		if e := ap.QueuePlaceReview(ctx, post.ID, *review); e != nil {
+			log.Printf("[ERROR] QueuePlaceReview received error: %#v", e)
+		}
+	}
+
 	// create households
 	if collection.HouseholdNumberHeader != "" {
 		for householdID, recordIndexes := range households {
@@ -336,7 +455,7 @@ func loadRecords(ctx context.Context, ap *api.API, post *model.Post) error {
 		}
 	}
 
-	return errs
+	return recordCount, errs
 }
 
 func processMessage(ctx context.Context, ap *api.API, rawMsg []byte) error {
@@ -370,7 +489,19 @@ func processMessage(ctx context.Context, ap *api.API, rawMsg []byte) error {
 	}
 
 	// do the work
-	loadErrs := loadRecords(sctx, ap, post)
+	ap.AddPostEvent(sctx, post.ID, model.PostEventBody{Type: model.PostEventTypeWorkerStart, Worker: "recordswriter"})
+	start := time.Now()
+	recordCount, loadErrs := loadRecords(sctx, ap, post)
+	finish := model.PostEventBody{
+		Type:        model.PostEventTypeWorkerFinish,
+		Worker:      "recordswriter",
+		RecordCount: recordCount,
+		Duration:    time.Since(start).Milliseconds(),
+	}
+	if loadErrs != nil {
+		finish.Error = loadErrs.Error()
+	}
+	ap.AddPostEvent(sctx, post.ID, finish)
 
 	// get post again, in case there were any changes in the meantime
 	post, errs = ap.GetPost(sctx, msg.PostID)
@@ -483,6 +614,9 @@ func main() {
 			CollectionPersister(p).
 			PostPersister(p).
 			RecordPersister(p).
+			PostEventPersister(p).
+			PlacePersister(p).
+			PlaceReviewPersister(p).
 			PlaceStandardizer(ctx, p)
 		if err != nil {
 			log.Fatalf("[FATAL] Error initializing place standardizer %v\n", err)
@@ -501,6 +635,9 @@ func main() {
 			CollectionPersister(p).
 			PostPersister(p).
 			RecordPersister(p).
+			// PostEventPersister(p).
+			PlacePersister(p).
+			// PlaceReviewPersister(p).
 			PlaceStandardizer(ctx, p)
 		// This doesn't do anything
 		// if err != nil {
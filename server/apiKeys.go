@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/ourrootsorg/cms-server/model"
+)
+
+// GetAPIKeys returns all API keys for a society
+// @summary returns all API keys, with the time each was last used
+// @router /societies/{society}/api-keys [get]
+// @tags apiKeys
+// @id getAPIKeys
+// @produce application/json
+// @success 200 {array} model.APIKey "OK"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "API keys not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetAPIKeys(w http.ResponseWriter, req *http.Request) {
+	apiKeys, errors := app.api.GetAPIKeys(req.Context())
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(apiKeys)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// PostAPIKey creates a new API key
+// @summary creates a new API key; the key is returned only in this response
+// @router /societies/{society}/api-keys [post]
+// @tags apiKeys
+// @id addAPIKey
+// @Param apiKey body model.APIKeyBody true "Add API key"
+// @accept application/json
+// @produce application/json
+// @success 201 {object} model.APIKeySecret "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 403 {object} api.Error "Forbidden"
+// @failure 415 {object} api.Error "Bad Content-Type"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "API keys not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PostAPIKey(w http.ResponseWriter, req *http.Request) {
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mt != contentType {
+		msg := fmt.Sprintf("Bad Content-Type '%s'", mt)
+		ErrorResponse(w, http.StatusUnsupportedMediaType, msg)
+		return
+	}
+	body := model.APIKeyBody{}
+	err = json.NewDecoder(req.Body).Decode(&body)
+	if err != nil {
+		msg := fmt.Sprintf("Bad request: %v", err.Error())
+		ErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	apiKey, errors := app.api.AddAPIKey(req.Context(), body)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusCreated)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(apiKey)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// DeleteAPIKey revokes an API key
+// @summary revokes an API key
+// @router /societies/{society}/api-keys/{id} [delete]
+// @tags apiKeys
+// @id deleteAPIKey
+// @Param id path integer true "API key ID"
+// @success 204 "OK"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "API keys not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) DeleteAPIKey(w http.ResponseWriter, req *http.Request) {
+	id, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	errors = app.api.DeleteAPIKey(req.Context(), id)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
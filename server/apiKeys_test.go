@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/ourrootsorg/cms-server/api"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostAPIKey(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	in := model.APIKeyBody{Name: "Nightly transcriptions", Level: model.AuthContributor}
+	expected := &model.APIKeySecret{
+		APIKey: model.NewAPIKey(1, model.APIKeyIn{APIKeyBody: in, Prefix: "ork_01234567", SocietyID: 1}),
+		Key:    "ork_0123456789abcdef",
+	}
+	am.Result = expected
+	am.Errors = nil
+
+	buf := new(bytes.Buffer)
+	_ = json.NewEncoder(buf).Encode(in)
+	request, _ := http.NewRequest("POST", "/societies/1/api-keys", buf)
+	request.Header.Add("Content-Type", contentType)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusCreated, response.Code)
+	assert.Equal(t, in, am.Request)
+	var actual model.APIKeySecret
+	err := json.NewDecoder(response.Body).Decode(&actual)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.Key, actual.Key)
+	assert.Equal(t, expected.Prefix, actual.Prefix)
+	assert.Empty(t, actual.Hash)
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	var user *model.User
+
+	r := mux.NewRouter()
+	r.Handle("/societies/{society}/posts", app.setSociety(app.verifyToken(app.authenticate(model.AuthContributor,
+		http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			user, _ = utils.GetUserFromContext(req.Context())
+			w.WriteHeader(http.StatusOK)
+		}))))).Methods("POST")
+	r.Handle("/current_user", app.verifyToken(http.HandlerFunc(app.GetCurrentUser))).Methods("GET")
+
+	tests := []struct {
+		url    string
+		apiKey model.APIKey
+		status int
+	}{
+		{url: "/societies/1/posts", apiKey: model.APIKey{APIKeyIn: model.APIKeyIn{
+			APIKeyBody: model.APIKeyBody{Name: "Nightly", Level: model.AuthEditor}, SocietyID: 1}}, status: http.StatusOK},
+		{url: "/societies/1/posts", apiKey: model.APIKey{APIKeyIn: model.APIKeyIn{
+			APIKeyBody: model.APIKeyBody{Name: "Nightly", Level: model.AuthReader}, SocietyID: 1}}, status: http.StatusForbidden},
+		{url: "/societies/2/posts", apiKey: model.APIKey{APIKeyIn: model.APIKeyIn{
+			APIKeyBody: model.APIKeyBody{Name: "Nightly", Level: model.AuthEditor}, SocietyID: 1}}, status: http.StatusForbidden},
+	}
+	for _, test := range tests {
+		am.Result = &test.apiKey
+		am.Errors = nil
+		user = nil
+		request, _ := http.NewRequest("POST", test.url, nil)
+		request.Header.Add("Authorization", "Bearer "+api.APIKeyPrefix+"0123456789abcdef")
+		response := httptest.NewRecorder()
+		r.ServeHTTP(response, request)
+		assert.Equal(t, test.status, response.Code, test.url)
+		assert.Equal(t, api.APIKeyPrefix+"0123456789abcdef", am.Request)
+		if test.status == http.StatusOK {
+			assert.Equal(t, "Nightly", user.Name)
+		}
+	}
+
+	// revoked or unknown keys
+	am.Result = (*model.APIKey)(nil)
+	am.Errors = api.NewHTTPError(errors.New("Invalid API key"), http.StatusUnauthorized)
+	request, _ := http.NewRequest("POST", "/societies/1/posts", nil)
+	request.Header.Add("Authorization", "Bearer "+api.APIKeyPrefix+"revoked")
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+
+	// API keys can't be used outside of a society
+	request, _ = http.NewRequest("GET", "/current_user", nil)
+	request.Header.Add("Authorization", "Bearer "+api.APIKeyPrefix+"0123456789abcdef")
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+}
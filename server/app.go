@@ -15,6 +15,7 @@ import (
 	"github.com/dgrijalva/jwt-go"
 
 	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/ratelimit"
 
 	"github.com/ourrootsorg/cms-server/utils"
 
@@ -38,6 +39,9 @@ type App struct {
 	oidcDomain       string
 	oidcProvider     *oidc.Provider
 	oidcVerifier     verifier
+	oidcIssuers      map[string]*oidcIssuer // trusted issuers in addition to oidcDomain, by issuer URL
+	rateLimiter      *ratelimit.Limiter
+	rateLimits       RateLimits
 	sandboxSocietyID uint32
 	authDisabled     bool // If set to true, this disables authentication. This should only be done in test code!
 }
@@ -79,6 +83,53 @@ func (app *App) OIDC(oidcAudience string, oidcDomain string) *App {
 	return app
 }
 
+// oidcIssuer is an identity provider, such as a society's own Keycloak or Google Workspace, whose users can log in
+type oidcIssuer struct {
+	provider api.OIDCProvider
+	verifier verifier
+	claims   api.OIDCClaims
+}
+
+// OIDCIssuerConfig configures a trusted issuer; Claims maps the issuer's UserInfo claims to the user's name and email
+type OIDCIssuerConfig struct {
+	Issuer   string         `json:"issuer" validate:"required,url"`
+	Audience string         `json:"audience" validate:"required"`
+	Claims   api.OIDCClaims `json:"claims"`
+}
+
+// OIDCIssuer trusts tokens from an additional issuer, which must support discovery
+func (app *App) OIDCIssuer(config OIDCIssuerConfig) *App {
+	provider, err := oidc.NewProvider(context.TODO(), config.Issuer)
+	if err != nil {
+		log.Fatalf("Unable to intialize OIDC verifier for %s: %v", config.Issuer, err)
+	}
+	if app.oidcIssuers == nil {
+		app.oidcIssuers = map[string]*oidcIssuer{}
+	}
+	app.oidcIssuers[config.Issuer] = &oidcIssuer{
+		provider: provider,
+		verifier: provider.Verifier(&oidc.Config{ClientID: config.Audience}),
+		claims:   config.Claims,
+	}
+	return app
+}
+
+// tokenIssuer returns the trusted issuer named by the token's unverified iss claim, or the default issuer.
+// The issuer's verifier then checks that the token really came from it.
+func (app App) tokenIssuer(rawToken string) *oidcIssuer {
+	if len(app.oidcIssuers) > 0 {
+		claims := jwt.MapClaims{}
+		if _, _, err := new(jwt.Parser).ParseUnverified(rawToken, claims); err == nil {
+			if iss, ok := claims["iss"].(string); ok {
+				if issuer, ok := app.oidcIssuers[iss]; ok {
+					return issuer
+				}
+			}
+		}
+	}
+	return &oidcIssuer{provider: app.oidcProvider, verifier: app.oidcVerifier}
+}
+
 // SandboxSociety - everyone is added as an editor to the default society if it exists
 func (app *App) SandboxSociety(id uint32) *App {
 	app.sandboxSocietyID = id
@@ -143,9 +194,16 @@ func (app App) verifyToken(next http.Handler) http.Handler {
 		// Make sure that the incoming request has our token header
 		accessJWT := authHeaderParts[1]
 
+		// API keys let scripts use society routes without an OIDC token
+		if api.IsAPIKey(accessJWT) {
+			app.verifyAPIKey(w, r, next, accessJWT)
+			return
+		}
+
 		// Verify the access token
 		ctx := r.Context()
-		parsedToken, err := app.oidcVerifier.Verify(ctx, accessJWT)
+		issuer := app.tokenIssuer(accessJWT)
+		parsedToken, err := issuer.verifier.Verify(ctx, accessJWT)
 		if err != nil {
 			msg := fmt.Sprintf("Invalid token: %s", err.Error())
 			log.Print("[DEBUG] " + msg)
@@ -153,7 +211,7 @@ func (app App) verifyToken(next http.Handler) http.Handler {
 			return
 		}
 		//log.Printf("[DEBUG] Found valid token for subject '%s'", parsedToken.Subject)
-		user, isNew, errors := app.api.RetrieveUser(r.Context(), app.oidcProvider, parsedToken, accessJWT)
+		user, isNew, errors := app.api.RetrieveUser(r.Context(), issuer.provider, issuer.claims, parsedToken, accessJWT)
 		if errors != nil {
 			msg := fmt.Sprintf("RetrieveUser error %v", errors)
 			log.Print("[ERROR] " + msg)
@@ -185,6 +243,36 @@ func (app App) verifyToken(next http.Handler) http.Handler {
 	return http.HandlerFunc(fn)
 }
 
+// verifyAPIKey authenticates a request to a society route with an API key.
+// The request acts as the key's service account, with the key's level in the society.
+func (app App) verifyAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, key string) {
+	societyID, err := utils.GetSocietyIDFromContext(r.Context())
+	if err != nil {
+		ErrorResponse(w, http.StatusUnauthorized, "API keys can only be used for society requests")
+		return
+	}
+	apiKey, errors := app.api.AuthenticateAPIKey(r.Context(), key)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	if apiKey.SocietyID != societyID {
+		ErrorResponse(w, http.StatusForbidden, fmt.Sprintf("API key is not valid for society %d", societyID))
+		return
+	}
+	user, errors := app.api.GetAPIKeyUser(r.Context(), apiKey)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	c := utils.AddAPIKeyToContext(r.Context(), apiKey)
+	c = utils.AddUserToContext(c, user)
+	newRequest := r.WithContext(c)
+	// Update the current request with the new context information.
+	*r = *newRequest
+	next.ServeHTTP(w, r)
+}
+
 func (app App) verifySearchToken(next http.Handler) http.Handler {
 	if app.authDisabled {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -213,18 +301,11 @@ func (app App) verifySearchToken(next http.Handler) http.Handler {
 		// Verify the access token
 		ctx := r.Context()
 		token, err := jwt.Parse(accessJWT, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
 			societyID, _, err := parseSearchTokenClaims(token)
 			if err != nil {
 				return nil, err
 			}
-			society, err := app.api.GetSociety(ctx, societyID)
-			if err != nil {
-				return nil, err
-			}
-			return []byte(society.SecretKey), nil
+			return app.api.SearchTokenKey(ctx, societyID, token)
 		})
 		errMsg := ""
 		if err != nil || !token.Valid {
@@ -301,6 +382,16 @@ func (app App) addUserToSandboxSociety(ctx context.Context, level model.AuthLeve
 }
 
 func (app App) authenticate(minAuthLevel model.AuthLevel, next http.Handler) http.Handler {
+	return app.authenticateLevel(minAuthLevel, false, next)
+}
+
+// authenticateCollection is used for routes whose api methods enforce collection grants;
+// it also lets users through whose grant on some collection reaches minAuthLevel
+func (app App) authenticateCollection(minAuthLevel model.AuthLevel, next http.Handler) http.Handler {
+	return app.authenticateLevel(minAuthLevel, true, next)
+}
+
+func (app App) authenticateLevel(minAuthLevel model.AuthLevel, granted bool, next http.Handler) http.Handler {
 	if app.authDisabled {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			next.ServeHTTP(w, r)
@@ -313,6 +404,16 @@ func (app App) authenticate(minAuthLevel model.AuthLevel, next http.Handler) htt
 			ErrorResponse(w, http.StatusUnauthorized, fmt.Sprintf("Missing user: %v", err))
 			return
 		}
+		// API keys have their own level instead of a society user
+		if apiKey, err := utils.GetAPIKeyFromContext(ctx); err == nil {
+			if apiKey.Level < minAuthLevel {
+				ErrorResponse(w, http.StatusForbidden,
+					fmt.Sprintf("API key is level '%s' but '%s' is required", apiKey.Level, minAuthLevel))
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
 		// read society user
 		societyUser, errors := app.api.GetSocietyUserByUser(r.Context(), user.ID)
 		if errors != nil {
@@ -322,9 +423,20 @@ func (app App) authenticate(minAuthLevel model.AuthLevel, next http.Handler) htt
 			return
 		}
 
-		if societyUser.Level < minAuthLevel {
+		level := societyUser.Level
+		if granted {
+			permissions, errors := app.api.GetPermissions(ctx, user.ID, societyUser.Level)
+			if errors != nil {
+				ErrorsResponse(w, errors)
+				return
+			}
+			level = permissions.MaxLevel()
+			r = r.WithContext(utils.AddPermissionsToContext(ctx, permissions))
+		}
+
+		if level < minAuthLevel {
 			ErrorResponse(w, http.StatusForbidden,
-				fmt.Sprintf("User is level '%s' but '%s' is required: %v", societyUser.Level, minAuthLevel, err))
+				fmt.Sprintf("User is level '%s' but '%s' is required: %v", level, minAuthLevel, err))
 			return
 		}
 		next.ServeHTTP(w, r)
@@ -336,6 +448,7 @@ func (app App) authenticate(minAuthLevel model.AuthLevel, next http.Handler) htt
 func (app App) NewRouter() *mux.Router {
 	r := mux.NewRouter()
 	r.StrictSlash(true)
+	r.Use(app.requestID)
 	r.HandleFunc(app.baseURL.Path+"/", app.GetIndex).Methods("GET")
 	r.HandleFunc(app.baseURL.Path+"/health", app.GetHealth).Methods("GET")
 	r.HandleFunc(app.baseURL.Path+"/index.html", app.GetIndex).Methods("GET")
@@ -355,17 +468,17 @@ func (app App) NewRouter() *mux.Router {
 		http.HandlerFunc(app.DeleteCategory))))).Methods("DELETE")
 
 	r.Handle(app.baseURL.Path+"/societies/{society}/collections", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
-	r.Handle(app.baseURL.Path+"/societies/{society}/collections", app.setSociety(app.verifyToken(app.authenticate(model.AuthReader,
+	r.Handle(app.baseURL.Path+"/societies/{society}/collections", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthReader,
 		http.HandlerFunc(app.GetCollections))))).Methods("GET")
-	r.Handle(app.baseURL.Path+"/societies/{society}/collections", app.setSociety(app.verifyToken(app.authenticate(model.AuthEditor,
+	r.Handle(app.baseURL.Path+"/societies/{society}/collections", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthEditor,
 		http.HandlerFunc(app.PostCollection))))).Methods("POST")
 
 	r.Handle(app.baseURL.Path+"/societies/{society}/collections/{id}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
-	r.Handle(app.baseURL.Path+"/societies/{society}/collections/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthReader,
+	r.Handle(app.baseURL.Path+"/societies/{society}/collections/{id}", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthReader,
 		http.HandlerFunc(app.GetCollection))))).Methods("GET")
-	r.Handle(app.baseURL.Path+"/societies/{society}/collections/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthEditor,
+	r.Handle(app.baseURL.Path+"/societies/{society}/collections/{id}", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthEditor,
 		http.HandlerFunc(app.PutCollection))))).Methods("PUT")
-	r.Handle(app.baseURL.Path+"/societies/{society}/collections/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthEditor,
+	r.Handle(app.baseURL.Path+"/societies/{society}/collections/{id}", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthEditor,
 		http.HandlerFunc(app.DeleteCollection))))).Methods("DELETE")
 
 	r.Handle(app.baseURL.Path+"/societies/{society}/content", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
@@ -377,34 +490,53 @@ func (app App) NewRouter() *mux.Router {
 		http.HandlerFunc(app.GetContentRequest))))).Methods("GET")
 
 	r.Handle(app.baseURL.Path+"/societies/{society}/posts", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
-	r.Handle(app.baseURL.Path+"/societies/{society}/posts", app.setSociety(app.verifyToken(app.authenticate(model.AuthReader,
+	r.Handle(app.baseURL.Path+"/societies/{society}/posts", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthReader,
 		http.HandlerFunc(app.GetPosts))))).Methods("GET")
-	r.Handle(app.baseURL.Path+"/societies/{society}/posts", app.setSociety(app.verifyToken(app.authenticate(model.AuthContributor,
+	r.Handle(app.baseURL.Path+"/societies/{society}/posts", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthContributor,
 		http.HandlerFunc(app.PostPost))))).Methods("POST")
 
 	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
-	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthReader,
+	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthReader,
 		http.HandlerFunc(app.GetPost))))).Methods("GET")
-	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthEditor,
+	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthEditor,
 		http.HandlerFunc(app.PutPost))))).Methods("PUT")
-	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthEditor,
+	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthEditor,
 		http.HandlerFunc(app.DeletePost))))).Methods("DELETE")
 
+	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}/events", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}/events", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthReader,
+		http.HandlerFunc(app.GetPostEvents))))).Methods("GET")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}/status-override", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}/status-override", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.PostPostStatusOverride))))).Methods("POST")
+
 	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}/images/{filePath:.*}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
-	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}/images/{filePath:.*}", app.setSociety(app.verifyToken(app.authenticate(model.AuthReader,
+	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}/images/{filePath:.*}", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthReader,
 		http.HandlerFunc(app.GetPostImage))))).Methods("GET")
 
+	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}/ocr/{filePath:.*}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}/ocr/{filePath:.*}", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthReader,
+		http.HandlerFunc(app.GetPostImageOCR))))).Methods("GET")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}/duplicates", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/posts/{id}/duplicates", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthReader,
+		http.HandlerFunc(app.GetPostImageDuplicates))))).Methods("GET")
+
 	r.Handle(app.baseURL.Path+"/societies/{society}/records", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
-	r.Handle(app.baseURL.Path+"/societies/{society}/records", app.setSociety(app.verifyToken(app.authenticate(model.AuthReader,
+	r.Handle(app.baseURL.Path+"/societies/{society}/records", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthReader,
 		http.HandlerFunc(app.GetRecords))))).Methods("GET")
 
 	r.Handle(app.baseURL.Path+"/societies/{society}/records/{id}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
-	r.Handle(app.baseURL.Path+"/societies/{society}/records/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthReader,
+	r.Handle(app.baseURL.Path+"/societies/{society}/records/{id}", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthReader,
 		http.HandlerFunc(app.GetRecord))))).Methods("GET")
 
 	r.Handle(app.baseURL.Path+"/society_summaries", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
 	r.Handle(app.baseURL.Path+"/society_summaries", app.verifyToken(http.HandlerFunc(app.GetSocietySummariesForCurrentUser))).Methods("GET")
 
+	r.Handle(app.baseURL.Path+"/trashed_societies", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/trashed_societies", app.verifyToken(http.HandlerFunc(app.GetTrashedSocieties))).Methods("GET")
+
 	r.Handle(app.baseURL.Path+"/society_summaries/{society}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
 	r.Handle(app.baseURL.Path+"/society_summaries/{society}", app.setSociety(app.verifyToken(app.authenticate(model.AuthReader,
 		http.HandlerFunc(app.GetSocietySummary))))).Methods("GET")
@@ -434,35 +566,173 @@ func (app App) NewRouter() *mux.Router {
 	r.Handle(app.baseURL.Path+"/societies/{society}/users/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
 		http.HandlerFunc(app.DeleteSocietyUser))))).Methods("DELETE")
 
+	r.Handle(app.baseURL.Path+"/societies/{society}/places", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/places", app.setSociety(app.verifyToken(app.authenticate(model.AuthReader,
+		http.HandlerFunc(app.GetSocietyPlacesByPrefix))))).Methods("GET")
+	r.Handle(app.baseURL.Path+"/societies/{society}/places", app.setSociety(app.verifyToken(app.authenticate(model.AuthEditor,
+		http.HandlerFunc(app.PostPlace))))).Methods("POST")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/places/{id}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/places/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthReader,
+		http.HandlerFunc(app.GetPlace))))).Methods("GET")
+	r.Handle(app.baseURL.Path+"/societies/{society}/places/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthEditor,
+		http.HandlerFunc(app.PutPlace))))).Methods("PUT")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/places/{id}/alt-names", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/places/{id}/alt-names", app.setSociety(app.verifyToken(app.authenticate(model.AuthEditor,
+		http.HandlerFunc(app.PutPlaceAltNames))))).Methods("PUT")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/places/{id}/merge", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/places/{id}/merge", app.setSociety(app.verifyToken(app.authenticate(model.AuthEditor,
+		http.HandlerFunc(app.PostPlaceMerge))))).Methods("POST")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/place-reviews", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/place-reviews", app.setSociety(app.verifyToken(app.authenticate(model.AuthEditor,
+		http.HandlerFunc(app.GetPlaceReviews))))).Methods("GET")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/place-reviews/{id}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/place-reviews/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthEditor,
+		http.HandlerFunc(app.GetPlaceReview))))).Methods("GET")
+	r.Handle(app.baseURL.Path+"/societies/{society}/place-reviews/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthEditor,
+		http.HandlerFunc(app.PutPlaceReview))))).Methods("PUT")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/name-variants/{type}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/name-variants/{type}", app.setSociety(app.verifyToken(app.authenticate(model.AuthReader,
+		http.HandlerFunc(app.GetSocietyNameVariants))))).Methods("GET")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/name-variants/{type}/{name}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/name-variants/{type}/{name}", app.setSociety(app.verifyToken(app.authenticate(model.AuthReader,
+		http.HandlerFunc(app.GetNameVariants))))).Methods("GET")
+	r.Handle(app.baseURL.Path+"/societies/{society}/name-variants/{type}/{name}", app.setSociety(app.verifyToken(app.authenticate(model.AuthEditor,
+		http.HandlerFunc(app.PostNameVariants))))).Methods("POST")
+	r.Handle(app.baseURL.Path+"/societies/{society}/name-variants/{type}/{name}", app.setSociety(app.verifyToken(app.authenticate(model.AuthEditor,
+		http.HandlerFunc(app.DeleteNameVariants))))).Methods("DELETE")
+
 	r.Handle(app.baseURL.Path+"/societies/{society}/invitations", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
 	r.Handle(app.baseURL.Path+"/societies/{society}/invitations", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
 		http.HandlerFunc(app.GetInvitations))))).Methods("GET")
 	r.Handle(app.baseURL.Path+"/societies/{society}/invitations", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
 		http.HandlerFunc(app.PostInvitation))))).Methods("POST")
 
+	r.Handle(app.baseURL.Path+"/societies/{society}/invitations/bulk", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/invitations/bulk", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.PostInvitationsBulk))))).Methods("POST")
+
 	r.Handle(app.baseURL.Path+"/societies/{society}/invitations/{id}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
 	r.Handle(app.baseURL.Path+"/societies/{society}/invitations/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
 		http.HandlerFunc(app.DeleteInvitation))))).Methods("DELETE")
 
+	r.Handle(app.baseURL.Path+"/societies/{society}/api-keys", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/api-keys", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.GetAPIKeys))))).Methods("GET")
+	r.Handle(app.baseURL.Path+"/societies/{society}/api-keys", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.PostAPIKey))))).Methods("POST")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/api-keys/{id}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/api-keys/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.DeleteAPIKey))))).Methods("DELETE")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/search-keys", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/search-keys", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.GetSearchKeys))))).Methods("GET")
+	r.Handle(app.baseURL.Path+"/societies/{society}/search-keys", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.PostSearchKey))))).Methods("POST")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/search-keys/{kid}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/search-keys/{kid}", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.DeleteSearchKey))))).Methods("DELETE")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/search-tokens", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/search-tokens", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.PostSearchToken))))).Methods("POST")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/collection-grants", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/collection-grants", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.GetCollectionGrants))))).Methods("GET")
+	r.Handle(app.baseURL.Path+"/societies/{society}/collection-grants", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.PostCollectionGrant))))).Methods("POST")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/collection-grants/{id}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/collection-grants/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.DeleteCollectionGrant))))).Methods("DELETE")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/audit-log", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/audit-log", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.GetAuditEvents))))).Methods("GET")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/templates", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/templates", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.GetSocietyTemplates))))).Methods("GET")
+	r.Handle(app.baseURL.Path+"/societies/{society}/templates", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.PostSocietyTemplate))))).Methods("POST")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/templates/{id}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/templates/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.GetSocietyTemplate))))).Methods("GET")
+	r.Handle(app.baseURL.Path+"/societies/{society}/templates/{id}", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.DeleteSocietyTemplate))))).Methods("DELETE")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/templates/{id}/apply", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/templates/{id}/apply", app.setSociety(app.verifyToken(app.authenticate(model.AuthAdmin,
+		http.HandlerFunc(app.PostSocietyTemplateApply))))).Methods("POST")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/trash", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/trash", app.setSociety(app.verifyToken(app.authenticate(model.AuthEditor,
+		http.HandlerFunc(app.GetTrash))))).Methods("GET")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/trash/collections/{id}/restore", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/trash/collections/{id}/restore", app.setSociety(app.verifyToken(app.authenticate(model.AuthEditor,
+		http.HandlerFunc(app.PostRestoreCollection))))).Methods("POST")
+
+	r.Handle(app.baseURL.Path+"/societies/{society}/trash/posts/{id}/restore", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/trash/posts/{id}/restore", app.setSociety(app.verifyToken(app.authenticateCollection(model.AuthEditor,
+		http.HandlerFunc(app.PostRestorePost))))).Methods("POST")
+
+	// a trashed society has no society users to authenticate against, so RestoreSociety checks its admins itself
+	r.Handle(app.baseURL.Path+"/societies/{society}/restore", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/societies/{society}/restore", app.setSociety(app.verifyToken(
+		http.HandlerFunc(app.PostRestoreSociety)))).Methods("POST")
+
 	r.Handle(app.baseURL.Path+"/invitations/{code}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
 	r.Handle(app.baseURL.Path+"/invitations/{code}", http.HandlerFunc(app.GetInvitationSocietyName)).Methods("GET")
 	r.Handle(app.baseURL.Path+"/invitations/{code}", app.verifyToken(http.HandlerFunc(app.AcceptInvitation))).Methods("POST")
 
 	r.Handle(app.baseURL.Path+"/search", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
-	r.Handle(app.baseURL.Path+"/search", app.verifySearchToken(http.HandlerFunc(app.Search))).Methods("GET")
+	r.Handle(app.baseURL.Path+"/search", app.limitIP(app.verifySearchToken(app.limitSearch(http.HandlerFunc(app.Search))))).Methods("GET")
 
 	r.Handle(app.baseURL.Path+"/search/{id}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
-	r.Handle(app.baseURL.Path+"/search/{id}", app.verifySearchToken(http.HandlerFunc(app.SearchByID))).Methods("GET")
+	r.Handle(app.baseURL.Path+"/search/{id}", app.limitIP(app.verifySearchToken(app.limitSearch(http.HandlerFunc(app.SearchByID))))).Methods("GET")
 
 	r.Handle(app.baseURL.Path+"/search-image/{society}/{id}/{filePath:.*}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
-	r.Handle(app.baseURL.Path+"/search-image/{society}/{id}/{filePath:.*}", app.verifySearchToken(http.HandlerFunc(app.SearchImage))).Methods("GET")
+	r.Handle(app.baseURL.Path+"/search-image/{society}/{id}/{filePath:.*}", app.limitIP(app.verifySearchToken(app.limitSearch(http.HandlerFunc(app.SearchImage))))).Methods("GET")
 
 	r.Handle(app.baseURL.Path+"/places", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
-	r.HandleFunc(app.baseURL.Path+"/places", http.HandlerFunc(app.GetPlacesByPrefix)).Methods("GET")
+	r.Handle(app.baseURL.Path+"/places", app.limitIP(http.HandlerFunc(app.GetPlacesByPrefix))).Methods("GET")
+
+	r.Handle(app.baseURL.Path+"/standardize/place", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/standardize/place", app.limitIP(app.verifyToken(http.HandlerFunc(app.StandardizePlace)))).Methods("GET")
+
+	r.Handle(app.baseURL.Path+"/standardize/places", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/standardize/places", app.limitIP(app.verifyToken(http.HandlerFunc(app.StandardizePlaces)))).Methods("POST")
+
+	r.Handle(app.baseURL.Path+"/standardize/date", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/standardize/date", app.limitIP(app.verifyToken(http.HandlerFunc(app.StandardizeDate)))).Methods("GET")
+
+	r.Handle(app.baseURL.Path+"/standardize/dates", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/standardize/dates", app.limitIP(app.verifyToken(http.HandlerFunc(app.StandardizeDates)))).Methods("POST")
 
 	r.Handle(app.baseURL.Path+"/current_user", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
 	r.Handle(app.baseURL.Path+"/current_user", app.verifyToken(http.HandlerFunc(app.GetCurrentUser))).Methods("GET")
 
+	r.Handle(app.baseURL.Path+"/current_user/identities", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/current_user/identities", app.verifyToken(http.HandlerFunc(app.GetUserIdentities))).Methods("GET")
+	r.Handle(app.baseURL.Path+"/current_user/identities", app.verifyToken(http.HandlerFunc(app.PostUserIdentity))).Methods("POST")
+	r.Handle(app.baseURL.Path+"/current_user/identities/{id}", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/current_user/identities/{id}", app.verifyToken(http.HandlerFunc(app.DeleteUserIdentity))).Methods("DELETE")
+
+	r.Handle(app.baseURL.Path+"/users/{id}/deactivate", http.HandlerFunc(app.OptionsNoop)).Methods("OPTIONS")
+	r.Handle(app.baseURL.Path+"/users/{id}/deactivate", app.verifyToken(http.HandlerFunc(app.DeactivateUser))).Methods("POST")
+
 	return r
 }
 
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+// requestIDHeader carries the request ID, which is recorded in audit events
+const requestIDHeader = "X-Request-ID"
+
+// validRequestID limits request IDs supplied by clients or proxies to something safe to log and store
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// requestID adds the request's ID to its context and to the response, generating one if the request didn't supply one
+func (app App) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			b := make([]byte, 8)
+			if _, err := rand.Read(b); err != nil {
+				serverError(w, err)
+				return
+			}
+			id = hex.EncodeToString(b)
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(utils.AddRequestIDToContext(r.Context(), id)))
+	})
+}
+
+// GetAuditEvents returns the society's audit log
+// @summary returns the society's audit log, newest first
+// @router /societies/{society}/audit-log [get]
+// @tags audit
+// @id getAuditEvents
+// @Param society path integer true "Society ID"
+// @Param user query integer false "Only changes made by this user"
+// @Param entity query string false "Only changes to this type of entity, such as post or societyUser"
+// @Param entityId query integer false "Only changes to the entity with this ID"
+// @Param action query string false "Only this action, such as create, update, delete or publish"
+// @Param from query string false "Only changes made at or after this RFC 3339 time"
+// @Param to query string false "Only changes made before this RFC 3339 time"
+// @Param before query integer false "Only events older than this event ID; pass next_page to get the next page"
+// @Param limit query integer false "Maximum number of events to return; defaults to 100, at most 1000"
+// @produce application/json
+// @success 200 {object} api.AuditResult "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Audit log not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetAuditEvents(w http.ResponseWriter, req *http.Request) {
+	filter, err := parseAuditFilter(req.URL.Query())
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Bad request: %v", err))
+		return
+	}
+	result, errors := app.api.GetAuditEvents(req.Context(), *filter)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(result)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+func parseAuditFilter(query url.Values) (*model.AuditFilter, error) {
+	filter := &model.AuditFilter{
+		Entity: query.Get("entity"),
+		Action: model.AuditAction(query.Get("action")),
+	}
+	ids := []struct {
+		name string
+		id   *uint32
+	}{
+		{"user", &filter.User},
+		{"entityId", &filter.EntityID},
+		{"before", &filter.Before},
+	}
+	for _, p := range ids {
+		if s := query.Get(p.name); s != "" {
+			id, err := strconv.ParseUint(s, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %s", p.name, s)
+			}
+			*p.id = uint32(id)
+		}
+	}
+	times := []struct {
+		name string
+		t    **time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	}
+	for _, p := range times {
+		if s := query.Get(p.name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %s", p.name, s)
+			}
+			*p.t = &t
+		}
+	}
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid limit %s", s)
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
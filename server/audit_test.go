@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ourrootsorg/cms-server/api"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAuditEvents(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	expected := &api.AuditResult{
+		AuditEvents: []model.AuditEvent{
+			{ID: 9, AuditEventIn: model.AuditEventIn{SocietyID: 1, AuditEventBody: model.AuditEventBody{
+				User: 3, Entity: model.AuditEntityPost, EntityID: 4, Action: model.AuditActionPublish, RequestID: "abc"}}},
+		},
+		NextPage: "9",
+	}
+	am.Result = expected
+	am.Errors = nil
+
+	request, _ := http.NewRequest("GET", "/societies/1/audit-log?entity=post&entityId=4&from=2020-01-02T03:04:05Z&before=20&limit=1", nil)
+	request.Header.Set(requestIDHeader, "client-request-1")
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "client-request-1", response.Header().Get(requestIDHeader))
+	from := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.Equal(t, model.AuditFilter{Entity: "post", EntityID: 4, From: &from, Before: 20, Limit: 1}, am.Request.(model.AuditFilter))
+	var actual api.AuditResult
+	err := json.NewDecoder(response.Body).Decode(&actual)
+	if err != nil {
+		t.Errorf("Error parsing JSON: %v", err)
+	}
+	assert.Equal(t, *expected, actual)
+
+	// invalid filters are rejected, and requests without a usable ID get a generated one
+	request, _ = http.NewRequest("GET", "/societies/1/audit-log?to=yesterday", nil)
+	request.Header.Set(requestIDHeader, "not a valid id")
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Len(t, response.Header().Get(requestIDHeader), 16)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/ourrootsorg/cms-server/model"
+)
+
+// GetCollectionGrants returns all collection grants for a society
+// @summary returns all grants that override users' society levels for particular collections and categories
+// @router /societies/{society}/collection-grants [get]
+// @tags collectionGrants
+// @id getCollectionGrants
+// @produce application/json
+// @success 200 {array} model.CollectionGrant "OK"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Collection grants not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetCollectionGrants(w http.ResponseWriter, req *http.Request) {
+	grants, errors := app.api.GetCollectionGrants(req.Context())
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(grants)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// PostCollectionGrant grants a user a level on a collection or category
+// @summary grants a user a level on a collection or on every collection in a category, replacing any existing grant
+// @router /societies/{society}/collection-grants [post]
+// @tags collectionGrants
+// @id addCollectionGrant
+// @Param grant body model.CollectionGrantIn true "Add collection grant"
+// @accept application/json
+// @produce application/json
+// @success 201 {object} model.CollectionGrant "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 415 {object} api.Error "Bad Content-Type"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Collection grants not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PostCollectionGrant(w http.ResponseWriter, req *http.Request) {
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mt != contentType {
+		msg := fmt.Sprintf("Bad Content-Type '%s'", mt)
+		ErrorResponse(w, http.StatusUnsupportedMediaType, msg)
+		return
+	}
+	in := model.CollectionGrantIn{}
+	err = json.NewDecoder(req.Body).Decode(&in)
+	if err != nil {
+		msg := fmt.Sprintf("Bad request: %v", err.Error())
+		ErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	grant, errors := app.api.AddCollectionGrant(req.Context(), in)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusCreated)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(grant)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// DeleteCollectionGrant removes a collection grant
+// @summary removes a collection grant, so the user's society level applies again
+// @router /societies/{society}/collection-grants/{id} [delete]
+// @tags collectionGrants
+// @id deleteCollectionGrant
+// @Param id path integer true "Collection grant ID"
+// @success 204 "OK"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Collection grants not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) DeleteCollectionGrant(w http.ResponseWriter, req *http.Request) {
+	id, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	errors = app.api.DeleteCollectionGrant(req.Context(), id)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -164,6 +164,7 @@ func (app App) PutCollection(w http.ResponseWriter, req *http.Request) {
 // @id deleteCollection
 // @Param id path integer true "Collection ID"
 // @success 204 {object} model.Collection "OK"
+// @failure 409 {object} api.Error "Collection has posts that aren't in the trash"
 // @failure 500 {object} api.Error "Server error"
 // @Security OAuth2Implicit[cms,openid,profile,email]
 // @Security OAuth2AuthCode[cms,openid,profile,email]
@@ -7,9 +7,13 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/ourrootsorg/cms-server/api"
 	"github.com/ourrootsorg/cms-server/model"
 )
 
+// maxBulkInvitationsBytes limits the size of a bulk invitation upload
+const maxBulkInvitationsBytes = 1 << 20
+
 // GetInvitations returns all invitations for a society
 // @summary returns all invitations
 // @router /societies/{society}/invitations [get]
@@ -77,6 +81,45 @@ func (app App) PostInvitation(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// PostInvitationsBulk adds many Invitations from a CSV file
+// @summary adds many Invitations from CSV with a header row naming the columns name, level, email, maxUses and expiresAt
+// @router /societies/{society}/invitations/bulk [post]
+// @tags invitations
+// @id addInvitationsBulk
+// @accept text/csv
+// @produce application/json
+// @success 200 {array} api.BulkInvitationResult "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 415 {object} api.Error "Bad Content-Type"
+// @failure 500 {object} api.Error "Server error"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PostInvitationsBulk(w http.ResponseWriter, req *http.Request) {
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mt != "text/csv" {
+		msg := fmt.Sprintf("Bad Content-Type '%s'", mt)
+		ErrorResponse(w, http.StatusUnsupportedMediaType, msg)
+		return
+	}
+	bodies, errors := api.ParseInvitationsCSV(http.MaxBytesReader(w, req.Body, maxBulkInvitationsBytes))
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	results, errors := app.api.AddInvitations(req.Context(), bodies)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(results)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
 // DeleteInvitation deletes an Invitation from the database
 // @summary deletes an Invitation
 // @router /societies/{society}/invitations/{id} [delete]
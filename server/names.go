@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/ourrootsorg/cms-server/api"
+	"github.com/ourrootsorg/cms-server/model"
+)
+
+// GetSocietyNameVariants returns the society's name dictionary
+// @summary returns the society's changes to the global variants of given names or surnames
+// @router /societies/{society}/name-variants/{type} [get]
+// @tags names
+// @id getSocietyNameVariants
+// @Param society path integer true "Society ID"
+// @Param type path string true "given or surname"
+// @produce application/json
+// @success 200 {array} model.SocietyNameVariants "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Name dictionaries not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetSocietyNameVariants(w http.ResponseWriter, req *http.Request) {
+	nameType, ok := getNameTypeFromRequest(w, req)
+	if !ok {
+		return
+	}
+	nameVariants, errors := app.api.GetSocietyNameVariants(req.Context(), nameType)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(nameVariants)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// GetNameVariants returns the variants of a name
+// @summary returns the variants of a name, with the society's dictionary layered over the global one
+// @router /societies/{society}/name-variants/{type}/{name} [get]
+// @tags names
+// @id getNameVariants
+// @Param society path integer true "Society ID"
+// @Param type path string true "given or surname"
+// @Param name path string true "Name"
+// @produce application/json
+// @success 200 {object} model.NameVariants "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 404 {object} api.Error "Not found"
+// @failure 500 {object} api.Error "Server error"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetNameVariants(w http.ResponseWriter, req *http.Request) {
+	nameType, ok := getNameTypeFromRequest(w, req)
+	if !ok {
+		return
+	}
+	nameVariants, err := app.api.GetNameVariants(req.Context(), nameType, mux.Vars(req)["name"])
+	if err != nil {
+		ErrorsResponse(w, api.NewError(err))
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(nameVariants)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// PostNameVariants adds variants to a name in the society's dictionary
+// @summary adds variants to a name; the name is also added as a variant of each variant
+// @router /societies/{society}/name-variants/{type}/{name} [post]
+// @tags names
+// @id addNameVariants
+// @Param society path integer true "Society ID"
+// @Param type path string true "given or surname"
+// @Param name path string true "Name"
+// @Param edit body model.NameVariantsEdit true "Variants to add"
+// @accept application/json
+// @produce application/json
+// @success 200 {object} model.NameVariants "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 415 {object} api.Error "Bad Content-Type"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Name dictionaries not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PostNameVariants(w http.ResponseWriter, req *http.Request) {
+	nameType, ok := getNameTypeFromRequest(w, req)
+	if !ok {
+		return
+	}
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mt != contentType {
+		msg := fmt.Sprintf("Bad Content-Type '%s'", mt)
+		ErrorResponse(w, http.StatusUnsupportedMediaType, msg)
+		return
+	}
+	var in model.NameVariantsEdit
+	err = json.NewDecoder(req.Body).Decode(&in)
+	if err != nil {
+		msg := fmt.Sprintf("Bad request: %v", err)
+		ErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	nameVariants, errors := app.api.AddNameVariants(req.Context(), nameType, mux.Vars(req)["name"], in)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(nameVariants)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// DeleteNameVariants removes variants from a name in the society's dictionary
+// @summary removes variants from a name, including global variants; the name is also removed from the variants of each variant
+// @router /societies/{society}/name-variants/{type}/{name} [delete]
+// @tags names
+// @id removeNameVariants
+// @Param society path integer true "Society ID"
+// @Param type path string true "given or surname"
+// @Param name path string true "Name"
+// @Param variant query []string true "Variants to remove" collectionFormat(multi)
+// @produce application/json
+// @success 200 {object} model.NameVariants "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Name dictionaries not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) DeleteNameVariants(w http.ResponseWriter, req *http.Request) {
+	nameType, ok := getNameTypeFromRequest(w, req)
+	if !ok {
+		return
+	}
+	in := model.NameVariantsEdit{Variants: req.URL.Query()["variant"]}
+	nameVariants, errors := app.api.RemoveNameVariants(req.Context(), nameType, mux.Vars(req)["name"], in)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(nameVariants)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// getNameTypeFromRequest returns the name type in the request path, or writes a bad request response
+func getNameTypeFromRequest(w http.ResponseWriter, req *http.Request) (model.NameType, bool) {
+	nameType, ok := model.ParseNameType(mux.Vars(req)["type"])
+	if !ok {
+		msg := fmt.Sprintf("Bad request: invalid name type %s", mux.Vars(req)["type"])
+		ErrorResponse(w, http.StatusBadRequest, msg)
+	}
+	return nameType, ok
+}
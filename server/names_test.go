@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ourrootsorg/cms-server/api"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostNameVariants(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	expected := &model.NameVariants{Name: "jones", Variants: model.StringSlice{"johns", "sion"}}
+	am.Result = expected
+	am.Errors = nil
+
+	in := model.NameVariantsEdit{Variants: model.StringSlice{"sion"}}
+	buf := new(bytes.Buffer)
+	_ = json.NewEncoder(buf).Encode(in)
+	request, _ := http.NewRequest("POST", "/societies/1/name-variants/surname/jones", buf)
+	request.Header.Add("Content-Type", contentType)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, in, am.Request)
+	var actual model.NameVariants
+	err := json.NewDecoder(response.Body).Decode(&actual)
+	assert.NoError(t, err)
+	assert.Equal(t, *expected, actual)
+
+	request, _ = http.NewRequest("POST", "/societies/1/name-variants/nickname/jones", buf)
+	request.Header.Add("Content-Type", contentType)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func TestDeleteNameVariants(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	am.Result = &model.NameVariants{Name: "jones", Variants: model.StringSlice{"johns"}}
+	am.Errors = nil
+
+	request, _ := http.NewRequest("DELETE", "/societies/1/name-variants/surname/jones?variant=jonas&variant=sion", nil)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, model.NameVariantsEdit{Variants: model.StringSlice{"jonas", "sion"}}, am.Request)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/ourrootsorg/cms-server/model"
+)
+
+// GetPlaceReviews returns the society's place review queue
+// @summary returns place text that didn't standardize cleanly, most frequent first
+// @router /societies/{society}/place-reviews [get]
+// @tags placeReviews
+// @id getPlaceReviews
+// @Param society path integer true "Society ID"
+// @Param status query string false "Pending or Resolved; all place reviews are returned if omitted"
+// @produce application/json
+// @success 200 {array} model.PlaceReview "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Place review not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetPlaceReviews(w http.ResponseWriter, req *http.Request) {
+	status := model.PlaceReviewStatus(req.URL.Query().Get("status"))
+	placeReviews, errors := app.api.GetPlaceReviews(req.Context(), status)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(placeReviews)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// GetPlaceReview returns a place review
+// @summary returns a place review, with the candidate places and their scores
+// @router /societies/{society}/place-reviews/{id} [get]
+// @tags placeReviews
+// @id getPlaceReview
+// @Param society path integer true "Society ID"
+// @Param id path integer true "Place review ID"
+// @produce application/json
+// @success 200 {object} model.PlaceReview "OK"
+// @failure 404 {object} api.Error "Not found"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Place review not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetPlaceReview(w http.ResponseWriter, req *http.Request) {
+	placeReviewID, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	placeReview, errors := app.api.GetPlaceReview(req.Context(), placeReviewID)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(placeReview)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// PutPlaceReview confirms or overrides the place for the text of a place review
+// @summary sets the place for the text of a place review; existing and future records with the text use the place
+// @router /societies/{society}/place-reviews/{id} [put]
+// @tags placeReviews
+// @id resolvePlaceReview
+// @Param society path integer true "Society ID"
+// @Param id path integer true "Place review ID"
+// @Param decision body model.PlaceReviewDecision true "Place to use for the text, or 0 to leave it unstandardized"
+// @accept application/json
+// @produce application/json
+// @success 200 {object} model.PlaceReview "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 404 {object} api.Error "Not found"
+// @failure 415 {object} api.Error "Bad Content-Type"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Place review not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PutPlaceReview(w http.ResponseWriter, req *http.Request) {
+	placeReviewID, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mt != contentType {
+		msg := fmt.Sprintf("Bad Content-Type '%s'", mt)
+		ErrorResponse(w, http.StatusUnsupportedMediaType, msg)
+		return
+	}
+	var in model.PlaceReviewDecision
+	err = json.NewDecoder(req.Body).Decode(&in)
+	if err != nil {
+		msg := fmt.Sprintf("Bad request: %v", err)
+		ErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	placeReview, errors := app.api.ResolvePlaceReview(req.Context(), placeReviewID, in)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(placeReview)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
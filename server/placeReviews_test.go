@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ourrootsorg/cms-server/api"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPlaceReviews(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	expected := []model.PlaceReview{
+		{ID: 1, PlaceReviewIn: model.PlaceReviewIn{Text: "springfield", PlaceReviewBody: model.PlaceReviewBody{
+			Reason: model.PlaceReviewReasonAmbiguous,
+			Status: model.PlaceReviewStatusPending,
+			Candidates: []model.PlaceCandidate{
+				{PlaceID: 10, FullName: "Springfield, Sangamon, Illinois, United States", Score: 20},
+				{PlaceID: 11, FullName: "Springfield, Hampden, Massachusetts, United States", Score: 15},
+			},
+			Occurrences:     3,
+			PostOccurrences: map[uint32]int{1: 3},
+		}}},
+	}
+	am.Result = expected
+	am.Errors = nil
+
+	request, _ := http.NewRequest("GET", "/societies/1/place-reviews?status=Pending", nil)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, model.PlaceReviewStatusPending, am.Request.(model.PlaceReviewStatus))
+	var actual []model.PlaceReview
+	err := json.NewDecoder(response.Body).Decode(&actual)
+	if err != nil {
+		t.Errorf("Error parsing JSON: %v", err)
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestPutPlaceReview(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	am.Result = &model.PlaceReview{ID: 1, PlaceReviewIn: model.PlaceReviewIn{Text: "springfield", PlaceReviewBody: model.PlaceReviewBody{
+		Status:  model.PlaceReviewStatusResolved,
+		PlaceID: 11,
+	}}}
+	am.Errors = nil
+
+	request, _ := http.NewRequest("PUT", "/societies/1/place-reviews/1", bytes.NewBufferString(`{"placeId":11}`))
+	request.Header.Add("Content-Type", contentType)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code, "Response: %s", string(response.Body.Bytes()))
+	assert.Equal(t, uint32(11), am.Request.(model.PlaceReviewDecision).PlaceID)
+	var updated model.PlaceReview
+	err := json.NewDecoder(response.Body).Decode(&updated)
+	if err != nil {
+		t.Errorf("Error parsing JSON: %v", err)
+	}
+	assert.Equal(t, model.PlaceReviewStatusResolved, updated.Status)
+
+	// bad content type
+	request, _ = http.NewRequest("PUT", "/societies/1/place-reviews/1", bytes.NewBufferString(`{}`))
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusUnsupportedMediaType, response.Code)
+}
@@ -2,8 +2,13 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
 	"net/http"
 	"strconv"
+
+	"github.com/ourrootsorg/cms-server/model"
 )
 
 // GetPlacesByPrefix returns places matching prefix
@@ -19,9 +24,30 @@ import (
 // @Security OAuth2Implicit[cms,openid,profile,email]
 // @Security OAuth2AuthCode[cms,openid,profile,email]
 func (app App) GetPlacesByPrefix(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Cache-Control", "max-age=7200")
+	app.getPlacesByPrefix(w, req)
+}
+
+// GetSocietyPlacesByPrefix returns global places and places added by the society matching prefix
+// @summary returns global places and places added by the society matching prefix
+// @router /societies/{society}/places [get]
+// @param society path integer true "Society ID"
+// @param prefix query string false "place prefix"
+// @param count query int false "maximum number of places to return"
+// @tags places
+// @id getSocietyPlacesByPrefix
+// @produce application/json
+// @success 200 {array} model.Place "OK"
+// @failure 500 {object} api.Error "Server error"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetSocietyPlacesByPrefix(w http.ResponseWriter, req *http.Request) {
+	app.getPlacesByPrefix(w, req)
+}
+
+func (app App) getPlacesByPrefix(w http.ResponseWriter, req *http.Request) {
 	enc := json.NewEncoder(w)
 	w.Header().Set("Content-Type", contentType)
-	w.Header().Set("Cache-Control", "max-age=7200")
 	prefix := req.URL.Query().Get("prefix")
 	count, err := strconv.Atoi(req.URL.Query().Get("count"))
 	if err != nil || count <= 0 || count > 20 {
@@ -38,3 +64,236 @@ func (app App) GetPlacesByPrefix(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 }
+
+// GetPlace returns a place, along with the society's alternate names for it
+// @summary returns a place
+// @router /societies/{society}/places/{id} [get]
+// @tags places
+// @id getPlace
+// @Param society path integer true "Society ID"
+// @Param id path integer true "Place ID"
+// @produce application/json
+// @success 200 {object} model.Place "OK"
+// @failure 404 {object} api.Error "Not found"
+// @failure 500 {object} api.Error "Server error"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetPlace(w http.ResponseWriter, req *http.Request) {
+	placeID, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	place, errors := app.api.GetPlace(req.Context(), placeID)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(place)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// PostPlace adds a place to the society's gazetteer
+// @summary adds a place to the society's gazetteer
+// @router /societies/{society}/places [post]
+// @tags places
+// @id addPlace
+// @Param society path integer true "Society ID"
+// @Param place body model.PlaceIn true "Add Place"
+// @accept application/json
+// @produce application/json
+// @success 201 {object} model.Place "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 415 {object} api.Error "Bad Content-Type"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Gazetteer administration not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PostPlace(w http.ResponseWriter, req *http.Request) {
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mt != contentType {
+		msg := fmt.Sprintf("Bad Content-Type '%s'", mt)
+		ErrorResponse(w, http.StatusUnsupportedMediaType, msg)
+		return
+	}
+	in := model.PlaceIn{}
+	err = json.NewDecoder(req.Body).Decode(&in)
+	if err != nil {
+		msg := fmt.Sprintf("Bad request: %v", err)
+		ErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	place, errors := app.api.AddPlace(req.Context(), in)
+	if errors != nil {
+		log.Printf("[DEBUG] PostPlace AddPlace %v\n", errors)
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusCreated)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(place)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// PutPlace updates a place added by the society
+// @summary updates a place added by the society; the full names of places located within it are updated to match
+// @router /societies/{society}/places/{id} [put]
+// @tags places
+// @id updatePlace
+// @Param society path integer true "Society ID"
+// @Param id path integer true "Place ID"
+// @Param place body model.PlaceIn true "Update Place"
+// @accept application/json
+// @produce application/json
+// @success 200 {object} model.Place "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 403 {object} api.Error "Place is part of the global gazetteer"
+// @failure 404 {object} api.Error "Not found"
+// @failure 415 {object} api.Error "Bad Content-Type"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Gazetteer administration not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PutPlace(w http.ResponseWriter, req *http.Request) {
+	placeID, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mt != contentType {
+		msg := fmt.Sprintf("Bad Content-Type '%s'", mt)
+		ErrorResponse(w, http.StatusUnsupportedMediaType, msg)
+		return
+	}
+	var in model.PlaceIn
+	err = json.NewDecoder(req.Body).Decode(&in)
+	if err != nil {
+		msg := fmt.Sprintf("Bad request: %v", err)
+		ErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	place, errors := app.api.UpdatePlace(req.Context(), placeID, in)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(place)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// PutPlaceAltNames sets the society's alternate names for a place
+// @summary sets the society's alternate names for a place; alternate names for global places are only used for the society's records
+// @router /societies/{society}/places/{id}/alt-names [put]
+// @tags places
+// @id updatePlaceAltNames
+// @Param society path integer true "Society ID"
+// @Param id path integer true "Place ID"
+// @Param altNames body model.PlaceAltNames true "Alternate names"
+// @accept application/json
+// @produce application/json
+// @success 200 {object} model.Place "OK"
+// @failure 404 {object} api.Error "Not found"
+// @failure 415 {object} api.Error "Bad Content-Type"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Gazetteer administration not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PutPlaceAltNames(w http.ResponseWriter, req *http.Request) {
+	placeID, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mt != contentType {
+		msg := fmt.Sprintf("Bad Content-Type '%s'", mt)
+		ErrorResponse(w, http.StatusUnsupportedMediaType, msg)
+		return
+	}
+	var in model.PlaceAltNames
+	err = json.NewDecoder(req.Body).Decode(&in)
+	if err != nil {
+		msg := fmt.Sprintf("Bad request: %v", err)
+		ErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	place, errors := app.api.UpdatePlaceAltNames(req.Context(), placeID, in)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(place)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// PostPlaceMerge merges a place added by the society into another place
+// @summary merges a place added by the society into another place, keeping its names as alternate names
+// @router /societies/{society}/places/{id}/merge [post]
+// @tags places
+// @id mergePlace
+// @Param society path integer true "Society ID"
+// @Param id path integer true "ID of the place to merge"
+// @Param merge body model.PlaceMerge true "Place to merge into"
+// @accept application/json
+// @produce application/json
+// @success 200 {object} model.Place "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 403 {object} api.Error "Place is part of the global gazetteer"
+// @failure 404 {object} api.Error "Not found"
+// @failure 415 {object} api.Error "Bad Content-Type"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Gazetteer administration not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PostPlaceMerge(w http.ResponseWriter, req *http.Request) {
+	placeID, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mt != contentType {
+		msg := fmt.Sprintf("Bad Content-Type '%s'", mt)
+		ErrorResponse(w, http.StatusUnsupportedMediaType, msg)
+		return
+	}
+	var in model.PlaceMerge
+	err = json.NewDecoder(req.Body).Decode(&in)
+	if err != nil {
+		msg := fmt.Sprintf("Bad request: %v", err)
+		ErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	place, errors := app.api.MergePlace(req.Context(), placeID, in)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(place)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -36,3 +37,84 @@ func TestGetPlacesByPrefix(t *testing.T) {
 		contentType,
 		response.Result().Header["Content-Type"][0])
 }
+
+func TestPostPlace(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	am.Result = &model.Place{ID: 1000000000, Name: "Oak Hill Cemetery", FullName: "Oak Hill Cemetery, Wroclaw, Poland",
+		LocatedInID: 2, SocietyID: 1}
+	am.Errors = nil
+
+	request, _ := http.NewRequest("POST", "/societies/1/places",
+		bytes.NewBufferString(`{"name":"Oak Hill Cemetery","locatedInId":2,"types":["Cemetery"]}`))
+	request.Header.Add("Content-Type", contentType)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusCreated, response.Code, "Response: %s", string(response.Body.Bytes()))
+	assert.Equal(t, "Oak Hill Cemetery", am.Request.(model.PlaceIn).Name)
+	assert.Equal(t, uint32(2), am.Request.(model.PlaceIn).LocatedInID)
+	var created model.Place
+	err := json.NewDecoder(response.Body).Decode(&created)
+	if err != nil {
+		t.Errorf("Error parsing JSON: %v", err)
+	}
+	assert.Equal(t, am.Result, &created)
+
+	// bad content type
+	request, _ = http.NewRequest("POST", "/societies/1/places", bytes.NewBufferString(`{}`))
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusUnsupportedMediaType, response.Code)
+}
+
+func TestPutPlaceAltNames(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	am.Result = &model.Place{ID: 2, Name: "Wroclaw", FullName: "Wroclaw, Poland", LocalAltNames: model.StringSlice{"Breslau"}}
+	am.Errors = nil
+
+	request, _ := http.NewRequest("PUT", "/societies/1/places/2/alt-names", bytes.NewBufferString(`{"altNames":["Breslau"]}`))
+	request.Header.Add("Content-Type", contentType)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code, "Response: %s", string(response.Body.Bytes()))
+	assert.Equal(t, model.StringSlice{"Breslau"}, am.Request.(model.PlaceAltNames).AltNames)
+	var updated model.Place
+	err := json.NewDecoder(response.Body).Decode(&updated)
+	if err != nil {
+		t.Errorf("Error parsing JSON: %v", err)
+	}
+	assert.Equal(t, model.StringSlice{"Breslau"}, updated.LocalAltNames)
+}
+
+func TestPostPlaceMerge(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	am.Result = &model.Place{ID: 2, Name: "Wroclaw", FullName: "Wroclaw, Poland", LocalAltNames: model.StringSlice{"Breslaw"}}
+	am.Errors = nil
+
+	request, _ := http.NewRequest("POST", "/societies/1/places/1000000001/merge", bytes.NewBufferString(`{"intoId":2}`))
+	request.Header.Add("Content-Type", contentType)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code, "Response: %s", string(response.Body.Bytes()))
+	assert.Equal(t, uint32(2), am.Request.(model.PlaceMerge).IntoID)
+
+	// not found
+	am.Result = (*model.Place)(nil)
+	am.Errors = api.NewError(model.NewError(model.ErrNotFound, "1000000001"))
+	request, _ = http.NewRequest("POST", "/societies/1/places/1000000001/merge", bytes.NewBufferString(`{"intoId":2}`))
+	request.Header.Add("Content-Type", contentType)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
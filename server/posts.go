@@ -79,6 +79,7 @@ func (app App) GetPost(w http.ResponseWriter, req *http.Request) {
 // @param noredirect query bool false "return the url as json {url, height, width} if true"
 // @param thumbnail query bool false "return thumbnail"
 // @success 307 {header} string
+// @failure 400 {object} api.Error "Bad request"
 // @failure 404 {object} api.Error "Not found"
 // @failure 500 {object} api.Error "Server error"
 // @Security OAuth2Implicit[cms,openid,profile,email]
@@ -117,6 +118,77 @@ func (app App) GetPostImage(w http.ResponseWriter, req *http.Request) {
 	http.Redirect(w, req, imageMetadata.URL, http.StatusTemporaryRedirect)
 }
 
+// GetPostImageOCR returns the text recognized in an image
+// @summary Returns the text and word bounding boxes recognized in an image
+// @router /posts/{id}/ocr/{filePath} [get]
+// @tags posts
+// @id getPostImageOCR
+// @Param id path integer true "Post ID"
+// @Param imageFile path string true "Image file path"
+// @param highlight query string false "flag words matching these words"
+// @produce application/json
+// @success 200 {object} model.ImageOCR "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 404 {object} api.Error "Not found"
+// @failure 500 {object} api.Error "Server error"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetPostImageOCR(w http.ResponseWriter, req *http.Request) {
+	postID, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	filePath := mux.Vars(req)["filePath"]
+	if filePath == "" {
+		ErrorResponse(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	imageOCR, errors := app.api.GetPostImageOCR(req.Context(), postID, filePath, req.URL.Query().Get("highlight"))
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", contentType)
+	err := enc.Encode(imageOCR)
+	if err != nil {
+		serverError(w, err)
+	}
+}
+
+// GetPostImageDuplicates returns the exact and near duplicates of a post's images
+// @summary Returns the images in a post that duplicate other images in the post or society
+// @router /posts/{id}/duplicates [get]
+// @tags posts
+// @id getPostImageDuplicates
+// @Param id path integer true "Post ID"
+// @produce application/json
+// @success 200 {array} model.ImageDuplicate "OK"
+// @failure 404 {object} api.Error "Not found"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Duplicate detection not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetPostImageDuplicates(w http.ResponseWriter, req *http.Request) {
+	postID, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	duplicates, errors := app.api.GetPostImageDuplicates(req.Context(), postID)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", contentType)
+	err := enc.Encode(duplicates)
+	if err != nil {
+		serverError(w, err)
+	}
+}
+
 // PostPost adds a new Post to the database
 // @summary adds a new Post
 // @router /posts [post]
@@ -222,6 +294,87 @@ func (app App) PutPost(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// GetPostEvents returns a Post's event log
+// @summary returns the status changes and worker runs for a Post, oldest first
+// @router /posts/{id}/events [get]
+// @tags posts
+// @id getPostEvents
+// @Param id path integer true "Post ID"
+// @produce application/json
+// @success 200 {array} model.PostEvent "OK"
+// @failure 404 {object} api.Error "Not found"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Event log not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetPostEvents(w http.ResponseWriter, req *http.Request) {
+	postID, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	postEvents, errors := app.api.GetPostEvents(req.Context(), postID)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", contentType)
+	err := enc.Encode(postEvents)
+	if err != nil {
+		serverError(w, err)
+	}
+}
+
+// PostPostStatusOverride forces a Post's statuses
+// @summary forces a Post's post, records or images status; administrators only
+// @router /posts/{id}/status-override [post]
+// @tags posts
+// @id overridePostStatus
+// @Param id path integer true "Post ID"
+// @Param override body model.PostStatusOverride true "Statuses to force, with the reason"
+// @accept application/json
+// @produce application/json
+// @success 200 {object} model.Post "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 404 {object} api.Error "Not found"
+// @failure 415 {object} api.Error "Bad Content-Type"
+// @failure 500 {object} api.Error "Server error"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PostPostStatusOverride(w http.ResponseWriter, req *http.Request) {
+	postID, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mt != contentType {
+		msg := fmt.Sprintf("Bad Content-Type '%s'", mt)
+		ErrorResponse(w, http.StatusUnsupportedMediaType, msg)
+		return
+	}
+	var in model.PostStatusOverride
+	err = json.NewDecoder(req.Body).Decode(&in)
+	if err != nil {
+		msg := fmt.Sprintf("Bad request: %v", err)
+		ErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	post, errors := app.api.OverridePostStatus(req.Context(), postID, in)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(post)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
 // DeletePost deletes a Post from the database
 // @summary deletes a Post
 // @router /posts/{id} [delete]
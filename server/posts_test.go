@@ -184,6 +184,65 @@ func TestGetPostImage(t *testing.T) {
 	assert.Equal(t, url, metadata.URL)
 }
 
+func TestGetPostImageOCR(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	expected := model.ImageOCR{
+		Text: "John Smith",
+		Words: []model.OCRWord{
+			{Text: "John", Left: 10, Top: 20, Width: 80, Height: 30, Confidence: 96},
+			{Text: "Smith", Left: 100, Top: 20, Width: 120, Height: 30, Confidence: 91, Highlight: true},
+		},
+	}
+	am.Result = &expected
+	am.Errors = nil
+
+	request, _ := http.NewRequest("GET", "/societies/1/posts/1/ocr/foo/bar/image.jpg?highlight=smith", nil)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t,
+		contentType,
+		response.Result().Header["Content-Type"][0])
+	var actual model.ImageOCR
+	err := json.NewDecoder(response.Body).Decode(&actual)
+	if err != nil {
+		t.Errorf("Error parsing JSON: %v", err)
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestGetPostImageDuplicates(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	expected := []model.ImageDuplicate{
+		{Path: "a.jpg", DuplicatePost: 2, DuplicatePath: "b.jpg", Exact: true, Distance: 0, Deduplicated: true},
+		{Path: "c.jpg", DuplicatePost: 1, DuplicatePath: "d.jpg", Exact: false, Distance: 3},
+	}
+	am.Result = expected
+	am.Errors = nil
+
+	request, _ := http.NewRequest("GET", "/societies/1/posts/1/duplicates", nil)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t,
+		contentType,
+		response.Result().Header["Content-Type"][0])
+	var actual []model.ImageDuplicate
+	err := json.NewDecoder(response.Body).Decode(&actual)
+	if err != nil {
+		t.Errorf("Error parsing JSON: %v", err)
+	}
+	assert.Equal(t, expected, actual)
+}
+
 func TestPostPost(t *testing.T) {
 	am := &api.ApiMock{}
 	app := NewApp().API(am)
@@ -261,6 +320,63 @@ func TestPutPost(t *testing.T) {
 	assert.NotEmpty(t, created.ID)
 }
 
+func TestGetPostEvents(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	expected := []model.PostEvent{
+		{ID: 1, PostEventIn: model.NewPostEventIn(1, model.PostEventBody{Type: model.PostEventTypeWorkerStart, Worker: "recordswriter"})},
+		{ID: 2, PostEventIn: model.NewPostEventIn(1, model.PostEventBody{Type: model.PostEventTypeWorkerFinish, Worker: "recordswriter",
+			RecordCount: 42, Duration: 1500})},
+	}
+	am.Result = expected
+	am.Errors = nil
+
+	request, _ := http.NewRequest("GET", "/societies/1/posts/1/events", nil)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	var actual []model.PostEvent
+	err := json.NewDecoder(response.Body).Decode(&actual)
+	if err != nil {
+		t.Errorf("Error parsing JSON: %v", err)
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestPostPostStatusOverride(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	in, _ := makePostIn(t, 0)
+	in.PostStatus = model.PostStatusError
+	am.Result = &model.Post{ID: 1, PostIn: in}
+	am.Errors = nil
+
+	request, _ := http.NewRequest("POST", "/societies/1/posts/1/status-override",
+		bytes.NewBufferString(`{"postStatus":"Error","reason":"publisher crashed"}`))
+	request.Header.Add("Content-Type", contentType)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code, "Response: %s", string(response.Body.Bytes()))
+	var updated model.Post
+	err := json.NewDecoder(response.Body).Decode(&updated)
+	if err != nil {
+		t.Errorf("Error parsing JSON: %v", err)
+	}
+	assert.Equal(t, model.PostStatusError, updated.PostStatus)
+
+	// bad content type
+	request, _ = http.NewRequest("POST", "/societies/1/posts/1/status-override", bytes.NewBufferString(`{}`))
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusUnsupportedMediaType, response.Code)
+}
+
 func TestPutPostInvalidStatus(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping tests in short mode")
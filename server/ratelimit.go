@@ -0,0 +1,127 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ourrootsorg/cms-server/ratelimit"
+	"github.com/ourrootsorg/cms-server/utils"
+)
+
+// RateLimits are the limits on the public search and places routes; zero limits are unlimited
+type RateLimits struct {
+	IP        ratelimit.Limit // per client IP address
+	Token     ratelimit.Limit // per search token
+	Society   ratelimit.Limit // per society, for all searches
+	Anonymous ratelimit.Limit // per society, for searches by anonymous users
+	// TrustForwardedFor uses the last X-Forwarded-For address, which is added by our load balancer, as the client IP address.
+	// Only set it when the server is behind a load balancer, or clients could choose their own addresses.
+	TrustForwardedFor bool
+}
+
+func (l RateLimits) enabled() bool {
+	return !l.IP.Unlimited() || !l.Token.Unlimited() || !l.Society.Unlimited() || !l.Anonymous.Unlimited()
+}
+
+// maxWindow returns the longest window of the limits
+func (l RateLimits) maxWindow() time.Duration {
+	var window time.Duration
+	for _, limit := range []ratelimit.Limit{l.IP, l.Token, l.Society, l.Anonymous} {
+		if limit.Window > window {
+			window = limit.Window
+		}
+	}
+	return window
+}
+
+// RateLimiter limits requests to the public search and places routes
+func (app *App) RateLimiter(limiter *ratelimit.Limiter, limits RateLimits) *App {
+	app.rateLimiter = limiter
+	app.rateLimits = limits
+	return app
+}
+
+// limitIP limits requests by the client's IP address; it comes before authentication so that bad tokens are limited too
+func (app App) limitIP(next http.Handler) http.Handler {
+	if app.rateLimiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, retryAfter := app.rateLimiter.Allow(r.Context(), "ip:"+app.clientIP(r), app.rateLimits.IP); !ok {
+			tooManyRequests(w, "IP address", retryAfter)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitSearch limits searches by search token and by society, and anonymous searches by society,
+// so anonymous traffic to one society doesn't use up another society's anonymous quota;
+// it comes after verifySearchToken, which puts the society and user in context
+func (app App) limitSearch(next http.Handler) http.Handler {
+	if app.rateLimiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if token := bearerToken(r); token != "" {
+			// tokens are long, so count them by their hash
+			hash := sha256.Sum256([]byte(token))
+			if ok, retryAfter := app.rateLimiter.Allow(ctx, "token:"+hex.EncodeToString(hash[:16]), app.rateLimits.Token); !ok {
+				tooManyRequests(w, "search token", retryAfter)
+				return
+			}
+		}
+		societyID, err := utils.GetSocietyIDFromContext(ctx)
+		if err == nil {
+			society := strconv.FormatUint(uint64(societyID), 10)
+			if ok, retryAfter := app.rateLimiter.Allow(ctx, "society:"+society, app.rateLimits.Society); !ok {
+				tooManyRequests(w, "society", retryAfter)
+				return
+			}
+			if userID, err := utils.GetSearchUserIDFromContext(ctx); err == nil && userID == 0 {
+				if ok, retryAfter := app.rateLimiter.Allow(ctx, "anonymous:"+society, app.rateLimits.Anonymous); !ok {
+					tooManyRequests(w, "anonymous searches of the society", retryAfter)
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP returns the IP address of the client that made the request
+func (app App) clientIP(r *http.Request) string {
+	if app.rateLimits.TrustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			addrs := strings.Split(forwarded, ",")
+			return strings.TrimSpace(addrs[len(addrs)-1])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func bearerToken(r *http.Request) string {
+	parts := strings.Fields(r.Header.Get("Authorization"))
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		return ""
+	}
+	return parts[1]
+}
+
+// tooManyRequests returns an http.StatusTooManyRequests response that tells the client when to try again
+func tooManyRequests(w http.ResponseWriter, scope string, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	ErrorResponse(w, http.StatusTooManyRequests, fmt.Sprintf("Too many requests for this %s; try again in %s", scope, retryAfter.Round(time.Second)))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ourrootsorg/cms-server/api"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/ratelimit"
+	"github.com/ourrootsorg/cms-server/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitIP(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	app.RateLimiter(ratelimit.NewLimiter(ratelimit.NewMemoryStore()), RateLimits{
+		IP:                ratelimit.Limit{Requests: 2, Window: time.Hour},
+		TrustForwardedFor: true,
+	})
+	r := app.NewRouter()
+	am.Result = []model.Place{}
+	am.Errors = nil
+
+	get := func(forwardedFor string) *httptest.ResponseRecorder {
+		request, _ := http.NewRequest("GET", "/places?prefix=Ne", nil)
+		request.Header.Set("X-Forwarded-For", forwardedFor)
+		response := httptest.NewRecorder()
+		r.ServeHTTP(response, request)
+		return response
+	}
+	// the load balancer appends the client's address, so the client can't choose it
+	assert.Equal(t, http.StatusOK, get("10.0.0.1, 1.2.3.4").Code)
+	assert.Equal(t, http.StatusOK, get("10.0.0.2, 1.2.3.4").Code)
+	response := get("1.2.3.4")
+	assert.Equal(t, http.StatusTooManyRequests, response.Code)
+	assert.NotEmpty(t, response.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, get("5.6.7.8").Code)
+}
+
+func TestRateLimitSearch(t *testing.T) {
+	app := NewApp()
+	app.RateLimiter(ratelimit.NewLimiter(ratelimit.NewMemoryStore()), RateLimits{
+		Token:     ratelimit.Limit{Requests: 3, Window: time.Hour},
+		Anonymous: ratelimit.Limit{Requests: 2, Window: time.Hour},
+	})
+	h := app.limitSearch(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	search := func(societyID uint32, token string, userID uint32) int {
+		ctx := utils.AddSearchUserIDToContext(utils.AddSocietyIDToContext(context.TODO(), societyID), userID)
+		request, _ := http.NewRequestWithContext(ctx, "GET", "/search/1", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		response := httptest.NewRecorder()
+		h.ServeHTTP(response, request)
+		return response.Code
+	}
+
+	// anonymous searches share the society's quota
+	assert.Equal(t, http.StatusOK, search(1, "anonymous-1", 0))
+	assert.Equal(t, http.StatusOK, search(1, "anonymous-2", 0))
+	assert.Equal(t, http.StatusTooManyRequests, search(1, "anonymous-3", 0))
+	// each society has its own anonymous quota
+	assert.Equal(t, http.StatusOK, search(2, "anonymous-4", 0))
+	assert.Equal(t, http.StatusOK, search(2, "anonymous-5", 0))
+	assert.Equal(t, http.StatusTooManyRequests, search(2, "anonymous-6", 0))
+	// signed in users aren't limited by the anonymous quota, only by their token's
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, search(1, "user", 5))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, search(1, "user", 5))
+}
@@ -35,6 +35,8 @@ var decoder = schema.NewDecoder()
 // @param givenFuzziness query int false "principal given name fuzziness flags"
 // @param surname query string false "principal surname(s)"
 // @param surnameFuzziness query int false "principal surname fuzziness flags"
+// @param farm query string false "principal farm name"
+// @param farmFuzziness query int false "principal farm name fuzziness flags"
 // @param fatherGiven query string false "father given and middle names"
 // @param fatherGivenFuzziness query int false "father given name fuzziness flags"
 // @param fatherSurname query string false "father surname(s)"
@@ -71,7 +73,23 @@ var decoder = schema.NewDecoder()
 // @param anyDateFuzziness query int false "+/- year range"
 // @param anyPlace query string false "place"
 // @param anyPlaceFuzziness query int false "fuzziness flags"
+// @param birthPlaceNear query string false "lat,lon; match places within birthPlaceRadius and return the distance in each hit"
+// @param birthPlaceRadius query string false "radius around birthPlaceNear, e.g. 25km or 10mi (default 25km)"
+// @param birthPlaceBox query string false "match places within the bounding box top,left,bottom,right"
+// @param marriagePlaceNear query string false "lat,lon; match places within marriagePlaceRadius and return the distance in each hit"
+// @param marriagePlaceRadius query string false "radius around marriagePlaceNear, e.g. 25km or 10mi (default 25km)"
+// @param marriagePlaceBox query string false "match places within the bounding box top,left,bottom,right"
+// @param residencePlaceNear query string false "lat,lon; match places within residencePlaceRadius and return the distance in each hit"
+// @param residencePlaceRadius query string false "radius around residencePlaceNear, e.g. 25km or 10mi (default 25km)"
+// @param residencePlaceBox query string false "match places within the bounding box top,left,bottom,right"
+// @param deathPlaceNear query string false "lat,lon; match places within deathPlaceRadius and return the distance in each hit"
+// @param deathPlaceRadius query string false "radius around deathPlaceNear, e.g. 25km or 10mi (default 25km)"
+// @param deathPlaceBox query string false "match places within the bounding box top,left,bottom,right"
+// @param anyPlaceNear query string false "lat,lon; match places within anyPlaceRadius and return the distance in each hit"
+// @param anyPlaceRadius query string false "radius around anyPlaceNear, e.g. 25km or 10mi (default 25km)"
+// @param anyPlaceBox query string false "match places within the bounding box top,left,bottom,right"
 // @param keywords query string false "text search on the keywords field"
+// @param imageText query string false "text search on the text recognized in record images; matches are returned in imageHighlight"
 // @param collectionPlace1Facet query bool false "facet on collection location level 1"
 // @param collectionPlace1 query string false "filter on collection location level 1"
 // @param collectionPlace2Facet query bool false "facet on collection location level 2"
@@ -155,6 +173,7 @@ func (app App) SearchByID(w http.ResponseWriter, req *http.Request) {
 // @Param imageFile path string true "Image file path"
 // @param thumbnail query bool false "return thumbnail"
 // @success 307 {header} string
+// @failure 400 {object} api.Error "Bad request"
 // @failure 404 {object} api.Error "Not found"
 // @failure 500 {object} api.Error "Server error"
 // @Security OAuth2Implicit[cms,openid,profile,email]
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/ourrootsorg/cms-server/api"
+	"github.com/ourrootsorg/cms-server/model"
+)
+
+// GetSearchKeys returns the society's search keys
+// @summary returns the keys that sign and verify search tokens, newest first, without their secrets
+// @router /societies/{society}/search-keys [get]
+// @tags searchTokens
+// @id getSearchKeys
+// @produce application/json
+// @success 200 {array} model.SearchKey "OK"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Search keys not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetSearchKeys(w http.ResponseWriter, req *http.Request) {
+	searchKeys, errors := app.api.GetSearchKeys(req.Context())
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(searchKeys)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// PostSearchKey adds a search key
+// @summary adds a search key; new search tokens are signed with the newest key that has a secret.
+// @description Missing secrets and key pairs are generated; the secret is returned only in this response.
+// @router /societies/{society}/search-keys [post]
+// @tags searchTokens
+// @id addSearchKey
+// @Param searchKey body model.SearchKeyBody true "Add search key"
+// @accept application/json
+// @produce application/json
+// @success 201 {object} model.SearchKey "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 415 {object} api.Error "Bad Content-Type"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Search keys not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PostSearchKey(w http.ResponseWriter, req *http.Request) {
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mt != contentType {
+		msg := fmt.Sprintf("Bad Content-Type '%s'", mt)
+		ErrorResponse(w, http.StatusUnsupportedMediaType, msg)
+		return
+	}
+	body := model.SearchKeyBody{}
+	err = json.NewDecoder(req.Body).Decode(&body)
+	if err != nil {
+		msg := fmt.Sprintf("Bad request: %v", err.Error())
+		ErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	searchKey, errors := app.api.AddSearchKey(req.Context(), body)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusCreated)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(searchKey)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// DeleteSearchKey deletes a search key
+// @summary deletes a search key; search tokens it signed are no longer accepted
+// @router /societies/{society}/search-keys/{kid} [delete]
+// @tags searchTokens
+// @id deleteSearchKey
+// @Param kid path string true "Search key ID"
+// @success 204 "OK"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Search keys not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) DeleteSearchKey(w http.ResponseWriter, req *http.Request) {
+	errors := app.api.DeleteSearchKey(req.Context(), mux.Vars(req)["kid"])
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PostSearchToken mints a search token
+// @summary mints a search token for a user of the society's membership site
+// @router /societies/{society}/search-tokens [post]
+// @tags searchTokens
+// @id mintSearchToken
+// @Param searchToken body api.SearchTokenRequest true "User and lifetime of the token"
+// @accept application/json
+// @produce application/json
+// @success 201 {object} api.SearchToken "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 415 {object} api.Error "Bad Content-Type"
+// @failure 500 {object} api.Error "Server error"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PostSearchToken(w http.ResponseWriter, req *http.Request) {
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mt != contentType {
+		msg := fmt.Sprintf("Bad Content-Type '%s'", mt)
+		ErrorResponse(w, http.StatusUnsupportedMediaType, msg)
+		return
+	}
+	body := api.SearchTokenRequest{}
+	err = json.NewDecoder(req.Body).Decode(&body)
+	if err != nil {
+		msg := fmt.Sprintf("Bad request: %v", err.Error())
+		ErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	token, errors := app.api.MintSearchToken(req.Context(), body)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusCreated)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(token)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -24,8 +25,11 @@ import (
 	"github.com/gorilla/handlers"
 	"github.com/hashicorp/logutils"
 	"github.com/ourrootsorg/cms-server/api"
+	"github.com/ourrootsorg/cms-server/mail"
+	"github.com/ourrootsorg/cms-server/model"
 	"github.com/ourrootsorg/cms-server/persist"
 	"github.com/ourrootsorg/cms-server/persist/dynamo"
+	"github.com/ourrootsorg/cms-server/ratelimit"
 	"github.com/ourrootsorg/cms-server/server/docs"
 	"gocloud.dev/postgres"
 
@@ -38,7 +42,8 @@ import (
 )
 
 const (
-	defaultURL = "http://localhost:3000"
+	defaultURL                = "http://localhost:3000"
+	defaultTrashPurgeInterval = time.Hour
 )
 
 // @title OurRoots API
@@ -104,7 +109,18 @@ func main() {
 		QueueConfig("recordswriter", env.PubSubRecordsWriterURL).
 		QueueConfig("imageswriter", env.PubSubImagesWriterURL).
 		ElasticsearchConfig(env.ElasticsearchURLString, esTransport)
+	mailer, err := mail.NewMailer(env.Mailer, env.SMTPURL, env.MailFrom, env.MailLogFile)
+	if err != nil {
+		log.Fatalf("[FATAL] Error creating mailer: %v", err)
+	}
+	ap = ap.Mailer(mailer, env.InvitationURL).
+		UserRefreshInterval(env.UserRefreshInterval).
+		TrashRetention(env.TrashRetention).
+		PlatformAdmins(env.PlatformAdmins)
 	app := NewApp().BaseURL(*env.BaseURL).API(ap).OIDC(env.OIDCAudience, env.OIDCDomain).SandboxSociety(env.SandboxSociety)
+	for _, issuer := range env.OIDCIssuers {
+		app.OIDCIssuer(issuer)
+	}
 	if env.BaseURL.Scheme == "https" {
 		docs.SwaggerInfo.Schemes = []string{"https"}
 	} else {
@@ -131,6 +147,7 @@ func main() {
 			}
 		}()
 	}
+	var rateLimitPersister model.RateLimitPersister
 	if env.DatabaseURL != "" {
 		// Don't leak credentials from URL
 		dbURL, err := url.Parse(env.DatabaseURL)
@@ -171,11 +188,25 @@ func main() {
 			PostPersister(p).
 			RecordPersister(p).
 			UserPersister(p).
+			UserIdentityPersister(p).
 			SocietyPersister(p).
 			SocietyUserPersister(p).
+			SocietyTemplatePersister(p).
+			TrashPersister(p).
 			InvitationPersister(p).
+			APIKeyPersister(p).
+			SearchKeyPersister(p).
+			CollectionGrantPersister(p).
+			ImageHashPersister(p).
+			PostEventPersister(p).
+			AuditPersister(p).
 			PlacePersister(p).
-			NamePersister(p)
+			PlaceAdminPersister(p).
+			PlaceReviewPersister(p).
+			PlaceStandardizer(context.TODO(), p).
+			NamePersister(p).
+			NameAdminPersister(p)
+		rateLimitPersister = p
 		log.Print("[INFO] Using PostgresPersister")
 
 	} else {
@@ -194,13 +225,36 @@ func main() {
 			RecordPersister(p).
 			// TODO implement
 			//UserPersister(p).
+			//UserIdentityPersister(p).
 			//SocietyPersister(p).
 			//SocietyUserPersister(p).
+			//SocietyTemplatePersister(p).
+			//TrashPersister(p).
 			//InvitationPersister(p).
+			//APIKeyPersister(p).
+			//SearchKeyPersister(p).
+			//CollectionGrantPersister(p).
+			//ImageHashPersister(p).
+			//PostEventPersister(p).
+			AuditPersister(p).
 			PlacePersister(p).
+			//PlaceAdminPersister(p).
+			//PlaceReviewPersister(p).
+			//NameAdminPersister(p).
 			NamePersister(p)
 		log.Print("[INFO] Using DynamoDBPersister")
 	}
+	if env.RateLimits.enabled() {
+		store, err := ratelimit.NewStore(env.RateLimitStore, rateLimitPersister, env.RateLimits.maxWindow())
+		if err != nil {
+			log.Fatalf("[FATAL] Error creating rate limit store: %v", err)
+		}
+		app.RateLimiter(ratelimit.NewLimiter(store), env.RateLimits)
+	}
+	// Lambdas are frozen between requests, so they need the purge run on a schedule instead
+	if !env.IsLambda && env.TrashRetention > 0 && env.TrashPurgeInterval > 0 {
+		go purgeTrash(ap, env.TrashPurgeInterval)
+	}
 	r := app.NewRouter()
 	docs.SwaggerInfo.Host = env.BaseURL.Hostname()
 	if env.BaseURL.Port() != "" {
@@ -215,7 +269,8 @@ func main() {
 	))
 	r.NotFoundHandler = http.HandlerFunc(NotFound)
 	corsMiddleware := handlers.CORS(
-		handlers.AllowedHeaders([]string{"X-Requested-With", "Content-Type", "Authorization"}),
+		handlers.AllowedHeaders([]string{"X-Requested-With", "X-Request-ID", "Content-Type", "Authorization"}),
+		handlers.ExposedHeaders([]string{"X-Request-ID", "Retry-After"}),
 		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "HEAD", "OPTIONS"}),
 		handlers.AllowedOrigins([]string{"*"}))
 	r.Use(corsMiddleware)
@@ -264,19 +319,37 @@ type Env struct {
 	MigrationDatabaseURL   string `env:"MIGRATION_DATABASE_URL" validate:"omitempty,url"`
 	DynamoDBTableName      string `env:"DYNAMODB_TABLE_NAME" validate:"required_without=DatabaseURL"`
 	BaseURL                *url.URL
-	Region                 string `env:"AWS_REGION"`
-	BlobStoreEndpoint      string `env:"BLOB_STORE_ENDPOINT"`
-	BlobStoreAccessKey     string `env:"BLOB_STORE_ACCESS_KEY"`
-	BlobStoreSecretKey     string `env:"BLOB_STORE_SECRET_KEY"`
-	BlobStoreBucket        string `env:"BLOB_STORE_BUCKET"`
-	BlobStoreDisableSSL    bool   `env:"BLOB_STORE_DISABLE_SSL"`
-	PubSubRecordsWriterURL string `env:"PUB_SUB_RECORDSWRITER_URL" validate:"required,url"`
-	PubSubImagesWriterURL  string `env:"PUB_SUB_IMAGESWRITER_URL" validate:"required,url"`
-	PubSubPublisherURL     string `env:"PUB_SUB_PUBLISHER_URL" validate:"required,url"`
-	OIDCAudience           string `env:"OIDC_AUDIENCE" validate:"omitempty"`
-	OIDCDomain             string `env:"OIDC_DOMAIN" validate:"omitempty"`
-	ElasticsearchURLString string `env:"ELASTICSEARCH_URL" validate:"required,url"`
-	SandboxSociety         uint32 `env:"SANDBOX_SOCIETY_ID" validate:"omitempty"`
+	Region                 string        `env:"AWS_REGION"`
+	BlobStoreEndpoint      string        `env:"BLOB_STORE_ENDPOINT"`
+	BlobStoreAccessKey     string        `env:"BLOB_STORE_ACCESS_KEY"`
+	BlobStoreSecretKey     string        `env:"BLOB_STORE_SECRET_KEY"`
+	BlobStoreBucket        string        `env:"BLOB_STORE_BUCKET"`
+	BlobStoreDisableSSL    bool          `env:"BLOB_STORE_DISABLE_SSL"`
+	PubSubRecordsWriterURL string        `env:"PUB_SUB_RECORDSWRITER_URL" validate:"required,url"`
+	PubSubImagesWriterURL  string        `env:"PUB_SUB_IMAGESWRITER_URL" validate:"required,url"`
+	PubSubPublisherURL     string        `env:"PUB_SUB_PUBLISHER_URL" validate:"required,url"`
+	OIDCAudience           string        `env:"OIDC_AUDIENCE" validate:"omitempty"`
+	OIDCDomain             string        `env:"OIDC_DOMAIN" validate:"omitempty"`
+	OIDCIssuersJSON        string        `env:"OIDC_ISSUERS" validate:"omitempty,json"`
+	ElasticsearchURLString string        `env:"ELASTICSEARCH_URL" validate:"required,url"`
+	SandboxSociety         uint32        `env:"SANDBOX_SOCIETY_ID" validate:"omitempty"`
+	Mailer                 string        `env:"MAILER" validate:"omitempty,eq=none|eq=smtp|eq=log"`
+	SMTPURL                string        `env:"SMTP_URL" validate:"omitempty,url"`
+	MailFrom               string        `env:"MAIL_FROM" validate:"omitempty,email"`
+	MailLogFile            string        `env:"MAIL_LOG_FILE"`
+	InvitationURL          string        `env:"INVITATION_URL" validate:"omitempty,url"`
+	UserRefreshInterval    time.Duration `env:"USER_REFRESH_INTERVAL"`
+	TrashRetention         time.Duration `env:"TRASH_RETENTION"`
+	TrashPurgeInterval     time.Duration `env:"TRASH_PURGE_INTERVAL"`
+	PlatformAdmins         []uint32      `env:"PLATFORM_ADMIN_USER_IDS"`
+	RateLimitStore         string        `env:"RATE_LIMIT_STORE" validate:"omitempty,eq=memory|eq=postgres"`
+	RateLimitIP            string        `env:"RATE_LIMIT_IP"`
+	RateLimitToken         string        `env:"RATE_LIMIT_TOKEN"`
+	RateLimitSociety       string        `env:"RATE_LIMIT_SOCIETY"`
+	RateLimitAnonymous     string        `env:"RATE_LIMIT_ANONYMOUS"`
+	RateLimitForwardedFor  bool          `env:"RATE_LIMIT_TRUST_FORWARDED_FOR"`
+	OIDCIssuers            []OIDCIssuerConfig
+	RateLimits             RateLimits
 }
 
 // ParseEnv parses and validates environment variables and stores them in the Env structure
@@ -310,6 +383,18 @@ func ParseEnv() (*Env, error) {
 				errs += fmt.Sprintf("  Invalid PUB_SUB_PUBLISHER_URL: '%v'is not a valid URL\n", fe.Value())
 			case "ELASTICSEARCH_URL":
 				errs += fmt.Sprintf("  Invalid ELASTICSEARCH_URL: '%v' is not a valid URL\n", fe.Value())
+			case "MAILER":
+				errs += fmt.Sprintf("  Invalid MAILER: '%v', valid values are 'none', 'smtp' or 'log'\n", fe.Value())
+			case "SMTP_URL":
+				errs += fmt.Sprintf("  Invalid SMTP_URL: '%v' is not a valid URL\n", fe.Value())
+			case "MAIL_FROM":
+				errs += fmt.Sprintf("  Invalid MAIL_FROM: '%v' is not a valid email address\n", fe.Value())
+			case "OIDC_ISSUERS":
+				errs += fmt.Sprintf("  Invalid OIDC_ISSUERS: '%v' is not valid JSON\n", fe.Value())
+			case "RATE_LIMIT_STORE":
+				errs += fmt.Sprintf("  Invalid RATE_LIMIT_STORE: '%v', valid values are 'memory' or 'postgres'\n", fe.Value())
+			case "INVITATION_URL":
+				errs += fmt.Sprintf("  Invalid INVITATION_URL: '%v' is not a valid URL\n", fe.Value())
 			}
 		}
 		return nil, errors.New(errs)
@@ -317,10 +402,55 @@ func ParseEnv() (*Env, error) {
 	if config.DatabaseURL != "" && config.DynamoDBTableName != "" {
 		return nil, errors.New("Must only set one of DATABASE_URL or DYNAMODB_TABLE_NAME")
 	}
+	if config.Mailer == "smtp" && (config.SMTPURL == "" || config.MailFrom == "" || config.InvitationURL == "") {
+		return nil, errors.New("Must set SMTP_URL, MAIL_FROM and INVITATION_URL when MAILER is smtp")
+	}
+	if config.OIDCIssuersJSON != "" {
+		if err := json.Unmarshal([]byte(config.OIDCIssuersJSON), &config.OIDCIssuers); err != nil {
+			return nil, fmt.Errorf("Invalid OIDC_ISSUERS: %v", err)
+		}
+		for _, issuer := range config.OIDCIssuers {
+			if err := validate.Struct(issuer); err != nil {
+				return nil, fmt.Errorf("Invalid OIDC_ISSUERS: each issuer needs an issuer URL and an audience: %v", err)
+			}
+		}
+	}
+	limits := []struct {
+		name  string
+		value string
+		limit *ratelimit.Limit
+	}{
+		{"RATE_LIMIT_IP", config.RateLimitIP, &config.RateLimits.IP},
+		{"RATE_LIMIT_TOKEN", config.RateLimitToken, &config.RateLimits.Token},
+		{"RATE_LIMIT_SOCIETY", config.RateLimitSociety, &config.RateLimits.Society},
+		{"RATE_LIMIT_ANONYMOUS", config.RateLimitAnonymous, &config.RateLimits.Anonymous},
+	}
+	for _, l := range limits {
+		if *l.limit, err = ratelimit.ParseLimit(l.value); err != nil {
+			return nil, fmt.Errorf("Invalid %s: %v", l.name, err)
+		}
+	}
+	config.RateLimits.TrustForwardedFor = config.RateLimitForwardedFor
+	if config.RateLimitStore == "postgres" && config.DatabaseURL == "" {
+		return nil, errors.New("Must set DATABASE_URL when RATE_LIMIT_STORE is postgres")
+	}
 	config.IsLambda = config.LambdaTaskRoot != ""
 	if config.MinLogLevel == "" {
 		config.MinLogLevel = "DEBUG"
 	}
+	if config.UserRefreshInterval <= 0 {
+		config.UserRefreshInterval = api.DefaultUserRefreshInterval
+	}
+	// A negative TRASH_RETENTION turns the trash off, so deletes are immediate
+	if config.TrashRetention == 0 {
+		config.TrashRetention = api.DefaultTrashRetention
+	} else if config.TrashRetention < 0 {
+		config.TrashRetention = 0
+	}
+	// A negative TRASH_PURGE_INTERVAL turns off the background purge
+	if config.TrashPurgeInterval == 0 {
+		config.TrashPurgeInterval = defaultTrashPurgeInterval
+	}
 	if config.BaseURLString == "" {
 		config.BaseURLString = defaultURL
 	}
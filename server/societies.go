@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"mime"
 	"net/http"
+	"strconv"
 
 	"github.com/ourrootsorg/cms-server/utils"
 
@@ -69,9 +70,11 @@ func (app App) GetSocietySummary(w http.ResponseWriter, req *http.Request) {
 // @tags societies
 // @id addSociety
 // @Param society body model.SocietyIn true "Add Society"
+// @Param template query integer false "ID of a template to apply to the new society"
 // @accept application/json
 // @produce application/json
 // @success 201 {object} model.Society "OK"
+// @failure 404 {object} api.Error "Template not found"
 // @failure 415 {object} api.Error "Bad Content-Type"
 // @failure 500 {object} api.Error "Server error"
 // @Security OAuth2Implicit[cms,openid,profile,email]
@@ -90,11 +93,36 @@ func (app App) PostSociety(w http.ResponseWriter, req *http.Request) {
 		ErrorResponse(w, http.StatusBadRequest, msg)
 		return
 	}
+	// check the template before creating the society, so a bad template doesn't leave an empty society behind
+	var templateID uint32
+	if t := req.URL.Query().Get("template"); t != "" {
+		id, err := strconv.Atoi(t)
+		if err != nil || id <= 0 {
+			ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Bad template '%s'", t))
+			return
+		}
+		templateID = uint32(id)
+		if _, errors := app.api.GetSocietyTemplate(req.Context(), templateID); errors != nil {
+			ErrorsResponse(w, errors)
+			return
+		}
+	}
 	society, errors := app.api.AddSociety(req.Context(), in)
 	if errors != nil {
 		ErrorsResponse(w, errors)
 		return
 	}
+	if templateID != 0 {
+		ctx := utils.AddSocietyIDToContext(req.Context(), society.ID)
+		if _, errors := app.api.ApplySocietyTemplate(ctx, templateID); errors != nil {
+			ErrorsResponse(w, errors)
+			return
+		}
+		// return the society with the template's post metadata
+		if updated, errors := app.api.GetSociety(ctx, society.ID); errors == nil {
+			society = updated
+		}
+	}
 	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(http.StatusCreated)
 	enc := json.NewEncoder(w)
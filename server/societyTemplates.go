@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/ourrootsorg/cms-server/model"
+)
+
+// GetSocietyTemplates returns the society's templates and the public templates of other societies
+// @summary returns the society's templates and the public templates of other societies
+// @router /societies/{society}/templates [get]
+// @tags societyTemplates
+// @id getSocietyTemplates
+// @produce application/json
+// @success 200 {array} model.SocietyTemplate "OK"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Society templates not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetSocietyTemplates(w http.ResponseWriter, req *http.Request) {
+	templates, errors := app.api.GetSocietyTemplates(req.Context())
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(templates)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// GetSocietyTemplate returns a society template
+// @summary returns a template that is public, belongs to the society, or belongs to another society the user administers
+// @router /societies/{society}/templates/{id} [get]
+// @tags societyTemplates
+// @id getSocietyTemplate
+// @Param id path integer true "Template ID"
+// @produce application/json
+// @success 200 {object} model.SocietyTemplate "OK"
+// @failure 404 {object} api.Error "Not found"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Society templates not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetSocietyTemplate(w http.ResponseWriter, req *http.Request) {
+	id, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	template, errors := app.api.GetSocietyTemplate(req.Context(), id)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(template)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// PostSocietyTemplate exports the society as a template
+// @summary saves the society's categories, collection layouts and post metadata as a template
+// @router /societies/{society}/templates [post]
+// @tags societyTemplates
+// @id exportSocietyTemplate
+// @Param template body model.SocietyTemplateExport true "Name and visibility of the template"
+// @accept application/json
+// @produce application/json
+// @success 201 {object} model.SocietyTemplate "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 415 {object} api.Error "Bad Content-Type"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Society templates not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PostSocietyTemplate(w http.ResponseWriter, req *http.Request) {
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mt != contentType {
+		msg := fmt.Sprintf("Bad Content-Type '%s'", mt)
+		ErrorResponse(w, http.StatusUnsupportedMediaType, msg)
+		return
+	}
+	in := model.SocietyTemplateExport{}
+	err = json.NewDecoder(req.Body).Decode(&in)
+	if err != nil {
+		msg := fmt.Sprintf("Bad request: %v", err.Error())
+		ErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	template, errors := app.api.ExportSocietyTemplate(req.Context(), in)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusCreated)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(template)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// DeleteSocietyTemplate deletes a society template
+// @summary deletes one of the society's templates
+// @router /societies/{society}/templates/{id} [delete]
+// @tags societyTemplates
+// @id deleteSocietyTemplate
+// @Param id path integer true "Template ID"
+// @success 204 "OK"
+// @failure 404 {object} api.Error "Not found"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Society templates not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) DeleteSocietyTemplate(w http.ResponseWriter, req *http.Request) {
+	id, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	errors = app.api.DeleteSocietyTemplate(req.Context(), id)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PostSocietyTemplateApply applies a template to the society
+// @summary adds a template's categories, collections and post metadata to the society, reusing those with the same name
+// @router /societies/{society}/templates/{id}/apply [post]
+// @tags societyTemplates
+// @id applySocietyTemplate
+// @Param id path integer true "Template ID"
+// @produce application/json
+// @success 200 {object} api.SocietyTemplateResult "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 404 {object} api.Error "Not found"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Society templates not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PostSocietyTemplateApply(w http.ResponseWriter, req *http.Request) {
+	id, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	result, errors := app.api.ApplySocietyTemplate(req.Context(), id)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(result)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
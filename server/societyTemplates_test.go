@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ourrootsorg/cms-server/api"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostSocietyTemplate(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	in := model.SocietyTemplateExport{Name: "County records", Public: true}
+	am.Result = &model.SocietyTemplate{ID: 3, SocietyTemplateIn: model.SocietyTemplateIn{
+		SocietyTemplateBody: model.SocietyTemplateBody{SocietyTemplateExport: in}, SocietyID: 1}}
+	am.Errors = nil
+	buf := new(bytes.Buffer)
+	_ = json.NewEncoder(buf).Encode(in)
+	request, _ := http.NewRequest("POST", "/societies/1/templates", buf)
+	request.Header.Add("Content-Type", contentType)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusCreated, response.Code)
+	assert.Equal(t, in, am.Request)
+
+	am.Result = &api.SocietyTemplateResult{Categories: map[uint32]uint32{1: 20}}
+	request, _ = http.NewRequest("POST", "/societies/1/templates/3/apply", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, uint32(3), am.Request)
+	var result api.SocietyTemplateResult
+	err := json.NewDecoder(response.Body).Decode(&result)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(20), result.Categories[1])
+
+	// a society isn't created from a template the user can't use
+	am.Result = (*model.SocietyTemplate)(nil)
+	am.Errors = api.NewError(model.NewError(model.ErrNotFound, "9"))
+	buf = new(bytes.Buffer)
+	_ = json.NewEncoder(buf).Encode(model.SocietyIn{SocietyBody: model.SocietyBody{Name: "New society"}})
+	request, _ = http.NewRequest("POST", "/societies?template=9", buf)
+	request.Header.Add("Content-Type", contentType)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.Equal(t, uint32(9), am.Request)
+}
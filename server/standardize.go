@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ourrootsorg/cms-server/api"
+)
+
+const csvContentType = "text/csv"
+
+// maxStandardizeBodyBytes limits the size of batch standardize requests
+const maxStandardizeBodyBytes = 10 << 20
+
+// StandardizePlace standardizes place text
+// @summary standardizes place text, returning the matched place hierarchy with IDs and coordinates
+// @router /standardize/place [get]
+// @tags standardize
+// @id standardizePlace
+// @Param text query string true "place text"
+// @Param defaultContainingPlace query string false "place used to choose between ambiguous matches"
+// @Param year query integer false "year of the event, used to match historical names and jurisdictions"
+// @produce application/json
+// @success 200 {object} api.StandardizedPlace "OK"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Place standardization not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) StandardizePlace(w http.ResponseWriter, req *http.Request) {
+	year, _ := strconv.Atoi(req.URL.Query().Get("year"))
+	results, errors := app.api.StandardizePlaces(req.Context(), []api.PlaceStandardizeRequest{{
+		Text:                   req.URL.Query().Get("text"),
+		DefaultContainingPlace: req.URL.Query().Get("defaultContainingPlace"),
+		Year:                   year,
+	}})
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(results[0])
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// StandardizePlaces standardizes a batch of place text
+// @summary standardizes a batch of place text; CSV must have a header row with a text column, and optional defaultContainingPlace and year columns
+// @router /standardize/places [post]
+// @tags standardize
+// @id standardizePlaces
+// @Param places body []api.PlaceStandardizeRequest true "Place text to standardize"
+// @accept application/json
+// @accept text/csv
+// @produce application/json
+// @produce text/csv
+// @success 200 {array} api.StandardizedPlace "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 415 {object} api.Error "Bad Content-Type"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Place standardization not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) StandardizePlaces(w http.ResponseWriter, req *http.Request) {
+	var reqs []api.PlaceStandardizeRequest
+	if !readStandardizeBatch(w, req, &reqs, func(row map[string]string) error {
+		var year int
+		if row["year"] != "" {
+			var err error
+			if year, err = strconv.Atoi(row["year"]); err != nil {
+				return fmt.Errorf("invalid year '%s'", row["year"])
+			}
+		}
+		reqs = append(reqs, api.PlaceStandardizeRequest{
+			Text:                   row["text"],
+			DefaultContainingPlace: row["defaultcontainingplace"],
+			Year:                   year,
+		})
+		return nil
+	}) {
+		return
+	}
+	results, errors := app.api.StandardizePlaces(req.Context(), reqs)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	if !acceptsCSV(req) {
+		writeJSONResults(w, results)
+		return
+	}
+	rows := [][]string{{"text", "found", "id", "fullName", "latitude", "longitude", "hierarchyIds", "unresolved", "candidateIds", "error"}}
+	for _, result := range results {
+		var hierarchyIDs, candidateIDs []string
+		for _, level := range result.Hierarchy {
+			hierarchyIDs = append(hierarchyIDs, strconv.Itoa(int(level.ID)))
+		}
+		for _, candidate := range result.Candidates {
+			candidateIDs = append(candidateIDs, strconv.Itoa(int(candidate.PlaceID)))
+		}
+		row := []string{result.Text, strconv.FormatBool(result.Found), "", result.FullName, "", "",
+			strings.Join(hierarchyIDs, ";"), strconv.FormatBool(result.Unresolved), strings.Join(candidateIDs, ";"), result.Error}
+		if result.ID != 0 {
+			row[2] = strconv.Itoa(int(result.ID))
+		}
+		if result.Latitude != 0 || result.Longitude != 0 {
+			row[4] = strconv.FormatFloat(float64(result.Latitude), 'f', -1, 32)
+			row[5] = strconv.FormatFloat(float64(result.Longitude), 'f', -1, 32)
+		}
+		rows = append(rows, row)
+	}
+	writeCSVResults(w, rows)
+}
+
+// StandardizeDate standardizes date text
+// @summary standardizes date text, returning the parsed date with modifier, quality and encoded range
+// @router /standardize/date [get]
+// @tags standardize
+// @id standardizeDate
+// @Param text query string true "date text"
+// @produce application/json
+// @success 200 {object} api.StandardizedDate "OK"
+// @failure 500 {object} api.Error "Server error"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) StandardizeDate(w http.ResponseWriter, req *http.Request) {
+	results, errors := app.api.StandardizeDates(req.Context(), []api.DateStandardizeRequest{{Text: req.URL.Query().Get("text")}})
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(results[0])
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// StandardizeDates standardizes a batch of date text
+// @summary standardizes a batch of date text; CSV must have a header row with a text column
+// @router /standardize/dates [post]
+// @tags standardize
+// @id standardizeDates
+// @Param dates body []api.DateStandardizeRequest true "Date text to standardize"
+// @accept application/json
+// @accept text/csv
+// @produce application/json
+// @produce text/csv
+// @success 200 {array} api.StandardizedDate "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 415 {object} api.Error "Bad Content-Type"
+// @failure 500 {object} api.Error "Server error"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) StandardizeDates(w http.ResponseWriter, req *http.Request) {
+	var reqs []api.DateStandardizeRequest
+	if !readStandardizeBatch(w, req, &reqs, func(row map[string]string) error {
+		reqs = append(reqs, api.DateStandardizeRequest{Text: row["text"]})
+		return nil
+	}) {
+		return
+	}
+	results, errors := app.api.StandardizeDates(req.Context(), reqs)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	if !acceptsCSV(req) {
+		writeJSONResults(w, results)
+		return
+	}
+	rows := [][]string{{"text", "found", "type", "encoded", "startDate", "endDate",
+		"year", "month", "day", "modifier", "quality", "doubleDate", "calendar",
+		"secondYear", "secondMonth", "secondDay", "secondModifier", "secondQuality", "secondDoubleDate", "secondCalendar"}}
+	for _, result := range results {
+		row := []string{result.Text, strconv.FormatBool(result.Found), result.Type, result.Encoded, result.StartDate, result.EndDate}
+		for _, part := range []*api.StandardizedDatePart{result.First, result.Second} {
+			if part == nil {
+				row = append(row, "", "", "", "", "", "", "")
+				continue
+			}
+			row = append(row, strconv.Itoa(part.Year), strconv.Itoa(part.Month), strconv.Itoa(part.Day),
+				part.Modifier, part.Quality, strconv.FormatBool(part.DoubleDate), part.Calendar)
+		}
+		rows = append(rows, row)
+	}
+	writeCSVResults(w, rows)
+}
+
+// readStandardizeBatch decodes a JSON array into reqs, or passes each CSV row to addRow keyed by lower-cased header
+// It writes an error response and returns false if the request can't be read
+func readStandardizeBatch(w http.ResponseWriter, req *http.Request, reqs interface{}, addRow func(map[string]string) error) bool {
+	body := http.MaxBytesReader(w, req.Body, maxStandardizeBodyBytes)
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch {
+	case err == nil && mt == contentType:
+		err = json.NewDecoder(body).Decode(reqs)
+		if err != nil {
+			ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Bad request: %v", err))
+			return false
+		}
+	case err == nil && mt == csvContentType:
+		r := csv.NewReader(body)
+		r.FieldsPerRecord = -1
+		r.TrimLeadingSpace = true
+		var headers []string
+		for line := 1; ; line++ {
+			record, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Bad request: %v", err))
+				return false
+			}
+			if headers == nil {
+				for _, header := range record {
+					headers = append(headers, strings.ToLower(strings.TrimSpace(header)))
+				}
+				if !containsHeader(headers, "text") {
+					ErrorResponse(w, http.StatusBadRequest, "Bad request: CSV must have a header row with a text column")
+					return false
+				}
+				continue
+			}
+			if line > api.MaxStandardizeBatch+1 {
+				ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Bad request: at most %d values can be standardized at once", api.MaxStandardizeBatch))
+				return false
+			}
+			row := map[string]string{}
+			for i, value := range record {
+				if i < len(headers) {
+					row[headers[i]] = value
+				}
+			}
+			if err := addRow(row); err != nil {
+				ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Bad request: line %d: %v", line, err))
+				return false
+			}
+		}
+	default:
+		ErrorResponse(w, http.StatusUnsupportedMediaType, fmt.Sprintf("Bad Content-Type '%s'", mt))
+		return false
+	}
+	return true
+}
+
+func acceptsCSV(req *http.Request) bool {
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		if mt, _, err := mime.ParseMediaType(accept); err == nil && mt == csvContentType {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSONResults(w http.ResponseWriter, results interface{}) {
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(results)
+	if err != nil {
+		serverError(w, err)
+	}
+}
+
+func writeCSVResults(w http.ResponseWriter, rows [][]string) {
+	w.Header().Set("Content-Type", csvContentType)
+	cw := csv.NewWriter(w)
+	err := cw.WriteAll(rows)
+	if err != nil {
+		serverError(w, err)
+	}
+}
+
+func containsHeader(headers []string, header string) bool {
+	for _, h := range headers {
+		if h == header {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ourrootsorg/cms-server/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStandardizePlaces(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+
+	// standardizing requires a logged-in user
+	request, _ := http.NewRequest("POST", "/standardize/places", bytes.NewBufferString(`[{"text":"Wroclaw"}]`))
+	request.Header.Add("Content-Type", contentType)
+	response := httptest.NewRecorder()
+	app.NewRouter().ServeHTTP(response, request)
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	am.Result = []api.StandardizedPlace{
+		{Text: "Wroclaw", Found: true, ID: 3, FullName: "Wroclaw, Lower Silesia, Poland", Latitude: 51.1, Longitude: 17.03,
+			Hierarchy: []api.PlaceLevel{{ID: 3, Name: "Wroclaw"}, {ID: 2, Name: "Lower Silesia"}, {ID: 1, Name: "Poland"}}},
+		{Text: "Xyzzy", Unresolved: true},
+	}
+	am.Errors = nil
+
+	// JSON in and out
+	request, _ = http.NewRequest("POST", "/standardize/places", bytes.NewBufferString(`[{"text":"Wroclaw","year":1950},{"text":"Xyzzy"}]`))
+	request.Header.Add("Content-Type", contentType)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code, "Response: %s", string(response.Body.Bytes()))
+	assert.Equal(t, []api.PlaceStandardizeRequest{{Text: "Wroclaw", Year: 1950}, {Text: "Xyzzy"}}, am.Request)
+	var results []api.StandardizedPlace
+	err := json.NewDecoder(response.Body).Decode(&results)
+	if err != nil {
+		t.Errorf("Error parsing JSON: %v", err)
+	}
+	assert.Equal(t, am.Result, results)
+
+	// CSV in and out
+	request, _ = http.NewRequest("POST", "/standardize/places",
+		bytes.NewBufferString("Text,Year,DefaultContainingPlace\nWroclaw,1950,Poland\nXyzzy,,\n"))
+	request.Header.Add("Content-Type", "text/csv")
+	request.Header.Add("Accept", "text/csv")
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code, "Response: %s", string(response.Body.Bytes()))
+	assert.Equal(t, []api.PlaceStandardizeRequest{{Text: "Wroclaw", DefaultContainingPlace: "Poland", Year: 1950}, {Text: "Xyzzy"}}, am.Request)
+	assert.Equal(t, "text/csv", response.Result().Header.Get("Content-Type"))
+	rows, err := csv.NewReader(response.Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, []string{"Wroclaw", "true", "3", "Wroclaw, Lower Silesia, Poland", "51.1", "17.03", "3;2;1", "false", "", ""}, rows[1])
+	assert.Equal(t, []string{"Xyzzy", "false", "", "", "", "", "", "true", "", ""}, rows[2])
+
+	// CSV without a text column
+	request, _ = http.NewRequest("POST", "/standardize/places", bytes.NewBufferString("Place\nWroclaw\n"))
+	request.Header.Add("Content-Type", "text/csv")
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	// bad content type
+	request, _ = http.NewRequest("POST", "/standardize/places", bytes.NewBufferString("Wroclaw"))
+	request.Header.Add("Content-Type", "text/plain")
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusUnsupportedMediaType, response.Code)
+}
+
+func TestStandardizeDate(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	am.Result = []api.StandardizedDate{{Text: "abt 1900", Found: true, First: &api.StandardizedDatePart{Year: 1900, Modifier: "About"},
+		Encoded: "19000000,18990101-19011231", StartDate: "18990101", EndDate: "19011231"}}
+	am.Errors = nil
+
+	request, _ := http.NewRequest("GET", "/standardize/date?text=abt+1900", nil)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code, "Response: %s", string(response.Body.Bytes()))
+	assert.Equal(t, []api.DateStandardizeRequest{{Text: "abt 1900"}}, am.Request)
+	var result api.StandardizedDate
+	err := json.NewDecoder(response.Body).Decode(&result)
+	if err != nil {
+		t.Errorf("Error parsing JSON: %v", err)
+	}
+	assert.Equal(t, am.Result.([]api.StandardizedDate)[0], result)
+}
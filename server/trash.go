@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ourrootsorg/cms-server/api"
+)
+
+// GetTrash returns the society's trashed collections and posts
+// @summary returns the society's trashed collections and posts, most recently deleted first
+// @router /societies/{society}/trash [get]
+// @tags trash
+// @id getTrash
+// @produce application/json
+// @success 200 {array} model.TrashItem "OK"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Trash not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetTrash(w http.ResponseWriter, req *http.Request) {
+	items, errors := app.api.GetTrash(req.Context())
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(items)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// GetTrashedSocieties returns the trashed societies the current user administers
+// @summary returns the trashed societies the current user administers
+// @router /trashed_societies [get]
+// @tags trash
+// @id getTrashedSocieties
+// @produce application/json
+// @success 200 {array} model.TrashItem "OK"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Trash not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetTrashedSocieties(w http.ResponseWriter, req *http.Request) {
+	items, errors := app.api.GetTrashedSocieties(req.Context())
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(items)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// PostRestoreSociety restores a trashed society
+// @summary takes a society out of the trash; only its admins can restore it
+// @router /societies/{society}/restore [post]
+// @tags trash
+// @id restoreSociety
+// @produce application/json
+// @success 200 {object} model.Society "OK"
+// @failure 403 {object} api.Error "Forbidden"
+// @failure 404 {object} api.Error "Not found"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Trash not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PostRestoreSociety(w http.ResponseWriter, req *http.Request) {
+	society, errors := app.api.RestoreSociety(req.Context())
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(society)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// PostRestoreCollection restores a trashed collection
+// @summary takes a collection out of the trash
+// @router /societies/{society}/trash/collections/{id}/restore [post]
+// @tags trash
+// @id restoreCollection
+// @Param id path integer true "Collection ID"
+// @produce application/json
+// @success 200 {object} model.Collection "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 403 {object} api.Error "Forbidden"
+// @failure 404 {object} api.Error "Not found"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Trash not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PostRestoreCollection(w http.ResponseWriter, req *http.Request) {
+	id, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	collection, errors := app.api.RestoreCollection(req.Context(), id)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(collection)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// PostRestorePost restores a trashed post
+// @summary takes a post out of the trash; its collection must not be in the trash
+// @router /societies/{society}/trash/posts/{id}/restore [post]
+// @tags trash
+// @id restorePost
+// @Param id path integer true "Post ID"
+// @produce application/json
+// @success 200 {object} model.Post "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 403 {object} api.Error "Forbidden"
+// @failure 404 {object} api.Error "Not found"
+// @failure 409 {object} api.Error "Collection is in the trash"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Trash not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PostRestorePost(w http.ResponseWriter, req *http.Request) {
+	id, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	post, errors := app.api.RestorePost(req.Context(), id)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(post)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// purgeTrash purges expired trash every interval until the process exits
+func purgeTrash(ap *api.API, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		count, err := ap.PurgeTrash(context.Background())
+		if err != nil {
+			log.Printf("[ERROR] Error purging trash: %v", err)
+			continue
+		}
+		if count > 0 {
+			log.Printf("[INFO] Purged %d items from the trash", count)
+		}
+	}
+}
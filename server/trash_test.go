@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ourrootsorg/cms-server/api"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestorePost(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	am.Result = &model.Post{ID: 3, PostIn: model.PostIn{PostBody: model.PostBody{Name: "1870"}, Collection: 2}}
+	am.Errors = nil
+	request, _ := http.NewRequest("POST", "/societies/1/trash/posts/3/restore", nil)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, uint32(3), am.Request)
+	var post model.Post
+	err := json.NewDecoder(response.Body).Decode(&post)
+	assert.NoError(t, err)
+	assert.Equal(t, "1870", post.Name)
+
+	// posts can't be restored into a trashed collection
+	am.Result = (*model.Post)(nil)
+	am.Errors = api.NewHTTPError(model.NewError(model.ErrConflict, "2"), http.StatusConflict)
+	request, _ = http.NewRequest("POST", "/societies/1/trash/posts/3/restore", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusConflict, response.Code)
+}
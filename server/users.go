@@ -2,11 +2,19 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
+	"mime"
 	"net/http"
 
 	"github.com/ourrootsorg/cms-server/utils"
 )
 
+// LinkIdentityRequest holds a token for the identity to link to the current user
+type LinkIdentityRequest struct {
+	// Token is an ID token from one of the trusted issuers, proving that the current user owns the identity
+	Token string `json:"token"`
+}
+
 // GetCurrentUser returns the current user
 // @summary returns the current user
 // @router /currentuser [get]
@@ -31,3 +39,140 @@ func (app App) GetCurrentUser(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 }
+
+// DeactivateUser deactivates a user and removes them from all of their societies
+// @summary deactivates a user on every society; only platform admins can deactivate users
+// @router /users/{id}/deactivate [post]
+// @tags users
+// @id deactivateUser
+// @Param id path integer true "User ID"
+// @produce application/json
+// @success 200 {object} model.User "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 403 {object} api.Error "Not a platform admin"
+// @failure 404 {object} api.Error "Not found"
+// @failure 500 {object} api.Error "Server error"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) DeactivateUser(w http.ResponseWriter, req *http.Request) {
+	id, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	user, errors := app.api.DeactivateUser(req.Context(), id)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(user)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// GetUserIdentities returns the identities linked to the current user
+// @summary returns the identities, in addition to the one the current user was created with, that sign in as the current user
+// @router /current_user/identities [get]
+// @tags users
+// @id getUserIdentities
+// @produce application/json
+// @success 200 {array} model.UserIdentity "OK"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Linked identities not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) GetUserIdentities(w http.ResponseWriter, req *http.Request) {
+	identities, errors := app.api.GetUserIdentities(req.Context())
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	enc := json.NewEncoder(w)
+	err := enc.Encode(identities)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// PostUserIdentity links an identity to the current user
+// @summary links the identity in a token from a trusted issuer to the current user, so that it signs in as the current user
+// @router /current_user/identities [post]
+// @tags users
+// @id linkUserIdentity
+// @Param identity body LinkIdentityRequest true "Token for the identity"
+// @accept application/json
+// @produce application/json
+// @success 201 {object} model.UserIdentity "OK"
+// @failure 400 {object} api.Error "Bad request"
+// @failure 401 {object} api.Error "Invalid token"
+// @failure 409 {object} api.Error "Identity already signs in as a user"
+// @failure 415 {object} api.Error "Bad Content-Type"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Linked identities not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) PostUserIdentity(w http.ResponseWriter, req *http.Request) {
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mt != contentType {
+		msg := fmt.Sprintf("Bad Content-Type '%s'", mt)
+		ErrorResponse(w, http.StatusUnsupportedMediaType, msg)
+		return
+	}
+	body := LinkIdentityRequest{}
+	err = json.NewDecoder(req.Body).Decode(&body)
+	if err != nil || body.Token == "" {
+		msg := fmt.Sprintf("Bad request: %v", err)
+		ErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	token, err := app.tokenIssuer(body.Token).verifier.Verify(req.Context(), body.Token)
+	if err != nil {
+		ErrorResponse(w, http.StatusUnauthorized, fmt.Sprintf("Invalid token: %v", err))
+		return
+	}
+	identity, errors := app.api.LinkUserIdentity(req.Context(), token)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusCreated)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(identity)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+}
+
+// DeleteUserIdentity unlinks an identity from the current user
+// @summary unlinks an identity from the current user
+// @router /current_user/identities/{id} [delete]
+// @tags users
+// @id deleteUserIdentity
+// @Param id path integer true "User identity ID"
+// @success 204 "OK"
+// @failure 404 {object} api.Error "Not found"
+// @failure 500 {object} api.Error "Server error"
+// @failure 501 {object} api.Error "Linked identities not configured"
+// @Security OAuth2Implicit[cms,openid,profile,email]
+// @Security OAuth2AuthCode[cms,openid,profile,email]
+func (app App) DeleteUserIdentity(w http.ResponseWriter, req *http.Request) {
+	id, errors := getIDFromRequest(req)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	errors = app.api.DeleteUserIdentity(req.Context(), id)
+	if errors != nil {
+		ErrorsResponse(w, errors)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/ourrootsorg/cms-server/api"
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/go-oidc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDeactivateUser(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	r := app.NewRouter()
+
+	expected := &model.User{ID: 11, UserBody: model.UserBody{Name: "Somebody", Email: "user@example.com"}}
+	am.Result = expected
+	am.Errors = nil
+
+	request, _ := http.NewRequest("POST", "/users/11/deactivate", nil)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, uint32(11), am.Request)
+	var actual model.User
+	err := json.NewDecoder(response.Body).Decode(&actual)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.Email, actual.Email)
+	assert.False(t, actual.Enabled)
+
+	// only platform admins can deactivate users
+	am.Errors = api.NewHTTPError(errors.New("only platform admins can deactivate users"), http.StatusForbidden)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusForbidden, response.Code)
+}
+
+func TestPostUserIdentity(t *testing.T) {
+	am := &api.ApiMock{}
+	app := NewApp().API(am)
+	app.authDisabled = true
+	defaultVerifier := &mockVerifier{}
+	societyVerifier := &mockVerifier{}
+	app.oidcVerifier = defaultVerifier
+	app.oidcIssuers = map[string]*oidcIssuer{"https://society.example.org": {verifier: societyVerifier}}
+	r := app.NewRouter()
+
+	// tokens are verified by the issuer they name
+	rawToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"iss": "https://society.example.org", "sub": "member1"}).
+		SignedString([]byte("unverified"))
+	assert.NoError(t, err)
+	token := &oidc.IDToken{Issuer: "https://society.example.org", Subject: "member1"}
+	societyVerifier.On("Verify", mock.Anything, rawToken).Once().Return(token, nil)
+	expected := &model.UserIdentity{ID: 5, UserIdentityIn: model.UserIdentityIn{
+		UserIdentityBody: model.UserIdentityBody{Issuer: token.Issuer, Subject: token.Subject}, UserID: 1}}
+	am.Result = expected
+	am.Errors = nil
+
+	buf := new(bytes.Buffer)
+	_ = json.NewEncoder(buf).Encode(LinkIdentityRequest{Token: rawToken})
+	request, _ := http.NewRequest("POST", "/current_user/identities", buf)
+	request.Header.Add("Content-Type", contentType)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusCreated, response.Code)
+	assert.Equal(t, token, am.Request)
+	var actual model.UserIdentity
+	err = json.NewDecoder(response.Body).Decode(&actual)
+	assert.NoError(t, err)
+	assert.Equal(t, *expected, actual)
+	societyVerifier.AssertExpectations(t)
+
+	// tokens from other issuers go to the default verifier
+	defaultVerifier.On("Verify", mock.Anything, "Abc").Once().Return((*oidc.IDToken)(nil), errors.New("Bad token format"))
+	buf = new(bytes.Buffer)
+	_ = json.NewEncoder(buf).Encode(LinkIdentityRequest{Token: "Abc"})
+	request, _ = http.NewRequest("POST", "/current_user/identities", buf)
+	request.Header.Add("Content-Type", contentType)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	defaultVerifier.AssertExpectations(t)
+}
@@ -0,0 +1,769 @@
+package stddate
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CalendarType is the calendar a date was written in
+// Dates are always converted to the Gregorian calendar, so Calendar only records how the date was written
+type CalendarType int8
+
+const (
+	CalendarGregorian CalendarType = iota
+	CalendarJulian
+	CalendarFrenchRepublican
+	CalendarHebrew
+	CalendarQuaker
+	CalendarRegnal
+	CalendarFeast
+)
+
+func (ct CalendarType) String() string {
+	switch ct {
+	case CalendarGregorian:
+		return ""
+	case CalendarJulian:
+		return "Julian"
+	case CalendarFrenchRepublican:
+		return "FrenchRepublican"
+	case CalendarHebrew:
+		return "Hebrew"
+	case CalendarQuaker:
+		return "Quaker"
+	case CalendarRegnal:
+		return "Regnal"
+	case CalendarFeast:
+		return "Feast"
+	default:
+		return "ERROR"
+	}
+}
+
+// gregorianReformYear is the year Britain and its colonies adopted the Gregorian calendar and began the year on 1 January;
+// Quaker, regnal and feast dates before then are Julian dates
+const gregorianReformYear = 1752
+
+// ladyDayYear returns the calendar year of a date written in a year that began on 25 March (Lady Day), as years did before 1752;
+// January, February and March up to the 24th belong to the following calendar year
+func ladyDayYear(year, month, day int) int {
+	if year < gregorianReformYear && (month < 3 || (month == 3 && day > 0 && day < 25)) {
+		return year + 1
+	}
+	return year
+}
+
+// parseCalendarDate parses dates written in calendars other than the Gregorian calendar
+func parseCalendarDate(tokens []token, julian bool) *CompoundDate {
+	if cd := parseFrenchRepublican(tokens); cd != nil {
+		return cd
+	}
+	if cd := parseHebrew(tokens); cd != nil {
+		return cd
+	}
+	if cd := parseQuaker(tokens); cd != nil {
+		return cd
+	}
+	if cd := parseRegnal(tokens); cd != nil {
+		return cd
+	}
+	if cd := parseFeast(tokens, julian); cd != nil {
+		return cd
+	}
+	return nil
+}
+
+// removeJulianMarker removes "O.S.", "old style", "julian", "N.S." and "new style" and returns true if the date is Julian
+func removeJulianMarker(tokens []token) ([]token, bool) {
+	var result []token
+	julian := false
+	for i := 0; i < len(tokens); i++ {
+		word := upper(tokens[i])
+		next := ""
+		if i+1 < len(tokens) {
+			next = upper(tokens[i+1])
+		}
+		switch {
+		case word == "OS" || word == "JULIAN":
+			julian = true
+		case (word == "O" && next == "S") || (word == "OLD" && next == "STYLE"):
+			julian = true
+			i++
+		case word == "NS":
+		case (word == "N" && next == "S") || (word == "NEW" && next == "STYLE"):
+			i++
+		default:
+			result = append(result, tokens[i])
+		}
+	}
+	return result, julian
+}
+
+// fromOldStyle converts the dates in a compound date written in the old style
+func (cd *CompoundDate) fromOldStyle() {
+	cd.First = cd.First.fromOldStyle()
+	if cd.Type != CompoundNone {
+		cd.Second = cd.Second.fromOldStyle()
+	}
+}
+
+// fromOldStyle converts a Julian date whose year began on Lady Day; double dates like 1731/2 already show both years
+func (d Date) fromOldStyle() Date {
+	if d.Double == DoubleNone {
+		d.Year = ladyDayYear(d.Year, d.Month, d.Day)
+	}
+	return d.fromJulian()
+}
+
+// fromJulian converts a date from the Julian calendar; dates without a day are close enough as-is
+func (d Date) fromJulian() Date {
+	if d.Day > 0 && d.Month > 0 {
+		d.Year, d.Month, d.Day = jdnToGregorian(julianToJDN(d.Year, d.Month, d.Day))
+	}
+	d.Calendar = CalendarJulian
+	return d
+}
+
+func upper(t token) string {
+	return strings.ToUpper(t.value)
+}
+
+// tokenNumber returns the value of a numeric token, whatever type the scanner gave it
+func tokenNumber(t token) (int, bool) {
+	if t.tokType != typeDay && t.tokType != typeMonthDay && t.tokType != typeYear && t.tokType != typeNoise {
+		return 0, false
+	}
+	n, err := strconv.Atoi(t.value)
+	return n, err == nil
+}
+
+var romanValues = map[rune]int{'I': 1, 'V': 5, 'X': 10, 'L': 50, 'C': 100}
+
+// romanNumber returns the value of a roman numeral
+func romanNumber(s string) (int, bool) {
+	if s == "" {
+		return 0, false
+	}
+	total := 0
+	prev := 0
+	runes := []rune(strings.ToUpper(s))
+	for i := len(runes) - 1; i >= 0; i-- {
+		v, ok := romanValues[runes[i]]
+		if !ok {
+			return 0, false
+		}
+		if v < prev {
+			total -= v
+		} else {
+			total += v
+			prev = v
+		}
+	}
+	return total, true
+}
+
+// dateRange returns a single date if first and last are the same, otherwise a date range
+func dateRange(first, last Date) *CompoundDate {
+	if first == last {
+		return &CompoundDate{First: first}
+	}
+	return &CompoundDate{First: first, Second: last, Type: CompoundRange}
+}
+
+func jdnToDate(jdn int, calendar CalendarType) Date {
+	y, m, d := jdnToGregorian(jdn)
+	return Date{Year: y, Month: m, Day: d, Calendar: calendar}
+}
+
+// Julian day numbers
+
+func gregorianToJDN(y, m, d int) int {
+	a := (14 - m) / 12
+	y2 := y + 4800 - a
+	m2 := m + 12*a - 3
+	return d + (153*m2+2)/5 + 365*y2 + y2/4 - y2/100 + y2/400 - 32045
+}
+
+func julianToJDN(y, m, d int) int {
+	a := (14 - m) / 12
+	y2 := y + 4800 - a
+	m2 := m + 12*a - 3
+	return d + (153*m2+2)/5 + 365*y2 + y2/4 - 32083
+}
+
+func jdnToGregorian(jdn int) (int, int, int) {
+	a := jdn + 32044
+	b := (4*a + 3) / 146097
+	c := a - 146097*b/4
+	d := (4*c + 3) / 1461
+	e := c - 1461*d/4
+	m := (5*e + 2) / 153
+	day := e - (153*m+2)/5 + 1
+	month := m + 3 - 12*(m/10)
+	year := 100*b + d - 4800 + m/10
+	return year, month, day
+}
+
+// French Republican calendar
+
+// frenchRepublicanEpoch is the day number of 1 Vendémiaire an I (22 September 1792)
+var frenchRepublicanEpoch = gregorianToJDN(1792, 9, 22)
+
+var frenchRepublicanMonths = map[string]int{
+	"VENDEMIAIRE": 1, "VEND": 1,
+	"BRUMAIRE": 2, "BRUM": 2,
+	"FRIMAIRE": 3, "FRIM": 3,
+	"NIVOSE": 4, "NIV": 4,
+	"PLUVIOSE": 5, "PLUV": 5,
+	"VENTOSE": 6, "VENT": 6,
+	"GERMINAL": 7, "GERM": 7,
+	"FLOREAL": 8, "FLOR": 8,
+	"PRAIRIAL": 9, "PRAIR": 9,
+	"MESSIDOR": 10, "MESS": 10,
+	"THERMIDOR": 11, "THERM": 11,
+	"FRUCTIDOR": 12, "FRUCT": 12,
+	"SANSCULOTTIDE": 13, "SANSCULOTTIDES": 13, "COMPLEMENTAIRE": 13, "COMPLEMENTAIRES": 13,
+}
+
+// frenchRepublicanSextile returns true if the year has a sixth complementary day; years III, VII and XI were sextile
+func frenchRepublicanSextile(year int) bool {
+	return year%4 == 3
+}
+
+func frenchRepublicanToJDN(year, month, day int) int {
+	jdn := frenchRepublicanEpoch
+	for y := 1; y < year; y++ {
+		jdn += 365
+		if frenchRepublicanSextile(y) {
+			jdn++
+		}
+	}
+	return jdn + (month-1)*30 + day - 1
+}
+
+func frenchRepublicanMonthDays(year, month int) int {
+	if month < 13 {
+		return 30
+	}
+	if frenchRepublicanSextile(year) {
+		return 6
+	}
+	return 5
+}
+
+// parseFrenchRepublican parses dates like "12 brumaire an VIII", "1er jour complémentaire an 2" or "an XII"
+func parseFrenchRepublican(tokens []token) *CompoundDate {
+	year := 0
+	yearPos := -1
+	roman := false
+	for i := 0; i+1 < len(tokens); i++ {
+		if upper(tokens[i]) != "AN" {
+			continue
+		}
+		if y, ok := romanNumber(tokens[i+1].value); ok {
+			year, roman = y, true
+		} else if y, ok := tokenNumber(tokens[i+1]); ok {
+			year = y
+		}
+		if year >= 1 && year <= 22 {
+			yearPos = i
+			break
+		}
+		year = 0
+	}
+	if yearPos < 0 {
+		return nil
+	}
+
+	month := 0
+	monthPos := -1
+	for i := 0; i < yearPos; i++ {
+		word := upper(tokens[i])
+		if m := frenchRepublicanMonths[word]; m > 0 {
+			month, monthPos = m, i
+			break
+		}
+		// jour de la Révolution is the sixth complementary day
+		if word == "REVOLUTION" {
+			return &CompoundDate{First: jdnToDate(frenchRepublicanToJDN(year, 13, 6), CalendarFrenchRepublican)}
+		}
+	}
+	if month == 0 {
+		// a year on its own must be written as a roman numeral so we don't mistake "an 8" for a date
+		if !roman {
+			return nil
+		}
+		return dateRange(jdnToDate(frenchRepublicanToJDN(year, 1, 1), CalendarFrenchRepublican),
+			jdnToDate(frenchRepublicanToJDN(year, 13, frenchRepublicanMonthDays(year, 13)), CalendarFrenchRepublican))
+	}
+
+	// find the day before the month, skipping "er", "jour" and the like
+	day := 0
+	for i := monthPos - 1; i >= 0; i-- {
+		if d, ok := tokenNumber(tokens[i]); ok {
+			day = d
+			break
+		}
+		if word := upper(tokens[i]); word != "ER" && word != "E" && word != "EME" && word != "JOUR" && word != "JOURS" {
+			break
+		}
+	}
+	if day > frenchRepublicanMonthDays(year, month) {
+		return nil
+	}
+	if day == 0 {
+		return dateRange(jdnToDate(frenchRepublicanToJDN(year, month, 1), CalendarFrenchRepublican),
+			jdnToDate(frenchRepublicanToJDN(year, month, frenchRepublicanMonthDays(year, month)), CalendarFrenchRepublican))
+	}
+	return &CompoundDate{First: jdnToDate(frenchRepublicanToJDN(year, month, day), CalendarFrenchRepublican)}
+}
+
+// Hebrew calendar
+
+// hebrewEpoch is the day number of the day before the molad of Tishri AM 1
+const hebrewEpoch = 347998
+
+const (
+	hebrewNisan  = 1
+	hebrewTishri = 7
+	hebrewAdar   = 12
+	hebrewAdarII = 13
+)
+
+var hebrewMonths = map[string]int{
+	"NISAN": 1, "NISSAN": 1,
+	"IYAR": 2, "IYYAR": 2, "IJAR": 2,
+	"SIVAN": 3, "SIWAN": 3,
+	"TAMMUZ": 4, "TAMUZ": 4,
+	"AV": 5, "AB": 5,
+	"ELUL":   6,
+	"TISHRI": 7, "TISHREI": 7, "TISRI": 7,
+	"HESHVAN": 8, "CHESHVAN": 8, "MARHESHVAN": 8, "MARCHESHVAN": 8, "HESVAN": 8,
+	"KISLEV": 9, "KISLEW": 9, "KISLEU": 9,
+	"TEVET": 10, "TEVETH": 10, "TEBETH": 10, "TEBET": 10,
+	"SHEVAT": 11, "SHVAT": 11, "SHEBAT": 11, "SEBAT": 11,
+	"ADAR":   12,
+	"VEADAR": 13, "VADAR": 13,
+}
+
+func hebrewLeap(year int) bool {
+	return (7*year+1)%19 < 7
+}
+
+func hebrewYearMonths(year int) int {
+	if hebrewLeap(year) {
+		return 13
+	}
+	return 12
+}
+
+// hebrewDelay1 delays the start of the year so that Rosh Hashanah doesn't fall on Sunday, Wednesday or Friday
+func hebrewDelay1(year int) int {
+	months := (235*year - 234) / 19
+	parts := 12084 + 13753*months
+	day := months*29 + parts/25920
+	if (3*(day+1))%7 < 3 {
+		day++
+	}
+	return day
+}
+
+// hebrewDelay2 keeps the length of adjacent years valid
+func hebrewDelay2(year int) int {
+	last := hebrewDelay1(year - 1)
+	present := hebrewDelay1(year)
+	next := hebrewDelay1(year + 1)
+	switch {
+	case next-present == 356:
+		return 2
+	case present-last == 382:
+		return 1
+	}
+	return 0
+}
+
+func hebrewYearDays(year int) int {
+	return hebrewToJDN(year+1, hebrewTishri, 1) - hebrewToJDN(year, hebrewTishri, 1)
+}
+
+func hebrewMonthDays(year, month int) int {
+	switch {
+	case month == 2 || month == 4 || month == 6 || month == 10 || month == 13:
+		return 29
+	case month == hebrewAdar && !hebrewLeap(year):
+		return 29
+	case month == 8 && hebrewYearDays(year)%10 != 5:
+		return 29
+	case month == 9 && hebrewYearDays(year)%10 == 3:
+		return 29
+	}
+	return 30
+}
+
+// hebrewToJDN converts a Hebrew date; months are numbered from Nisan, although the year starts in Tishri
+func hebrewToJDN(year, month, day int) int {
+	jdn := hebrewEpoch + hebrewDelay1(year) + hebrewDelay2(year) + day - 1
+	if month < hebrewTishri {
+		for m := hebrewTishri; m <= hebrewYearMonths(year); m++ {
+			jdn += hebrewMonthDays(year, m)
+		}
+		for m := hebrewNisan; m < month; m++ {
+			jdn += hebrewMonthDays(year, m)
+		}
+	} else {
+		for m := hebrewTishri; m < month; m++ {
+			jdn += hebrewMonthDays(year, m)
+		}
+	}
+	return jdn
+}
+
+// parseHebrew parses dates like "15 Nisan 5660", "Adar II 3, 5663" or "Tishri 5701"
+func parseHebrew(tokens []token) *CompoundDate {
+	year := 0
+	yearPos := -1
+	for i, t := range tokens {
+		if y, ok := tokenNumber(t); ok && y >= 3000 && y <= 6999 {
+			year, yearPos = y, i
+			break
+		}
+	}
+	if yearPos < 0 {
+		return nil
+	}
+
+	month := 0
+	monthPos := -1
+	for i := 0; i < yearPos; i++ {
+		if m := hebrewMonths[upper(tokens[i])]; m > 0 {
+			month, monthPos = m, i
+			break
+		}
+	}
+	if month == 0 {
+		return nil
+	}
+	dayPos := monthPos + 1
+	if month == hebrewAdar && monthPos+1 < yearPos {
+		switch upper(tokens[monthPos+1]) {
+		case "II", "SHENI", "BET", "BEIT", "B":
+			month = hebrewAdarII
+			dayPos++
+		case "I", "RISHON", "ALEPH", "ALEF", "A":
+			dayPos++
+		}
+	}
+	if month == hebrewAdarII && !hebrewLeap(year) {
+		month = hebrewAdar
+	}
+
+	// the day is before the month or between the month and the year
+	day := 0
+	if monthPos > 0 {
+		if d, ok := tokenNumber(tokens[monthPos-1]); ok {
+			day = d
+		}
+	}
+	if day == 0 && dayPos < yearPos {
+		if d, ok := tokenNumber(tokens[dayPos]); ok {
+			day = d
+		}
+	}
+	monthDays := hebrewMonthDays(year, month)
+	if day > monthDays {
+		return nil
+	}
+	if day == 0 {
+		return dateRange(jdnToDate(hebrewToJDN(year, month, 1), CalendarHebrew),
+			jdnToDate(hebrewToJDN(year, month, monthDays), CalendarHebrew))
+	}
+	return &CompoundDate{First: jdnToDate(hebrewToJDN(year, month, day), CalendarHebrew)}
+}
+
+// Quaker dates
+
+var quakerDayWords = map[string]bool{"DAY": true, "DA": true, "D": true}
+var quakerMonthWords = map[string]bool{"MONTH": true, "MO": true, "MOS": true, "MON": true, "M": true}
+
+// parseQuaker parses dates like "5th day of 3rd month 1701", "5 da 3 mo 1701" or "3rd mo 5th 1701"
+// Before 1752 the first month was March and the year began on Lady Day
+func parseQuaker(tokens []token) *CompoundDate {
+	year := 0
+	yearPos := -1
+	for i, t := range tokens {
+		if t.tokType == typeYear {
+			year, _ = strconv.Atoi(t.value)
+			yearPos = i
+			break
+		}
+	}
+	if yearPos < 1 {
+		return nil
+	}
+
+	day, month := 0, 0
+	var pending int // a number not yet followed by "day" or "month"
+	for i := 0; i < yearPos; i++ {
+		word := upper(tokens[i])
+		if n, ok := tokenNumber(tokens[i]); ok {
+			if pending > 0 {
+				// "3rd mo 5th 1701": a number after the month is the day
+				if month == 0 || day > 0 {
+					return nil
+				}
+				day = pending
+			}
+			pending = n
+			continue
+		}
+		switch {
+		case quakerDayWords[word] && pending > 0 && day == 0:
+			day, pending = pending, 0
+		case quakerMonthWords[word] && pending > 0 && month == 0:
+			month, pending = pending, 0
+		case word == "OF" || word == "THE" || tokens[i].tokType == typeSeparator:
+		default:
+			return nil
+		}
+	}
+	if pending > 0 {
+		if month == 0 || day > 0 {
+			return nil
+		}
+		day = pending
+	}
+	if month < 1 || month > 12 || day > 31 {
+		return nil
+	}
+
+	if year >= gregorianReformYear {
+		return &CompoundDate{First: Date{Year: year, Month: month, Day: day, Calendar: CalendarQuaker}}
+	}
+	// 1st month was March; 11th and 12th months were January and February
+	calMonth := month + 2
+	if month > 10 {
+		calMonth = month - 10
+	}
+	d := Date{Year: ladyDayYear(year, calMonth, day), Month: calMonth, Day: day}.fromJulian()
+	d.Calendar = CalendarQuaker
+	return &CompoundDate{First: d}
+}
+
+// Regnal years
+
+type monarch struct {
+	names    []string
+	numeral  int
+	year     int
+	month    int
+	day      int
+	reignEnd int // last year of the reign
+}
+
+// English and British monarchs and the days they acceded; regnal years run from the anniversary of accession
+var monarchs = []monarch{
+	{names: []string{"HEN", "HENRY", "HENR"}, numeral: 7, year: 1485, month: 8, day: 22, reignEnd: 1509},
+	{names: []string{"HEN", "HENRY", "HENR"}, numeral: 8, year: 1509, month: 4, day: 22, reignEnd: 1547},
+	{names: []string{"EDW", "EDWARD", "EDWD"}, numeral: 6, year: 1547, month: 1, day: 28, reignEnd: 1553},
+	{names: []string{"MARY"}, numeral: 1, year: 1553, month: 7, day: 6, reignEnd: 1558},
+	{names: []string{"ELIZ", "ELIZABETH", "ELIZAB"}, numeral: 1, year: 1558, month: 11, day: 17, reignEnd: 1603},
+	{names: []string{"JAS", "JAC", "JAMES"}, numeral: 1, year: 1603, month: 3, day: 24, reignEnd: 1625},
+	{names: []string{"CHAS", "CAR", "CHARLES", "CAROL"}, numeral: 1, year: 1625, month: 3, day: 27, reignEnd: 1649},
+	{names: []string{"CHAS", "CAR", "CHARLES", "CAROL"}, numeral: 2, year: 1649, month: 1, day: 30, reignEnd: 1685},
+	{names: []string{"JAS", "JAC", "JAMES"}, numeral: 2, year: 1685, month: 2, day: 6, reignEnd: 1688},
+	{names: []string{"WM", "WILL", "WILLIAM", "GUL", "GULIELMI"}, numeral: 3, year: 1689, month: 2, day: 13, reignEnd: 1702},
+	{names: []string{"ANNE", "ANN"}, numeral: 1, year: 1702, month: 3, day: 8, reignEnd: 1714},
+	{names: []string{"GEO", "GEORGE", "GEORG"}, numeral: 1, year: 1714, month: 8, day: 1, reignEnd: 1727},
+	{names: []string{"GEO", "GEORGE", "GEORG"}, numeral: 2, year: 1727, month: 6, day: 11, reignEnd: 1760},
+	{names: []string{"GEO", "GEORGE", "GEORG"}, numeral: 3, year: 1760, month: 10, day: 25, reignEnd: 1820},
+	{names: []string{"GEO", "GEORGE", "GEORG"}, numeral: 4, year: 1820, month: 1, day: 29, reignEnd: 1830},
+	{names: []string{"WM", "WILL", "WILLIAM", "GUL", "GULIELMI"}, numeral: 4, year: 1830, month: 6, day: 26, reignEnd: 1837},
+	{names: []string{"VIC", "VICT", "VICTORIA", "VICTORIAE"}, numeral: 1, year: 1837, month: 6, day: 20, reignEnd: 1901},
+	{names: []string{"EDW", "EDWARD", "EDWD"}, numeral: 7, year: 1901, month: 1, day: 22, reignEnd: 1910},
+	{names: []string{"GEO", "GEORGE", "GEORG"}, numeral: 5, year: 1910, month: 5, day: 6, reignEnd: 1936},
+	{names: []string{"EDW", "EDWARD", "EDWD"}, numeral: 8, year: 1936, month: 1, day: 20, reignEnd: 1936},
+	{names: []string{"GEO", "GEORGE", "GEORG"}, numeral: 6, year: 1936, month: 12, day: 11, reignEnd: 1952},
+	{names: []string{"ELIZ", "ELIZABETH", "ELIZAB"}, numeral: 2, year: 1952, month: 2, day: 6, reignEnd: 2022},
+}
+
+// monarchs who can be written without a numeral
+var soleMonarchs = map[string]bool{"MARY": true, "ANNE": true, "ANN": true, "VIC": true, "VICT": true, "VICTORIA": true, "VICTORIAE": true}
+
+func findMonarch(name string, numeral int) *monarch {
+	for i := range monarchs {
+		if monarchs[i].numeral != numeral {
+			continue
+		}
+		for _, n := range monarchs[i].names {
+			if n == name {
+				return &monarchs[i]
+			}
+		}
+	}
+	return nil
+}
+
+// parseRegnal parses regnal years like "12 Geo III" or "12th year of the reign of George III"
+func parseRegnal(tokens []token) *CompoundDate {
+	for i, t := range tokens {
+		name := upper(t)
+		numeral := 0
+		if i+1 < len(tokens) {
+			if n, ok := romanNumber(tokens[i+1].value); ok {
+				numeral = n
+			}
+		}
+		if numeral == 0 {
+			if !soleMonarchs[name] && !(i+1 < len(tokens) && (name == "WM" || name == "WILL" || name == "WILLIAM") && upper(tokens[i+1]) == "MARY") {
+				continue
+			}
+			numeral = 1
+			if name == "WM" || name == "WILL" || name == "WILLIAM" {
+				numeral = 3
+			}
+		}
+		m := findMonarch(name, numeral)
+		if m == nil {
+			continue
+		}
+
+		// find the regnal year before the monarch, skipping "year of the reign of" and the like
+		regnalYear := 0
+		for j := i - 1; j >= 0; j-- {
+			if n, ok := tokenNumber(tokens[j]); ok {
+				regnalYear = n
+				break
+			}
+			if word := upper(tokens[j]); word != "YEAR" && word != "OF" && word != "THE" && word != "REIGN" &&
+				word != "KING" && word != "QUEEN" && word != "REGNI" && word != "ANNO" {
+				break
+			}
+		}
+		if regnalYear < 1 || m.year+regnalYear-1 > m.reignEnd {
+			return nil
+		}
+
+		// the regnal year runs from the anniversary of the accession to the day before the next anniversary
+		start := m.year + regnalYear - 1
+		var first, last int
+		if start < gregorianReformYear {
+			first = julianToJDN(start, m.month, m.day)
+			last = julianToJDN(start+1, m.month, m.day) - 1
+		} else {
+			first = gregorianToJDN(start, m.month, m.day)
+			last = gregorianToJDN(start+1, m.month, m.day) - 1
+		}
+		return dateRange(jdnToDate(first, CalendarRegnal), jdnToDate(last, CalendarRegnal))
+	}
+	return nil
+}
+
+// Feast days
+
+type feast struct {
+	words []string
+	month int
+	day   int
+	// easterOffset is the number of days after Easter for movable feasts; month is 0
+	easterOffset int
+}
+
+var feasts = []feast{
+	{words: []string{"LADY", "DAY"}, month: 3, day: 25},
+	{words: []string{"MIDSUMMER"}, month: 6, day: 24},
+	{words: []string{"MICHAELMAS"}, month: 9, day: 29},
+	{words: []string{"CHRISTMAS"}, month: 12, day: 25},
+	{words: []string{"CANDLEMAS"}, month: 2, day: 2},
+	{words: []string{"LAMMAS"}, month: 8, day: 1},
+	{words: []string{"MARTINMAS"}, month: 11, day: 11},
+	{words: []string{"ALLHALLOWS"}, month: 11, day: 1},
+	{words: []string{"ALL", "SAINTS"}, month: 11, day: 1},
+	{words: []string{"EPIPHANY"}, month: 1, day: 6},
+	{words: []string{"TWELFTH", "DAY"}, month: 1, day: 6},
+	{words: []string{"SHROVE", "TUESDAY"}, easterOffset: -47},
+	{words: []string{"ASH", "WEDNESDAY"}, easterOffset: -46},
+	{words: []string{"GOOD", "FRIDAY"}, easterOffset: -2},
+	{words: []string{"EASTER"}, easterOffset: 0},
+	{words: []string{"ASCENSION"}, easterOffset: 39},
+	{words: []string{"WHITSUNDAY"}, easterOffset: 49},
+	{words: []string{"WHITSUN"}, easterOffset: 49},
+	{words: []string{"PENTECOST"}, easterOffset: 49},
+	{words: []string{"TRINITY", "SUNDAY"}, easterOffset: 56},
+}
+
+// easterJDN returns the day number of Easter Sunday in the Gregorian calendar
+func easterJDN(year int) int {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return gregorianToJDN(year, month, day)
+}
+
+// julianEasterJDN returns the day number of Easter Sunday in the Julian calendar
+func julianEasterJDN(year int) int {
+	a := year % 4
+	b := year % 7
+	c := year % 19
+	d := (19*c + 15) % 30
+	e := (2*a + 4*b - d + 34) % 7
+	month := (d + e + 114) / 31
+	day := (d+e+114)%31 + 1
+	return julianToJDN(year, month, day)
+}
+
+// parseFeast parses feast days like "Michaelmas 1700" or "Lady Day 1750 O.S."
+// Like Quaker and regnal dates, feasts before 1752 or marked old style are Julian dates in a year that began on Lady Day;
+// movable feasts are counted from Easter of the year as written
+func parseFeast(tokens []token, julian bool) *CompoundDate {
+	year := 0
+	yearPos := -1
+	for i, t := range tokens {
+		if t.tokType == typeYear {
+			year, _ = strconv.Atoi(t.value)
+			yearPos = i
+			break
+		}
+	}
+	if yearPos < 1 {
+		return nil
+	}
+	for _, f := range feasts {
+		for i := 0; i+len(f.words) <= yearPos; i++ {
+			matched := true
+			for j, word := range f.words {
+				if upper(tokens[i+j]) != word {
+					matched = false
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+			oldStyle := julian || year < gregorianReformYear
+			var jdn int
+			switch {
+			case f.month == 0 && oldStyle:
+				jdn = julianEasterJDN(year) + f.easterOffset
+			case f.month == 0:
+				jdn = easterJDN(year) + f.easterOffset
+			case oldStyle:
+				jdn = julianToJDN(ladyDayYear(year, f.month, f.day), f.month, f.day)
+			default:
+				jdn = gregorianToJDN(year, f.month, f.day)
+			}
+			return &CompoundDate{First: jdnToDate(jdn, CalendarFeast)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package stddate
+
+import "strings"
+
+// DateOrder is the order in which the parts of a numeric date like 5/3/1900 are written
+type DateOrder int8
+
+const (
+	DateOrderNone DateOrder = iota // unknown; month-first and day-first dates are both possible
+	DateOrderDMY
+	DateOrderMDY
+	DateOrderYMD // year-first dates are always recognized, so this order behaves like DateOrderNone
+)
+
+func (do DateOrder) String() string {
+	switch do {
+	case DateOrderNone:
+		return ""
+	case DateOrderDMY:
+		return "DMY"
+	case DateOrderMDY:
+		return "MDY"
+	case DateOrderYMD:
+		return "YMD"
+	default:
+		return "ERROR"
+	}
+}
+
+// ParseDateOrder returns the date order for DMY, MDY or YMD, or DateOrderNone
+func ParseDateOrder(s string) DateOrder {
+	switch strings.ToUpper(s) {
+	case "DMY":
+		return DateOrderDMY
+	case "MDY":
+		return DateOrderMDY
+	case "YMD":
+		return DateOrderYMD
+	default:
+		return DateOrderNone
+	}
+}
+
+// DetectDateOrder returns the order of the numeric dates in values
+// A value like 25/3/1900 is evidence for day-first dates; the order is returned only if no value contradicts it
+func DetectDateOrder(values []string) DateOrder {
+	var dmy, mdy, ymd int
+	for _, value := range values {
+		switch numericDateOrder(scanTokens(value)) {
+		case DateOrderDMY:
+			dmy++
+		case DateOrderMDY:
+			mdy++
+		case DateOrderYMD:
+			ymd++
+		}
+	}
+	switch {
+	case dmy > 0 && mdy == 0:
+		return DateOrderDMY
+	case mdy > 0 && dmy == 0:
+		return DateOrderMDY
+	case ymd > 0 && dmy == 0 && mdy == 0:
+		return DateOrderYMD
+	}
+	return DateOrderNone
+}
+
+// numericDateOrder returns the order of the first numeric date in tokens if it can only be read one way
+func numericDateOrder(tokens []token) DateOrder {
+	// ignore separators
+	var parts []token
+	for _, t := range tokens {
+		if t.tokType != typeSeparator {
+			parts = append(parts, t)
+		}
+	}
+	isDayNumber := func(t token) bool {
+		return t.tokType == typeMonthDay || t.tokType == typeDay
+	}
+	for i := 0; i+2 < len(parts); i++ {
+		first, second, third := parts[i], parts[i+1], parts[i+2]
+		switch {
+		case first.tokType == typeYear && second.tokType == typeMonthDay && isDayNumber(third):
+			return DateOrderYMD
+		case isDayNumber(first) && isDayNumber(second) && third.tokType == typeYear:
+			switch {
+			case first.tokType == typeDay && second.tokType == typeMonthDay:
+				return DateOrderDMY
+			case first.tokType == typeMonthDay && second.tokType == typeDay:
+				return DateOrderMDY
+			}
+			return DateOrderNone
+		}
+	}
+	return DateOrderNone
+}
@@ -53,6 +53,7 @@ type Date struct {
 	Double   DoubleType
 	Modifier ModifierType
 	Quality  QualityType
+	Calendar CalendarType
 }
 
 func (d Date) String() string {
@@ -208,14 +209,38 @@ func (qt QualityType) String() string {
 }
 
 func Standardize(s string) *CompoundDate {
-	// get tokens
+	return StandardizeWithOrder(s, DateOrderNone)
+}
+
+// StandardizeWithOrder standardizes s, reading numeric dates like 5/3/1900 in the given order
+func StandardizeWithOrder(s string, order DateOrder) *CompoundDate {
+	tokens := scanTokens(s)
+
+	// remove old style markers; the date is converted to the Gregorian calendar once it is parsed
+	tokens, julian := removeJulianMarker(tokens)
+
+	// is this written in another calendar?
+	if cd := parseCalendarDate(tokens, julian); cd != nil {
+		return cd
+	}
+
+	cd := parseTokens(tokens, order)
+	if cd != nil && julian {
+		cd.fromOldStyle()
+	}
+	return cd
+}
+
+func scanTokens(s string) []token {
 	scanner := NewScanner(strings.NewReader(stdtext.AsciiFold(s)))
 	tokens := []token{}
 	for t := scanner.Scan(); t.tokType != typeEOF; t = scanner.Scan() {
 		tokens = append(tokens, t)
 	}
+	return tokens
+}
 
-	// parse tokens
+func parseTokens(tokens []token, order DateOrder) *CompoundDate {
 	pos := 0
 
 	// Is this an early year (before minYear)?
@@ -229,7 +254,7 @@ func Standardize(s string) *CompoundDate {
 	}
 
 	// is this a compound date?
-	if cd, _ := parseCompound(tokens, pos); cd != nil {
+	if cd, _ := parseCompound(tokens, pos, order); cd != nil {
 		return cd
 	}
 
@@ -254,7 +279,7 @@ func Standardize(s string) *CompoundDate {
 	}
 
 	// is this a single date?
-	if d, _ := parseDate(tokens, pos); d != nil {
+	if d, _ := parseDate(tokens, pos, order); d != nil {
 		return &CompoundDate{First: *d}
 	}
 
@@ -262,7 +287,7 @@ func Standardize(s string) *CompoundDate {
 	for pos < len(tokens) && tokens[pos].tokType != typeMonthAlpha && tokens[pos].tokType != typeYear {
 		pos++
 	}
-	if d, _ := parseDate(tokens, pos); d != nil {
+	if d, _ := parseDate(tokens, pos, order); d != nil {
 		return &CompoundDate{First: *d}
 	}
 
@@ -270,14 +295,14 @@ func Standardize(s string) *CompoundDate {
 	for pos < len(tokens) && tokens[pos].tokType != typeYear {
 		pos++
 	}
-	if d, _ := parseDate(tokens, pos); d != nil {
+	if d, _ := parseDate(tokens, pos, order); d != nil {
 		return &CompoundDate{First: *d}
 	}
 
 	return nil
 }
 
-func parseCompound(tokens []token, start int) (*CompoundDate, int) {
+func parseCompound(tokens []token, start int, order DateOrder) (*CompoundDate, int) {
 	pos := start
 	var typ tokenType
 	var isEstimated bool
@@ -297,7 +322,7 @@ func parseCompound(tokens []token, start int) (*CompoundDate, int) {
 		typ = typeFrom
 		pos++
 	}
-	d, pos := parseDate(tokens, pos)
+	d, pos := parseDate(tokens, pos, order)
 	switch {
 	case d != nil:
 		cd.First = *d
@@ -332,7 +357,7 @@ func parseCompound(tokens []token, start int) (*CompoundDate, int) {
 	}
 
 	// parse the second date
-	d, pos = parseDate(tokens, pos)
+	d, pos = parseDate(tokens, pos, order)
 	if d == nil {
 		return nil, start
 	}
@@ -365,7 +390,7 @@ func parseCompound(tokens []token, start int) (*CompoundDate, int) {
 	return cd, pos
 }
 
-func parseDate(tokens []token, start int) (*Date, int) {
+func parseDate(tokens []token, start int, order DateOrder) (*Date, int) {
 	pos := start
 	date := &Date{}
 
@@ -410,19 +435,17 @@ loop:
 		date.Month = d.Month
 		date.Year = d.Year
 		pos = p
-	} else if d, p := parseMonthDayYear(tokens, pos); d != nil {
+	} else if d, p := parseYearMonthDay(tokens, pos); d != nil {
 		date.Day = d.Day
 		date.Month = d.Month
 		date.Year = d.Year
-		if d.Day >= 1 && d.Day <= 12 && d.Month > 0 {
-			date.Quality = QualityAmbiguous
-		}
 		pos = p
-	} else if d, p := parseDayMonthYear(tokens, pos); d != nil {
+	} else if d, p := parseNumericDate(tokens, pos, order); d != nil {
 		date.Day = d.Day
 		date.Month = d.Month
 		date.Year = d.Year
-		if d.Day >= 1 && d.Day <= 12 && d.Month > 0 {
+		// without a date order, a day that could also be a month is ambiguous
+		if order != DateOrderDMY && order != DateOrderMDY && d.Day >= 1 && d.Day <= 12 && d.Month > 0 {
 			date.Quality = QualityAmbiguous
 		}
 		pos = p
@@ -518,6 +541,60 @@ func parseDayMonthAlphaYear(tokens []token, start int) (*Date, int) {
 	return d, pos
 }
 
+// parseNumericDate parses numeric dates, trying day-first dates first if the order is DMY
+func parseNumericDate(tokens []token, start int, order DateOrder) (*Date, int) {
+	if order == DateOrderDMY {
+		if d, p := parseDayMonthYear(tokens, start); d != nil {
+			return d, p
+		}
+	}
+	if d, p := parseMonthDayYear(tokens, start); d != nil {
+		return d, p
+	}
+	return parseDayMonthYear(tokens, start)
+}
+
+// YEAR separator MONTH_DAY separator DAY
+func parseYearMonthDay(tokens []token, start int) (*Date, int) {
+	pos := start
+	d := &Date{}
+
+	if pos < len(tokens) && tokens[pos].tokType == typeYear {
+		d.Year, _ = strconv.Atoi(tokens[pos].value)
+		pos++
+	} else {
+		return nil, start
+	}
+
+	if pos < len(tokens) && tokens[pos].tokType == typeSeparator {
+		pos++
+	} else {
+		return nil, start
+	}
+
+	if pos < len(tokens) && tokens[pos].tokType == typeMonthDay {
+		d.Month, _ = strconv.Atoi(tokens[pos].value)
+		pos++
+	} else {
+		return nil, start
+	}
+
+	if pos < len(tokens) && tokens[pos].tokType == typeSeparator {
+		pos++
+	} else {
+		return nil, start
+	}
+
+	if pos < len(tokens) && (tokens[pos].tokType == typeMonthDay || tokens[pos].tokType == typeDay) {
+		d.Day, _ = strconv.Atoi(tokens[pos].value)
+		pos++
+	} else {
+		return nil, start
+	}
+
+	return d, pos
+}
+
 // MONTH_DAY separator? DAY separator? YEAR
 func parseMonthDayYear(tokens []token, start int) (*Date, int) {
 	pos := start
@@ -295,3 +295,86 @@ func TestStandardizeDate(t *testing.T) {
 		assert.EqualValues(t, test.encoded, test.date.Encode())
 	}
 }
+
+func TestStandardizeCalendarDate(t *testing.T) {
+	tests := []struct {
+		text     string
+		calendar stddate.CalendarType
+		encoded  string
+	}{
+		{text: "18 Brumaire an VIII", calendar: stddate.CalendarFrenchRepublican, encoded: "17991109"},
+		{text: "1er jour complémentaire an II", calendar: stddate.CalendarFrenchRepublican, encoded: "17940917"},
+		{text: "jour de la Révolution an III", calendar: stddate.CalendarFrenchRepublican, encoded: "17950922"},
+		{text: "Germinal an 4", calendar: stddate.CalendarFrenchRepublican, encoded: "17960321,17960321-17960419"},
+		{text: "an XII", calendar: stddate.CalendarFrenchRepublican, encoded: "18030924,18030924-18040922"},
+		{text: "1 Tishri 5760", calendar: stddate.CalendarHebrew, encoded: "19990911"},
+		{text: "15 Nisan 5660", calendar: stddate.CalendarHebrew, encoded: "19000414"},
+		{text: "Adar II 3, 5784", calendar: stddate.CalendarHebrew, encoded: "20240313"},
+		{text: "5th day of 3rd month 1701", calendar: stddate.CalendarQuaker, encoded: "17010516"},
+		{text: "3 mo 5 1701", calendar: stddate.CalendarQuaker, encoded: "17010516"},
+		{text: "12th month 1700", calendar: stddate.CalendarQuaker, encoded: "17010200"},
+		{text: "1st month 1760", calendar: stddate.CalendarQuaker, encoded: "17600100"},
+		// old style years began on Lady Day, so dates before 25 March belong to the following calendar year
+		{text: "1 Jan 1700 O.S.", calendar: stddate.CalendarJulian, encoded: "17010112"},
+		{text: "11 Feb 1731 old style", calendar: stddate.CalendarJulian, encoded: "17320222"},
+		{text: "24 Mar 1731 O.S.", calendar: stddate.CalendarJulian, encoded: "17320404"},
+		{text: "25 Mar 1731 O.S.", calendar: stddate.CalendarJulian, encoded: "17310405"},
+		{text: "12 Geo III", calendar: stddate.CalendarRegnal, encoded: "17711025,17711025-17721024"},
+		{text: "3 Wm & Mary", calendar: stddate.CalendarRegnal, encoded: "16910223,16910223-16920222"},
+		{text: "12th year of the reign of Victoria", calendar: stddate.CalendarRegnal, encoded: "18480620,18480620-18490619"},
+		// feasts before 1752 are old style whether or not they're marked
+		{text: "Michaelmas 1700", calendar: stddate.CalendarFeast, encoded: "17001010"},
+		{text: "Candlemas 1700", calendar: stddate.CalendarFeast, encoded: "17010213"},
+		{text: "Lady Day 1750 O.S.", calendar: stddate.CalendarFeast, encoded: "17500405"},
+		{text: "Lady Day 1750", calendar: stddate.CalendarFeast, encoded: "17500405"},
+		{text: "Michaelmas 1800", calendar: stddate.CalendarFeast, encoded: "18000929"},
+		{text: "Good Friday 1900", calendar: stddate.CalendarFeast, encoded: "19000413"},
+		{text: "1 Jan 1900", calendar: stddate.CalendarGregorian, encoded: "19000101"},
+	}
+
+	for _, test := range tests {
+		date := stddate.Standardize(test.text)
+		if assert.NotNil(t, date, test.text) {
+			assert.Equal(t, test.calendar, date.First.Calendar, test.text)
+			assert.Equal(t, test.encoded, date.Encode(), test.text)
+		}
+	}
+
+	// invalid days and reigns aren't calendar dates
+	assert.Nil(t, stddate.Standardize("31 Brumaire an VIII"))
+	assert.Nil(t, stddate.Standardize("70 Geo III"))
+}
+
+func TestStandardizeDateOrder(t *testing.T) {
+	tests := []struct {
+		text    string
+		order   stddate.DateOrder
+		encoded string
+	}{
+		{text: "5/3/1900", order: stddate.DateOrderNone, encoded: "19000503,19000305"},
+		{text: "5/3/1900", order: stddate.DateOrderMDY, encoded: "19000503"},
+		{text: "5/3/1900", order: stddate.DateOrderDMY, encoded: "19000305"},
+		{text: "25/3/1900", order: stddate.DateOrderMDY, encoded: "19000325"},
+		{text: "3/25/1900", order: stddate.DateOrderDMY, encoded: "19000325"},
+		{text: "3/1900", order: stddate.DateOrderDMY, encoded: "19000300"},
+		{text: "1900-03-05", order: stddate.DateOrderNone, encoded: "19000305"},
+		{text: "1900-03-05", order: stddate.DateOrderYMD, encoded: "19000305"},
+		{text: "bet 5/3/1900 and 7/3/1900", order: stddate.DateOrderDMY, encoded: "19000305,19000305-19000307"},
+	}
+	for _, test := range tests {
+		date := stddate.StandardizeWithOrder(test.text, test.order)
+		if assert.NotNil(t, date, test.text) {
+			assert.Equal(t, test.encoded, date.Encode(), test.text+" "+test.order.String())
+		}
+	}
+}
+
+func TestDetectDateOrder(t *testing.T) {
+	assert.Equal(t, stddate.DateOrderDMY, stddate.DetectDateOrder([]string{"5/3/1900", "", "25/3/1900", "Abt 1900"}))
+	assert.Equal(t, stddate.DateOrderMDY, stddate.DetectDateOrder([]string{"5/3/1900", "3/25/1900"}))
+	assert.Equal(t, stddate.DateOrderYMD, stddate.DetectDateOrder([]string{"1900-03-05", "1900-03-25"}))
+	assert.Equal(t, stddate.DateOrderNone, stddate.DetectDateOrder([]string{"25/3/1900", "3/25/1900"}))
+	assert.Equal(t, stddate.DateOrderNone, stddate.DetectDateOrder([]string{"5/3/1900", "1 Jan 1900"}))
+	assert.Equal(t, stddate.DateOrderDMY, stddate.ParseDateOrder("dmy"))
+	assert.Equal(t, stddate.DateOrderNone, stddate.ParseDateOrder("auto"))
+}
@@ -0,0 +1,253 @@
+package stdname
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Order is the order in which the parts of a full name are written
+type Order int8
+
+const (
+	OrderAuto         Order = iota // detect the order from the name
+	OrderSurnameLast               // John Smith
+	OrderSurnameFirst              // Smith John or Smith, John
+)
+
+func (o Order) String() string {
+	switch o {
+	case OrderAuto:
+		return ""
+	case OrderSurnameLast:
+		return "surnameLast"
+	case OrderSurnameFirst:
+		return "surnameFirst"
+	default:
+		return "ERROR"
+	}
+}
+
+// ParseOrder returns the order for surnameFirst or surnameLast, or OrderAuto
+func ParseOrder(s string) Order {
+	switch s {
+	case "surnameLast":
+		return OrderSurnameLast
+	case "surnameFirst":
+		return OrderSurnameFirst
+	default:
+		return OrderAuto
+	}
+}
+
+// Name holds the parts of a full name
+type Name struct {
+	Title string // Rev., Dr.
+	Given string
+	// Particles precede the surname, like van der in Johann van der Berg
+	Particles string
+	Surname   string
+	Suffix    string // Jr., III
+}
+
+// FullSurname returns the surname including its particles
+func (n Name) FullSurname() string {
+	if n.Particles == "" {
+		return n.Surname
+	}
+	if n.Surname == "" {
+		return n.Particles
+	}
+	return n.Particles + " " + n.Surname
+}
+
+var titles = map[string]bool{
+	"mr": true, "mrs": true, "miss": true, "ms": true, "mstr": true, "master": true, "dr": true, "doctor": true,
+	"rev": true, "revd": true, "reverend": true, "fr": true, "father": true, "sister": true, "bro": true, "brother": true,
+	"sir": true, "dame": true, "lady": true, "lord": true, "hon": true, "prof": true, "professor": true,
+	"capt": true, "captain": true, "col": true, "colonel": true, "lt": true, "lieut": true, "maj": true, "major": true,
+	"gen": true, "general": true, "sgt": true, "sergeant": true, "cpl": true, "corporal": true, "pvt": true, "private": true,
+	"herr": true, "frau": true, "fraulein": true, "mme": true, "mlle": true, "madame": true, "monsieur": true,
+	"sr": true, "widow": true, "wid": true,
+}
+
+var suffixes = map[string]bool{
+	"jr": true, "jnr": true, "junior": true, "sr": true, "snr": true, "senior": true,
+	"ii": true, "iii": true, "iv": true, "2nd": true, "3rd": true, "4th": true,
+	"esq": true, "esquire": true, "md": true, "phd": true, "dd": true, "jp": true, "mp": true,
+}
+
+var particles = map[string]bool{
+	"van": true, "von": true, "vom": true, "der": true, "den": true, "ter": true, "ten": true, "op": true, "zu": true, "zum": true, "zur": true,
+	"de": true, "del": true, "della": true, "dela": true, "di": true, "da": true, "das": true, "dos": true, "du": true, "des": true,
+	"la": true, "le": true, "af": true, "av": true, "d'": true, "van't": true, "'t": true, "st": true, "ste": true,
+}
+
+// Parse splits a full name like "Smith, John Jr." or "Rev. Johann van der Berg" into its parts
+func Parse(s string, order Order) Name {
+	var name Name
+
+	// a comma separates the surname from the given names, unless it only sets off a suffix as in John Smith, Jr.
+	if before, after, found := strings.Cut(s, ","); found {
+		afterWords := strings.Fields(after)
+		if len(afterWords) > 0 && allSuffixes(afterWords) {
+			name = parseWords(strings.Fields(before), order)
+			name.Suffix = joinWords(name.Suffix, afterWords)
+			return name
+		}
+		surnameWords := strings.Fields(before)
+		givenWords := strings.Fields(after)
+		name.Title, surnameWords = leadingTitles(surnameWords)
+		if name.Title == "" {
+			name.Title, givenWords = leadingTitles(givenWords)
+		}
+		var suffix []string
+		givenWords, suffix = trailingSuffixes(givenWords)
+		name.Suffix = joinWords("", suffix)
+		// particles may follow the given names in surname-first names, as in Berg, Johann van der
+		var particleWords []string
+		for len(givenWords) > 1 && isParticle(givenWords[len(givenWords)-1]) {
+			particleWords = append([]string{givenWords[len(givenWords)-1]}, particleWords...)
+			givenWords = givenWords[:len(givenWords)-1]
+		}
+		leading, surname := splitParticles(surnameWords)
+		name.Particles = joinWords(joinWords("", particleWords), leading)
+		name.Surname = joinWords("", surname)
+		name.Given = joinWords("", givenWords)
+		return name
+	}
+
+	return parseWords(strings.Fields(s), order)
+}
+
+// parseWords parses the words of a name that doesn't have a comma
+func parseWords(words []string, order Order) Name {
+	var name Name
+	name.Title, words = leadingTitles(words)
+	var suffix []string
+	words, suffix = trailingSuffixes(words)
+	name.Suffix = joinWords("", suffix)
+	if len(words) == 0 {
+		return name
+	}
+	if len(words) == 1 {
+		// a single word is more likely to be a given name unless we know the surname comes first
+		if order == OrderSurnameFirst || isUpper(words[0]) {
+			name.Surname = words[0]
+		} else {
+			name.Given = words[0]
+		}
+		return name
+	}
+
+	if order == OrderAuto {
+		order = detectOrder(words)
+	}
+	if order == OrderSurnameFirst {
+		// the surname is the first word following any particles
+		i := 0
+		for i < len(words)-1 && isParticle(words[i]) {
+			i++
+		}
+		name.Particles = joinWords("", words[:i])
+		name.Surname = words[i]
+		name.Given = joinWords("", words[i+1:])
+		return name
+	}
+
+	// the surname is the last word preceded by any particles, but the first word is always a given name
+	i := len(words) - 1
+	for i > 1 && isParticle(words[i-1]) {
+		i--
+	}
+	name.Given = joinWords("", words[:i])
+	name.Particles = joinWords("", words[i:len(words)-1])
+	name.Surname = words[len(words)-1]
+	return name
+}
+
+// detectOrder detects surname-first names like SMITH John, where the surname alone is written in capitals
+func detectOrder(words []string) Order {
+	first := words[0]
+	for _, word := range words {
+		if !isParticle(word) {
+			first = word
+			break
+		}
+	}
+	if isUpper(first) && !isUpper(words[len(words)-1]) {
+		return OrderSurnameFirst
+	}
+	return OrderSurnameLast
+}
+
+func leadingTitles(words []string) (string, []string) {
+	i := 0
+	for i < len(words)-1 && titles[normalize(words[i])] {
+		i++
+	}
+	return joinWords("", words[:i]), words[i:]
+}
+
+func trailingSuffixes(words []string) ([]string, []string) {
+	i := len(words)
+	for i > 1 && suffixes[normalize(words[i-1])] {
+		i--
+	}
+	return words[:i], words[i:]
+}
+
+// splitParticles splits leading particles from a surname
+func splitParticles(words []string) ([]string, []string) {
+	i := 0
+	for i < len(words)-1 && isParticle(words[i]) {
+		i++
+	}
+	return words[:i], words[i:]
+}
+
+func allSuffixes(words []string) bool {
+	for _, word := range words {
+		if !suffixes[normalize(word)] {
+			return false
+		}
+	}
+	return true
+}
+
+func isParticle(word string) bool {
+	return particles[normalize(word)]
+}
+
+// isUpper returns true if word has more than one letter and all of its letters are upper case
+func isUpper(word string) bool {
+	letters := 0
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			if !unicode.IsUpper(r) {
+				return false
+			}
+			letters++
+		}
+	}
+	return letters > 1
+}
+
+// normalize lower-cases a word and removes trailing periods
+func normalize(word string) string {
+	return strings.TrimRight(strings.ToLower(word), ".")
+}
+
+// joinWords appends words to s, separated by spaces
+func joinWords(s string, words []string) string {
+	for _, word := range words {
+		word = strings.Trim(word, ",")
+		if word == "" {
+			continue
+		}
+		if s != "" {
+			s += " "
+		}
+		s += word
+	}
+	return s
+}
@@ -0,0 +1,65 @@
+package stdname_test
+
+import (
+	"testing"
+
+	"github.com/ourrootsorg/cms-server/stdname"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text  string
+		order stdname.Order
+		name  stdname.Name
+	}{
+		{text: "John Smith", name: stdname.Name{Given: "John", Surname: "Smith"}},
+		{text: "Smith, John Jr.", name: stdname.Name{Given: "John", Surname: "Smith", Suffix: "Jr."}},
+		{text: "John Smith, Jr.", name: stdname.Name{Given: "John", Surname: "Smith", Suffix: "Jr."}},
+		{text: "Rev. Johann van der Berg", name: stdname.Name{Title: "Rev.", Given: "Johann", Particles: "van der", Surname: "Berg"}},
+		{text: "Berg, Johann van der", name: stdname.Name{Given: "Johann", Particles: "van der", Surname: "Berg"}},
+		{text: "van der Berg, Johann", name: stdname.Name{Given: "Johann", Particles: "van der", Surname: "Berg"}},
+		{text: "Maria de la Cruz", name: stdname.Name{Given: "Maria", Particles: "de la", Surname: "Cruz"}},
+		{text: "Dr. Mary Ann Jones III", name: stdname.Name{Title: "Dr.", Given: "Mary Ann", Surname: "Jones", Suffix: "III"}},
+		{text: "SMITH John Henry", name: stdname.Name{Given: "John Henry", Surname: "SMITH"}},
+		{text: "Smith John Henry", order: stdname.OrderSurnameFirst, name: stdname.Name{Given: "John Henry", Surname: "Smith"}},
+		{text: "John Henry Smith", order: stdname.OrderSurnameLast, name: stdname.Name{Given: "John Henry", Surname: "Smith"}},
+		{text: "Mary", name: stdname.Name{Given: "Mary"}},
+		{text: "SMITH", name: stdname.Name{Surname: "SMITH"}},
+		{text: "Da Silva", name: stdname.Name{Given: "Da", Surname: "Silva"}},
+		{text: "", name: stdname.Name{}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.name, stdname.Parse(test.text, test.order), test.text)
+	}
+	assert.Equal(t, "van der Berg", stdname.Parse("Johann van der Berg", stdname.OrderAuto).FullSurname())
+	assert.Equal(t, stdname.OrderSurnameFirst, stdname.ParseOrder("surnameFirst"))
+	assert.Equal(t, stdname.OrderAuto, stdname.ParseOrder(""))
+}
+
+func TestPatronym(t *testing.T) {
+	tests := []struct {
+		surname string
+		stem    string
+	}{
+		{surname: "Olsen", stem: "ol"},
+		{surname: "Olsdatter", stem: "ol"},
+		{surname: "Olesen", stem: "ol"},
+		{surname: "Hansen", stem: "han"},
+		{surname: "Hansdotter", stem: "han"},
+		{surname: "Jónsdóttir", stem: "jon"},
+		{surname: "Jonsson", stem: "jon"},
+		{surname: "Andersen", stem: "ander"},
+		{surname: "Johannessen", stem: "johann"},
+		{surname: "Haugen", stem: ""},
+		{surname: "Son", stem: ""},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.stem, stdname.Patronym(test.surname), test.surname)
+	}
+	for given, stem := range map[string]string{"Ole": "ol", "Ola": "ol", "Hans": "han", "Jón": "jon", "Anders": "ander", "Johannes": "johann"} {
+		assert.Equal(t, stem, stdname.GivenStem(given), given)
+	}
+	assert.Equal(t, []string{"ol", "han"}, stdname.Patronyms("Olsdatter Olsen Hansen Haugen"))
+	assert.Equal(t, []string{"ol", "han"}, stdname.GivenStems("Ole Ola Hans"))
+}
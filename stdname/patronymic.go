@@ -0,0 +1,79 @@
+package stdname
+
+import (
+	"strings"
+
+	"github.com/ourrootsorg/cms-server/stdtext"
+)
+
+// patronymicSuffixes are the endings of Nordic patronymic surnames, longest first so that
+// Olsdatter loses sdatter rather than datter
+var patronymicSuffixes = []string{
+	"sdatter", "sdotter", "sdottir", "sdatr", "sdtr",
+	"datter", "dotter", "dottir", "datr", "dtr",
+	"sson", "ssen", "son", "sen",
+}
+
+// minStemLength keeps short surnames like Dahl or Son from being treated as patronymics
+const minStemLength = 2
+
+// Patronym returns the stem of the father's given name that a patronymic surname was formed from,
+// so Olsen, Olsson and Olsdatter all return ol, matching GivenStem("Ole").
+// It returns an empty string if the surname doesn't end in a patronymic suffix.
+func Patronym(surname string) string {
+	s := normalizeStem(surname)
+	for _, suffix := range patronymicSuffixes {
+		if strings.HasSuffix(s, suffix) && len(s)-len(suffix) >= minStemLength {
+			return GivenStem(s[:len(s)-len(suffix)])
+		}
+	}
+	return ""
+}
+
+// GivenStem returns the stem of a given name as it appears in patronymics:
+// a final s and then a final vowel are removed, so Hans becomes han and Ole becomes ol
+func GivenStem(given string) string {
+	s := normalizeStem(given)
+	if len(s) > minStemLength+1 && strings.HasSuffix(s, "s") {
+		s = s[:len(s)-1]
+	}
+	if len(s) > minStemLength && strings.ContainsAny(s[len(s)-1:], "aei") {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// Patronyms returns the unique patronym stems of the words in the surnames
+func Patronyms(surnames string) []string {
+	var stems []string
+	for _, word := range strings.Fields(surnames) {
+		if stem := Patronym(word); stem != "" && !contains(stems, stem) {
+			stems = append(stems, stem)
+		}
+	}
+	return stems
+}
+
+// GivenStems returns the unique stems of the words in the given names
+func GivenStems(givens string) []string {
+	var stems []string
+	for _, word := range strings.Fields(givens) {
+		if stem := GivenStem(word); stem != "" && !contains(stems, stem) {
+			stems = append(stems, stem)
+		}
+	}
+	return stems
+}
+
+func normalizeStem(name string) string {
+	return stdtext.AsciiFold(strings.ToLower(strings.TrimSpace(name)))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
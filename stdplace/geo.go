@@ -0,0 +1,70 @@
+package stdplace
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ourrootsorg/cms-server/model"
+)
+
+// GeoSuffix is appended to a place field name to hold the "lat,lon" of the standardized place
+const GeoSuffix = "_geo"
+
+const earthRadiusKm = 6371.0088
+
+// KmPerMile converts miles to kilometers
+const KmPerMile = 1.609344
+
+// FormatGeo returns the location of a standardized place as "lat,lon", or "" if the place has no location
+func FormatGeo(place *model.Place) string {
+	if place == nil || (place.Latitude == 0 && place.Longitude == 0) {
+		return ""
+	}
+	return strconv.FormatFloat(float64(place.Latitude), 'f', -1, 32) + "," +
+		strconv.FormatFloat(float64(place.Longitude), 'f', -1, 32)
+}
+
+// ParseGeo parses a "lat,lon" string
+func ParseGeo(s string) (float64, float64, bool) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, false
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+// ParseDistance parses a distance like "25", "25km" or "10mi" and returns it in kilometers
+func ParseDistance(s string) (float64, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	factor := 1.0
+	if strings.HasSuffix(s, "mi") {
+		factor = KmPerMile
+		s = strings.TrimSuffix(s, "mi")
+	} else {
+		s = strings.TrimSuffix(s, "km")
+	}
+	d, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d * factor, true
+}
+
+// Distance returns the great-circle distance in kilometers between two points
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
@@ -0,0 +1,48 @@
+package stdplace_test
+
+import (
+	"testing"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/stdplace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeo(t *testing.T) {
+	assert.Equal(t, "", stdplace.FormatGeo(nil))
+	assert.Equal(t, "", stdplace.FormatGeo(&model.Place{}))
+	geo := stdplace.FormatGeo(&model.Place{Latitude: 40.7128, Longitude: -74.006})
+	assert.Equal(t, "40.7128,-74.006", geo)
+	lat, lon, ok := stdplace.ParseGeo(geo)
+	assert.True(t, ok)
+	assert.InDelta(t, 40.7128, lat, 0.00001)
+	assert.InDelta(t, -74.006, lon, 0.00001)
+
+	for _, s := range []string{"", "40", "a,b", "91,0", "0,181"} {
+		_, _, ok = stdplace.ParseGeo(s)
+		assert.False(t, ok, s)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		s  string
+		km float64
+		ok bool
+	}{
+		{"25", 25, true},
+		{"25km", 25, true},
+		{"10 mi", 16.09344, true},
+		{"0", 0, false},
+		{"far", 0, false},
+	}
+	for _, test := range tests {
+		km, ok := stdplace.ParseDistance(test.s)
+		assert.Equal(t, test.ok, ok, test.s)
+		assert.InDelta(t, test.km, km, 0.00001, test.s)
+	}
+
+	// New York to London
+	assert.InDelta(t, 5570, stdplace.Distance(40.7128, -74.006, 51.5074, -0.1278), 10)
+	assert.Equal(t, 0.0, stdplace.Distance(10, 10, 10, 10))
+}
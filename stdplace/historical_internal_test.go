@@ -0,0 +1,243 @@
+package stdplace
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/ourrootsorg/cms-server/model"
+	"github.com/ourrootsorg/cms-server/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// gazetteerMock holds a small set of places for exercising historical jurisdictions
+type gazetteerMock struct {
+	places       map[uint32]model.Place
+	words        map[string][]uint32
+	societyWords map[uint32]map[string][]uint32
+}
+
+func (gm *gazetteerMock) SelectPlaceSettings(ctx context.Context) (*model.PlaceSettings, error) {
+	return &model.PlaceSettings{
+		PlaceSettingsIn: model.PlaceSettingsIn{
+			PlaceSettingsBody: model.PlaceSettingsBody{
+				SmallCountryLevelWeights: []int{0, 0, 0, 0},
+			},
+		},
+	}, nil
+}
+func (gm *gazetteerMock) SelectPlace(ctx context.Context, id uint32) (*model.Place, error) {
+	place, ok := gm.places[id]
+	if !ok {
+		return nil, model.NewError(model.ErrNotFound, fmt.Sprint(id))
+	}
+	return &place, nil
+}
+func (gm *gazetteerMock) SelectPlacesByID(ctx context.Context, ids []uint32) ([]model.Place, error) {
+	var result []model.Place
+	for _, id := range ids {
+		if place, ok := gm.places[id]; ok {
+			result = append(result, place)
+		}
+	}
+	return result, nil
+}
+func (gm *gazetteerMock) SelectPlaceWord(ctx context.Context, word string) (*model.PlaceWord, error) {
+	return &model.PlaceWord{Word: word, IDs: gm.words[word]}, nil
+}
+func (gm *gazetteerMock) SelectPlaceWordsByWord(ctx context.Context, words []string) ([]model.PlaceWord, error) {
+	var result []model.PlaceWord
+	for _, word := range words {
+		if ids, ok := gm.words[word]; ok {
+			result = append(result, model.PlaceWord{Word: word, IDs: ids})
+		}
+	}
+	return result, nil
+}
+func (gm *gazetteerMock) SelectSocietyPlaceWordsByWord(ctx context.Context, societyID uint32, words []string) ([]model.PlaceWord, error) {
+	var result []model.PlaceWord
+	for _, word := range words {
+		if ids, ok := gm.societyWords[societyID][word]; ok {
+			result = append(result, model.PlaceWord{Word: word, IDs: ids})
+		}
+	}
+	return result, nil
+}
+func (gm *gazetteerMock) SelectPlacesByFullNamePrefix(ctx context.Context, prefix string, count int) ([]model.Place, error) {
+	return nil, fmt.Errorf("SelectPlacesByFullNamePrefix not implemented")
+}
+
+func newGazetteerMock() *gazetteerMock {
+	return &gazetteerMock{
+		places: map[uint32]model.Place{
+			1: {ID: 1, Name: "Poland", FullName: "Poland", Level: 1, CountryID: 1},
+			2: {ID: 2, Name: "Lower Silesia", FullName: "Lower Silesia, Poland", LocatedInID: 1, Level: 2, CountryID: 1},
+			3: {ID: 3, Name: "Wroclaw", FullName: "Wroclaw, Lower Silesia, Poland", LocatedInID: 2, Level: 3, CountryID: 1,
+				Periods: model.PlacePeriods{{Name: "Breslau", LocatedInID: 5, ToYear: 1945}}},
+			4: {ID: 4, Name: "Prussia", FullName: "Prussia", Level: 1, CountryID: 4},
+			5: {ID: 5, Name: "Silesia", FullName: "Silesia, Prussia", LocatedInID: 4, Level: 2, CountryID: 4},
+			100: {ID: 100, Name: "Oak Hill Cemetery", FullName: "Oak Hill Cemetery, Wroclaw, Lower Silesia, Poland",
+				LocatedInID: 3, Level: 4, CountryID: 1, SocietyID: 7},
+		},
+		words: map[string][]uint32{
+			"poland":       {1},
+			"lowersilesia": {2},
+			"wroclaw":      {3},
+			"breslau":      {3},
+			"prussia":      {4},
+			"silesia":      {5},
+		},
+		societyWords: map[uint32]map[string][]uint32{
+			7: {"oakhillcemetery": {100}},
+		},
+	}
+}
+
+func TestStandardizeHistorical(t *testing.T) {
+	ctx := context.TODO()
+	std, err := NewStandardizer(ctx, newGazetteerMock())
+	assert.NoError(t, err)
+	defer std.Close()
+
+	tests := []struct {
+		text      string
+		eventYear int
+		id        uint32
+	}{
+		{text: "Breslau, Prussia", eventYear: 1870, id: 3},
+		{text: "Breslau, Silesia, Prussia", eventYear: 1870, id: 3},
+		{text: "Breslau, Prussia", id: 3},
+		{text: "Wroclaw, Poland", eventYear: 1950, id: 3},
+		// written with the modern country in a year the place was elsewhere
+		{text: "Breslau, Poland", eventYear: 1870, id: 3},
+	}
+	for _, test := range tests {
+		place, err := std.Standardize(ctx, test.text, "", test.eventYear)
+		assert.NoError(t, err, test.text)
+		assert.Equal(t, test.id, place.ID, test.text)
+		assert.Equal(t, "Wroclaw, Lower Silesia, Poland", place.FullName, test.text)
+	}
+
+	place, err := std.getPlace(3)
+	assert.NoError(t, err)
+	names, err := std.HistoricalFullNames(place)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Breslau, Silesia, Prussia"}, names)
+
+	place, err = std.getPlace(2)
+	assert.NoError(t, err)
+	names, err = std.HistoricalFullNames(place)
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+func TestNamesAt(t *testing.T) {
+	place := &model.Place{
+		Name: "Wroclaw",
+		Periods: model.PlacePeriods{
+			{Name: "Breslau", AltNames: model.StringSlice{"Bresla"}, FromYear: 1741, ToYear: 1945},
+			{AltNames: model.StringSlice{"Vratislavia"}, ToYear: 1740},
+		},
+	}
+	assert.Equal(t, []string{"Wroclaw", "Breslau", "Bresla", "Vratislavia"}, namesAt(place, 0))
+	assert.Equal(t, []string{"Wroclaw", "Breslau", "Bresla"}, namesAt(place, 1870))
+	assert.Equal(t, []string{"Wroclaw", "Vratislavia"}, namesAt(place, 1700))
+	assert.Equal(t, []string{"Wroclaw"}, namesAt(place, 1950))
+}
+
+func TestStandardizeSocietyPlaces(t *testing.T) {
+	ctx := context.TODO()
+	gm := newGazetteerMock()
+	std, err := NewStandardizer(ctx, gm)
+	assert.NoError(t, err)
+	defer std.Close()
+
+	assert.Equal(t, "oakhillcemetery", std.NameWord("Oak Hill Cemetery"))
+
+	place, err := std.Standardize(utils.AddSocietyIDToContext(ctx, 7), "Oak Hill Cemetery, Wroclaw", "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(100), place.ID)
+
+	// other societies don't see the place
+	place, err = std.Standardize(utils.AddSocietyIDToContext(ctx, 8), "Oak Hill Cemetery, Wroclaw", "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0), place.ID)
+	assert.Equal(t, uint32(3), place.LocatedInID)
+
+	// a new alternate name is seen once the word is invalidated
+	gm.societyWords[8] = map[string][]uint32{"wroclawek": {3}}
+	std.InvalidateWords(8, "wroclawek")
+	place, err = std.Standardize(utils.AddSocietyIDToContext(ctx, 8), "Wroclawek", "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), place.ID)
+}
+
+func TestMatchNeedsReview(t *testing.T) {
+	ctx := context.TODO()
+	gm := newGazetteerMock()
+	gm.places[6] = model.Place{ID: 6, Name: "Olawa", FullName: "Olawa, Lower Silesia, Poland", LocatedInID: 2, Level: 3, CountryID: 1}
+	gm.places[7] = model.Place{ID: 7, Name: "Olawa", FullName: "Olawa, Lower Silesia, Poland", LocatedInID: 2, Level: 3, CountryID: 1,
+		Types: model.StringSlice{"Village"}}
+	gm.words["olawa"] = []uint32{6, 7}
+	std, err := NewStandardizer(ctx, gm)
+	assert.NoError(t, err)
+	defer std.Close()
+
+	match, err := std.Match(ctx, "Wroclaw, Poland", "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), match.Place.ID)
+	assert.False(t, match.NeedsReview())
+
+	match, err = std.Match(ctx, "Olawa, Lower Silesia", "", 0)
+	assert.NoError(t, err)
+	assert.True(t, match.NeedsReview())
+	assert.False(t, match.Unresolved)
+	assert.Len(t, match.Candidates, 2)
+	assert.Equal(t, match.Place.ID, match.Candidates[0].PlaceID)
+
+	match, err = std.Match(ctx, "Oak Hill Cemetery, Wroclaw", "", 0)
+	assert.NoError(t, err)
+	assert.True(t, match.Unresolved)
+	assert.Equal(t, uint32(3), match.Place.LocatedInID)
+
+	match, err = std.Match(ctx, "Xyzzy", "", 0)
+	assert.NoError(t, err)
+	assert.True(t, match.Unresolved)
+	assert.Nil(t, match.Place)
+	_, err = std.Standardize(ctx, "Xyzzy", "", 0)
+	assert.True(t, model.ErrNotFound.Matches(err))
+}
+
+func TestGetWordSocietiesShareCachedWord(t *testing.T) {
+	gm := newGazetteerMock()
+	// decoded word IDs can have spare capacity, which appending to would share
+	gm.words["cemetery"] = append(make([]uint32, 0, 4), 3)
+	gm.societyWords[7]["cemetery"] = []uint32{100}
+	gm.societyWords[8] = map[string][]uint32{"cemetery": {200}}
+	std, err := NewStandardizer(context.TODO(), gm)
+	assert.NoError(t, err)
+	defer std.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		for _, societyID := range []uint32{7, 8} {
+			wg.Add(1)
+			go func(societyID uint32) {
+				defer wg.Done()
+				_, _ = std.getWord(societyID, "cemetery")
+			}(societyID)
+		}
+	}
+	wg.Wait()
+
+	ids, err := std.getWord(7, "cemetery")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{3, 100}, ids)
+	ids, err = std.getWord(8, "cemetery")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{3, 200}, ids)
+	ids, err = std.getWord(0, "cemetery")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{3}, ids)
+}
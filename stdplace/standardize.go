@@ -5,6 +5,7 @@ import (
 	"log"
 	"math"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -13,9 +14,19 @@ import (
 
 	"github.com/ourrootsorg/cms-server/model"
 	"github.com/ourrootsorg/cms-server/stdtext"
+	"github.com/ourrootsorg/cms-server/utils"
 )
 
 const StdSuffix = "_std"
+
+// HistSuffix is appended to a place field name to hold the historical full names of the standardized place
+const HistSuffix = "_hist"
+
+// HistSeparator separates historical full names
+const HistSeparator = "|"
+
+const maxHistoricalNames = 10
+const maxCandidates = 10
 const topLevel = 1
 const maxLevels = 4
 const maxRecursion = 7
@@ -23,13 +34,16 @@ const maxRequests = 20
 const timeoutMillis = 10
 const cacheSize = 10000
 
+// cacheTTL bounds how long a cached place or word is used, so gazetteer changes made by other processes are picked up
+const cacheTTL = 10 * time.Minute
+
 type Standardizer struct {
 	persister                 model.PlacePersister
 	placeRequestChan          chan placeRequest
 	placeRequestMap           map[uint32][]chan placeResponse
 	placeResponseCache        *lru.TwoQueueCache
 	wordRequestChan           chan wordRequest
-	wordRequestMap            map[string][]chan wordResponse
+	wordRequestMap            map[wordKey][]chan wordResponse
 	wordResponseCache         *lru.TwoQueueCache
 	abbreviations             map[string]string
 	typeWords                 map[string]bool
@@ -77,7 +91,7 @@ func NewStandardizer(ctx context.Context, persister model.PlacePersister) (*Stan
 		placeRequestMap:           map[uint32][]chan placeResponse{},
 		placeResponseCache:        placeResponseCache,
 		wordRequestChan:           wordRequestChan,
-		wordRequestMap:            map[string][]chan wordResponse{},
+		wordRequestMap:            map[wordKey][]chan wordResponse{},
 		wordResponseCache:         wordResponseCache,
 		abbreviations:             settings.Abbreviations,
 		typeWords:                 toStringMap(settings.TypeWords),
@@ -102,7 +116,40 @@ func (ps *Standardizer) Close() {
 	close(ps.wordRequestChan)
 }
 
-func (ps *Standardizer) Standardize(ctx context.Context, text, defaultContainingPlace string) (*model.Place, error) {
+// Match is the result of matching text against the gazetteer
+type Match struct {
+	// Place is the best match, or nil if nothing matched
+	Place *model.Place
+	// Candidates are the places the best match was chosen from when the text was ambiguous, highest score first
+	Candidates []model.PlaceCandidate
+	// Unresolved is true if some part of the text didn't match
+	Unresolved bool
+}
+
+// NeedsReview returns true if the match should be confirmed by an editor
+func (m Match) NeedsReview() bool {
+	return m.Unresolved || len(m.Candidates) > 1
+}
+
+// Standardize returns the place that best matches text
+// If eventYear is not 0, places are matched against the names and jurisdictions they had in that year
+func (ps *Standardizer) Standardize(ctx context.Context, text, defaultContainingPlace string, eventYear int) (*model.Place, error) {
+	match, err := ps.Match(ctx, text, defaultContainingPlace, eventYear)
+	if err != nil {
+		return nil, err
+	}
+	if match.Place == nil {
+		return nil, model.NewError(model.ErrNotFound, text)
+	}
+	return match.Place, nil
+}
+
+// Match matches text against the gazetteer like Standardize, and also reports
+// the candidates considered for ambiguous text and whether any part of the text didn't match
+func (ps *Standardizer) Match(ctx context.Context, text, defaultContainingPlace string, eventYear int) (*Match, error) {
+	match := &Match{}
+	// include places added by the society, if any
+	societyID, _ := utils.GetSocietyIDFromContext(ctx)
 	levelWords := tokenize(text)
 	var err error
 	var currentIDs []uint32
@@ -119,7 +166,7 @@ func (ps *Standardizer) Standardize(ctx context.Context, text, defaultContaining
 		for wordsToSkip < len(words) {
 			nameType = ps.getNameTypeToken(words, wordsToSkip)
 			// lookup name token
-			ids, err = ps.getWord(nameType[0])
+			ids, err = ps.getWord(societyID, nameType[0])
 			if err != nil && !model.ErrNotFound.Matches(err) {
 				return nil, err
 			}
@@ -148,11 +195,12 @@ func (ps *Standardizer) Standardize(ctx context.Context, text, defaultContaining
 		// didn't find any matches; log and ignore
 		if len(ids) == 0 {
 			log.Printf("[DEBUG] Token not found text=%s word=%s\n", text, nameType[0])
+			match.Unresolved = true
 		} else {
 			// if we found previous matches, filter subplaces
 			ignoreTypeToken := false
 			if len(currentIDs) > 0 {
-				matchingIDs, err := ps.filterSubplaceMatches(ids, currentIDs)
+				matchingIDs, err := ps.filterSubplaceMatches(ids, currentIDs, eventYear)
 				if err != nil {
 					return nil, err
 				}
@@ -165,7 +213,7 @@ func (ps *Standardizer) Standardize(ctx context.Context, text, defaultContaining
 					if skippable {
 						// try attaching to the grandparent level if there is one
 						if len(previousIDs) > 0 {
-							matchingIDs, err = ps.filterSubplaceMatches(ids, previousIDs)
+							matchingIDs, err = ps.filterSubplaceMatches(ids, previousIDs, eventYear)
 							if err != nil {
 								return nil, err
 							}
@@ -192,6 +240,7 @@ func (ps *Standardizer) Standardize(ctx context.Context, text, defaultContaining
 				if len(matchingIDs) == 0 {
 					ignoreTypeToken = true // no sense matching the type if we couldn't match the name
 					log.Printf("[DEBUG] Subplace matches empty text=%s word=%s\n", text, nameType[0])
+					match.Unresolved = true
 					ids = currentIDs
 					currentIDs = previousIDs
 				} else {
@@ -226,7 +275,7 @@ func (ps *Standardizer) Standardize(ctx context.Context, text, defaultContaining
 
 			// if we still have multiple matches, filter on type
 			if len(ids) > 1 && nameType[1] != "" && !ignoreTypeToken {
-				matchingIDs, err := ps.filterTypeMatches(ids, nameType[1])
+				matchingIDs, err := ps.filterTypeMatches(ids, nameType[1], eventYear)
 				if err != nil {
 					return nil, err
 				}
@@ -247,12 +296,13 @@ func (ps *Standardizer) Standardize(ctx context.Context, text, defaultContaining
 	// if we have no matches, return not found
 	if len(currentIDs) == 0 {
 		log.Printf("[DEBUG] Place not found text=%s\n", text)
-		return nil, model.NewError(model.ErrNotFound, text)
+		match.Unresolved = true
+		return match, nil
 	}
 
 	// remove children if we have the parents
 	if len(currentIDs) > 1 {
-		currentIDs, err = ps.removeChildIDs(currentIDs)
+		currentIDs, err = ps.removeChildIDs(currentIDs, eventYear)
 		if err != nil {
 			return nil, err
 		}
@@ -267,11 +317,19 @@ func (ps *Standardizer) Standardize(ctx context.Context, text, defaultContaining
 			if err != nil {
 				return nil, err
 			}
-			score := ps.scoreMatch(currentNameToken, p)
+			score := ps.scoreMatch(currentNameToken, p, eventYear)
 			if score > bestScore {
 				bestScore = score
 				place = p
 			}
+			match.Candidates = append(match.Candidates, model.PlaceCandidate{PlaceID: p.ID, FullName: p.FullName, Score: score})
+		}
+		// the sort is stable so the chosen place stays ahead of candidates with the same score
+		sort.SliceStable(match.Candidates, func(i, j int) bool {
+			return match.Candidates[i].Score > match.Candidates[j].Score
+		})
+		if len(match.Candidates) > maxCandidates {
+			match.Candidates = match.Candidates[:maxCandidates]
 		}
 		log.Printf("[DEBUG] Ambiguous text=%s\n", text)
 	} else {
@@ -283,6 +341,7 @@ func (ps *Standardizer) Standardize(ctx context.Context, text, defaultContaining
 
 	// if we didn't match the last level, return "unmatched levels, best match"
 	if lastFoundLevel > 0 {
+		match.Unresolved = true
 		var name string
 		fullName := place.FullName
 		for i := lastFoundLevel - 1; i >= 0; i-- {
@@ -301,7 +360,31 @@ func (ps *Standardizer) Standardize(ctx context.Context, text, defaultContaining
 		}
 	}
 
-	return place, nil
+	match.Place = place
+	return match, nil
+}
+
+// NameWord returns the word under which a place name is indexed
+func (ps *Standardizer) NameWord(name string) string {
+	levelWords := tokenize(name)
+	if len(levelWords) == 0 {
+		return ""
+	}
+	return ps.getNameTypeToken(levelWords[len(levelWords)-1], 0)[0]
+}
+
+// InvalidatePlaces removes places from the cache so they are re-read
+func (ps *Standardizer) InvalidatePlaces(ids ...uint32) {
+	for _, id := range ids {
+		ps.placeResponseCache.Remove(id)
+	}
+}
+
+// InvalidateWords removes a society's words from the cache so they are re-read
+func (ps *Standardizer) InvalidateWords(societyID uint32, words ...string) {
+	for _, word := range words {
+		ps.wordResponseCache.Remove(wordKey{societyID: societyID, word: word})
+	}
 }
 
 // catenate all of the words together into one token, with ending type words in a second token
@@ -346,7 +429,7 @@ func (ps *Standardizer) getNameTypeToken(words []string, wordsToSkip int) []stri
 }
 
 func (ps *Standardizer) filterDefaultContainingPlace(ctx context.Context, ids []uint32, countryText string) ([]uint32, error) {
-	country, err := ps.Standardize(ctx, countryText, "")
+	country, err := ps.Standardize(ctx, countryText, "", 0)
 	if err != nil {
 		return nil, err
 	}
@@ -373,7 +456,7 @@ func (ps *Standardizer) filterDefaultContainingPlace(ctx context.Context, ids []
 	return matchingIDs, nil
 }
 
-func (ps *Standardizer) filterTypeMatches(ids []uint32, typeToken string) ([]uint32, error) {
+func (ps *Standardizer) filterTypeMatches(ids []uint32, typeToken string, year int) ([]uint32, error) {
 	var matchingIDs []uint32
 	for _, id := range ids {
 		place, err := ps.getPlace(id)
@@ -381,7 +464,7 @@ func (ps *Standardizer) filterTypeMatches(ids []uint32, typeToken string) ([]uin
 			return nil, err
 		}
 		// does primary name contain the type token?
-		if strings.Index(normalize(place.Name), typeToken) >= 0 {
+		if containsToken(namesAt(place, year), typeToken) {
 			matchingIDs = append(matchingIDs, id)
 			continue
 		}
@@ -412,20 +495,24 @@ func (ps *Standardizer) filterTopLevel(ids []uint32) ([]uint32, error) {
 	return matchingIDs, nil
 }
 
-func (ps *Standardizer) filterSubplaceMatches(ids, parentIDs []uint32) ([]uint32, error) {
+func (ps *Standardizer) filterSubplaceMatches(ids, parentIDs []uint32, year int) ([]uint32, error) {
 	var matchingIDs []uint32
 	for _, id := range ids {
-		if found, err := ps.checkAncestorMatch(id, parentIDs, maxRecursion); found || err != nil {
+		if found, err := ps.checkAncestorMatch(id, parentIDs, year, maxRecursion); found || err != nil {
 			if err != nil {
 				return nil, err
 			}
 			matchingIDs = append(matchingIDs, id)
 		}
 	}
+	// fall back to the jurisdictions of any period so places written with names from another time still match
+	if len(matchingIDs) == 0 && year != 0 {
+		return ps.filterSubplaceMatches(ids, parentIDs, 0)
+	}
 	return matchingIDs, nil
 }
 
-func (ps *Standardizer) scoreMatch(nameToken string, place *model.Place) int {
+func (ps *Standardizer) scoreMatch(nameToken string, place *model.Place, year int) int {
 	var weights []int
 	switch {
 	case ps.largeCountries[place.CountryID]:
@@ -440,7 +527,7 @@ func (ps *Standardizer) scoreMatch(nameToken string, place *model.Place) int {
 		level = maxLevels
 	}
 	score := weights[level-1]
-	if strings.Index(normalize(place.Name), nameToken) >= 0 {
+	if containsToken(namesAt(place, year), nameToken) {
 		score += ps.primaryMatchWeight
 	}
 	return score
@@ -489,13 +576,13 @@ func (ps *Standardizer) isSkippable(ids []uint32) (bool, error) {
 	return true, nil
 }
 
-func (ps *Standardizer) removeChildIDs(ids []uint32) ([]uint32, error) {
+func (ps *Standardizer) removeChildIDs(ids []uint32, year int) ([]uint32, error) {
 	if len(ids) == 0 {
 		return ids, nil
 	}
 	var result []uint32
 	for _, id := range ids {
-		match, err := ps.checkAncestorMatch(id, ids, maxRecursion)
+		match, err := ps.checkAncestorMatch(id, ids, year, maxRecursion)
 		if err != nil {
 			return nil, err
 		}
@@ -514,12 +601,7 @@ func (ps *Standardizer) isLocatedIn(id, parentID uint32, max int) (bool, error)
 	if err != nil {
 		return false, err
 	}
-	if place.LocatedInID > 0 {
-		if found, err := ps.isLocatedIn(place.LocatedInID, parentID, max-1); found || err != nil {
-			return found, err
-		}
-	}
-	for _, ali := range place.AlsoLocatedInIDs {
+	for _, ali := range place.ParentIDs(0) {
 		if found, err := ps.isLocatedIn(ali, parentID, max-1); found || err != nil {
 			return found, err
 		}
@@ -527,28 +609,107 @@ func (ps *Standardizer) isLocatedIn(id, parentID uint32, max int) (bool, error)
 	return false, nil
 }
 
-func (ps *Standardizer) checkAncestorMatch(id uint32, ids []uint32, max int) (bool, error) {
+func (ps *Standardizer) checkAncestorMatch(id uint32, ids []uint32, year, max int) (bool, error) {
 	place, err := ps.getPlace(id)
 	if err != nil {
 		return false, err
 	}
-	if place.LocatedInID > 0 {
-		if containsUint32(ids, place.LocatedInID) {
+	for _, ali := range place.ParentIDs(year) {
+		if containsUint32(ids, ali) {
 			return true, nil
 		}
-		if match, err := ps.checkAncestorMatch(place.LocatedInID, ids, max-1); err != nil || match {
+		if match, err := ps.checkAncestorMatch(ali, ids, year, max-1); err != nil || match {
 			return match, err
 		}
 	}
-	for _, ali := range place.AlsoLocatedInIDs {
-		if containsUint32(ids, ali) {
-			return true, nil
+	return false, nil
+}
+
+// HistoricalFullNames returns the full names a place had under its historical names and jurisdictions,
+// not including its current full name
+func (ps *Standardizer) HistoricalFullNames(place *model.Place) ([]string, error) {
+	names, err := ps.historicalFullNames(place, maxRecursion)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, name := range names {
+		if name != place.FullName && !containsString(result, name) {
+			result = append(result, name)
+			if len(result) == maxHistoricalNames {
+				break
+			}
 		}
-		if match, err := ps.checkAncestorMatch(ali, ids, max-1); err != nil || match {
-			return match, err
+	}
+	return result, nil
+}
+
+func (ps *Standardizer) historicalFullNames(place *model.Place, max int) ([]string, error) {
+	if max <= 0 {
+		return nil, nil
+	}
+	var names []string
+	// names within the parents of each historical period
+	for _, period := range place.Periods {
+		name := period.Name
+		if name == "" {
+			name = place.Name
+		}
+		if period.LocatedInID == 0 {
+			names = append(names, name)
+			continue
+		}
+		parent, err := ps.getPlace(period.LocatedInID)
+		if err != nil {
+			return nil, err
+		}
+		parentNames, err := ps.historicalFullNames(parent, max-1)
+		if err != nil {
+			return nil, err
+		}
+		for _, parentName := range append([]string{parent.FullName}, parentNames...) {
+			names = append(names, name+", "+parentName)
 		}
 	}
-	return false, nil
+	// the current name within the historical names of the current parent
+	if place.LocatedInID > 0 {
+		parent, err := ps.getPlace(place.LocatedInID)
+		if err != nil {
+			return nil, err
+		}
+		parentNames, err := ps.historicalFullNames(parent, max-1)
+		if err != nil {
+			return nil, err
+		}
+		for _, parentName := range parentNames {
+			names = append(names, place.Name+", "+parentName)
+		}
+	}
+	return names, nil
+}
+
+// namesAt returns the current name of the place along with the names it had in year, or all of its historical names if year is 0
+func namesAt(place *model.Place, year int) []string {
+	names := []string{place.Name}
+	for _, period := range place.Periods {
+		if year != 0 && !period.Contains(year) {
+			continue
+		}
+		if period.Name != "" {
+			names = append(names, period.Name)
+		}
+		names = append(names, period.AltNames...)
+	}
+	return names
+}
+
+func containsToken(names []string, token string) bool {
+	for _, name := range names {
+		if strings.Index(normalize(name), token) >= 0 {
+			return true
+		}
+	}
+	return false
 }
 
 type placeRequest struct {
@@ -557,12 +718,23 @@ type placeRequest struct {
 	force bool
 }
 
+// wordKey identifies a word in the global gazetteer (societyID 0) or in a society's additions
+type wordKey struct {
+	societyID uint32
+	word      string
+}
+
 type wordRequest struct {
-	word  string
+	key   wordKey
 	ch    chan wordResponse
 	force bool
 }
 
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
 type placeResponse struct {
 	place *model.Place
 	err   error
@@ -573,6 +745,11 @@ type wordResponse struct {
 	err error
 }
 
+// GetPlace returns a place, using the standardizer's cache
+func (ps *Standardizer) GetPlace(id uint32) (*model.Place, error) {
+	return ps.getPlace(id)
+}
+
 func (ps *Standardizer) getPlace(id uint32) (*model.Place, error) {
 	ch := make(chan placeResponse, 1)
 	ps.placeRequestChan <- placeRequest{id: id, ch: ch}
@@ -580,9 +757,32 @@ func (ps *Standardizer) getPlace(id uint32) (*model.Place, error) {
 	return res.place, res.err
 }
 
-func (ps *Standardizer) getWord(word string) ([]uint32, error) {
+// getWord returns the IDs of the global places, and the places added by the society if societyID is not 0, indexed under word
+func (ps *Standardizer) getWord(societyID uint32, word string) ([]uint32, error) {
+	ids, err := ps.requestWord(wordKey{word: word})
+	if societyID == 0 || (err != nil && !model.ErrNotFound.Matches(err)) {
+		return ids, err
+	}
+	societyIDs, societyErr := ps.requestWord(wordKey{societyID: societyID, word: word})
+	if societyErr != nil {
+		if !model.ErrNotFound.Matches(societyErr) {
+			return nil, societyErr
+		}
+		return ids, err
+	}
+	// ids is shared with the cache, so merge into a copy
+	merged := append([]uint32(nil), ids...)
+	for _, id := range societyIDs {
+		if !containsUint32(merged, id) {
+			merged = append(merged, id)
+		}
+	}
+	return merged, nil
+}
+
+func (ps *Standardizer) requestWord(key wordKey) ([]uint32, error) {
 	ch := make(chan wordResponse, 1)
-	ps.wordRequestChan <- wordRequest{word: word, ch: ch}
+	ps.wordRequestChan <- wordRequest{key: key, ch: ch}
 	res := <-ch
 	return res.ids, res.err
 }
@@ -593,8 +793,8 @@ func (ps *Standardizer) placeRequestListener(ch chan placeRequest) {
 	for req := range ch {
 		if req.ch != nil {
 			// if in LRU cache, reply immediately
-			if p, ok := ps.placeResponseCache.Get(req.id); ok {
-				if place, ok := p.(model.Place); ok {
+			if entry, ok := ps.placeResponseCache.Get(req.id); ok && time.Now().Before(entry.(cacheEntry).expires) {
+				if place, ok := entry.(cacheEntry).value.(model.Place); ok {
 					req.ch <- placeResponse{
 						place: &place,
 						err:   nil,
@@ -642,9 +842,10 @@ func (ps *Standardizer) issuePlaceRequests(ctx context.Context, reqs map[uint32]
 	}
 	places, err := ps.persister.SelectPlacesByID(ctx, ids)
 	// cache place responses
-	if err != nil {
+	if err == nil {
+		expires := time.Now().Add(cacheTTL)
 		for _, place := range places {
-			ps.placeResponseCache.Add(place.ID, place)
+			ps.placeResponseCache.Add(place.ID, cacheEntry{value: place, expires: expires})
 		}
 	}
 	// send responses
@@ -674,8 +875,8 @@ func (ps *Standardizer) wordRequestListener(ch chan wordRequest) {
 	for req := range ch {
 		if req.ch != nil {
 			// if in LRU cache, reply immediately
-			if w, ok := ps.wordResponseCache.Get(req.word); ok {
-				if ids, ok := w.([]uint32); ok {
+			if entry, ok := ps.wordResponseCache.Get(req.key); ok && time.Now().Before(entry.(cacheEntry).expires) {
+				if ids, ok := entry.(cacheEntry).value.([]uint32); ok {
 					req.ch <- wordResponse{
 						ids: ids,
 						err: nil,
@@ -684,7 +885,7 @@ func (ps *Standardizer) wordRequestListener(ch chan wordRequest) {
 				}
 			}
 			// add request to requests map
-			ps.wordRequestMap[req.word] = append(ps.wordRequestMap[req.word], req.ch)
+			ps.wordRequestMap[req.key] = append(ps.wordRequestMap[req.key], req.ch)
 		}
 		// if this is the first request, set up a timeout
 		if !req.force && len(ps.wordRequestMap) == 1 {
@@ -705,46 +906,56 @@ func (ps *Standardizer) wordRequestListener(ch chan wordRequest) {
 				cancelChan <- true
 				cancelChan = nil
 			}
-			go func(requests map[string][]chan wordResponse) {
+			go func(requests map[wordKey][]chan wordResponse) {
 				ps.issueWordRequests(ctx, requests)
 			}(ps.wordRequestMap)
-			ps.wordRequestMap = map[string][]chan wordResponse{}
+			ps.wordRequestMap = map[wordKey][]chan wordResponse{}
 		}
 	}
 }
 
-func (ps *Standardizer) issueWordRequests(ctx context.Context, reqs map[string][]chan wordResponse) {
+func (ps *Standardizer) issueWordRequests(ctx context.Context, reqs map[wordKey][]chan wordResponse) {
 	if len(reqs) == 0 {
 		return
 	}
-	var words []string
-	for word := range reqs {
-		words = append(words, word)
+	// global and society words are stored separately, so issue one request per society
+	societyWords := map[uint32][]string{}
+	for key := range reqs {
+		societyWords[key.societyID] = append(societyWords[key.societyID], key.word)
 	}
-	placeWords, err := ps.persister.SelectPlaceWordsByWord(ctx, words)
-	// cache responses
-	if err != nil {
-		for _, placeWord := range placeWords {
-			ps.wordResponseCache.Add(placeWord.Word, placeWord.IDs)
+	for societyID, words := range societyWords {
+		var placeWords []model.PlaceWord
+		var err error
+		if societyID == 0 {
+			placeWords, err = ps.persister.SelectPlaceWordsByWord(ctx, words)
+		} else {
+			placeWords, err = ps.persister.SelectSocietyPlaceWordsByWord(ctx, societyID, words)
 		}
-	}
-	// send place responses
-	for word, chs := range reqs {
-		var ids []uint32
-		responseErr := err
-		if responseErr == nil {
+		// cache responses
+		if err == nil {
+			expires := time.Now().Add(cacheTTL)
 			for _, placeWord := range placeWords {
-				if word == placeWord.Word {
-					ids = placeWord.IDs
-					break
-				}
+				ps.wordResponseCache.Add(wordKey{societyID: societyID, word: placeWord.Word}, cacheEntry{value: []uint32(placeWord.IDs), expires: expires})
 			}
 		}
-		if responseErr == nil && len(ids) == 0 {
-			responseErr = model.NewError(model.ErrNotFound, word)
-		}
-		for _, ch := range chs {
-			ch <- wordResponse{ids: ids, err: responseErr}
+		// send place responses
+		for _, word := range words {
+			var ids []uint32
+			responseErr := err
+			if responseErr == nil {
+				for _, placeWord := range placeWords {
+					if word == placeWord.Word {
+						ids = placeWord.IDs
+						break
+					}
+				}
+			}
+			if responseErr == nil && len(ids) == 0 {
+				responseErr = model.NewError(model.ErrNotFound, word)
+			}
+			for _, ch := range reqs[wordKey{societyID: societyID, word: word}] {
+				ch <- wordResponse{ids: ids, err: responseErr}
+			}
 		}
 	}
 }
@@ -799,6 +1010,15 @@ func normalize(text string) string {
 	return stdtext.AsciiFold(strings.ToLower(text))
 }
 
+func containsString(haystack []string, needle string) bool {
+	for _, item := range haystack {
+		if item == needle {
+			return true
+		}
+	}
+	return false
+}
+
 func containsUint32(haystack []uint32, needle uint32) bool {
 	for _, item := range haystack {
 		if item == needle {
@@ -78,6 +78,9 @@ func (pp *placePersisterMock) SelectPlaceWordsByWord(ctx context.Context, words
 	}
 	return result, nil
 }
+func (pp *placePersisterMock) SelectSocietyPlaceWordsByWord(ctx context.Context, societyID uint32, words []string) ([]model.PlaceWord, error) {
+	return nil, nil
+}
 func (pp *placePersisterMock) SelectPlacesByFullNamePrefix(ctx context.Context, prefix string, count int) ([]model.Place, error) {
 	return nil, fmt.Errorf("SelectPlacesByFullNamePrefix not implemented")
 }
@@ -143,7 +146,7 @@ func TestGetWord(t *testing.T) {
 	out := make(chan wordRequestResponse, totalRequests)
 	for i := 0; i < totalRequests; i++ {
 		go func(word string) {
-			ids, err := std.getWord(word)
+			ids, err := std.getWord(0, word)
 			out <- wordRequestResponse{req: word, ids: ids, err: err}
 		}(strconv.Itoa(i))
 	}
@@ -132,7 +132,7 @@ func doStandardizePlaceTests(t *testing.T, p model.PlacePersister) {
 	defer ps.Close()
 
 	for _, test := range tests {
-		place, err := ps.Standardize(ctx, test.text, test.defaultContainer)
+		place, err := ps.Standardize(ctx, test.text, test.defaultContainer, 0)
 		if test.errCode != "" {
 			assert.True(t, test.errCode.Matches(err), test.text)
 		} else {
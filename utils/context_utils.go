@@ -46,3 +46,48 @@ func GetSearchUserIDFromContext(ctx context.Context) (uint32, error) {
 func AddSearchUserIDToContext(ctx context.Context, userID uint32) context.Context {
 	return context.WithValue(ctx, searchUserKey, userID)
 }
+
+const apiKeyKey = "apiKey"
+
+// GetAPIKeyFromContext returns the API key the request was authenticated with, if any
+func GetAPIKeyFromContext(ctx context.Context) (*model.APIKey, error) {
+	apiKey, ok := ctx.Value(apiKeyKey).(*model.APIKey)
+	if !ok {
+		return nil, errors.New("API key not found in context")
+	}
+	return apiKey, nil
+}
+
+func AddAPIKeyToContext(ctx context.Context, apiKey *model.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyKey, apiKey)
+}
+
+const permissionsKey = "permissions"
+
+// GetPermissionsFromContext returns the collection permissions of the current user, if they have been loaded
+func GetPermissionsFromContext(ctx context.Context) (*model.Permissions, error) {
+	permissions, ok := ctx.Value(permissionsKey).(*model.Permissions)
+	if !ok {
+		return nil, errors.New("permissions not found in context")
+	}
+	return permissions, nil
+}
+
+func AddPermissionsToContext(ctx context.Context, permissions *model.Permissions) context.Context {
+	return context.WithValue(ctx, permissionsKey, permissions)
+}
+
+const requestIDKey = "requestID"
+
+// GetRequestIDFromContext returns the ID of the HTTP request being handled, if any
+func GetRequestIDFromContext(ctx context.Context) (string, error) {
+	requestID, ok := ctx.Value(requestIDKey).(string)
+	if !ok {
+		return "", errors.New("request ID not found in context")
+	}
+	return requestID, nil
+}
+
+func AddRequestIDToContext(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}